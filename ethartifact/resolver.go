@@ -0,0 +1,161 @@
+package ethartifact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/goware/cachestore"
+	"github.com/goware/cachestore/memlru"
+)
+
+var ErrABINotFound = errors.New("ethartifact: abi not found")
+
+// ABISource fetches the ABI of a deployed contract, ie. from an ABI registry or a
+// contract verification service. It returns ErrABINotFound if it has no ABI for the
+// contract.
+type ABISource interface {
+	FetchABI(ctx context.Context, chainID uint64, address common.Address) (abi.ABI, error)
+}
+
+// ABISourceFunc is a function used as an ABISource.
+type ABISourceFunc func(ctx context.Context, chainID uint64, address common.Address) (abi.ABI, error)
+
+func (f ABISourceFunc) FetchABI(ctx context.Context, chainID uint64, address common.Address) (abi.ABI, error) {
+	return f(ctx, chainID, address)
+}
+
+type ABIResolverOptions struct {
+	// CacheSize is the number of contract ABIs to cache, defaults to 1000.
+	CacheSize int
+
+	// NotFoundCacheExpiry is how long contracts without an ABI are remembered, so the
+	// source isn't queried again for them until, ie. they've been verified. Defaults to
+	// 10 minutes, and a negative value disables it.
+	NotFoundCacheExpiry time.Duration
+}
+
+var DefaultABIResolverOptions = ABIResolverOptions{
+	CacheSize:           1000,
+	NotFoundCacheExpiry: 10 * time.Minute,
+}
+
+// ABIResolver resolves the ABIs of deployed contracts from a pluggable source, ie. to
+// decode their transactions and logs, and caches them by chain and address.
+type ABIResolver struct {
+	source  ABISource
+	options ABIResolverOptions
+
+	// cache of ABIs by chain and address, where nil is a contract without an ABI
+	cache cachestore.Store[*abi.ABI]
+}
+
+func NewABIResolver(source ABISource, options ...ABIResolverOptions) (*ABIResolver, error) {
+	if source == nil {
+		return nil, fmt.Errorf("ethartifact: abi source is required")
+	}
+
+	opts := DefaultABIResolverOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = DefaultABIResolverOptions.CacheSize
+	}
+	if opts.NotFoundCacheExpiry == 0 {
+		opts.NotFoundCacheExpiry = DefaultABIResolverOptions.NotFoundCacheExpiry
+	}
+
+	cache, err := memlru.NewWithSize[*abi.ABI](opts.CacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("ethartifact: %w", err)
+	}
+
+	return &ABIResolver{
+		source:  source,
+		options: opts,
+		cache:   cache,
+	}, nil
+}
+
+// ResolveABI returns the ABI of the contract, from the cache or else from the source.
+// It returns ErrABINotFound if the source has no ABI for the contract.
+func (r *ABIResolver) ResolveABI(ctx context.Context, chainID uint64, address common.Address) (abi.ABI, error) {
+	key := fmt.Sprintf("%d:%s", chainID, address.Hex())
+
+	contractABI, ok, _ := r.cache.Get(ctx, key)
+	if ok {
+		if contractABI == nil {
+			return abi.ABI{}, ErrABINotFound
+		}
+		return *contractABI, nil
+	}
+
+	fetchedABI, err := r.source.FetchABI(ctx, chainID, address)
+	if errors.Is(err, ErrABINotFound) {
+		if r.options.NotFoundCacheExpiry > 0 {
+			r.cache.SetEx(ctx, key, nil, r.options.NotFoundCacheExpiry)
+		}
+		return abi.ABI{}, ErrABINotFound
+	}
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("ethartifact: failed to fetch abi of %s on chain %d: %w", address.Hex(), chainID, err)
+	}
+
+	r.cache.Set(ctx, key, &fetchedABI)
+	return fetchedABI, nil
+}
+
+// ClearCache drops all of the cached ABIs.
+func (r *ABIResolver) ClearCache() {
+	r.cache.ClearAll(context.Background())
+}
+
+// SourcifyABISource fetches the ABIs of contracts verified on Sourcify, or on another
+// server implementing its v2 API, ie. "https://sourcify.dev/server". The http client
+// defaults to http.DefaultClient.
+func SourcifyABISource(serverURL string, client *http.Client) ABISource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	serverURL = strings.TrimSuffix(serverURL, "/")
+
+	return ABISourceFunc(func(ctx context.Context, chainID uint64, address common.Address) (abi.ABI, error) {
+		url := fmt.Sprintf("%s/v2/contract/%d/%s?fields=abi", serverURL, chainID, address.Hex())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return abi.ABI{}, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return abi.ABI{}, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			return abi.ABI{}, ErrABINotFound
+		}
+		if resp.StatusCode != http.StatusOK {
+			return abi.ABI{}, fmt.Errorf("sourcify responded with status %d", resp.StatusCode)
+		}
+
+		var contract struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&contract); err != nil {
+			return abi.ABI{}, fmt.Errorf("failed to decode sourcify response: %w", err)
+		}
+		if len(contract.ABI) == 0 || string(contract.ABI) == "null" {
+			return abi.ABI{}, ErrABINotFound
+		}
+
+		return abi.JSON(strings.NewReader(string(contract.ABI)))
+	})
+}
@@ -0,0 +1,165 @@
+package ethartifact_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethartifact"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+const erc20ABI = `[{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}]`
+
+// mockSourcify serves the ABI of the verified contract, and 404s for other contracts.
+func mockSourcify(t *testing.T, verified common.Address, hits *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		require.Equal(t, "abi", r.URL.Query().Get("fields"))
+		if r.URL.Path != fmt.Sprintf("/v2/contract/1/%s", verified.Hex()) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"customCode":"not_found","message":"contract not found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"abi":%s,"matchId":"1","creationMatch":"match","runtimeMatch":"match","chainId":"1","address":"%s"}`, erc20ABI, verified.Hex())
+	}))
+}
+
+func TestSourcifyABISource(t *testing.T) {
+	verified := common.HexToAddress("0x1")
+	var hits atomic.Int32
+	server := mockSourcify(t, verified, &hits)
+	defer server.Close()
+
+	source := ethartifact.SourcifyABISource(server.URL+"/", nil)
+
+	contractABI, err := source.FetchABI(context.Background(), 1, verified)
+	require.NoError(t, err)
+	require.Contains(t, contractABI.Methods, "balanceOf")
+
+	_, err = source.FetchABI(context.Background(), 1, common.HexToAddress("0x2"))
+	require.ErrorIs(t, err, ethartifact.ErrABINotFound)
+
+	_, err = source.FetchABI(context.Background(), 5, verified)
+	require.ErrorIs(t, err, ethartifact.ErrABINotFound)
+}
+
+func TestSourcifyABISourceErrors(t *testing.T) {
+	var status atomic.Int32
+	var body atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		fmt.Fprint(w, body.Load())
+	}))
+	defer server.Close()
+
+	source := ethartifact.SourcifyABISource(server.URL, server.Client())
+	fetch := func(code int, response string) error {
+		status.Store(int32(code))
+		body.Store(response)
+		_, err := source.FetchABI(context.Background(), 1, common.HexToAddress("0x1"))
+		return err
+	}
+
+	// a verified contract response without an abi
+	require.ErrorIs(t, fetch(http.StatusOK, `{"abi":null}`), ethartifact.ErrABINotFound)
+	require.ErrorIs(t, fetch(http.StatusOK, `{}`), ethartifact.ErrABINotFound)
+
+	err := fetch(http.StatusTooManyRequests, `{}`)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ethartifact.ErrABINotFound)
+
+	require.Error(t, fetch(http.StatusOK, `not json`))
+	require.Error(t, fetch(http.StatusOK, `{"abi":[{"type":"function","name":"f","inputs":[{"type":"unknown"}]}]}`))
+}
+
+func TestABIResolver(t *testing.T) {
+	verified := common.HexToAddress("0x1")
+	var hits atomic.Int32
+	server := mockSourcify(t, verified, &hits)
+	defer server.Close()
+
+	resolver, err := ethartifact.NewABIResolver(ethartifact.SourcifyABISource(server.URL, nil))
+	require.NoError(t, err)
+
+	// the abi is fetched once, and then served from the cache
+	for i := 0; i < 3; i++ {
+		contractABI, err := resolver.ResolveABI(context.Background(), 1, verified)
+		require.NoError(t, err)
+		require.Contains(t, contractABI.Methods, "balanceOf")
+	}
+	require.Equal(t, int32(1), hits.Load())
+
+	// the cache is per chain
+	_, err = resolver.ResolveABI(context.Background(), 5, verified)
+	require.ErrorIs(t, err, ethartifact.ErrABINotFound)
+	require.Equal(t, int32(2), hits.Load())
+
+	resolver.ClearCache()
+	_, err = resolver.ResolveABI(context.Background(), 1, verified)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), hits.Load())
+}
+
+func TestABIResolverNotFoundExpiry(t *testing.T) {
+	unverified := common.HexToAddress("0x2")
+	var hits atomic.Int32
+	server := mockSourcify(t, common.HexToAddress("0x1"), &hits)
+	defer server.Close()
+
+	resolver, err := ethartifact.NewABIResolver(ethartifact.SourcifyABISource(server.URL, nil), ethartifact.ABIResolverOptions{
+		NotFoundCacheExpiry: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// a contract without an abi isn't queried again until the not found expiry
+	for i := 0; i < 3; i++ {
+		_, err = resolver.ResolveABI(context.Background(), 1, unverified)
+		require.ErrorIs(t, err, ethartifact.ErrABINotFound)
+	}
+	require.Equal(t, int32(1), hits.Load())
+
+	// the cache drops expired keys every few seconds
+	require.Eventually(t, func() bool {
+		_, err := resolver.ResolveABI(context.Background(), 1, unverified)
+		return errors.Is(err, ethartifact.ErrABINotFound) && hits.Load() == 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// or never remembered, if disabled
+	resolver, err = ethartifact.NewABIResolver(ethartifact.SourcifyABISource(server.URL, nil), ethartifact.ABIResolverOptions{
+		NotFoundCacheExpiry: -1,
+	})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = resolver.ResolveABI(context.Background(), 1, unverified)
+		require.ErrorIs(t, err, ethartifact.ErrABINotFound)
+	}
+	require.Equal(t, int32(4), hits.Load())
+}
+
+func TestABIResolverSourceError(t *testing.T) {
+	var calls atomic.Int32
+	resolver, err := ethartifact.NewABIResolver(ethartifact.ABISourceFunc(func(ctx context.Context, chainID uint64, address common.Address) (abi.ABI, error) {
+		calls.Add(1)
+		return abi.ABI{}, fmt.Errorf("unavailable")
+	}))
+	require.NoError(t, err)
+
+	// failures other than not found aren't cached
+	for i := 0; i < 2; i++ {
+		_, err = resolver.ResolveABI(context.Background(), 1, common.HexToAddress("0x1"))
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ethartifact.ErrABINotFound)
+	}
+	require.Equal(t, int32(2), calls.Load())
+
+	_, err = ethartifact.NewABIResolver(nil)
+	require.Error(t, err)
+}
@@ -1,8 +1,11 @@
 package ethcoder
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
 	"strings"
 	"sync"
@@ -41,7 +44,13 @@ func (d *ABI) AddEvent(eventSig string) (string, error) {
 	return d.AddABISignature(abiSig, true)
 }
 
+// AddMethod adds the method by its signature, ie. "balanceOf(address)". The signature
+// may include the return types, ie. "balanceOf(address)(uint256)", which are ignored.
 func (d *ABI) AddMethod(methodSig string) (string, error) {
+	methodSig, _, err := splitMethodReturnSignature(methodSig)
+	if err != nil {
+		return "", err
+	}
 	abiSig, err := ParseABISignature(methodSig)
 	if err != nil {
 		return "", nil
@@ -199,7 +208,11 @@ func (d *ABI) EncodeMethodCalldataFromStringValuesAny(methodName string, argStri
 	if err != nil {
 		return nil, err
 	}
-	return d.rawABI.Pack(methodName, argValues...)
+	args, err := packableArgValues(d.rawABI, methodName, argValues)
+	if err != nil {
+		return nil, err
+	}
+	return d.rawABI.Pack(methodName, args...)
 }
 
 // EncodeMethodCalldataFromStringValues decodes the abi method argument string values into
@@ -237,6 +250,47 @@ type ContractCallDef struct {
 
 	// Args is the arguments to the call, which can be nested.
 	Args []any `json:"args"`
+
+	// NamedArgs is the arguments to the call keyed by the abi parameter names, ie.
+	// {"_to": "0x..", "_value": "100"}, instead of Args. It requires the abi to have
+	// named parameters.
+	NamedArgs map[string]any `json:"namedArgs,omitempty"`
+
+	// ArgsJSON is the arguments to the call as JSON, instead of Args or NamedArgs,
+	// either as an array of positional arguments, or as an object of named arguments,
+	// ie. for calls defined in a config file. Nested calls may also name their args.
+	ArgsJSON json.RawMessage `json:"argsJSON,omitempty"`
+}
+
+// UnmarshalJSON decodes the contract call definition, where "args" may either be an array
+// of the positional arguments, or an object of the arguments keyed by the abi parameter
+// names, which is decoded into NamedArgs.
+func (c *ContractCallDef) UnmarshalJSON(data []byte) error {
+	type contractCallDef ContractCallDef
+	var v struct {
+		contractCallDef
+		Args json.RawMessage `json:"args"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = ContractCallDef(v.contractCallDef)
+
+	args := bytes.TrimSpace(v.Args)
+	if len(args) == 0 || bytes.Equal(args, []byte("null")) {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	switch args[0] {
+	case '[':
+		return dec.Decode(&c.Args)
+	case '{':
+		return dec.Decode(&c.NamedArgs)
+	default:
+		return fmt.Errorf("ethcoder: contract call args must be an array, or an object keyed by the input names")
+	}
 }
 
 // EncodeContractCall encodes a contract call as a hex encoded calldata.
@@ -251,14 +305,25 @@ func EncodeContractCall(callDef ContractCallDef) (string, error) {
 		return "", fmt.Errorf("no method names added")
 	}
 	methodName := names[0]
+	if callDef.Func != "" {
+		methodName = callDef.Func
+	}
 
 	abiSig, ok := abi.GetMethodABISignature(methodName)
 	if !ok {
 		return "", fmt.Errorf("method %s not found", methodName)
 	}
 
+	rawABI := abi.RawABI()
+
+	// Map the arguments in the order of the method inputs, if they're named
+	callArgs, err := contractCallArgs(rawABI.Methods[methodName], callDef)
+	if err != nil {
+		return "", err
+	}
+
 	// Prepare the arguments, which may be nested
-	argStringValues, err := prepareContractCallArgs(callDef.Args)
+	argStringValues, err := prepareContractCallArgs(callArgs)
 	if err != nil {
 		return "", err
 	}
@@ -269,8 +334,6 @@ func EncodeContractCall(callDef ContractCallDef) (string, error) {
 		return "", err
 	}
 
-	rawABI := abi.RawABI()
-
 	// Pre-process all argValues to be in the format that the geth abi encoder expects.
 	// argValues are runtime types.
 	args, err := packableArgValues(rawABI, methodName, argValues)
@@ -299,63 +362,237 @@ func packableArgValues(mabi abi.ABI, method string, argValues []any) ([]any, err
 	}
 
 	out := make([]any, len(argValues))
-
 	for i, input := range m.Inputs {
-		isTuple := false
-		typ := input.Type.String()
-		if len(typ) >= 2 && typ[0] == '(' && typ[len(typ)-1] == ')' {
-			isTuple = true
+		v, err := packableValue(input.Type, argValues[i])
+		if err != nil {
+			return nil, fmt.Errorf("arg %d of type %s: %w", i, input.Type.String(), err)
 		}
+		out[i] = v
+	}
+	return out, nil
+}
 
-		if !isTuple {
-			out[i] = argValues[i]
-		} else {
-			// build struct for the tuple, as that is what the geth abi encoder expects
-			// NOTE: in future we could fork or modify it if we want to avoid the need for this,
-			// as it means decoding tuples will be more intensive the necessary.
-			fields := []reflect.StructField{}
-
-			v, ok := argValues[i].([]any)
+// packableValue converts a runtime value from ABIUnmarshalStringValuesAny into the type the
+// geth abi encoder expects for the abi type, ie. a struct for a tuple, and a typed slice of
+// structs for an array of tuples. Other values are returned as is.
+//
+// NOTE: in future we could fork or modify the geth abi encoder if we want to avoid the need
+// for this, as it means encoding tuples will be more intensive than necessary.
+func packableValue(typ abi.Type, v any) (any, error) {
+	switch typ.T {
+	case abi.TupleTy:
+		elems, ok := v.([]any)
+		if !ok {
+			strs, ok := v.([]string)
 			if !ok {
-				vv, ok := argValues[i].([]string)
-				if !ok {
-					return nil, errors.New("tuple arg values must be an array")
-				}
-				v = make([]any, len(vv))
-				for j, x := range vv {
-					v[j] = x
-				}
+				return nil, errors.New("tuple arg values must be an array")
+			}
+			elems = make([]any, len(strs))
+			for j, x := range strs {
+				elems[j] = x
 			}
+		}
+		if len(elems) != len(typ.TupleElems) {
+			return nil, fmt.Errorf("tuple expects %d values but received %d", len(typ.TupleElems), len(elems))
+		}
 
-			for j, vv := range v {
-				fields = append(fields, reflect.StructField{
-					Name: fmt.Sprintf("Name%d", j),
-					Type: reflect.TypeOf(vv),
-				})
+		instance := reflect.New(typ.GetType()).Elem()
+		for j, elemTyp := range typ.TupleElems {
+			elem, err := packableValue(*elemTyp, elems[j])
+			if err != nil {
+				return nil, err
+			}
+			if err := setPackableValue(instance.Field(j), elem); err != nil {
+				return nil, err
 			}
+		}
+		return instance.Interface(), nil
 
-			structType := reflect.StructOf(fields)
-			instance := reflect.New(structType).Elem()
+	case abi.SliceTy, abi.ArrayTy:
+		elems, ok := v.([]any)
+		if !ok {
+			// typed slices, ie. []common.Address, are packed as is
+			return v, nil
+		}
 
-			for j, vv := range v {
-				instance.Field(j).Set(reflect.ValueOf(vv))
+		var out reflect.Value
+		if typ.T == abi.SliceTy {
+			out = reflect.MakeSlice(typ.GetType(), len(elems), len(elems))
+		} else {
+			if len(elems) != typ.Size {
+				return nil, fmt.Errorf("array expects %d values but received %d", typ.Size, len(elems))
+			}
+			out = reflect.New(typ.GetType()).Elem()
+		}
+		for j := range elems {
+			elem, err := packableValue(*typ.Elem, elems[j])
+			if err != nil {
+				return nil, err
+			}
+			if err := setPackableValue(out.Index(j), elem); err != nil {
+				return nil, err
 			}
-			out[i] = instance.Interface()
 		}
+		return out.Interface(), nil
+
+	default:
+		return v, nil
+	}
+}
+
+// setPackableValue sets dst to v, converting v to the type of dst where needed, ie. a
+// *big.Int to a uint8, a uint64 to a *big.Int, or a []byte to a [32]byte.
+func setPackableValue(dst reflect.Value, v any) error {
+	src := reflect.ValueOf(v)
+	if !src.IsValid() {
+		return fmt.Errorf("missing value for %s", dst.Type())
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	if dst.Type() == reflect.TypeOf((*big.Int)(nil)) {
+		switch src.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			dst.Set(reflect.ValueOf(big.NewInt(src.Int())))
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			dst.Set(reflect.ValueOf(new(big.Int).SetUint64(src.Uint())))
+			return nil
+		}
+	}
+
+	if n, ok := v.(*big.Int); ok {
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !n.IsInt64() || dst.OverflowInt(n.Int64()) {
+				return fmt.Errorf("value %s overflows %s", n, dst.Type())
+			}
+			dst.SetInt(n.Int64())
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if !n.IsUint64() || dst.OverflowUint(n.Uint64()) {
+				return fmt.Errorf("value %s overflows %s", n, dst.Type())
+			}
+			dst.SetUint(n.Uint64())
+			return nil
+		}
+	}
+
+	if src.Kind() == reflect.Slice && dst.Kind() == reflect.Array {
+		if src.Len() != dst.Len() || !src.Type().Elem().ConvertibleTo(dst.Type().Elem()) {
+			return fmt.Errorf("cannot use %T as %s", v, dst.Type())
+		}
+		for j := 0; j < src.Len(); j++ {
+			dst.Index(j).Set(src.Index(j).Convert(dst.Type().Elem()))
+		}
+		return nil
+	}
+
+	if src.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("cannot use %T as %s", v, dst.Type())
+}
+
+// contractCallArgs returns the positional arguments of the call to the method, where
+// named arguments, keyed by the names of the method inputs, are put in the order of the
+// inputs. Arguments passed as JSON are decoded first.
+func contractCallArgs(method abi.Method, callDef ContractCallDef) ([]any, error) {
+	n := 0
+	for _, set := range []bool{callDef.Args != nil, callDef.NamedArgs != nil, len(callDef.ArgsJSON) > 0} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return nil, fmt.Errorf("only one of args, named args and json args can be passed")
 	}
 
+	switch {
+	case callDef.NamedArgs != nil:
+		return namedContractCallArgs(method, callDef.NamedArgs)
+
+	case len(callDef.ArgsJSON) > 0:
+		var args any
+		dec := json.NewDecoder(bytes.NewReader(callDef.ArgsJSON))
+		dec.UseNumber()
+		if err := dec.Decode(&args); err != nil {
+			return nil, fmt.Errorf("failed to decode json args: %w", err)
+		}
+		switch args := args.(type) {
+		case []any:
+			return args, nil
+		case map[string]any:
+			return namedContractCallArgs(method, args)
+		default:
+			return nil, fmt.Errorf("json args must be an array, or an object keyed by the input names")
+		}
+
+	default:
+		return callDef.Args, nil
+	}
+}
+
+func namedContractCallArgs(method abi.Method, args map[string]any) ([]any, error) {
+	out := make([]any, len(method.Inputs))
+	names := make(map[string]struct{}, len(method.Inputs))
+	for i, input := range method.Inputs {
+		// unnamed inputs of an abi signature are named by their position, ie. arg1
+		if input.Name == "" || input.Name == fmt.Sprintf("arg%d", i+1) {
+			return nil, fmt.Errorf("named args require the abi of method %s to have named inputs, but input %d is unnamed", method.Name, i)
+		}
+		arg, ok := args[input.Name]
+		if !ok {
+			return nil, fmt.Errorf("named args are missing method %s input '%s'", method.Name, input.Name)
+		}
+		out[i] = arg
+		names[input.Name] = struct{}{}
+	}
+	for name := range args {
+		if _, ok := names[name]; !ok {
+			return nil, fmt.Errorf("named arg '%s' is not an input of method %s", name, method.Name)
+		}
+	}
 	return out, nil
 }
 
+// jsonNumbersToStrings returns the array with the json numbers in it, and in its nested
+// arrays, converted to their string values, as the string values of the args are decoded
+// by their abi types.
+func jsonNumbersToStrings(values []any) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case json.Number:
+			out[i] = v.String()
+		case []any:
+			out[i] = jsonNumbersToStrings(v)
+		default:
+			out[i] = v
+		}
+	}
+	return out
+}
+
 func prepareContractCallArgs(args []any) ([]any, error) {
 	var err error
 	out := make([]any, len(args))
 
 	for i, arg := range args {
 		switch arg := arg.(type) {
-		case string, []string, []any:
+		case string, []string:
 			out[i] = arg
 
+		case []any:
+			out[i] = jsonNumbersToStrings(arg)
+
+		case json.Number:
+			out[i] = arg.String()
+
 		case map[string]interface{}:
 			nst := arg
 
@@ -364,21 +601,22 @@ func prepareContractCallArgs(args []any) ([]any, error) {
 				funcName = v
 			}
 
-			args, ok := nst["args"].([]interface{})
-			if !ok {
-				return nil, fmt.Errorf("nested encode expects the 'args' field to be an array")
-			}
-
 			abi, ok := nst["abi"].(string)
 			if !ok {
 				return nil, fmt.Errorf("nested encode expects an 'abi' field")
 			}
 
-			out[i], err = EncodeContractCall(ContractCallDef{
-				ABI:  abi,
-				Func: funcName,
-				Args: args,
-			})
+			nestedDef := ContractCallDef{ABI: abi, Func: funcName}
+			switch args := nst["args"].(type) {
+			case []interface{}:
+				nestedDef.Args = args
+			case map[string]interface{}:
+				nestedDef.NamedArgs = args
+			default:
+				return nil, fmt.Errorf("nested encode expects the 'args' field to be an array, or an object keyed by the input names")
+			}
+
+			out[i], err = EncodeContractCall(nestedDef)
 			if err != nil {
 				return nil, err
 			}
@@ -113,6 +113,11 @@ func ABIMarshalStringValues(argTypes []string, input []byte) ([]string, error) {
 //     returns []interface{}{[]common.Address{common.HexToAddress("0x1234..."), common.HexToAddress("0x5678...")}}
 //   - AbiUnmarshalStringValuesAny([]string{"(address,uint256)"}, []any{[]any{"0x1234...", "543"}})
 //     returns []interface{}{[]interface{}{common.HexToAddress("0x1234..."), big.NewInt(543)}}
+//   - AbiUnmarshalStringValuesAny([]string{"(address,uint256)[]"}, []any{[]any{[]any{"0x1234...", "543"}}})
+//     returns []interface{}{[]interface{}{[]interface{}{common.HexToAddress("0x1234..."), big.NewInt(543)}}}
+//
+// Arrays of types other than addresses and numbers, ie. arrays of tuples, are returned as
+// []interface{} of their elements, see EncodeContractCall for packing them into calldata.
 //
 // The common use for this method is to pass a JSON object of string values for an abi method
 // and have it properly encode to the native abi types.
@@ -196,16 +201,9 @@ func ABIUnmarshalStringValuesAny(argTypes []string, stringValues []any) ([]any,
 				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. invalid number type '%s'", i, typ)
 			}
 
-			base := 10
-			if strings.HasPrefix(s, "0x") {
-				base = 16
-				s = s[2:]
-			}
-
-			num := big.NewInt(0)
-			num, ok = num.SetString(s, base)
-			if !ok {
-				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. expecting number. unable to set value of '%s'", i, s)
+			num, err := parseABINumber(typ, match[1] == "int", size, s)
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. %w", i, err)
 			}
 			values = append(values, num)
 			continue
@@ -248,39 +246,29 @@ func ABIUnmarshalStringValuesAny(argTypes []string, stringValues []any) ([]any,
 				return nil, err
 			}
 
-			if baseTyp != "address" {
-				submatch := regexArgNumber.FindStringSubmatch(baseTyp)
-				if len(submatch) == 0 {
-					return nil, fmt.Errorf("ethcoder: value at position %d of type %s is unsupported. Only number string arrays are presently supported", i, typ)
-				}
-			}
-
-			s, ok := v.([]string)
-			if !ok {
-				vv, ok := v.([]any)
-				if !ok {
-					return nil, fmt.Errorf("ethcoder: value at position %d is invalid, expecting string array", i)
-				}
-				s = make([]string, len(vv))
-				for j, x := range vv {
-					s[j], ok = x.(string)
-					if !ok {
-						return nil, fmt.Errorf("ethcoder: value at position %d is invalid, expecting string array", i)
-					}
+			var elems []any
+			switch v := v.(type) {
+			case []any:
+				elems = v
+			case []string:
+				elems = make([]any, len(v))
+				for j, x := range v {
+					elems[j] = x
 				}
+			default:
+				return nil, fmt.Errorf("ethcoder: value at position %d is invalid, expecting array", i)
 			}
 
-			stringValues := s
-			if count > 0 && len(stringValues) != int(count) {
+			if count > 0 && len(elems) != int(count) {
 				return nil, fmt.Errorf("ethcoder: value at position %d is invalid, array size does not match required size of %d", i, count)
 			}
 
 			var arrayArgs []string
-			for i := 0; i < len(stringValues); i++ {
+			for i := 0; i < len(elems); i++ {
 				arrayArgs = append(arrayArgs, baseTyp)
 			}
 
-			arrayValues, err := ABIUnmarshalStringValues(arrayArgs, stringValues)
+			arrayValues, err := ABIUnmarshalStringValuesAny(arrayArgs, elems)
 			if err != nil {
 				return nil, fmt.Errorf("ethcoder: value at position %d is invalid, failed to get string values for array - %w", i, err)
 			}
@@ -295,7 +283,7 @@ func ABIUnmarshalStringValuesAny(argTypes []string, stringValues []any) ([]any,
 					addresses = append(addresses, address)
 				}
 				values = append(values, addresses)
-			} else {
+			} else if regexArgNumber.MatchString(baseTyp) {
 				var bnArray []*big.Int
 				for _, n := range arrayValues {
 					bn, ok := n.(*big.Int)
@@ -305,23 +293,20 @@ func ABIUnmarshalStringValuesAny(argTypes []string, stringValues []any) ([]any,
 					bnArray = append(bnArray, bn)
 				}
 				values = append(values, bnArray)
+			} else {
+				// arrays of any other type, ie. tuples, are returned as []any, and
+				// are converted to the native abi type when packed
+				values = append(values, arrayValues)
 			}
+			continue
 		}
 
-		// tuples
-		idx := strings.Index(typ, "(")
-		idx2 := strings.Index(typ, ")")
-		if idx >= 0 && idx2 > 0 {
-
-			// TODO: add nested tuple support in the future:
-			// need to encode inner parts first, find the next '(' after idx .. etc.. and call AbiUnmarshalStringValuesAny recursively
-
-			t := typ[idx+1 : idx2]
-			idx := strings.Index(t, "(")
-			if idx >= 0 {
-				return nil, fmt.Errorf("ethcoder: value at position %d has found a nested tuple, which is unsupported currently, please contact support and make a request", i)
+		// tuples, which may be nested
+		if strings.HasPrefix(typ, "(") && strings.HasSuffix(typ, ")") {
+			args, err := splitTupleArgTypes(typ[1 : len(typ)-1])
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: value at position %d has invalid tuple type %s: %w", i, typ, err)
 			}
-			args := strings.Split(t, ",")
 
 			var vv []any
 			switch v := v.(type) {
@@ -347,7 +332,10 @@ func ABIUnmarshalStringValuesAny(argTypes []string, stringValues []any) ([]any,
 				return nil, fmt.Errorf("ethcoder: value at position %d is invalid, failed to get string values for tuple: %w", i, err)
 			}
 			values = append(values, out)
+			continue
 		}
+
+		return nil, fmt.Errorf("ethcoder: value at position %d is of unsupported type %s", i, typ)
 	}
 
 	return values, nil
@@ -414,16 +402,9 @@ func ABIUnmarshalStringValues(argTypes []string, stringValues []string) ([]any,
 				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. invalid number type '%s'", i, typ)
 			}
 
-			base := 10
-			if strings.HasPrefix(s, "0x") {
-				base = 16
-				s = s[2:]
-			}
-
-			num := big.NewInt(0)
-			num, ok := num.SetString(s, base)
-			if !ok {
-				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. expecting number. unable to set value of '%s'", i, s)
+			num, err := parseABINumber(typ, match[1] == "int", size, s)
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. %w", i, err)
 			}
 			values = append(values, num)
 			continue
@@ -550,6 +531,55 @@ func ABIEncodeMethodCalldataFromStringValuesAny(methodSig string, argStringValue
 	return abi.EncodeMethodCalldataFromStringValuesAny(methodName, argStringValues)
 }
 
+// ABIDecodeMethodReturn decodes the data returned from a contract call by the return types
+// of a combined method signature, ie. "balanceOf(address)(uint256)", the same signature
+// which can be passed to ABIEncodeMethodCalldata or EncodeContractCall to encode the call.
+func ABIDecodeMethodReturn(methodSig string, data []byte) ([]any, error) {
+	_, returnSig, err := splitMethodReturnSignature(methodSig)
+	if err != nil {
+		return nil, err
+	}
+	if returnSig == "" {
+		return nil, fmt.Errorf("ethcoder: method signature %q has no return types", methodSig)
+	}
+	abiSig, err := ParseABISignature(returnSig)
+	if err != nil {
+		return nil, err
+	}
+	return ABIUnpackArguments(abiSig.ArgTypes, data)
+}
+
+// splitTupleArgTypes splits the comma separated types of a tuple, ie. "uint256,(address,bytes)[]",
+// into its top-level types.
+func splitTupleArgTypes(t string) ([]string, error) {
+	var (
+		args  []string
+		depth int
+		start int
+	)
+	for i, c := range t {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parenthesis")
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(t[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parenthesis")
+	}
+	args = append(args, strings.TrimSpace(t[start:]))
+	return args, nil
+}
+
 func buildArgumentsFromTypes(argTypes []string) (abi.Arguments, error) {
 	args := abi.Arguments{}
 	for _, argType := range argTypes {
@@ -561,3 +591,38 @@ func buildArgumentsFromTypes(argTypes []string) (abi.Arguments, error) {
 	}
 	return args, nil
 }
+
+// parseABINumber parses the decimal or 0x prefixed hex string value of a number of the
+// abi type, ie. uint256 or int8, where negative values are prefixed with "-", ie. "-1"
+// or "-0x1". The value must be within the range of the type.
+func parseABINumber(typ string, signed bool, size int64, s string) (*big.Int, error) {
+	digits := strings.TrimPrefix(s, "-")
+	base := 10
+	if strings.HasPrefix(digits, "0x") {
+		base = 16
+		digits = digits[2:]
+	}
+
+	num, ok := new(big.Int).SetString(digits, base)
+	if !ok || strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		return nil, fmt.Errorf("expecting number. unable to set value of '%s'", s)
+	}
+	if strings.HasPrefix(s, "-") {
+		num.Neg(num)
+	}
+
+	var min, max *big.Int
+	if signed {
+		max = new(big.Int).Lsh(big.NewInt(1), uint(size-1))
+		min = new(big.Int).Neg(max)
+		max.Sub(max, big.NewInt(1))
+	} else {
+		max = new(big.Int).Lsh(big.NewInt(1), uint(size))
+		min = big.NewInt(0)
+		max.Sub(max, big.NewInt(1))
+	}
+	if num.Cmp(min) < 0 || num.Cmp(max) > 0 {
+		return nil, fmt.Errorf("value '%s' is out of range of %s", s, typ)
+	}
+	return num, nil
+}
@@ -2,8 +2,11 @@ package ethcoder
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 )
 
 type ABISignature struct {
@@ -14,6 +17,10 @@ type ABISignature struct {
 	ArgNames   []string // the method/event arg names, ie. [from, to, value] or ["","",""]
 	ArgIndexed []bool   // the event arg indexed flag, ie. [true, false, true]
 	NumIndexed int
+
+	// Anonymous is set for an anonymous event, whose logs have no topic0 of the event
+	// signature, so its indexed args are in the log topics from topic0 on.
+	Anonymous bool
 }
 
 func (e ABISignature) String() string {
@@ -33,6 +40,9 @@ func (e ABISignature) String() string {
 			s += ","
 		}
 	}
+	if e.Anonymous {
+		return fmt.Sprintf("%s(%s) anonymous", e.Name, s)
+	}
 	return fmt.Sprintf("%s(%s)", e.Name, s)
 }
 
@@ -61,7 +71,7 @@ func (s ABISignature) ToABI(isEvent bool) (abi.ABI, string, error) {
 
 	var contractABI abi.ABI
 	if isEvent {
-		abiEvent := abi.NewEvent(s.Name, s.Name, false, abiArgs)
+		abiEvent := abi.NewEvent(s.Name, s.Name, s.Anonymous, abiArgs)
 		contractABI = abi.ABI{
 			Events: map[string]abi.Event{},
 		}
@@ -76,3 +86,106 @@ func (s ABISignature) ToABI(isEvent bool) (abi.ABI, string, error) {
 
 	return contractABI, s.Name, nil
 }
+
+// ABIToSignatures returns the signatures of the functions and events of the contract ABI
+// JSON, in the canonical form accepted by EncodeContractCall and ParseABISignature, ie.
+// "transfer(address,uint256)", where tuples are expanded to their component types, ie.
+// "fill((address,uint256)[],bytes)". The Hash of an event signature is its topic hash,
+// and the method selector is the first 4 bytes of the Hash of a function signature.
+//
+// Anonymous events are skipped, as they have no topic hash. The signatures are sorted
+// by their Signature.
+func ABIToSignatures(abiJSON string) ([]ABISignature, []ABISignature, error) {
+	contractABI := NewABI()
+	if err := contractABI.AddABIFromJSON(abiJSON); err != nil {
+		return nil, nil, fmt.Errorf("ethcoder: invalid abi json: %w", err)
+	}
+	rawABI := contractABI.RawABI()
+
+	methodSigs := make([]ABISignature, 0, len(rawABI.Methods))
+	for _, method := range rawABI.Methods {
+		abiSig, err := abiArgumentsToABISignature(method.Sig, method.Inputs)
+		if err != nil {
+			return nil, nil, err
+		}
+		methodSigs = append(methodSigs, abiSig)
+	}
+
+	eventSigs := make([]ABISignature, 0, len(rawABI.Events))
+	for _, event := range rawABI.Events {
+		if event.Anonymous {
+			continue
+		}
+		abiSig, err := abiArgumentsToABISignature(event.Sig, event.Inputs)
+		if err != nil {
+			return nil, nil, err
+		}
+		eventSigs = append(eventSigs, abiSig)
+	}
+
+	sort.Slice(methodSigs, func(i, j int) bool { return methodSigs[i].Signature < methodSigs[j].Signature })
+	sort.Slice(eventSigs, func(i, j int) bool { return eventSigs[i].Signature < eventSigs[j].Signature })
+
+	return methodSigs, eventSigs, nil
+}
+
+// ABISelectors returns the lookup tables of the contract ABI JSON, of the function and
+// custom error selectors, and the event topic hashes, mapped to their canonical signatures,
+// ie. "transfer(address,uint256)". They're meant to be built once per ABI, ie. to route
+// or decode the calldata, reverts and logs of a contract.
+//
+// Anonymous events are skipped, as they have no topic hash.
+func ABISelectors(abiJSON string) (methods map[[4]byte]string, errorSigs map[[4]byte]string, events map[common.Hash]string, err error) {
+	contractABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("ethcoder: invalid abi json: %w", err)
+	}
+
+	methods = make(map[[4]byte]string, len(contractABI.Methods))
+	for _, method := range contractABI.Methods {
+		var selector [4]byte
+		copy(selector[:], method.ID)
+		methods[selector] = method.Sig
+	}
+
+	errorSigs = make(map[[4]byte]string, len(contractABI.Errors))
+	for _, abiError := range contractABI.Errors {
+		var selector [4]byte
+		copy(selector[:], abiError.ID[:4])
+		errorSigs[selector] = abiError.Sig
+	}
+
+	events = make(map[common.Hash]string, len(contractABI.Events))
+	for _, event := range contractABI.Events {
+		if event.Anonymous {
+			continue
+		}
+		events[event.ID] = event.Sig
+	}
+
+	return methods, errorSigs, events, nil
+}
+
+// abiArgumentsToABISignature parses the canonical signature of a method or event, and sets
+// the argument names and indexed flags of the abi arguments.
+func abiArgumentsToABISignature(sig string, args abi.Arguments) (ABISignature, error) {
+	abiSig, err := ParseABISignature(sig)
+	if err != nil {
+		return ABISignature{}, fmt.Errorf("ethcoder: invalid abi signature %s: %w", sig, err)
+	}
+	if len(args) != len(abiSig.ArgTypes) {
+		return ABISignature{}, fmt.Errorf("ethcoder: invalid abi signature %s: arguments do not match", sig)
+	}
+
+	abiSig.NumIndexed = 0
+	for i, arg := range args {
+		if arg.Name != "" {
+			abiSig.ArgNames[i] = arg.Name
+		}
+		abiSig.ArgIndexed[i] = arg.Indexed
+		if arg.Indexed {
+			abiSig.NumIndexed++
+		}
+	}
+	return abiSig, nil
+}
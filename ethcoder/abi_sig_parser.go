@@ -5,6 +5,9 @@ import (
 	"strings"
 )
 
+// ParseABISignature parses a method or event signature, ie.
+// "Transfer(address indexed from, address indexed to, uint256 value)". An event signature
+// may be followed by the "anonymous" keyword, as in solidity, for an anonymous event.
 func ParseABISignature(abiSignature string) (ABISignature, error) {
 	abiSig := ABISignature{
 		ArgTypes:   []string{},
@@ -30,6 +33,10 @@ func ParseABISignature(abiSignature string) (ABISignature, error) {
 	method := strings.TrimSpace(abiSignature[:a])
 	abiSig.Name = method
 
+	if strings.TrimSpace(abiSignature[b+1:]) == "anonymous" {
+		abiSig.Anonymous = true
+	}
+
 	args := strings.TrimSpace(abiSignature[a+1 : b])
 
 	if args == "" {
@@ -316,3 +323,26 @@ func findParensCloseIndex(args string) (int, error) {
 	}
 	return -1, fmt.Errorf("invalid function args, no closing parenthesis found")
 }
+
+// splitMethodReturnSignature splits a combined method signature with return types,
+// ie. "balanceOf(address)(uint256)" or "balanceOf(address) returns (uint256)", into the
+// method signature and the return types expression, ie. "balanceOf(address)" and "(uint256)".
+// The return types expression is empty if the signature has none.
+func splitMethodReturnSignature(sig string) (string, string, error) {
+	a := strings.Index(sig, "(")
+	if a < 0 {
+		return sig, "", nil
+	}
+	b, err := findParensCloseIndex(sig[a:])
+	if err != nil {
+		return "", "", err
+	}
+	methodSig := strings.TrimSpace(sig[:a+b+1])
+
+	returnSig := strings.TrimSpace(sig[a+b+1:])
+	returnSig = strings.TrimSpace(strings.TrimPrefix(returnSig, "returns"))
+	if returnSig != "" && (returnSig[0] != '(' || returnSig[len(returnSig)-1] != ')') {
+		return "", "", fmt.Errorf("abi format is invalid, expecting Method(arg1,arg2,..)(ret1,ret2,..)")
+	}
+	return methodSig, returnSig, nil
+}
@@ -3,6 +3,7 @@ package ethcoder
 import (
 	"testing"
 
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -150,3 +151,64 @@ func TestParseABISignatureArgs(t *testing.T) {
 		// require.True(t, ok)
 	}
 }
+
+func TestABIToSignatures(t *testing.T) {
+	abiJSON := `[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"fill","inputs":[{"name":"orders","type":"tuple[]","components":[{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[2]"}]},{"name":"","type":"bytes"}],"outputs":[]},
+		{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+		{"type":"event","name":"Filled","anonymous":false,"inputs":[{"name":"order","type":"tuple","indexed":false,"components":[{"name":"maker","type":"address"},{"name":"amount","type":"uint256"}]}]},
+		{"type":"event","name":"Anon","anonymous":true,"inputs":[]}
+	]`
+
+	methodSigs, eventSigs, err := ABIToSignatures(abiJSON)
+	require.NoError(t, err)
+
+	require.Len(t, methodSigs, 2)
+	require.Equal(t, "fill((address,uint256[2])[],bytes)", methodSigs[0].Signature)
+	require.Equal(t, []string{"(address,uint256[2])[]", "bytes"}, methodSigs[0].ArgTypes)
+	require.Equal(t, []string{"orders", "arg2"}, methodSigs[0].ArgNames)
+	require.Equal(t, "transfer(address,uint256)", methodSigs[1].Signature)
+	require.Equal(t, "0xa9059cbb", methodSigs[1].Hash[:10])
+
+	require.Len(t, eventSigs, 2)
+	require.Equal(t, "Filled((address,uint256))", eventSigs[0].Signature)
+	require.Equal(t, "Transfer(address,address,uint256)", eventSigs[1].Signature)
+	require.Equal(t, "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", eventSigs[1].Hash)
+	require.Equal(t, []bool{true, true, false}, eventSigs[1].ArgIndexed)
+	require.Equal(t, 2, eventSigs[1].NumIndexed)
+
+	// same as the parsed event signature
+	eventSig, err := ParseABISignature("Transfer(address indexed from, address indexed to, uint256 value)")
+	require.NoError(t, err)
+	require.Equal(t, eventSig, eventSigs[1])
+
+	_, _, err = ABIToSignatures("not json")
+	require.Error(t, err)
+}
+
+func TestABISelectors(t *testing.T) {
+	abiJSON := `[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"fill","inputs":[{"name":"orders","type":"tuple[]","components":[{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[2]"}]},{"name":"","type":"bytes"}],"outputs":[]},
+		{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]},
+		{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+		{"type":"event","name":"Anon","anonymous":true,"inputs":[]}
+	]`
+
+	methods, errors, events, err := ABISelectors(abiJSON)
+	require.NoError(t, err)
+
+	require.Len(t, methods, 2)
+	require.Equal(t, "transfer(address,uint256)", methods[[4]byte{0xa9, 0x05, 0x9c, 0xbb}])
+	require.Equal(t, "fill((address,uint256[2])[],bytes)", methods[[4]byte(Keccak256([]byte("fill((address,uint256[2])[],bytes)"))[:4])])
+
+	require.Len(t, errors, 1)
+	require.Equal(t, "InsufficientBalance(uint256,uint256)", errors[[4]byte{0xcf, 0x47, 0x91, 0x81}])
+
+	require.Len(t, events, 1)
+	require.Equal(t, "Transfer(address,address,uint256)", events[common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")])
+
+	_, _, _, err = ABISelectors("not json")
+	require.Error(t, err)
+}
@@ -0,0 +1,314 @@
+package ethcoder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+// EncodeStruct encodes the calldata of a method signature with the arguments read from
+// the fields of the struct v, ie.
+//
+//	type Transfer struct {
+//		To     common.Address `abi:"to"`
+//		Amount *big.Int       `abi:"amount"`
+//	}
+//	calldata, err := ethcoder.EncodeStruct("transfer(address to,uint256 amount)", Transfer{..})
+//
+// When every argument of the signature is named, fields are mapped to arguments by the
+// name of their `abi` tag, or by their field name if untagged, and otherwise by their
+// order in the struct. Fields tagged `abi:"-"` and unexported fields are skipped.
+//
+// Tuple arguments are encoded from nested structs, whose fields are always mapped by
+// order, as the signature does not retain the names of tuple components. Numeric fields
+// are converted to the size of their abi type, ie. a uint64 to a *big.Int for a uint256.
+func EncodeStruct(methodSig string, v any) ([]byte, error) {
+	methodSig, _, err := splitMethodReturnSignature(methodSig)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: %w", err)
+	}
+	abiSig, err := ParseABISignature(methodSig)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: invalid method signature %s: %w", methodSig, err)
+	}
+	contractABI, name, err := abiSig.ToABI(false)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: invalid method signature %s: %w", methodSig, err)
+	}
+	args := contractABI.Methods[name].Inputs
+
+	fields, err := mapStructFields(args, abiSig.ArgNames, reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i], err = structFieldToABIValue(arg.Type, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: argument %s: %w", arg.Name, err)
+		}
+	}
+
+	packed, err := contractABI.Pack(name, values...)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: %w", err)
+	}
+	return packed, nil
+}
+
+// DecodeToStruct decodes the abi encoded values of data into the fields of the struct
+// pointed to by out, see EncodeStruct for how fields are mapped. The types are those of
+// the return values of sig when it has any, ie. "balanceOf(address)(uint256 balance)",
+// or otherwise of its arguments, ie. "(uint256 balance,address owner)".
+//
+// NOTE: data holds only the encoded values, so calldata must have its 4 byte method
+// selector removed.
+func DecodeToStruct(sig string, data []byte, out any) error {
+	methodSig, returnSig, err := splitMethodReturnSignature(sig)
+	if err != nil {
+		return fmt.Errorf("ethcoder: %w", err)
+	}
+	if returnSig != "" {
+		methodSig = returnSig
+	}
+
+	abiSig, err := ParseABISignature(methodSig)
+	if err != nil {
+		return fmt.Errorf("ethcoder: invalid signature %s: %w", sig, err)
+	}
+	if abiSig.Name == "" {
+		// the abi requires a method name
+		abiSig.Name = "decode"
+		abiSig.Signature = abiSig.Name + abiSig.Signature
+	}
+	contractABI, name, err := abiSig.ToABI(false)
+	if err != nil {
+		return fmt.Errorf("ethcoder: invalid signature %s: %w", sig, err)
+	}
+	args := contractABI.Methods[name].Inputs
+
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("ethcoder: DecodeToStruct expects a pointer to a struct, got %T", out)
+	}
+
+	fields, err := mapStructFields(args, abiSig.ArgNames, rv)
+	if err != nil {
+		return err
+	}
+
+	values, err := args.UnpackValues(data)
+	if err != nil {
+		return fmt.Errorf("ethcoder: %w", err)
+	}
+	for i, arg := range args {
+		if err := setStructFieldFromABIValue(fields[i], arg.Type, values[i]); err != nil {
+			return fmt.Errorf("ethcoder: argument %s: %w", arg.Name, err)
+		}
+	}
+	return nil
+}
+
+// mapStructFields returns the fields of the struct v for each of the args, by the arg
+// names if they are all set, or otherwise by the order of the fields.
+func mapStructFields(args abi.Arguments, argNames []string, v reflect.Value) ([]reflect.Value, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, fmt.Errorf("ethcoder: expecting a struct, got nil")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ethcoder: expecting a struct, got %s", v.Type())
+	}
+
+	fields, names := structFields(v)
+
+	// unnamed args are given the names arg1, arg2.. by ParseABISignature
+	byName := len(argNames) > 0
+	for i, name := range argNames {
+		if name == "" || name == fmt.Sprintf("arg%d", i+1) {
+			byName = false
+			break
+		}
+	}
+
+	out := make([]reflect.Value, len(args))
+	if !byName {
+		if len(fields) != len(args) {
+			return nil, fmt.Errorf("ethcoder: struct %s has %d fields, expecting %d", v.Type(), len(fields), len(args))
+		}
+		copy(out, fields)
+		return out, nil
+	}
+
+	for i, argName := range argNames {
+		j := findStructField(names, argName)
+		if j < 0 {
+			return nil, fmt.Errorf("ethcoder: struct %s has no field for argument %s", v.Type(), argName)
+		}
+		out[i] = fields[j]
+	}
+	return out, nil
+}
+
+// structFields returns the exported fields of the struct v, in order, along with their
+// names, which are the names of their `abi` tag if set.
+func structFields(v reflect.Value) ([]reflect.Value, []string) {
+	var (
+		fields []reflect.Value
+		names  []string
+	)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("abi"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, v.Field(i))
+		names = append(names, name)
+	}
+	return fields, names
+}
+
+// findStructField returns the index of the field name matching the arg name, preferring
+// an exact match, ie. of an `abi` tag, over a case-insensitive one, ie. of a field name.
+func findStructField(names []string, argName string) int {
+	for i, name := range names {
+		if name == argName {
+			return i
+		}
+	}
+	for i, name := range names {
+		if strings.EqualFold(name, argName) {
+			return i
+		}
+	}
+	return -1
+}
+
+// structFieldToABIValue converts the field into the value the geth abi encoder expects
+// for the abi type, ie. a nested struct into the struct type of a tuple.
+func structFieldToABIValue(typ abi.Type, field reflect.Value) (any, error) {
+	switch typ.T {
+	case abi.TupleTy:
+		if field.Kind() == reflect.Pointer && !field.IsNil() {
+			field = field.Elem()
+		}
+		if field.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("tuple %s expects a struct, got %s", typ.String(), field.Type())
+		}
+		fields, _ := structFields(field)
+		if len(fields) != len(typ.TupleElems) {
+			return nil, fmt.Errorf("struct %s has %d fields, expecting %d for tuple %s", field.Type(), len(fields), len(typ.TupleElems), typ.String())
+		}
+
+		instance := reflect.New(typ.GetType()).Elem()
+		for j, elemTyp := range typ.TupleElems {
+			elem, err := structFieldToABIValue(*elemTyp, fields[j])
+			if err != nil {
+				return nil, err
+			}
+			if err := setPackableValue(instance.Field(j), elem); err != nil {
+				return nil, err
+			}
+		}
+		return instance.Interface(), nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		if field.Kind() != reflect.Slice && field.Kind() != reflect.Array {
+			return nil, fmt.Errorf("%s expects a slice or array, got %s", typ.String(), field.Type())
+		}
+
+		var out reflect.Value
+		if typ.T == abi.SliceTy {
+			out = reflect.MakeSlice(typ.GetType(), field.Len(), field.Len())
+		} else {
+			if field.Len() != typ.Size {
+				return nil, fmt.Errorf("%s expects %d values, got %d", typ.String(), typ.Size, field.Len())
+			}
+			out = reflect.New(typ.GetType()).Elem()
+		}
+		for j := 0; j < field.Len(); j++ {
+			elem, err := structFieldToABIValue(*typ.Elem, field.Index(j))
+			if err != nil {
+				return nil, err
+			}
+			if err := setPackableValue(out.Index(j), elem); err != nil {
+				return nil, err
+			}
+		}
+		return out.Interface(), nil
+
+	default:
+		if field.Kind() == reflect.Pointer && field.IsNil() {
+			return nil, fmt.Errorf("missing value for %s", typ.String())
+		}
+		out := reflect.New(typ.GetType()).Elem()
+		if err := setPackableValue(out, field.Interface()); err != nil {
+			return nil, err
+		}
+		return out.Interface(), nil
+	}
+}
+
+// setStructFieldFromABIValue sets the field to the value decoded by the geth abi decoder
+// for the abi type, ie. the struct type of a tuple into a nested struct.
+func setStructFieldFromABIValue(field reflect.Value, typ abi.Type, v any) error {
+	if field.Kind() == reflect.Pointer && typ.T == abi.TupleTy {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+
+	src := reflect.ValueOf(v)
+	switch typ.T {
+	case abi.TupleTy:
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("tuple %s expects a struct, got %s", typ.String(), field.Type())
+		}
+		fields, _ := structFields(field)
+		if len(fields) != len(typ.TupleElems) {
+			return fmt.Errorf("struct %s has %d fields, expecting %d for tuple %s", field.Type(), len(fields), len(typ.TupleElems), typ.String())
+		}
+		for j, elemTyp := range typ.TupleElems {
+			if err := setStructFieldFromABIValue(fields[j], *elemTyp, src.Field(j).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		switch field.Kind() {
+		case reflect.Slice:
+			field.Set(reflect.MakeSlice(field.Type(), src.Len(), src.Len()))
+		case reflect.Array:
+			if field.Len() != src.Len() {
+				return fmt.Errorf("%s has %d values, expecting %d for %s", field.Type(), field.Len(), src.Len(), typ.String())
+			}
+		default:
+			return fmt.Errorf("%s expects a slice or array, got %s", typ.String(), field.Type())
+		}
+		for j := 0; j < src.Len(); j++ {
+			if err := setStructFieldFromABIValue(field.Index(j), *typ.Elem, src.Index(j).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return setPackableValue(field, v)
+	}
+}
@@ -144,6 +144,34 @@ func TestABIEncodeMethodCalldata(t *testing.T) {
 	}
 }
 
+func TestABIDecodeMethodReturn(t *testing.T) {
+	ownerAddress := common.HexToAddress("0x6615e4e985bf0d137196897dfa182dbd7127f54f")
+
+	// return types of the combined signature are ignored when encoding
+	calldata, err := ABIEncodeMethodCalldata("balanceOf(address,uint256)(uint256)", []interface{}{ownerAddress, big.NewInt(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x00fdd58e0000000000000000000000006615e4e985bf0d137196897dfa182dbd7127f54f0000000000000000000000000000000000000000000000000000000000000002", HexEncode(calldata))
+
+	data, err := HexDecode("0x000000000000000000000000000000000000000000007998f984c2040a5a9e01")
+	assert.NoError(t, err)
+
+	values, err := ABIDecodeMethodReturn("balanceOf(address,uint256)(uint256)", data)
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, "574228229235365901934081", values[0].(*big.Int).String())
+
+	// solidity-style returns, with multiple named return values
+	data, err = ABIPackArguments([]string{"address", "bool"}, []interface{}{ownerAddress, true})
+	assert.NoError(t, err)
+
+	values, err = ABIDecodeMethodReturn("getOwner((uint256,address)) returns (address owner, bool ok)", data)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{ownerAddress, true}, values)
+
+	_, err = ABIDecodeMethodReturn("balanceOf(address)", data)
+	assert.Error(t, err)
+}
+
 func TestABIDecodeExpr(t *testing.T) {
 	ret := "0x000000000000000000000000000000000000000000007998f984c2040a5a9e01"
 
@@ -209,6 +237,83 @@ func TestABIDecodeExprAndStringify(t *testing.T) {
 		assert.Len(t, values, 1)
 		assert.Equal(t, "[1 2 3 4]", values[0])
 	}
+
+	{
+		minInt256, _ := new(big.Int).SetString("-57896044618658097711785492504343953926634992332820282019728792003956564819968", 10)
+		maxInt256, _ := new(big.Int).SetString("57896044618658097711785492504343953926634992332820282019728792003956564819967", 10)
+
+		data, err := ABIPackArguments([]string{"int256", "int256", "int256", "int8", "int8", "int64"}, []interface{}{big.NewInt(-1), minInt256, maxInt256, int8(-128), int8(127), int64(-1337)})
+		assert.NoError(t, err)
+
+		values, err := ABIUnpackAndStringify("(int256,int256,int256,int8,int8,int64)", data)
+		assert.NoError(t, err)
+		assert.Len(t, values, 6)
+		assert.Equal(t, "-1", values[0])
+		assert.Equal(t, minInt256.String(), values[1])
+		assert.Equal(t, maxInt256.String(), values[2])
+		assert.Equal(t, "-128", values[3])
+		assert.Equal(t, "127", values[4])
+		assert.Equal(t, "-1337", values[5])
+	}
+}
+
+func TestABIUnmarshalStringValuesSigned(t *testing.T) {
+	minInt256, _ := new(big.Int).SetString("-57896044618658097711785492504343953926634992332820282019728792003956564819968", 10)
+	maxInt256, _ := new(big.Int).SetString("57896044618658097711785492504343953926634992332820282019728792003956564819967", 10)
+	maxUint256, _ := new(big.Int).SetString("115792089237316195423570985008687907853269984665640564039457584007913129639935", 10)
+
+	{
+		values, err := ABIUnmarshalStringValues(
+			[]string{"int256", "int256", "int256", "int256", "int8", "int8", "uint256"},
+			[]string{"-1", "-0x1", minInt256.String(), maxInt256.String(), "-128", "0x7f", maxUint256.String()},
+		)
+		assert.NoError(t, err)
+		assert.Len(t, values, 7)
+		assert.Equal(t, big.NewInt(-1), values[0])
+		assert.Equal(t, big.NewInt(-1), values[1])
+		assert.Equal(t, minInt256, values[2])
+		assert.Equal(t, maxInt256, values[3])
+		assert.Equal(t, big.NewInt(-128), values[4])
+		assert.Equal(t, big.NewInt(127), values[5])
+		assert.Equal(t, maxUint256, values[6])
+
+		data, err := ABIPackArguments([]string{"int256", "int256", "int256", "int256", "int8", "int8", "uint256"}, []interface{}{
+			values[0], values[1], values[2], values[3], int8(values[4].(*big.Int).Int64()), int8(values[5].(*big.Int).Int64()), values[6],
+		})
+		assert.NoError(t, err)
+
+		out, err := ABIUnpackAndStringify("(int256,int256,int256,int256,int8,int8,uint256)", data)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"-1", "-1", minInt256.String(), maxInt256.String(), "-128", "127", maxUint256.String()}, out)
+	}
+
+	{
+		values, err := ABIUnmarshalStringValuesAny([]string{"int256", "int16"}, []any{"-0x10", "-32768"})
+		assert.NoError(t, err)
+		assert.Len(t, values, 2)
+		assert.Equal(t, big.NewInt(-16), values[0])
+		assert.Equal(t, big.NewInt(-32768), values[1])
+	}
+
+	{
+		// out of range of the type
+		_, err := ABIUnmarshalStringValues([]string{"int8"}, []string{"-129"})
+		assert.Error(t, err)
+		_, err = ABIUnmarshalStringValues([]string{"int8"}, []string{"128"})
+		assert.Error(t, err)
+		_, err = ABIUnmarshalStringValues([]string{"int256"}, []string{new(big.Int).Add(maxInt256, big.NewInt(1)).String()})
+		assert.Error(t, err)
+		_, err = ABIUnmarshalStringValues([]string{"uint256"}, []string{"-1"})
+		assert.Error(t, err)
+		_, err = ABIUnmarshalStringValues([]string{"uint8"}, []string{"0x100"})
+		assert.Error(t, err)
+
+		// malformed
+		_, err = ABIUnmarshalStringValues([]string{"int256"}, []string{"--1"})
+		assert.Error(t, err)
+		_, err = ABIUnmarshalStringValues([]string{"int256"}, []string{"-"})
+		assert.Error(t, err)
+	}
 }
 
 func TestABIUnmarshalStringValuesAny(t *testing.T) {
@@ -357,6 +462,44 @@ func TestABIUnmarshalStringValuesAny(t *testing.T) {
 		require.Equal(t, "0x6615e4e985BF0D137196897Dfa182dBD7127f54f", a2b[0].String())
 		require.Equal(t, "0x1231F65F29F98E7d71a4655CCD7B2bC441211FeB", a2b[1].String())
 	}
+
+	{
+		// (address,uint256)[]
+		in := []any{[]any{[]string{"0x6615e4e985bf0d137196897dfa182dbd7127f54f", "1"}, []any{"0x1231f65f29f98e7D71A4655cCD7B2bc441211feb", "2"}}}
+		values, err := ABIUnmarshalStringValuesAny([]string{"(address,uint256)[]"}, in)
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+
+		a1, ok := values[0].([]any)
+		require.True(t, ok)
+		require.Len(t, a1, 2)
+
+		a1b, ok := a1[1].([]any)
+		require.True(t, ok)
+		require.Equal(t, common.HexToAddress("0x1231f65f29f98e7D71A4655cCD7B2bc441211feb"), a1b[0])
+		require.Equal(t, "2", a1b[1].(*big.Int).String())
+	}
+
+	{
+		// ((uint256,(address,bytes)[])[2],string)
+		in := []any{[]any{
+			[]any{[]any{"1", []any{}}, []any{"2", []any{[]any{"0x6615e4e985bf0d137196897dfa182dbd7127f54f", "0xabcd"}}}},
+			"hello",
+		}}
+		values, err := ABIUnmarshalStringValuesAny([]string{"((uint256,(address,bytes)[])[2],string)"}, in)
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+
+		tuple := values[0].([]any)
+		require.Equal(t, "hello", tuple[1])
+		inner := tuple[0].([]any)[1].([]any)
+		require.Equal(t, "2", inner[0].(*big.Int).String())
+		require.Equal(t, []byte{0xab, 0xcd}, inner[1].([]any)[0].([]any)[1])
+
+		// fixed size array is enforced
+		_, err = ABIUnmarshalStringValuesAny([]string{"((uint256,(address,bytes)[])[2],string)"}, []any{[]any{[]any{}, "hello"}})
+		require.Error(t, err)
+	}
 }
 
 func TestABIUnmarshalStringValues(t *testing.T) {
@@ -517,13 +660,13 @@ func TestEncodeContractCall(t *testing.T) {
 	require.Equal(t, "0x23b872dd0000000000000000000000000dc9603d4da53841c1c83f3b550c6143e60e04250000000000000000000000000dc9603d4da53841c1c83f3b550c6143e60e04250000000000000000000000000000000000000000000000000000000000000064", res)
 
 	// Encode simple transferFrom, named
-	// res, err = EncodeContractCall(ContractCallDef{
-	// 	ABI:  `[{"name":"transferFrom","type":"function","inputs":[{"name":"_from","type":"address"},{"name":"_to","type":"address"},{"name":"_value","type":"uint256"}]}]`,
-	// 	Func: "transferFrom",
-	// 	Args: json.RawMessage(`{"_from": "0x0dc9603d4da53841C1C83f3B550C6143e60e0425", "_value": "100", "_to": "0x0dc9603d4da53841C1C83f3B550C6143e60e0425"}`),
-	// })
-	// require.Nil(t, err)
-	// require.Equal(t, res, "0x23b872dd0000000000000000000000000dc9603d4da53841c1c83f3b550c6143e60e04250000000000000000000000000dc9603d4da53841c1c83f3b550c6143e60e04250000000000000000000000000000000000000000000000000000000000000064")
+	res, err = EncodeContractCall(ContractCallDef{
+		ABI:      `[{"name":"transferFrom","type":"function","inputs":[{"name":"_from","type":"address"},{"name":"_to","type":"address"},{"name":"_value","type":"uint256"}]}]`,
+		Func:     "transferFrom",
+		ArgsJSON: json.RawMessage(`{"_from": "0x0dc9603d4da53841C1C83f3B550C6143e60e0425", "_value": "100", "_to": "0x0dc9603d4da53841C1C83f3B550C6143e60e0425"}`),
+	})
+	require.Nil(t, err)
+	require.Equal(t, res, "0x23b872dd0000000000000000000000000dc9603d4da53841c1c83f3b550c6143e60e04250000000000000000000000000dc9603d4da53841c1c83f3b550c6143e60e04250000000000000000000000000000000000000000000000000000000000000064")
 
 	// Encode simple transferFrom, not named, passed as function
 	res, err = EncodeContractCall(ContractCallDef{
@@ -534,13 +677,92 @@ func TestEncodeContractCall(t *testing.T) {
 	require.Equal(t, "0x23b872dd00000000000000000000000013915b1ea28fd2e8197c88ff9d2422182e83bf250000000000000000000000004ad47f1611c78c824ff3892c4ae1cc04637d6462000000000000000000000000000000000000000000044b87969b06250e50bdc5", res)
 
 	// Encode simple transferFrom, named, passed as function
-	// res, err = EncodeContractCall(ContractCallDef{
-	// 	ABI:  `transferFrom(address _from,address _to,uint256 _value)`,
-	// 	Func: "transferFrom",
-	// 	Args: json.RawMessage(`{"_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_value": "5192381927398174182391237", "_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462"}`),
-	// })
-	// require.Nil(t, err)
-	// require.Equal(t, res, "0x23b872dd00000000000000000000000013915b1ea28fd2e8197c88ff9d2422182e83bf250000000000000000000000004ad47f1611c78c824ff3892c4ae1cc04637d6462000000000000000000000000000000000000000000044b87969b06250e50bdc5")
+	res, err = EncodeContractCall(ContractCallDef{
+		ABI:      `transferFrom(address _from,address _to,uint256 _value)`,
+		Func:     "transferFrom",
+		ArgsJSON: json.RawMessage(`{"_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_value": "5192381927398174182391237", "_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462"}`),
+	})
+	require.Nil(t, err)
+	require.Equal(t, res, "0x23b872dd00000000000000000000000013915b1ea28fd2e8197c88ff9d2422182e83bf250000000000000000000000004ad47f1611c78c824ff3892c4ae1cc04637d6462000000000000000000000000000000000000000000044b87969b06250e50bdc5")
+
+	// Encode simple transferFrom, named as a map, with a json number
+	res, err = EncodeContractCall(ContractCallDef{
+		ABI:       `transferFrom(address _from,address _to,uint256 _value)`,
+		NamedArgs: map[string]any{"_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", "_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_value": json.Number("9")},
+	})
+	require.Nil(t, err)
+	require.Equal(t, "0x23b872dd00000000000000000000000013915b1ea28fd2e8197c88ff9d2422182e83bf250000000000000000000000004ad47f1611c78c824ff3892c4ae1cc04637d64620000000000000000000000000000000000000000000000000000000000000009", res)
+
+	// Fail passing named args which don't match the abi
+	_, err = EncodeContractCall(ContractCallDef{
+		ABI:      `transferFrom(address _from,address _to,uint256 _value)`,
+		ArgsJSON: json.RawMessage(`{"_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462"}`),
+	})
+	require.ErrorContains(t, err, "missing method transferFrom input '_value'")
+
+	_, err = EncodeContractCall(ContractCallDef{
+		ABI:      `transferFrom(address _from,address _to,uint256 _value)`,
+		ArgsJSON: json.RawMessage(`{"_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", "_value": "1", "_data": "0x"}`),
+	})
+	require.ErrorContains(t, err, "'_data' is not an input")
+
+	_, err = EncodeContractCall(ContractCallDef{
+		ABI:       `transferFrom(address _from,address _to,uint256 _value)`,
+		Args:      []any{"0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", "1"},
+		NamedArgs: map[string]any{"_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", "_value": "1"},
+	})
+	require.ErrorContains(t, err, "only one of")
+
+	// Encode json numbers nested in arrays, named and positional
+	want, err := EncodeContractCall(ContractCallDef{
+		ABI:  `batch(address to,uint256[] ids)`,
+		Args: []any{"0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", []string{"1", "2"}},
+	})
+	require.Nil(t, err)
+
+	res, err = EncodeContractCall(ContractCallDef{
+		ABI:      `batch(address to,uint256[] ids)`,
+		ArgsJSON: json.RawMessage(`{"to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", "ids": [1, 2]}`),
+	})
+	require.Nil(t, err)
+	require.Equal(t, want, res)
+
+	res, err = EncodeContractCall(ContractCallDef{
+		ABI:      `batch(address to,uint256[] ids)`,
+		ArgsJSON: json.RawMessage(`["0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", [1, 2]]`),
+	})
+	require.Nil(t, err)
+	require.Equal(t, want, res)
+
+	want, err = EncodeContractCall(ContractCallDef{
+		ABI:  `grid(uint256[][] cells)`,
+		Args: []any{[]any{[]string{"1", "2"}, []string{"3"}}},
+	})
+	require.Nil(t, err)
+
+	res, err = EncodeContractCall(ContractCallDef{
+		ABI:      `grid(uint256[][] cells)`,
+		ArgsJSON: json.RawMessage(`[[[1, 2], [3]]]`),
+	})
+	require.Nil(t, err)
+	require.Equal(t, want, res)
+
+	// Encode a nested call with named args
+	want, err = EncodeContractCall(ContractCallDef{
+		ABI: `exec(address target,bytes data)`,
+		Args: []any{"0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", map[string]any{
+			"abi":  "transfer(address _to,uint256 _value)",
+			"args": []any{"0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "100"},
+		}},
+	})
+	require.Nil(t, err)
+
+	res, err = EncodeContractCall(ContractCallDef{
+		ABI:      `exec(address target,bytes data)`,
+		ArgsJSON: json.RawMessage(`{"target": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", "data": {"abi": "transfer(address _to,uint256 _value)", "args": {"_value": 100, "_to": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25"}}}`),
+	})
+	require.Nil(t, err)
+	require.Equal(t, want, res)
 
 	// // Encode nested bytes, passed as function
 	// nestedEncodeType1 := ContractCallDef{
@@ -576,12 +798,18 @@ func TestEncodeContractCall(t *testing.T) {
 	require.Equal(t, "0x8b6701df00000000000000000000000013915b1ea28fd2e8197c88ff9d2422182e83bf25000000000000000000000000000000000000000000000000000000000000006000000000000000000000000000000000000000000000000000000000000000c0000000000000000000000000000000000000000000000000000000000000002477a11f7e00000000000000000000000000000000000000000000001a2009191df61e988b0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000646ce8ea55000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000056d756e646f00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000", res)
 
 	// Fail passing named args to non-named abi
-	// res, err = EncodeContractCall(ContractCallDef{
-	// 	ABI:  `transferFrom(address,uint256)`,
-	// 	Func: "transferFrom",
-	// 	Args: json.RawMessage(`{"_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_value": "5192381927398174182391237", "_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462"}`),
-	// })
-	// assert.NotNil(t, err)
+	_, err = EncodeContractCall(ContractCallDef{
+		ABI:      `transferFrom(address,uint256)`,
+		Func:     "transferFrom",
+		ArgsJSON: json.RawMessage(`{"_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_value": "5192381927398174182391237", "_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462"}`),
+	})
+	assert.NotNil(t, err)
+
+	_, err = EncodeContractCall(ContractCallDef{
+		ABI:       `[{"name":"transferFrom","type":"function","inputs":[{"name":"","type":"address"},{"name":"","type":"address"},{"name":"","type":"uint256"}]}]`,
+		NamedArgs: map[string]any{"_from": "0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "_to": "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", "_value": "1"},
+	})
+	require.ErrorContains(t, err, "named inputs")
 
 	// Accept passing ordened args to named abi
 	res, err = EncodeContractCall(ContractCallDef{
@@ -632,3 +860,224 @@ func TestEncodeContractCall(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, "0x6365f1646bd55a2877890bd58871eefe886770a7734077a74981910a75d7b1f044b5bf280000000000000000000000000000000000000000000000000de0b6b3a7640000000000000000000000000000000000000000000000000000000000000000008000000000000000000000000000000000000000000000000000000000000000c000000000000000000000000000000000000000000000000000000000000000010000000000000000000000008541d65829f98f7d71a4655ccd7b2bb8494673bf000000000000000000000000000000000000000000000000000000000000008446c421fa000000000000000000000000000000000000000000000000000000005f5e10000000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000d4e6f76203173742c20323032300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000", res)
 }
+
+func TestEncodeContractCallTuples(t *testing.T) {
+	// Arrays of tuples
+	type order struct {
+		Name0 common.Address
+		Name1 *big.Int
+	}
+	expected, err := ABIEncodeMethodCalldata("test((address,uint256)[])", []any{
+		[]order{
+			{Name0: common.HexToAddress("0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25"), Name1: big.NewInt(1)},
+			{Name0: common.HexToAddress("0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462"), Name1: big.NewInt(2)},
+		},
+	})
+	require.NoError(t, err)
+
+	res, err := EncodeContractCall(ContractCallDef{
+		ABI: `test((address,uint256)[])`,
+		Args: []any{
+			[]any{
+				[]any{"0x13915b1ea28Fd2E8197c88ff9D2422182E83bf25", "1"},
+				[]string{"0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462", "2"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, HexEncode(expected), res)
+
+	// Empty array of tuples
+	expected, err = ABIEncodeMethodCalldata("test((address,uint256)[])", []any{[]order{}})
+	require.NoError(t, err)
+
+	res, err = EncodeContractCall(ContractCallDef{
+		ABI:  `test((address,uint256)[])`,
+		Args: []any{[]any{}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, HexEncode(expected), res)
+
+	// Arrays of tuples with nested tuples and dynamic types, using JSON as input
+	type fee struct {
+		Name0 common.Address
+		Name1 [32]byte
+	}
+	type fillOrder struct {
+		Name0 *big.Int
+		Name1 string
+		Name2 []byte
+		Name3 []fee
+		Name4 uint8
+	}
+	expected, err = ABIEncodeMethodCalldata("fillOrders((uint256,string,bytes,(address,bytes32)[],uint8)[],bool)", []any{
+		[]fillOrder{
+			{Name0: big.NewInt(1), Name1: "first", Name2: []byte{0x01, 0x02}, Name3: []fee{}, Name4: 1},
+			{Name0: big.NewInt(2), Name1: "", Name2: []byte{}, Name3: []fee{{Name0: common.HexToAddress("0x8541D65829f98f7D71A4655cCD7B2bB8494673bF"), Name1: common.HexToHash("0x01")}}, Name4: 255},
+		},
+		true,
+	})
+	require.NoError(t, err)
+
+	jsonContractCall := `{
+		"abi": "fillOrders((uint256 id, string memo, bytes data, (address recipient, bytes32 ref)[] fees, uint8 kind)[] orders, bool partial)",
+		"args": [
+			[
+				["1", "first", "0x0102", [], "1"],
+				["2", "", "0x", [["0x8541D65829f98f7D71A4655cCD7B2bB8494673bF", "0x0000000000000000000000000000000000000000000000000000000000000001"]], "255"]
+			],
+			"true"
+		]
+	}`
+
+	var contractCall ContractCallDef
+	err = json.Unmarshal([]byte(jsonContractCall), &contractCall)
+	require.NoError(t, err)
+
+	res, err = EncodeContractCall(contractCall)
+	require.NoError(t, err)
+	require.Equal(t, HexEncode(expected), res)
+}
+
+func TestContractCallDefUnmarshalJSON(t *testing.T) {
+	to := "0x4Ad47F1611c78C824Ff3892c4aE1CC04637D6462"
+	expected, err := ABIEncodeMethodCalldata("transfer(address,uint256)", []any{common.HexToAddress(to), big.NewInt(100)})
+	require.NoError(t, err)
+
+	// args as an object are decoded into the named args
+	var contractCall ContractCallDef
+	err = json.Unmarshal([]byte(`{"abi": "transfer(address _to, uint256 _value)", "args": {"_value": 100, "_to": "`+to+`"}}`), &contractCall)
+	require.NoError(t, err)
+	require.Nil(t, contractCall.Args)
+	require.Equal(t, map[string]any{"_to": to, "_value": json.Number("100")}, contractCall.NamedArgs)
+
+	res, err := EncodeContractCall(contractCall)
+	require.NoError(t, err)
+	require.Equal(t, HexEncode(expected), res)
+
+	// args as an array are positional
+	contractCall = ContractCallDef{}
+	err = json.Unmarshal([]byte(`{"abi": "transfer(address,uint256)", "args": ["`+to+`", 100]}`), &contractCall)
+	require.NoError(t, err)
+	require.Equal(t, []any{to, json.Number("100")}, contractCall.Args)
+	require.Nil(t, contractCall.NamedArgs)
+
+	res, err = EncodeContractCall(contractCall)
+	require.NoError(t, err)
+	require.Equal(t, HexEncode(expected), res)
+
+	// the other fields are decoded as is
+	contractCall = ContractCallDef{}
+	err = json.Unmarshal([]byte(`{"abi": "transfer(address,uint256)", "func": "transfer", "argsJSON": ["`+to+`", "100"]}`), &contractCall)
+	require.NoError(t, err)
+	require.Equal(t, "transfer", contractCall.Func)
+	require.Nil(t, contractCall.Args)
+	require.JSONEq(t, `["`+to+`", "100"]`, string(contractCall.ArgsJSON))
+
+	err = json.Unmarshal([]byte(`{"abi": "transfer(address,uint256)", "args": "100"}`), &contractCall)
+	require.Error(t, err)
+}
+
+func TestEncodeStruct(t *testing.T) {
+	type Transfer struct {
+		Amount *big.Int       `abi:"amount"`
+		To     common.Address `abi:"to"`
+		Note   string         `abi:"-"`
+	}
+
+	to := common.HexToAddress("0x6615e4e985bf0d137196897dfa182dbd7127f54f")
+
+	// mapped by name, regardless of field order
+	calldata, err := EncodeStruct("transfer(address to,uint256 amount)", Transfer{Amount: big.NewInt(1337), To: to, Note: "skipped"})
+	require.NoError(t, err)
+	expected, err := ABIEncodeMethodCalldata("transfer(address,uint256)", []any{to, big.NewInt(1337)})
+	require.NoError(t, err)
+	require.Equal(t, expected, calldata)
+
+	var decoded Transfer
+	require.NoError(t, DecodeToStruct("transfer(address to,uint256 amount)", calldata[4:], &decoded))
+	require.Equal(t, to, decoded.To)
+	require.Equal(t, big.NewInt(1337), decoded.Amount)
+	require.Empty(t, decoded.Note)
+
+	// mapped by order for unnamed args, and numbers are converted to the abi type
+	type Positional struct {
+		To     common.Address
+		Amount uint64
+	}
+	calldata, err = EncodeStruct("transfer(address,uint256)", Positional{To: to, Amount: 1337})
+	require.NoError(t, err)
+	require.Equal(t, expected, calldata)
+
+	var positional Positional
+	require.NoError(t, DecodeToStruct("(address,uint256)", calldata[4:], &positional))
+	require.Equal(t, Positional{To: to, Amount: 1337}, positional)
+
+	_, err = EncodeStruct("transfer(address to,uint256 value)", Transfer{Amount: big.NewInt(1), To: to})
+	require.ErrorContains(t, err, "no field for argument value")
+	_, err = EncodeStruct("transfer(address,uint256,bool)", Positional{To: to, Amount: 1})
+	require.Error(t, err)
+	require.Error(t, DecodeToStruct("(address,uint256)", calldata[4:], positional))
+}
+
+func TestEncodeStructTuples(t *testing.T) {
+	type Item struct {
+		Token  common.Address
+		Amount *big.Int
+	}
+	type Order struct {
+		Maker  common.Address `abi:"maker"`
+		Items  []Item         `abi:"items"`
+		Fee    *Item          `abi:"fee"`
+		Expiry [2]uint64      `abi:"expiry"`
+		Data   []byte         `abi:"data"`
+	}
+
+	order := Order{
+		Maker: common.HexToAddress("0x1"),
+		Items: []Item{
+			{Token: common.HexToAddress("0x2"), Amount: big.NewInt(10)},
+			{Token: common.HexToAddress("0x3"), Amount: big.NewInt(20)},
+		},
+		Fee:    &Item{Token: common.HexToAddress("0x4"), Amount: big.NewInt(1)},
+		Expiry: [2]uint64{100, 200},
+		Data:   []byte{0xde, 0xad},
+	}
+
+	sig := "fill(address maker,(address,uint256)[] items,(address,uint256) fee,uint256[2] expiry,bytes data)"
+	calldata, err := EncodeStruct(sig, &order)
+	require.NoError(t, err)
+
+	expected, err := EncodeContractCall(ContractCallDef{
+		ABI: "fill(address,(address,uint256)[],(address,uint256),uint256[2],bytes)",
+		Args: []any{
+			"0x0000000000000000000000000000000000000001",
+			[]any{[]any{"0x0000000000000000000000000000000000000002", "10"}, []any{"0x0000000000000000000000000000000000000003", "20"}},
+			[]any{"0x0000000000000000000000000000000000000004", "1"},
+			[]any{"100", "200"},
+			"0xdead",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, HexEncode(calldata))
+
+	var decoded Order
+	require.NoError(t, DecodeToStruct(sig, calldata[4:], &decoded))
+	require.Equal(t, order, decoded)
+
+	// decoded from the return types of a method signature
+	encoded, err := EncodeContractCall(ContractCallDef{
+		ABI:  "balanceOf(uint256,(address,uint256))",
+		Args: []any{"5", []any{"0x0000000000000000000000000000000000000002", "10"}},
+	})
+	require.NoError(t, err)
+	data := MustHexDecode(encoded)[4:]
+
+	var ret struct {
+		Balance uint64 `abi:"balance"`
+		Item    Item   `abi:"item"`
+	}
+	require.NoError(t, DecodeToStruct("balanceOf(address)(uint256 balance,(address,uint256) item)", data, &ret))
+	require.Equal(t, uint64(5), ret.Balance)
+	require.Equal(t, Item{Token: common.HexToAddress("0x2"), Amount: big.NewInt(10)}, ret.Item)
+}
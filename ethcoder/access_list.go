@@ -0,0 +1,161 @@
+package ethcoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/params"
+)
+
+// maxPrecompileAddress is the highest precompile address, which are always warm.
+var maxPrecompileAddress = common.BytesToAddress([]byte{0x11})
+
+// AccessListResult is the result of eth_createAccessList, of the accounts and storage
+// keys accessed by a call, and the gas used by the call with the access list.
+type AccessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    hexutil.Uint64   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"` // error of the call, ie. if it reverted
+}
+
+// DecodeAccessListResult decodes the json result of eth_createAccessList.
+func DecodeAccessListResult(data []byte) (AccessListResult, error) {
+	var result AccessListResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return AccessListResult{}, fmt.Errorf("ethcoder: failed to decode access list result: %w", err)
+	}
+	if result.AccessList == nil {
+		result.AccessList = types.AccessList{}
+	}
+	return result, nil
+}
+
+// AccessListCost is the gas cost breakdown of an EIP-2930 access list, see AccessListGasCost.
+type AccessListCost struct {
+	Entries []AccessListEntryCost
+
+	// Gas is the intrinsic gas charged for the access list.
+	Gas uint64
+
+	// Savings is the estimated gas saved by the access list, net of its intrinsic gas,
+	// where a negative value is the overhead of including it.
+	Savings int64
+}
+
+// AccessListEntryCost is the gas cost of an address of an access list and its storage keys.
+type AccessListEntryCost struct {
+	Address        common.Address
+	NumStorageKeys int
+
+	// Warm is set if the address is accessed warm without the access list, ie. the
+	// sender, the recipient or a precompile, or if it's listed in a previous entry.
+	Warm bool
+
+	// AddressGas and StorageKeysGas are the intrinsic gas charged for the address and
+	// for its storage keys.
+	AddressGas     uint64
+	StorageKeysGas uint64
+
+	// Savings is the estimated gas saved by the entry, net of its intrinsic gas.
+	Savings int64
+}
+
+// IsWorthIncluding reports whether the access list is estimated to save gas.
+func (c AccessListCost) IsWorthIncluding() bool {
+	return c.Savings > 0
+}
+
+// AccessListGasCost computes the gas cost breakdown of the access list, to decide whether
+// it's worth including in a transaction. warmAddresses are the addresses accessed warm
+// regardless of the access list, ie. the sender, the recipient and the block's coinbase,
+// which along with the precompiles are pure overhead in an access list.
+//
+// Each address costs 2400 gas and each storage key 1900 gas upfront, and saves the
+// difference between a cold and a warm access on its first access, ie. 2500 gas for an
+// address and 2000 gas for a storage key. So each entry saves a net 100 gas, if it's
+// accessed by the transaction, as is the case for the access lists of eth_createAccessList,
+// while warm addresses and duplicate storage keys are an overhead.
+func AccessListGasCost(accessList types.AccessList, warmAddresses ...common.Address) AccessListCost {
+	warm := make(map[common.Address]bool, len(warmAddresses))
+	for _, address := range warmAddresses {
+		warm[address] = true
+	}
+	warmKeys := make(map[common.Address]map[common.Hash]bool)
+
+	var cost AccessListCost
+	for _, tuple := range accessList {
+		entry := AccessListEntryCost{
+			Address:        tuple.Address,
+			NumStorageKeys: len(tuple.StorageKeys),
+			Warm:           warm[tuple.Address] || isPrecompileAddress(tuple.Address),
+			AddressGas:     params.TxAccessListAddressGas,
+			StorageKeysGas: uint64(len(tuple.StorageKeys)) * params.TxAccessListStorageKeyGas,
+		}
+
+		entry.Savings = -int64(entry.AddressGas)
+		if !entry.Warm {
+			entry.Savings += int64(params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929)
+		}
+		warm[tuple.Address] = true
+
+		if warmKeys[tuple.Address] == nil {
+			warmKeys[tuple.Address] = make(map[common.Hash]bool, len(tuple.StorageKeys))
+		}
+		for _, key := range tuple.StorageKeys {
+			entry.Savings -= int64(params.TxAccessListStorageKeyGas)
+			if !warmKeys[tuple.Address][key] {
+				entry.Savings += int64(params.ColdSloadCostEIP2929 - params.WarmStorageReadCostEIP2929)
+			}
+			warmKeys[tuple.Address][key] = true
+		}
+
+		cost.Entries = append(cost.Entries, entry)
+		cost.Gas += entry.AddressGas + entry.StorageKeysGas
+		cost.Savings += entry.Savings
+	}
+
+	return cost
+}
+
+// PruneAccessList returns a copy of the access list without the entries which cost more
+// gas than they save, see AccessListGasCost. Duplicate addresses are merged and duplicate
+// storage keys dropped, and warm addresses are only kept with at least 25 storage keys,
+// for the savings of their keys to outweigh the cost of the address.
+func PruneAccessList(accessList types.AccessList, warmAddresses ...common.Address) types.AccessList {
+	// merge the storage keys of duplicate addresses, in order of first appearance
+	var addresses []common.Address
+	keys := make(map[common.Address][]common.Hash)
+	seenKeys := make(map[common.Address]map[common.Hash]bool)
+	for _, tuple := range accessList {
+		if seenKeys[tuple.Address] == nil {
+			addresses = append(addresses, tuple.Address)
+			seenKeys[tuple.Address] = make(map[common.Hash]bool)
+			keys[tuple.Address] = []common.Hash{}
+		}
+		for _, key := range tuple.StorageKeys {
+			if !seenKeys[tuple.Address][key] {
+				keys[tuple.Address] = append(keys[tuple.Address], key)
+				seenKeys[tuple.Address][key] = true
+			}
+		}
+	}
+
+	pruned := types.AccessList{}
+	for _, address := range addresses {
+		tuple := types.AccessTuple{Address: address, StorageKeys: keys[address]}
+		if cost := AccessListGasCost(types.AccessList{tuple}, warmAddresses...); cost.Savings <= 0 {
+			continue
+		}
+		pruned = append(pruned, tuple)
+	}
+	return pruned
+}
+
+func isPrecompileAddress(address common.Address) bool {
+	n := new(big.Int).SetBytes(address.Bytes())
+	return n.Sign() > 0 && n.Cmp(new(big.Int).SetBytes(maxPrecompileAddress.Bytes())) <= 0
+}
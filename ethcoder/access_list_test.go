@@ -0,0 +1,82 @@
+package ethcoder
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessListGasCost(t *testing.T) {
+	result, err := DecodeAccessListResult([]byte(`{
+		"accessList": [
+			{
+				"address": "0x1111111111111111111111111111111111111111",
+				"storageKeys": [
+					"0x0000000000000000000000000000000000000000000000000000000000000001",
+					"0x0000000000000000000000000000000000000000000000000000000000000002"
+				]
+			},
+			{
+				"address": "0x2222222222222222222222222222222222222222",
+				"storageKeys": []
+			},
+			{
+				"address": "0x0000000000000000000000000000000000000001",
+				"storageKeys": []
+			},
+			{
+				"address": "0x1111111111111111111111111111111111111111",
+				"storageKeys": [
+					"0x0000000000000000000000000000000000000000000000000000000000000002"
+				]
+			}
+		],
+		"gasUsed": "0x7a12"
+	}`))
+	require.NoError(t, err)
+	require.Len(t, result.AccessList, 4)
+	assert.Equal(t, uint64(31250), uint64(result.GasUsed))
+	assert.Empty(t, result.Error)
+
+	// the recipient is warm
+	recipient := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	cost := AccessListGasCost(result.AccessList, recipient)
+
+	require.Len(t, cost.Entries, 4)
+	assert.Equal(t, uint64(4*2400+3*1900), cost.Gas)
+
+	// a cold address and two storage keys save 100 gas each
+	assert.False(t, cost.Entries[0].Warm)
+	assert.Equal(t, 2, cost.Entries[0].NumStorageKeys)
+	assert.Equal(t, uint64(2400), cost.Entries[0].AddressGas)
+	assert.Equal(t, uint64(3800), cost.Entries[0].StorageKeysGas)
+	assert.Equal(t, int64(300), cost.Entries[0].Savings)
+
+	// the recipient and the precompile are overhead
+	assert.True(t, cost.Entries[1].Warm)
+	assert.Equal(t, int64(-2400), cost.Entries[1].Savings)
+	assert.True(t, cost.Entries[2].Warm)
+	assert.Equal(t, int64(-2400), cost.Entries[2].Savings)
+
+	// as well as the duplicate address and storage key
+	assert.True(t, cost.Entries[3].Warm)
+	assert.Equal(t, int64(-2400-1900), cost.Entries[3].Savings)
+
+	assert.Equal(t, int64(300-2400-2400-4300), cost.Savings)
+	assert.False(t, cost.IsWorthIncluding())
+
+	pruned := PruneAccessList(result.AccessList, recipient)
+	assert.Equal(t, types.AccessList{
+		{
+			Address: common.HexToAddress("0x1111111111111111111111111111111111111111"),
+			StorageKeys: []common.Hash{
+				common.HexToHash("0x01"),
+				common.HexToHash("0x02"),
+			},
+		},
+	}, pruned)
+	assert.True(t, AccessListGasCost(pruned, recipient).IsWorthIncluding())
+}
@@ -0,0 +1,108 @@
+package ethcoder
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// Salt returns a CREATE2 salt from the structured values, computed as the keccak256 hash
+// of their packed encoding, ie. keccak256(abi.encodePacked(values...)) in solidity.
+//
+// The solidity type of each value is inferred from its Go type:
+//
+//	common.Address          -> address
+//	common.Hash, [32]byte   -> bytes32
+//	[]byte                  -> bytes
+//	string                  -> string
+//	bool                    -> bool
+//	uint8 .. uint64         -> uint8 .. uint64
+//	int8 .. int64           -> int8 .. int64
+//	uint, *big.Int          -> uint256
+//	int                     -> int256
+//
+// NOTE: packed encoding does not pad values, so a salt with more than one dynamic
+// value (bytes or string) may be ambiguous.
+func Salt(values ...any) ([32]byte, error) {
+	argTypes := make([]string, len(values))
+	argValues := make([]any, len(values))
+
+	for i, v := range values {
+		switch x := v.(type) {
+		case common.Address:
+			argTypes[i], argValues[i] = "address", x
+		case common.Hash:
+			argTypes[i], argValues[i] = "bytes32", x
+		case [32]byte:
+			argTypes[i], argValues[i] = "bytes32", x
+		case []byte:
+			argTypes[i], argValues[i] = "bytes", x
+		case string:
+			argTypes[i], argValues[i] = "string", x
+		case bool:
+			argTypes[i], argValues[i] = "bool", x
+		case uint8:
+			argTypes[i], argValues[i] = "uint8", x
+		case uint16:
+			argTypes[i], argValues[i] = "uint16", x
+		case uint32:
+			argTypes[i], argValues[i] = "uint32", x
+		case uint64:
+			argTypes[i], argValues[i] = "uint64", x
+		case int8:
+			argTypes[i], argValues[i] = "int8", x
+		case int16:
+			argTypes[i], argValues[i] = "int16", x
+		case int32:
+			argTypes[i], argValues[i] = "int32", x
+		case int64:
+			argTypes[i], argValues[i] = "int64", x
+		case uint:
+			argTypes[i], argValues[i] = "uint256", new(big.Int).SetUint64(uint64(x))
+		case int:
+			if x < 0 {
+				return [32]byte{}, fmt.Errorf("ethcoder: Salt value %d is negative, use a sized int type", i)
+			}
+			argTypes[i], argValues[i] = "int256", big.NewInt(int64(x))
+		case *big.Int:
+			if x == nil || x.Sign() < 0 {
+				return [32]byte{}, fmt.Errorf("ethcoder: Salt value %d must be a non-negative *big.Int", i)
+			}
+			argTypes[i], argValues[i] = "uint256", x
+		default:
+			return [32]byte{}, fmt.Errorf("ethcoder: Salt value %d has unsupported type %T", i, v)
+		}
+	}
+
+	packed, err := SolidityPack(argTypes, argValues)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("ethcoder: Salt failed to pack values: %w", err)
+	}
+	return Keccak256Hash(packed), nil
+}
+
+// CreateAddress returns the address of a contract deployed with CREATE by the deployer,
+// ie. by a transaction of the deployer account with the nonce, computed as
+// keccak256(rlp([deployer, nonce]))[12:].
+func CreateAddress(deployer common.Address, nonce uint64) common.Address {
+	return crypto.CreateAddress(deployer, nonce)
+}
+
+// Create2Address returns the address of a contract deployed with CREATE2 by the deployer,
+// computed as keccak256(0xff ++ deployer ++ salt ++ keccak256(initCode))[12:].
+func Create2Address(deployer common.Address, salt [32]byte, initCode []byte) common.Address {
+	return Create2AddressFromInitCodeHash(deployer, salt, Keccak256Hash(initCode))
+}
+
+// Create2AddressFromInitCodeHash is like Create2Address, but with the keccak256 hash
+// of the init code.
+func Create2AddressFromInitCodeHash(deployer common.Address, salt [32]byte, initCodeHash common.Hash) common.Address {
+	data := make([]byte, 0, 1+20+32+32)
+	data = append(data, 0xff)
+	data = append(data, deployer.Bytes()...)
+	data = append(data, salt[:]...)
+	data = append(data, initCodeHash.Bytes()...)
+	return common.BytesToAddress(Keccak256(data)[12:])
+}
@@ -0,0 +1,47 @@
+package ethcoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSalt(t *testing.T) {
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	salt, err := Salt(owner, big.NewInt(1), "wallet")
+	require.NoError(t, err)
+
+	packed, err := SolidityPack([]string{"address", "uint256", "string"}, []any{owner, big.NewInt(1), "wallet"})
+	require.NoError(t, err)
+	require.Equal(t, Keccak256Hash(packed), common.Hash(salt))
+
+	_, err = Salt(owner, 1.5)
+	require.Error(t, err)
+}
+
+func TestCreateAddress(t *testing.T) {
+	deployer := common.HexToAddress("0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0")
+	require.Equal(t, common.HexToAddress("0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d"), CreateAddress(deployer, 0))
+	require.Equal(t, common.HexToAddress("0x343c43a37d37dff08ae8c4a11544c718abb4fcf8"), CreateAddress(deployer, 1))
+	require.Equal(t, common.HexToAddress("0xf778b86fa74e846c4f0a1fbd1335fe81c00a0c91"), CreateAddress(deployer, 2))
+
+	for _, nonce := range []uint64{127, 128, 255, 256, 1 << 32} {
+		require.Equal(t, crypto.CreateAddress(deployer, nonce), CreateAddress(deployer, nonce))
+	}
+}
+
+func TestCreate2Address(t *testing.T) {
+	// examples from EIP-1014
+	address := Create2Address(common.Address{}, [32]byte{}, []byte{0x00})
+	require.Equal(t, "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38", address.Hex())
+
+	deployer := common.HexToAddress("0xdeadbeef00000000000000000000000000000000")
+	salt := common.HexToHash("0x000000000000000000000000feed000000000000000000000000000000000000")
+	initCode := common.FromHex("0xdeadbeef")
+	address = Create2Address(deployer, salt, initCode)
+	require.Equal(t, crypto.CreateAddress2(deployer, salt, Keccak256(initCode)), address)
+}
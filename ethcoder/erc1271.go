@@ -0,0 +1,60 @@
+package ethcoder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var (
+	// ERC1271MagicValue is returned by isValidSignature(bytes32,bytes) for a valid signature.
+	ERC1271MagicValue = [4]byte{0x16, 0x26, 0xba, 0x7e}
+
+	// ERC1271LegacyMagicValue is returned by the legacy isValidSignature(bytes,bytes)
+	// for a valid signature.
+	ERC1271LegacyMagicValue = [4]byte{0x20, 0xc1, 0x3b, 0x0b}
+)
+
+// VerifyERC1271 verifies the signature of the hash for a smart-contract wallet signer, by
+// calling isValidSignature(bytes32,bytes) on the signer contract and checking for the
+// ERC-1271 magic value. If the signer does not return the magic value, the legacy
+// isValidSignature(bytes,bytes) variant is tried with the hash as the data.
+//
+// A reverted call or unexpected return value is reported as an invalid signature, an
+// error is only returned if the contract could not be called.
+func VerifyERC1271(ctx context.Context, provider ethereum.ContractCaller, signer common.Address, hash common.Hash, sig []byte) (bool, error) {
+	calldata, err := ABIEncodeMethodCalldata("isValidSignature(bytes32,bytes)", []any{[32]byte(hash), sig})
+	if err != nil {
+		return false, fmt.Errorf("ethcoder: failed to encode isValidSignature: %w", err)
+	}
+	ok, err := callIsValidSignature(ctx, provider, signer, calldata, ERC1271MagicValue)
+	if ok || err != nil {
+		return ok, err
+	}
+
+	calldata, err = ABIEncodeMethodCalldata("isValidSignature(bytes,bytes)", []any{hash.Bytes(), sig})
+	if err != nil {
+		return false, fmt.Errorf("ethcoder: failed to encode isValidSignature: %w", err)
+	}
+	return callIsValidSignature(ctx, provider, signer, calldata, ERC1271LegacyMagicValue)
+}
+
+func callIsValidSignature(ctx context.Context, provider ethereum.ContractCaller, signer common.Address, calldata []byte, magicValue [4]byte) (bool, error) {
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &signer, Data: calldata}, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "revert") {
+			return false, nil
+		}
+		return false, fmt.Errorf("ethcoder: isValidSignature call failed: %w", err)
+	}
+
+	// the magic value is returned as a left-aligned bytes4 word
+	if len(res) < 4 {
+		return false, nil
+	}
+	return bytes.Equal(res[:4], magicValue[:]), nil
+}
@@ -0,0 +1,71 @@
+package ethcoder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type mockContractCaller func(data []byte) ([]byte, error)
+
+func (m mockContractCaller) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return m(msg.Data)
+}
+
+func TestVerifyERC1271(t *testing.T) {
+	signer := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	hash := common.HexToHash("0xabcdef")
+	sig := []byte{0x01, 0x02, 0x03}
+
+	selector := FunctionSignature("isValidSignature(bytes32,bytes)")
+	legacySelector := FunctionSignature("isValidSignature(bytes,bytes)")
+
+	returnMagic := func(magic [4]byte) []byte {
+		return common.RightPadBytes(magic[:], 32)
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		caller := mockContractCaller(func(data []byte) ([]byte, error) {
+			require.Equal(t, selector, HexEncode(data[:4]))
+			return returnMagic(ERC1271MagicValue), nil
+		})
+		ok, err := VerifyERC1271(context.Background(), caller, signer, hash, sig)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		caller := mockContractCaller(func(data []byte) ([]byte, error) {
+			if HexEncode(data[:4]) == legacySelector {
+				return returnMagic(ERC1271LegacyMagicValue), nil
+			}
+			return nil, errors.New("execution reverted")
+		})
+		ok, err := VerifyERC1271(context.Background(), caller, signer, hash, sig)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		caller := mockContractCaller(func(data []byte) ([]byte, error) {
+			return bytes.Repeat([]byte{0xff}, 32), nil
+		})
+		ok, err := VerifyERC1271(context.Background(), caller, signer, hash, sig)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("CallError", func(t *testing.T) {
+		caller := mockContractCaller(func(data []byte) ([]byte, error) {
+			return nil, errors.New("connection refused")
+		})
+		_, err := VerifyERC1271(context.Background(), caller, signer, hash, sig)
+		require.Error(t, err)
+	})
+}
@@ -10,3 +10,45 @@ func TestFunctionSignature(t *testing.T) {
 	fnsig := FunctionSignature("balanceOf(address,uint256)")
 	assert.Equal(t, "0x00fdd58e", fnsig)
 }
+
+func TestFunctionSelector(t *testing.T) {
+	assert.Equal(t, [4]byte{0x00, 0xfd, 0xd5, 0x8e}, FunctionSelector("balanceOf(address,uint256)"))
+
+	// canonicalized signatures
+	assert.Equal(t, [4]byte{0xa9, 0x05, 0x9c, 0xbb}, FunctionSelector("transfer(address,uint256)"))
+	assert.Equal(t, [4]byte{0xa9, 0x05, 0x9c, 0xbb}, FunctionSelector(" transfer(address to, uint amount)"))
+	assert.Equal(t, [4]byte{0xa9, 0x05, 0x9c, 0xbb}, FunctionSelector("transfer(address,uint256)(bool)"))
+
+	// custom errors
+	assert.Equal(t, [4]byte{0xcf, 0x47, 0x91, 0x81}, FunctionSelector("InsufficientBalance(uint256,uint256)"))
+}
+
+func TestEventTopic(t *testing.T) {
+	transferTopic := "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	for _, sig := range []string{
+		"Transfer(address,address,uint256)",
+		"Transfer(address indexed from, address indexed to, uint value)",
+	} {
+		topic, err := EventTopic(sig)
+		assert.NoError(t, err)
+		assert.Equal(t, transferTopic, topic.Hex())
+	}
+
+	// same as EventTopicHash
+	topicHash, eventSig, err := EventTopicHash("Transfer(address,address,uint)")
+	assert.NoError(t, err)
+	assert.Equal(t, transferTopic, topicHash.Hex())
+	assert.Equal(t, "Transfer(address,address,uint256)", eventSig)
+
+	_, err = EventTopic("Transfer(address,address")
+	assert.Error(t, err)
+
+	assert.Equal(t, transferTopic, MustEventTopic("Transfer(address,address,uint)").Hex())
+	assert.Panics(t, func() { MustEventTopic("Transfer(address,address") })
+}
+
+func TestKeccak256Concat(t *testing.T) {
+	a, b := []byte("hello"), []byte("world")
+	assert.Equal(t, Keccak256([]byte("helloworld")), Keccak256Concat(a, b))
+	assert.Equal(t, Keccak256(nil), Keccak256Concat())
+}
@@ -12,7 +12,8 @@ import (
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 )
 
-// EventTopicHash returns the keccak256 hash of the event signature
+// EventTopicHash returns the keccak256 hash of the event signature, and its canonical
+// form, where the uint and int type aliases are expanded to uint256 and int256.
 //
 // e.g. "Transfer(address indexed from, address indexed to, uint256 value)"
 // will return 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef
@@ -21,8 +22,8 @@ func EventTopicHash(event string) (ethkit.Hash, string, error) {
 	if err != nil {
 		return ethkit.Hash{}, "", fmt.Errorf("ethcoder: %w", err)
 	}
-	topicHash := common.HexToHash(eventDef.Hash)
-	return topicHash, eventDef.Signature, nil
+	eventDef = canonicalEventDef(eventDef)
+	return common.HexToHash(eventDef.Hash), eventDef.Signature, nil
 }
 
 func ValidateEventSig(eventSig string) (bool, error) {
@@ -93,6 +94,73 @@ func DecodeTransactionLogByContractABIAsHex(txnLog types.Log, contractABI abi.AB
 	return decoder.DecodeLogAsHex(txnLog)
 }
 
+// DecodeEventLog decodes the log of the event definition, see ParseABISignature, into a
+// map of argument values keyed by argument name. Indexed arguments are decoded from the
+// log topics, and non-indexed arguments from the log data. Unnamed arguments are keyed
+// by their position, ie. "arg1", "arg2", etc.
+//
+// The logs of an anonymous event have no topic0 of the event signature, so its indexed
+// arguments are decoded from topic0 on, ie. "TxExecuted(bytes32 txHash) anonymous". Set
+// eventDef.Anonymous to decode a log without a signature topic, when the event is known.
+//
+// NOTE: indexed arguments of a dynamic type, ie. string, bytes, arrays and tuples, are
+// stored as the keccak256 hash of their value in the topic, and are returned as a
+// common.Hash of the value.
+func DecodeEventLog(eventDef ABISignature, log types.Log) (map[string]any, error) {
+	// the uint and int type aliases are expanded, as in the event topic
+	eventDef = canonicalEventDef(eventDef)
+
+	topics := log.Topics
+	if !eventDef.Anonymous {
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("ethcoder: DecodeEventLog, log has no topics")
+		}
+		if topics[0] != common.HexToHash(eventDef.Hash) {
+			return nil, fmt.Errorf("ethcoder: DecodeEventLog, log topic %s does not match event %s topic %s", topics[0].Hex(), eventDef.Signature, eventDef.Hash)
+		}
+		topics = topics[1:]
+	}
+	if len(topics) != eventDef.NumIndexed {
+		return nil, fmt.Errorf("ethcoder: DecodeEventLog, log has %d indexed topics but event %s expects %d", len(topics), eventDef.Signature, eventDef.NumIndexed)
+	}
+
+	eventABI, _, err := eventDef.ToABI(true)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: DecodeEventLog, %w", err)
+	}
+	abiEvent, ok := eventABI.Events[eventDef.Name]
+	if !ok {
+		return nil, fmt.Errorf("ethcoder: DecodeEventLog, event %s not found", eventDef.Name)
+	}
+
+	out := map[string]any{}
+
+	idx := 0
+	for _, arg := range abiEvent.Inputs {
+		if !arg.Indexed {
+			continue
+		}
+		topic := topics[idx]
+		idx++
+
+		if arg.Type.T == abi.TupleTy {
+			out[arg.Name] = topic
+			continue
+		}
+		err := abi.ParseTopicsIntoMap(out, abi.Arguments{arg}, []common.Hash{topic})
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: DecodeEventLog, failed to decode indexed argument %s: %w", arg.Name, err)
+		}
+	}
+
+	err = abiEvent.Inputs.NonIndexed().UnpackIntoMap(out, log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: DecodeEventLog, failed to decode data: %w", err)
+	}
+
+	return out, nil
+}
+
 type EventDecoder struct {
 	// options  EventDecoderOptions
 	decoders map[string][]eventDecoderDef
@@ -144,6 +212,10 @@ LOOP:
 		if err != nil {
 			return fmt.Errorf("ethcoder: %w", err)
 		}
+		eventDef = canonicalEventDef(eventDef)
+		if eventDef.Anonymous {
+			return fmt.Errorf("ethcoder: event %s is anonymous and has no topic hash, decode its logs with DecodeEventLog", eventDef.Signature)
+		}
 
 		_, ok := d.decoders[eventDef.Hash]
 		if !ok {
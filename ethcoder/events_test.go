@@ -581,3 +581,104 @@ func TestEventDecoder2(t *testing.T) {
 	require.Equal(t, "0x00000000000000000000000000000000000000000000000000000000000031f4", eventHexValues[9])
 	require.Equal(t, "0x00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000000", eventHexValues[10])
 }
+
+func TestDecodeEventLog(t *testing.T) {
+	eventDef, err := ethcoder.ParseABISignature("Named(string indexed name, address indexed owner, uint256 value, string)")
+	require.NoError(t, err)
+
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	data, err := ethcoder.ABIPackArguments([]string{"uint256", "string"}, []any{big.NewInt(42), "hello"})
+	require.NoError(t, err)
+
+	log := types.Log{
+		Topics: []common.Hash{
+			common.HexToHash(eventDef.Hash),
+			ethcoder.Keccak256Hash([]byte("alice")),
+			common.BytesToHash(owner.Bytes()),
+		},
+		Data: data,
+	}
+
+	values, err := ethcoder.DecodeEventLog(eventDef, log)
+	require.NoError(t, err)
+	require.Equal(t, ethcoder.Keccak256Hash([]byte("alice")), values["name"])
+	require.Equal(t, owner, values["owner"])
+	require.Equal(t, big.NewInt(42), values["value"])
+	require.Equal(t, "hello", values["arg4"])
+
+	// topic0 mismatch
+	transferDef, err := ethcoder.ParseABISignature("Transfer(address indexed from, address indexed to, uint256 value)")
+	require.NoError(t, err)
+	_, err = ethcoder.DecodeEventLog(transferDef, log)
+	require.ErrorContains(t, err, "does not match")
+}
+
+func TestDecodeEventLogTypeAlias(t *testing.T) {
+	// the uint alias is expanded to uint256 in the event topic
+	eventDef, err := ethcoder.ParseABISignature("Transfer(address indexed from, address indexed to, uint value)")
+	require.NoError(t, err)
+
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	log := types.Log{
+		Topics: []common.Hash{
+			ethcoder.MustEventTopic("Transfer(address,address,uint256)"),
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+		},
+		Data: common.LeftPadBytes(big.NewInt(100).Bytes(), 32),
+	}
+
+	values, err := ethcoder.DecodeEventLog(eventDef, log)
+	require.NoError(t, err)
+	require.Equal(t, from, values["from"])
+	require.Equal(t, to, values["to"])
+	require.Equal(t, big.NewInt(100), values["value"])
+
+	// and so are the event signatures registered to the decoder
+	decoder := ethcoder.NewEventDecoder()
+	require.NoError(t, decoder.RegisterEventSig("Transfer(address indexed from, address indexed to, uint value)"))
+	decodedDef, _, ok, err := decoder.DecodeLog(log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "Transfer(address,address,uint256)", decodedDef.Signature)
+}
+
+func TestDecodeEventLogAnonymous(t *testing.T) {
+	// TxExecuted is emitted by the sequence wallet without a signature topic
+	txExecutedDef, err := ethcoder.ParseABISignature("TxExecuted(bytes32 txHash) anonymous")
+	require.NoError(t, err)
+	require.True(t, txExecutedDef.Anonymous)
+	require.Equal(t, "TxExecuted(bytes32)", txExecutedDef.Signature)
+	require.Equal(t, "TxExecuted(bytes32 txHash) anonymous", txExecutedDef.String())
+
+	txHash := common.HexToHash("0x0639b0b186d373976f8bb98f9f7226ba8070f10cb6c7f9bd5086d3933f169a25")
+	values, err := ethcoder.DecodeEventLog(txExecutedDef, types.Log{Data: txHash.Bytes()})
+	require.NoError(t, err)
+	require.Equal(t, [32]byte(txHash), values["txHash"])
+
+	// indexed args of an anonymous event start at topic0
+	nonceChangeDef, err := ethcoder.ParseABISignature("NonceChange(uint256 indexed space, uint256 newNonce) anonymous")
+	require.NoError(t, err)
+
+	data, err := ethcoder.ABIPackArguments([]string{"uint256"}, []any{big.NewInt(7)})
+	require.NoError(t, err)
+	log := types.Log{
+		Topics: []common.Hash{common.BigToHash(big.NewInt(3))},
+		Data:   data,
+	}
+
+	values, err = ethcoder.DecodeEventLog(nonceChangeDef, log)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(3), values["space"])
+	require.Equal(t, big.NewInt(7), values["newNonce"])
+
+	// the same log can't be decoded as a non-anonymous event
+	nonceChangeDef.Anonymous = false
+	_, err = ethcoder.DecodeEventLog(nonceChangeDef, log)
+	require.ErrorContains(t, err, "does not match")
+
+	// anonymous events can't be registered by their topic hash
+	err = ethcoder.NewEventDecoder().RegisterEventSig("TxExecuted(bytes32 txHash) anonymous")
+	require.ErrorContains(t, err, "anonymous")
+}
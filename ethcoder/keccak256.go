@@ -1,6 +1,10 @@
 package ethcoder
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"golang.org/x/crypto/sha3"
 )
@@ -15,6 +19,91 @@ func Keccak256(input []byte) []byte {
 	return hasher.Sum(nil)
 }
 
+// Keccak256Concat returns the keccak256 hash of the concatenation of data, without
+// allocating the concatenated bytes, ie. Keccak256Concat(a, b) == Keccak256(append(a, b...)).
+func Keccak256Concat(data ...[]byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		hasher.Write(b)
+	}
+	return hasher.Sum(nil)
+}
+
 func SHA3(input []byte) common.Hash {
 	return Keccak256Hash(input)
 }
+
+// FunctionSelector returns the 4 byte selector of the function or custom error signature,
+// ie. "transfer(address,uint256)", which is the first 4 bytes of the keccak256 hash of its
+// canonical form. The signature is canonicalized first, so argument names and spaces are
+// dropped, type aliases expanded and return types ignored, ie. "transfer(address to, uint amount)"
+// and "transfer(address,uint256)(bool)" are the same.
+//
+// NOTE: if the signature can't be parsed, the selector of the signature as is is returned.
+func FunctionSelector(sig string) [4]byte {
+	var selector [4]byte
+	copy(selector[:], Keccak256([]byte(canonicalSignature(sig, false))))
+	return selector
+}
+
+// EventTopic returns the topic hash of the event signature, ie. "Transfer(address,address,uint256)",
+// which is the keccak256 hash of its canonical form, where "Transfer(address indexed from,
+// address indexed to, uint value)" is the same. See EventTopicHash.
+func EventTopic(sig string) (common.Hash, error) {
+	topicHash, _, err := EventTopicHash(sig)
+	return topicHash, err
+}
+
+// MustEventTopic is EventTopic for known event signatures, ie. of package level variables,
+// and panics if the signature is invalid.
+func MustEventTopic(sig string) common.Hash {
+	topicHash, err := EventTopic(sig)
+	if err != nil {
+		panic(fmt.Errorf("ethcoder: must event topic but failed due to, %v", err))
+	}
+	return topicHash
+}
+
+// intAliasRegexp matches the uint and int type aliases of uint256 and int256.
+var intAliasRegexp = regexp.MustCompile(`\bu?int\b`)
+
+// canonicalSignature returns the canonical form of the function or event signature, or
+// the signature as is if it can't be parsed.
+func canonicalSignature(sig string, isEvent bool) string {
+	sig = strings.TrimSpace(sig)
+
+	// drop the return types of a function signature, ie. "balanceOf(address)(uint256)"
+	if !isEvent {
+		if methodSig, _, err := splitMethodReturnSignature(sig); err == nil {
+			sig = methodSig
+		}
+	}
+
+	abiSig, err := ParseABISignature(sig)
+	if err != nil {
+		return sig
+	}
+	return canonicalABISignature(abiSig)
+}
+
+// canonicalEventDef returns the parsed event signature in its canonical form, where the
+// uint and int type aliases of its Signature and ArgTypes are expanded, so that its Hash
+// is the topic of the logs emitted by the event.
+func canonicalEventDef(eventDef ABISignature) ABISignature {
+	eventDef.Signature = canonicalABISignature(eventDef)
+	eventDef.Hash = Keccak256Hash([]byte(eventDef.Signature)).String()
+
+	argTypes := make([]string, len(eventDef.ArgTypes))
+	for i, argType := range eventDef.ArgTypes {
+		argTypes[i] = intAliasRegexp.ReplaceAllString(argType, "${0}256")
+	}
+	eventDef.ArgTypes = argTypes
+	return eventDef
+}
+
+// canonicalABISignature returns the Signature of the parsed abi signature, with the
+// uint and int type aliases expanded.
+func canonicalABISignature(abiSig ABISignature) string {
+	args := strings.TrimSuffix(strings.TrimPrefix(abiSig.Signature, abiSig.Name+"("), ")")
+	return abiSig.Name + "(" + intAliasRegexp.ReplaceAllString(args, "${0}256") + ")"
+}
@@ -0,0 +1,139 @@
+package ethcoder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// MaxUnwrapDepth is the maximum depth of nested wrapper calls which UnwrapMulticall
+// will recursively unwrap.
+const MaxUnwrapDepth = 4
+
+var ErrNotMulticall = errors.New("ethcoder: calldata is not a known multicall or forwarder call")
+
+// InnerCall is a call wrapped within the calldata of a multicall or forwarder contract call.
+type InnerCall struct {
+	// Target is the contract address the inner call is made to. A nil value means
+	// the call is made to the wrapping contract itself, ie. multicall(bytes[]).
+	Target *common.Address
+
+	// Value is the amount of ETH sent with the inner call, if known.
+	Value *big.Int
+
+	// Data is the calldata of the inner call.
+	Data []byte
+
+	// Calls are the inner calls of Data, in case the inner call is itself a
+	// multicall or forwarder call.
+	Calls []InnerCall
+}
+
+// wrapperABI is the abi of the common multicall and forwarder contract methods
+// which UnwrapMulticall recognizes.
+const wrapperABI = `[
+	{"type":"function","name":"aggregate","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}]}]},
+	{"type":"function","name":"tryAggregate","inputs":[{"name":"requireSuccess","type":"bool"},{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}]}]},
+	{"type":"function","name":"blockAndAggregate","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}]}]},
+	{"type":"function","name":"tryBlockAndAggregate","inputs":[{"name":"requireSuccess","type":"bool"},{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}]}]},
+	{"type":"function","name":"aggregate3","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}]},
+	{"type":"function","name":"aggregate3Value","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"value","type":"uint256"},{"name":"callData","type":"bytes"}]}]},
+	{"type":"function","name":"multicall","inputs":[{"name":"data","type":"bytes[]"}]},
+	{"type":"function","name":"multicall","inputs":[{"name":"deadline","type":"uint256"},{"name":"data","type":"bytes[]"}]},
+	{"type":"function","name":"execute","inputs":[{"name":"target","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}]},
+	{"type":"function","name":"execTransaction","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},{"name":"signatures","type":"bytes"}]}
+]`
+
+var wrapperMethods = func() map[[4]byte]abi.Method {
+	parsed, err := abi.JSON(strings.NewReader(wrapperABI))
+	if err != nil {
+		panic(fmt.Errorf("ethcoder: invalid wrapper abi: %w", err))
+	}
+	methods := make(map[[4]byte]abi.Method, len(parsed.Methods))
+	for _, method := range parsed.Methods {
+		var selector [4]byte
+		copy(selector[:], method.ID)
+		methods[selector] = method
+	}
+	return methods
+}()
+
+// UnwrapMulticall decodes the calldata of a call to a multicall or forwarder contract,
+// and returns the wrapped inner calls. Recognized patterns are Multicall3 aggregate,
+// tryAggregate, blockAndAggregate, tryBlockAndAggregate, aggregate3 and aggregate3Value,
+// Uniswap-style multicall(bytes[]), and execute / execTransaction forwarders.
+//
+// Inner calls which are themselves wrapper calls are unwrapped recursively up to
+// MaxUnwrapDepth levels. ErrNotMulticall is returned if the calldata is not recognized.
+func UnwrapMulticall(calldata []byte) ([]InnerCall, error) {
+	return unwrapMulticall(calldata, 0)
+}
+
+func unwrapMulticall(calldata []byte, depth int) ([]InnerCall, error) {
+	if len(calldata) < 4 {
+		return nil, ErrNotMulticall
+	}
+
+	var selector [4]byte
+	copy(selector[:], calldata[:4])
+	method, ok := wrapperMethods[selector]
+	if !ok {
+		return nil, ErrNotMulticall
+	}
+
+	args, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to decode %s calldata: %w", method.RawName, err)
+	}
+
+	var calls []InnerCall
+
+	switch method.RawName {
+	case "multicall":
+		for _, data := range args[len(args)-1].([][]byte) {
+			calls = append(calls, InnerCall{Data: data})
+		}
+
+	case "execute", "execTransaction":
+		target := args[0].(common.Address)
+		calls = append(calls, InnerCall{
+			Target: &target,
+			Value:  args[1].(*big.Int),
+			Data:   args[2].([]byte),
+		})
+
+	default:
+		// aggregate methods, where the last argument is an array of call structs
+		list := reflect.ValueOf(args[len(args)-1])
+		for i := 0; i < list.Len(); i++ {
+			call := list.Index(i)
+			target := call.FieldByName("Target").Interface().(common.Address)
+			innerCall := InnerCall{
+				Target: &target,
+				Data:   call.FieldByName("CallData").Bytes(),
+			}
+			if value := call.FieldByName("Value"); value.IsValid() {
+				innerCall.Value = value.Interface().(*big.Int)
+			}
+			calls = append(calls, innerCall)
+		}
+	}
+
+	// recursively unwrap inner calls
+	if depth+1 < MaxUnwrapDepth {
+		for i := range calls {
+			innerCalls, err := unwrapMulticall(calls[i].Data, depth+1)
+			if err != nil {
+				continue
+			}
+			calls[i].Calls = innerCalls
+		}
+	}
+
+	return calls, nil
+}
@@ -0,0 +1,82 @@
+package ethcoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnwrapMulticall(t *testing.T) {
+	tokenAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	multicallAddress := common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+	transferData, err := ABIEncodeMethodCalldata("transfer(address,uint256)", []interface{}{common.HexToAddress("0x2222222222222222222222222222222222222222"), big.NewInt(100)})
+	require.NoError(t, err)
+
+	balanceOfData, err := ABIEncodeMethodCalldata("balanceOf(address)", []interface{}{common.HexToAddress("0x2222222222222222222222222222222222222222")})
+	require.NoError(t, err)
+
+	t.Run("aggregate3", func(t *testing.T) {
+		calldata, err := ABIEncodeMethodCalldata("aggregate3((address,bool,bytes)[])", []interface{}{
+			[]struct {
+				Name0 common.Address
+				Name1 bool
+				Name2 []byte
+			}{
+				{Name0: tokenAddress, Name1: false, Name2: transferData},
+				{Name0: tokenAddress, Name1: true, Name2: balanceOfData},
+			},
+		})
+		require.NoError(t, err)
+
+		calls, err := UnwrapMulticall(calldata)
+		require.NoError(t, err)
+		require.Len(t, calls, 2)
+		require.Equal(t, tokenAddress, *calls[0].Target)
+		require.Equal(t, transferData, calls[0].Data)
+		require.Equal(t, balanceOfData, calls[1].Data)
+		require.Nil(t, calls[0].Calls)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		aggregateData, err := ABIEncodeMethodCalldata("aggregate((address,bytes)[])", []interface{}{
+			[]struct {
+				Name0 common.Address
+				Name1 []byte
+			}{
+				{Name0: tokenAddress, Name1: transferData},
+			},
+		})
+		require.NoError(t, err)
+
+		calldata, err := ABIEncodeMethodCalldata("execute(address,uint256,bytes)", []interface{}{multicallAddress, big.NewInt(1), aggregateData})
+		require.NoError(t, err)
+
+		calls, err := UnwrapMulticall(calldata)
+		require.NoError(t, err)
+		require.Len(t, calls, 1)
+		require.Equal(t, multicallAddress, *calls[0].Target)
+		require.Equal(t, uint64(1), calls[0].Value.Uint64())
+		require.Len(t, calls[0].Calls, 1)
+		require.Equal(t, tokenAddress, *calls[0].Calls[0].Target)
+		require.Equal(t, transferData, calls[0].Calls[0].Data)
+	})
+
+	t.Run("multicall", func(t *testing.T) {
+		calldata, err := ABIEncodeMethodCalldata("multicall(uint256,bytes[])", []interface{}{big.NewInt(1000), [][]byte{transferData, balanceOfData}})
+		require.NoError(t, err)
+
+		calls, err := UnwrapMulticall(calldata)
+		require.NoError(t, err)
+		require.Len(t, calls, 2)
+		require.Nil(t, calls[0].Target)
+		require.Equal(t, balanceOfData, calls[1].Data)
+	})
+
+	t.Run("not multicall", func(t *testing.T) {
+		_, err := UnwrapMulticall(transferData)
+		require.ErrorIs(t, err, ErrNotMulticall)
+	})
+}
@@ -0,0 +1,163 @@
+package ethcoder
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Permit2 -- https://github.com/Uniswap/permit2
+
+// Permit2Address is the canonical address of the Permit2 contract, which is the same on all chains.
+var Permit2Address = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+
+// Permit2Details are the details of an allowance of a token, ie. PermitDetails of the
+// Permit2 AllowanceTransfer, where Amount is a uint160, and Expiration and Nonce are uint48.
+type Permit2Details struct {
+	Token      common.Address
+	Amount     *big.Int
+	Expiration uint64
+	Nonce      uint64
+}
+
+// Permit2Single is a Permit2 PermitSingle message, which approves the spender for a
+// single token allowance until the SigDeadline timestamp.
+type Permit2Single struct {
+	Details     Permit2Details
+	Spender     common.Address
+	SigDeadline *big.Int
+}
+
+// Permit2Batch is a Permit2 PermitBatch message, which approves the spender for
+// many token allowances until the SigDeadline timestamp.
+type Permit2Batch struct {
+	Details     []Permit2Details
+	Spender     common.Address
+	SigDeadline *big.Int
+}
+
+var permit2Types = TypedDataTypes{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	"PermitDetails": {
+		{Name: "token", Type: "address"},
+		{Name: "amount", Type: "uint160"},
+		{Name: "expiration", Type: "uint48"},
+		{Name: "nonce", Type: "uint48"},
+	},
+	"PermitSingle": {
+		{Name: "details", Type: "PermitDetails"},
+		{Name: "spender", Type: "address"},
+		{Name: "sigDeadline", Type: "uint256"},
+	},
+	"PermitBatch": {
+		{Name: "details", Type: "PermitDetails[]"},
+		{Name: "spender", Type: "address"},
+		{Name: "sigDeadline", Type: "uint256"},
+	},
+}
+
+// Permit2Domain returns the EIP-712 domain of the canonical Permit2 contract on the chain.
+func Permit2Domain(chainID *big.Int) TypedDataDomain {
+	verifyingContract := Permit2Address
+	return TypedDataDomain{
+		Name:              "Permit2",
+		ChainID:           chainID,
+		VerifyingContract: &verifyingContract,
+	}
+}
+
+// TypedData returns the EIP-712 typed data of the PermitSingle message for the canonical
+// Permit2 contract on the chain, which can be signed with ethwallet.Wallet#SignTypedData.
+func (p Permit2Single) TypedData(chainID *big.Int) *TypedData {
+	return &TypedData{
+		Types:       permit2Types,
+		PrimaryType: "PermitSingle",
+		Domain:      Permit2Domain(chainID),
+		Message: map[string]interface{}{
+			"details":     p.Details.message(),
+			"spender":     p.Spender,
+			"sigDeadline": bigIntOrZero(p.SigDeadline),
+		},
+	}
+}
+
+// Hash returns the EIP-712 struct hash of the PermitSingle message.
+func (p Permit2Single) Hash() (common.Hash, error) {
+	return permit2HashStruct(p.TypedData(big.NewInt(1)))
+}
+
+// Digest returns the EIP-712 digest of the PermitSingle message for the canonical Permit2
+// contract on the chain, which is the hash signed by the owner of the tokens.
+func (p Permit2Single) Digest(chainID *big.Int) (common.Hash, error) {
+	return permit2Digest(p.TypedData(chainID))
+}
+
+// TypedData returns the EIP-712 typed data of the PermitBatch message for the canonical
+// Permit2 contract on the chain, which can be signed with ethwallet.Wallet#SignTypedData.
+func (p Permit2Batch) TypedData(chainID *big.Int) *TypedData {
+	details := make([]interface{}, len(p.Details))
+	for i, d := range p.Details {
+		details[i] = d.message()
+	}
+	return &TypedData{
+		Types:       permit2Types,
+		PrimaryType: "PermitBatch",
+		Domain:      Permit2Domain(chainID),
+		Message: map[string]interface{}{
+			"details":     details,
+			"spender":     p.Spender,
+			"sigDeadline": bigIntOrZero(p.SigDeadline),
+		},
+	}
+}
+
+// Hash returns the EIP-712 struct hash of the PermitBatch message.
+func (p Permit2Batch) Hash() (common.Hash, error) {
+	return permit2HashStruct(p.TypedData(big.NewInt(1)))
+}
+
+// Digest returns the EIP-712 digest of the PermitBatch message for the canonical Permit2
+// contract on the chain, which is the hash signed by the owner of the tokens.
+func (p Permit2Batch) Digest(chainID *big.Int) (common.Hash, error) {
+	return permit2Digest(p.TypedData(chainID))
+}
+
+func (d Permit2Details) message() map[string]interface{} {
+	return map[string]interface{}{
+		"token":      d.Token,
+		"amount":     bigIntOrZero(d.Amount),
+		"expiration": d.Expiration,
+		"nonce":      d.Nonce,
+	}
+}
+
+func permit2HashStruct(typedData *TypedData) (common.Hash, error) {
+	hash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("ethcoder: failed to hash permit2 %s: %w", typedData.PrimaryType, err)
+	}
+	return common.BytesToHash(hash), nil
+}
+
+func permit2Digest(typedData *TypedData) (common.Hash, error) {
+	if typedData.Domain.ChainID == nil {
+		return common.Hash{}, fmt.Errorf("ethcoder: chainID is required")
+	}
+	digest, err := typedData.EncodeDigest()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("ethcoder: failed to encode permit2 %s digest: %w", typedData.PrimaryType, err)
+	}
+	return common.BytesToHash(digest), nil
+}
+
+func bigIntOrZero(v *big.Int) *big.Int {
+	if v == nil {
+		return big.NewInt(0)
+	}
+	return v
+}
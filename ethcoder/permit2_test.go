@@ -0,0 +1,120 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// permit2 type strings, as defined by PermitHash.sol of the Permit2 contract
+const (
+	permit2DetailsType = "PermitDetails(address token,uint160 amount,uint48 expiration,uint48 nonce)"
+	permit2SingleType  = "PermitSingle(PermitDetails details,address spender,uint256 sigDeadline)" + permit2DetailsType
+	permit2BatchType   = "PermitBatch(PermitDetails[] details,address spender,uint256 sigDeadline)" + permit2DetailsType
+)
+
+func TestPermit2Single(t *testing.T) {
+	permit := ethcoder.Permit2Single{
+		Details: ethcoder.Permit2Details{
+			Token:      common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+			Amount:     new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1)),
+			Expiration: 1_700_000_000,
+			Nonce:      3,
+		},
+		Spender:     common.HexToAddress("0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD"),
+		SigDeadline: big.NewInt(1_700_001_800),
+	}
+
+	// struct hash as computed by PermitHash.sol
+	detailsHash := keccakABI(t, []string{"bytes32", "address", "uint160", "uint48", "uint48"}, []interface{}{
+		crypto.Keccak256Hash([]byte(permit2DetailsType)), permit.Details.Token, permit.Details.Amount,
+		new(big.Int).SetUint64(permit.Details.Expiration), new(big.Int).SetUint64(permit.Details.Nonce),
+	})
+	expectedHash := keccakABI(t, []string{"bytes32", "bytes32", "address", "uint256"}, []interface{}{
+		crypto.Keccak256Hash([]byte(permit2SingleType)), detailsHash, permit.Spender, permit.SigDeadline,
+	})
+
+	hash, err := permit.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, expectedHash, hash)
+
+	digest, err := permit.Digest(big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, permit2Digest(t, big.NewInt(1), expectedHash), digest)
+
+	// the digest is bound to the chain
+	digest137, err := permit.Digest(big.NewInt(137))
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, digest137)
+
+	// sign and recover
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	sig, _, err := wallet.SignTypedData(permit.TypedData(big.NewInt(1)))
+	require.NoError(t, err)
+	sig[64] -= 27
+	pubKey, err := crypto.SigToPub(digest.Bytes(), sig)
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Address(), crypto.PubkeyToAddress(*pubKey))
+}
+
+func TestPermit2Batch(t *testing.T) {
+	permit := ethcoder.Permit2Batch{
+		Details: []ethcoder.Permit2Details{
+			{Token: common.HexToAddress("0x1"), Amount: big.NewInt(100), Expiration: 10, Nonce: 0},
+			{Token: common.HexToAddress("0x2"), Amount: big.NewInt(200), Expiration: 20, Nonce: 1},
+		},
+		Spender:     common.HexToAddress("0x3"),
+		SigDeadline: big.NewInt(30),
+	}
+
+	var detailsHashes []byte
+	for _, d := range permit.Details {
+		detailsHash := keccakABI(t, []string{"bytes32", "address", "uint160", "uint48", "uint48"}, []interface{}{
+			crypto.Keccak256Hash([]byte(permit2DetailsType)), d.Token, d.Amount,
+			new(big.Int).SetUint64(d.Expiration), new(big.Int).SetUint64(d.Nonce),
+		})
+		detailsHashes = append(detailsHashes, detailsHash.Bytes()...)
+	}
+	expectedHash := keccakABI(t, []string{"bytes32", "bytes32", "address", "uint256"}, []interface{}{
+		crypto.Keccak256Hash([]byte(permit2BatchType)), crypto.Keccak256Hash(detailsHashes), permit.Spender, permit.SigDeadline,
+	})
+
+	hash, err := permit.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, expectedHash, hash)
+
+	digest, err := permit.Digest(big.NewInt(10))
+	require.NoError(t, err)
+	assert.Equal(t, permit2Digest(t, big.NewInt(10), expectedHash), digest)
+
+	_, err = permit.Digest(nil)
+	assert.Error(t, err)
+}
+
+func keccakABI(t *testing.T, argTypes []string, argValues []interface{}) common.Hash {
+	t.Helper()
+	for i, v := range argValues {
+		if h, ok := v.(common.Hash); ok {
+			argValues[i] = [32]byte(h)
+		}
+	}
+	encoded, err := ethcoder.ABIPackArguments(argTypes, argValues)
+	require.NoError(t, err)
+	return crypto.Keccak256Hash(encoded)
+}
+
+func permit2Digest(t *testing.T, chainID *big.Int, structHash common.Hash) common.Hash {
+	t.Helper()
+	domainSeparator := keccakABI(t, []string{"bytes32", "bytes32", "uint256", "address"}, []interface{}{
+		crypto.Keccak256Hash([]byte("EIP712Domain(string name,uint256 chainId,address verifyingContract)")),
+		crypto.Keccak256Hash([]byte("Permit2")), chainID, ethcoder.Permit2Address,
+	})
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), structHash.Bytes())
+}
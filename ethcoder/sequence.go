@@ -0,0 +1,170 @@
+package ethcoder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var ErrNotSequenceExecute = errors.New("ethcoder: calldata is not a Sequence wallet execute or selfExecute call")
+
+// SequenceTransaction is a single transaction of a Sequence wallet meta-transaction batch.
+type SequenceTransaction struct {
+	DelegateCall  bool
+	RevertOnError bool
+	GasLimit      *big.Int
+	To            common.Address
+	Value         *big.Int
+	Data          []byte
+}
+
+// SequenceExecute is a decoded call to a Sequence wallet, ie. execute(txs, nonce, signature),
+// or selfExecute(txs) where Nonce and Signature are nil.
+type SequenceExecute struct {
+	Transactions []SequenceTransaction
+	Nonce        *big.Int
+	Signature    []byte
+}
+
+// sequenceWalletABI is the abi of the Sequence wallet methods which execute a batch
+// of transactions.
+const sequenceWalletABI = `[
+	{"type":"function","name":"execute","inputs":[{"name":"_txs","type":"tuple[]","components":[{"name":"delegateCall","type":"bool"},{"name":"revertOnError","type":"bool"},{"name":"gasLimit","type":"uint256"},{"name":"target","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}]},{"name":"_nonce","type":"uint256"},{"name":"_signature","type":"bytes"}]},
+	{"type":"function","name":"selfExecute","inputs":[{"name":"_txs","type":"tuple[]","components":[{"name":"delegateCall","type":"bool"},{"name":"revertOnError","type":"bool"},{"name":"gasLimit","type":"uint256"},{"name":"target","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}]}]}
+]`
+
+var sequenceWallet = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(sequenceWalletABI))
+	if err != nil {
+		panic(fmt.Errorf("ethcoder: invalid sequence wallet abi: %w", err))
+	}
+	return parsed
+}()
+
+// sequenceTxn is the abi representation of a SequenceTransaction.
+type sequenceTxn struct {
+	DelegateCall  bool
+	RevertOnError bool
+	GasLimit      *big.Int
+	Target        common.Address
+	Value         *big.Int
+	Data          []byte
+}
+
+// DecodeSequenceExecute decodes the calldata of a Sequence wallet execute or selfExecute
+// call into its batch of transactions. ErrNotSequenceExecute is returned if the calldata
+// is not either of these calls.
+func DecodeSequenceExecute(calldata []byte) (*SequenceExecute, error) {
+	if len(calldata) < 4 {
+		return nil, ErrNotSequenceExecute
+	}
+	method, err := sequenceWallet.MethodById(calldata[:4])
+	if err != nil {
+		return nil, ErrNotSequenceExecute
+	}
+
+	args, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to decode %s calldata: %w", method.RawName, err)
+	}
+
+	txns, ok := abi.ConvertType(args[0], new([]sequenceTxn)).(*[]sequenceTxn)
+	if !ok {
+		return nil, fmt.Errorf("ethcoder: failed to decode %s transactions", method.RawName)
+	}
+
+	execute := &SequenceExecute{
+		Transactions: make([]SequenceTransaction, len(*txns)),
+	}
+	for i, txn := range *txns {
+		execute.Transactions[i] = SequenceTransaction{
+			DelegateCall:  txn.DelegateCall,
+			RevertOnError: txn.RevertOnError,
+			GasLimit:      txn.GasLimit,
+			To:            txn.Target,
+			Value:         txn.Value,
+			Data:          txn.Data,
+		}
+	}
+	if method.RawName == "execute" {
+		execute.Nonce = args[1].(*big.Int)
+		execute.Signature = args[2].([]byte)
+	}
+	return execute, nil
+}
+
+// EncodeSequenceExecute encodes the calldata of a Sequence wallet execute(txs, nonce, signature) call.
+func EncodeSequenceExecute(txns []SequenceTransaction, nonce *big.Int, signature []byte) ([]byte, error) {
+	if nonce == nil {
+		return nil, fmt.Errorf("ethcoder: nonce is required")
+	}
+	calldata, err := sequenceWallet.Pack("execute", toSequenceTxns(txns), nonce, signature)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to encode execute calldata: %w", err)
+	}
+	return calldata, nil
+}
+
+// EncodeSequenceSelfExecute encodes the calldata of a Sequence wallet selfExecute(txs) call,
+// used for batches sent by the wallet to itself.
+func EncodeSequenceSelfExecute(txns []SequenceTransaction) ([]byte, error) {
+	calldata, err := sequenceWallet.Pack("selfExecute", toSequenceTxns(txns))
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to encode selfExecute calldata: %w", err)
+	}
+	return calldata, nil
+}
+
+// SequenceMetaTxnID returns the id of a Sequence meta-transaction, ie. the batch of
+// transactions executed by the wallet with the nonce, as emitted by the TxExecuted and
+// TxFailed events of the wallet. It's computed as the EIP-191 sub-digest of
+// keccak256(abi.encode(nonce, txs)) for the wallet on the chain.
+func SequenceMetaTxnID(chainID *big.Int, wallet common.Address, txns []SequenceTransaction, nonce *big.Int) (common.Hash, error) {
+	if chainID == nil || nonce == nil {
+		return common.Hash{}, fmt.Errorf("ethcoder: chainID and nonce are required")
+	}
+
+	// the same arguments as execute, without the signature
+	args := sequenceWallet.Methods["execute"].Inputs
+	encoded, err := abi.Arguments{args[1], args[0]}.Pack(nonce, toSequenceTxns(txns))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("ethcoder: failed to encode transactions: %w", err)
+	}
+	digest := Keccak256Hash(encoded)
+
+	// keccak256(abi.encodePacked("\x19\x01", chainId, wallet, digest))
+	subDigest := make([]byte, 0, 2+32+20+32)
+	subDigest = append(subDigest, 0x19, 0x01)
+	subDigest = append(subDigest, common.LeftPadBytes(chainID.Bytes(), 32)...)
+	subDigest = append(subDigest, wallet.Bytes()...)
+	subDigest = append(subDigest, digest.Bytes()...)
+	return Keccak256Hash(subDigest), nil
+}
+
+func toSequenceTxns(txns []SequenceTransaction) []sequenceTxn {
+	out := make([]sequenceTxn, len(txns))
+	for i, txn := range txns {
+		out[i] = sequenceTxn{
+			DelegateCall:  txn.DelegateCall,
+			RevertOnError: txn.RevertOnError,
+			GasLimit:      txn.GasLimit,
+			Target:        txn.To,
+			Value:         txn.Value,
+			Data:          txn.Data,
+		}
+		if out[i].GasLimit == nil {
+			out[i].GasLimit = big.NewInt(0)
+		}
+		if out[i].Value == nil {
+			out[i].Value = big.NewInt(0)
+		}
+		if out[i].Data == nil {
+			out[i].Data = []byte{}
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package ethcoder
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceExecute(t *testing.T) {
+	to := common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	// execute([(false, true, 100000, to, 1, 0xabcd)], 7, 0x1234)
+	calldataHex := "0x7a9a1628" + strings.Join([]string{
+		"0000000000000000000000000000000000000000000000000000000000000060", // offset of _txs
+		"0000000000000000000000000000000000000000000000000000000000000007", // _nonce
+		"00000000000000000000000000000000000000000000000000000000000001a0", // offset of _signature
+		"0000000000000000000000000000000000000000000000000000000000000001", // len(_txs)
+		"0000000000000000000000000000000000000000000000000000000000000020", // offset of _txs[0]
+		"0000000000000000000000000000000000000000000000000000000000000000", // delegateCall
+		"0000000000000000000000000000000000000000000000000000000000000001", // revertOnError
+		"00000000000000000000000000000000000000000000000000000000000186a0", // gasLimit
+		"0000000000000000000000000000000000000000000000000000000000000001", // target
+		"0000000000000000000000000000000000000000000000000000000000000001", // value
+		"00000000000000000000000000000000000000000000000000000000000000c0", // offset of data
+		"0000000000000000000000000000000000000000000000000000000000000002", // len(data)
+		"abcd000000000000000000000000000000000000000000000000000000000000", // data
+		"0000000000000000000000000000000000000000000000000000000000000002", // len(_signature)
+		"1234000000000000000000000000000000000000000000000000000000000000", // _signature
+	}, "")
+
+	txns := []SequenceTransaction{{
+		RevertOnError: true,
+		GasLimit:      big.NewInt(100000),
+		To:            to,
+		Value:         big.NewInt(1),
+		Data:          []byte{0xab, 0xcd},
+	}}
+
+	calldata, err := EncodeSequenceExecute(txns, big.NewInt(7), []byte{0x12, 0x34})
+	require.NoError(t, err)
+	require.Equal(t, calldataHex, HexEncode(calldata))
+
+	execute, err := DecodeSequenceExecute(calldata)
+	require.NoError(t, err)
+	require.Equal(t, txns, execute.Transactions)
+	require.Equal(t, uint64(7), execute.Nonce.Uint64())
+	require.Equal(t, []byte{0x12, 0x34}, execute.Signature)
+
+	t.Run("selfExecute", func(t *testing.T) {
+		calldata, err := EncodeSequenceSelfExecute(txns)
+		require.NoError(t, err)
+		require.Equal(t, "0x61c2926c", HexEncode(calldata[:4]))
+
+		execute, err := DecodeSequenceExecute(calldata)
+		require.NoError(t, err)
+		require.Equal(t, txns, execute.Transactions)
+		require.Nil(t, execute.Nonce)
+		require.Nil(t, execute.Signature)
+	})
+
+	t.Run("not execute", func(t *testing.T) {
+		transferData, err := ABIEncodeMethodCalldata("transfer(address,uint256)", []interface{}{to, big.NewInt(100)})
+		require.NoError(t, err)
+
+		_, err = DecodeSequenceExecute(transferData)
+		require.ErrorIs(t, err, ErrNotSequenceExecute)
+	})
+}
+
+func TestSequenceMetaTxnID(t *testing.T) {
+	chainID := big.NewInt(137)
+	wallet := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	txns := []SequenceTransaction{{
+		RevertOnError: true,
+		To:            common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		Value:         big.NewInt(1),
+		Data:          []byte{0xab, 0xcd},
+	}}
+
+	// keccak256(abi.encode(nonce, txs)), where the encoding is the same as the execute
+	// arguments without the signature
+	calldata, err := EncodeSequenceExecute(txns, big.NewInt(7), nil)
+	require.NoError(t, err)
+	encoded := append(common.LeftPadBytes(big.NewInt(7).Bytes(), 32), common.LeftPadBytes([]byte{0x40}, 32)...)
+	encoded = append(encoded, calldata[4+3*32:len(calldata)-32]...)
+	digest := Keccak256Hash(encoded)
+
+	subDigest := append([]byte{0x19, 0x01}, common.LeftPadBytes(chainID.Bytes(), 32)...)
+	subDigest = append(subDigest, wallet.Bytes()...)
+	subDigest = append(subDigest, digest.Bytes()...)
+
+	id, err := SequenceMetaTxnID(chainID, wallet, txns, big.NewInt(7))
+	require.NoError(t, err)
+	require.Equal(t, Keccak256Hash(subDigest), id)
+
+	// the id depends on the nonce
+	id2, err := SequenceMetaTxnID(chainID, wallet, txns, big.NewInt(8))
+	require.NoError(t, err)
+	require.NotEqual(t, id, id2)
+}
@@ -0,0 +1,213 @@
+package ethcoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+const siwePreamble = " wants you to sign in with your Ethereum account:"
+
+// SIWEMessage is an EIP-4361 Sign-In With Ethereum message, see https://eips.ethereum.org/EIPS/eip-4361
+//
+// The times are RFC 3339 strings, as they're signed as-is.
+type SIWEMessage struct {
+	Scheme         string // optional, ie. https
+	Domain         string // ie. example.com
+	Address        common.Address
+	Statement      string // optional, a single line
+	URI            string
+	Version        string // always "1"
+	ChainID        uint64
+	Nonce          string // at least 8 alphanumeric characters
+	IssuedAt       string
+	ExpirationTime string   // optional
+	NotBefore      string   // optional
+	RequestID      string   // optional
+	Resources      []string // optional
+}
+
+// String formats the message as it is presented to the user to sign.
+func (m SIWEMessage) String() string {
+	var b strings.Builder
+
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + siwePreamble + "\n")
+	b.WriteString(m.Address.Hex() + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("URI: " + m.URI + "\n")
+	b.WriteString("Version: " + m.Version + "\n")
+	b.WriteString("Chain ID: " + strconv.FormatUint(m.ChainID, 10) + "\n")
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + m.IssuedAt)
+	if m.ExpirationTime != "" {
+		b.WriteString("\nExpiration Time: " + m.ExpirationTime)
+	}
+	if m.NotBefore != "" {
+		b.WriteString("\nNot Before: " + m.NotBefore)
+	}
+	if m.RequestID != "" {
+		b.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+
+	return b.String()
+}
+
+// SIWEMessageHash returns the EIP-191 personal_sign hash of the formatted message, which
+// is the digest signed by the wallet, ie. with ethwallet.Wallet#SignMessage, and from which
+// the signer is recovered, ie. with ethwallet.RecoverMessageSigner.
+func SIWEMessageHash(message SIWEMessage) common.Hash {
+	msg := message.String()
+	return Keccak256Hash([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(msg), msg)))
+}
+
+// ParseSIWEMessage parses an EIP-4361 message as signed by the user, so its fields can
+// be verified, ie. the domain, nonce and expiration time. The message is rejected if it
+// is not in the exact EIP-4361 format, as the signature is over the message as-is.
+func ParseSIWEMessage(message string) (SIWEMessage, error) {
+	var m SIWEMessage
+	lines := strings.Split(message, "\n")
+
+	errInvalid := func(reason string, args ...any) error {
+		return fmt.Errorf("ethcoder: invalid SIWE message: %s", fmt.Sprintf(reason, args...))
+	}
+
+	if len(lines) < 9 {
+		return m, errInvalid("message is too short")
+	}
+
+	// header
+	origin, ok := strings.CutSuffix(lines[0], siwePreamble)
+	if !ok || origin == "" {
+		return m, errInvalid("missing preamble")
+	}
+	if scheme, domain, ok := strings.Cut(origin, "://"); ok {
+		m.Scheme, m.Domain = scheme, domain
+	} else {
+		m.Domain = origin
+	}
+
+	if !common.IsHexAddress(lines[1]) || common.HexToAddress(lines[1]).Hex() != lines[1] {
+		return m, errInvalid("address %q is not an EIP-55 checksummed address", lines[1])
+	}
+	m.Address = common.HexToAddress(lines[1])
+
+	if lines[2] != "" {
+		return m, errInvalid("expecting an empty line after the address")
+	}
+	i := 3
+	if lines[i] != "" {
+		m.Statement = lines[i]
+		i++
+	}
+	if lines[i] != "" {
+		return m, errInvalid("expecting an empty line after the statement")
+	}
+	i++
+
+	// fields, in order
+	field := func(tag string, required bool) (string, error) {
+		if i < len(lines) {
+			if value, ok := strings.CutPrefix(lines[i], tag+": "); ok {
+				i++
+				return value, nil
+			}
+		}
+		if required {
+			return "", errInvalid("missing %s", tag)
+		}
+		return "", nil
+	}
+
+	var err error
+	if m.URI, err = field("URI", true); err != nil {
+		return m, err
+	}
+	if m.Version, err = field("Version", true); err != nil {
+		return m, err
+	}
+	if m.Version != "1" {
+		return m, errInvalid("unsupported version %q", m.Version)
+	}
+
+	chainID, err := field("Chain ID", true)
+	if err != nil {
+		return m, err
+	}
+	if m.ChainID, err = strconv.ParseUint(chainID, 10, 64); err != nil {
+		return m, errInvalid("invalid chain id %q", chainID)
+	}
+
+	if m.Nonce, err = field("Nonce", true); err != nil {
+		return m, err
+	}
+	if !isSIWENonce(m.Nonce) {
+		return m, errInvalid("nonce must be at least 8 alphanumeric characters")
+	}
+
+	if m.IssuedAt, err = field("Issued At", true); err != nil {
+		return m, err
+	}
+	if m.ExpirationTime, err = field("Expiration Time", false); err != nil {
+		return m, err
+	}
+	if m.NotBefore, err = field("Not Before", false); err != nil {
+		return m, err
+	}
+	for tag, value := range map[string]string{"Issued At": m.IssuedAt, "Expiration Time": m.ExpirationTime, "Not Before": m.NotBefore} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return m, errInvalid("invalid %s %q", tag, value)
+		}
+	}
+
+	if m.RequestID, err = field("Request ID", false); err != nil {
+		return m, err
+	}
+
+	if i < len(lines) && lines[i] == "Resources:" {
+		i++
+		for ; i < len(lines); i++ {
+			resource, ok := strings.CutPrefix(lines[i], "- ")
+			if !ok {
+				break
+			}
+			m.Resources = append(m.Resources, resource)
+		}
+	}
+
+	if i < len(lines) {
+		return m, errInvalid("unexpected line %q", lines[i])
+	}
+
+	return m, nil
+}
+
+func isSIWENonce(nonce string) bool {
+	if len(nonce) < 8 {
+		return false
+	}
+	for _, c := range nonce {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
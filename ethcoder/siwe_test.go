@@ -0,0 +1,98 @@
+package ethcoder
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSIWEMessage(t *testing.T) {
+	// examples of EIP-4361
+	cases := []struct {
+		message  string
+		expected SIWEMessage
+	}{
+		{
+			message: "example.com wants you to sign in with your Ethereum account:\n" +
+				"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n" +
+				"\n" +
+				"I accept the ExampleOrg Terms of Service: https://example.com/tos\n" +
+				"\n" +
+				"URI: https://example.com/login\n" +
+				"Version: 1\n" +
+				"Chain ID: 1\n" +
+				"Nonce: 32891756\n" +
+				"Issued At: 2021-09-30T16:25:24Z\n" +
+				"Resources:\n" +
+				"- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/\n" +
+				"- https://example.com/my-web2-claim.json",
+			expected: SIWEMessage{
+				Domain:    "example.com",
+				Address:   common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+				Statement: "I accept the ExampleOrg Terms of Service: https://example.com/tos",
+				URI:       "https://example.com/login",
+				Version:   "1",
+				ChainID:   1,
+				Nonce:     "32891756",
+				IssuedAt:  "2021-09-30T16:25:24Z",
+				Resources: []string{
+					"ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/",
+					"https://example.com/my-web2-claim.json",
+				},
+			},
+		},
+		{
+			message: "https://example.com:3388 wants you to sign in with your Ethereum account:\n" +
+				"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n" +
+				"\n" +
+				"\n" +
+				"URI: https://example.com:3388/login\n" +
+				"Version: 1\n" +
+				"Chain ID: 137\n" +
+				"Nonce: 32891756\n" +
+				"Issued At: 2021-09-30T16:25:24.000Z\n" +
+				"Expiration Time: 2021-10-30T16:25:24Z\n" +
+				"Request ID: abc-123",
+			expected: SIWEMessage{
+				Scheme:         "https",
+				Domain:         "example.com:3388",
+				Address:        common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+				URI:            "https://example.com:3388/login",
+				Version:        "1",
+				ChainID:        137,
+				Nonce:          "32891756",
+				IssuedAt:       "2021-09-30T16:25:24.000Z",
+				ExpirationTime: "2021-10-30T16:25:24Z",
+				RequestID:      "abc-123",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		m, err := ParseSIWEMessage(c.message)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, m)
+		require.Equal(t, c.message, m.String())
+		require.Equal(t, common.BytesToHash(accounts.TextHash([]byte(c.message))), SIWEMessageHash(m))
+	}
+
+	invalid := []string{
+		"",
+		// address is not checksummed
+		"example.com wants you to sign in with your Ethereum account:\n0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2\n\n\nURI: https://example.com\nVersion: 1\nChain ID: 1\nNonce: 32891756\nIssued At: 2021-09-30T16:25:24Z",
+		// unsupported version
+		"example.com wants you to sign in with your Ethereum account:\n0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n\nURI: https://example.com\nVersion: 2\nChain ID: 1\nNonce: 32891756\nIssued At: 2021-09-30T16:25:24Z",
+		// nonce is too short
+		"example.com wants you to sign in with your Ethereum account:\n0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n\nURI: https://example.com\nVersion: 1\nChain ID: 1\nNonce: 1234\nIssued At: 2021-09-30T16:25:24Z",
+		// missing issued at
+		"example.com wants you to sign in with your Ethereum account:\n0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n\nURI: https://example.com\nVersion: 1\nChain ID: 1\nNonce: 32891756\nExpiration Time: 2021-09-30T16:25:24Z",
+		// fields out of order
+		"example.com wants you to sign in with your Ethereum account:\n0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n\nURI: https://example.com\nVersion: 1\nChain ID: 1\nNonce: 32891756\nIssued At: 2021-09-30T16:25:24Z\nRequest ID: 1\nNot Before: 2021-09-30T16:25:24Z",
+	}
+	for _, message := range invalid {
+		_, err := ParseSIWEMessage(message)
+		require.Error(t, err, message)
+	}
+}
@@ -38,6 +38,34 @@ func SolidityPackHex(argTypes []string, argValues []interface{}) (string, error)
 	return h, nil
 }
 
+// ABIPackedEncode encodes the values with Solidity's non-standard packed mode, as with
+// abi.encodePacked, ie. to compute CREATE2 salts or the preimage of signed messages.
+// Values are concatenated without padding, where address is 20 bytes, uintN and intN
+// are N/8 bytes, bytesN is N bytes, bool is 1 byte, and string and bytes are encoded
+// in place without their length. uint and int are aliases of uint256 and int256.
+//
+// Arrays are the exception, as their elements are padded to 32 bytes as with the standard
+// encoding, ie. each element of address[] or uint8[] takes 32 bytes, negative elements of
+// intN[] are sign-extended, and elements of bytesN[] are right-padded. Arrays are encoded
+// in place without their length. Arrays of string or bytes, nested arrays and tuples are
+// not supported in packed mode by Solidity, and return an error.
+//
+// NOTE: as dynamic values aren't length-prefixed, the encoding of more than one dynamic
+// value is ambiguous, ie. ("a", "bc") and ("ab", "c") encode the same, so don't hash
+// the packed encoding where such collisions matter.
+func ABIPackedEncode(argTypes []string, argValues []interface{}) ([]byte, error) {
+	for _, typ := range argTypes {
+		match := regexArgArray.FindStringSubmatch(typ)
+		if len(match) == 0 {
+			continue
+		}
+		if baseTyp := match[1]; baseTyp == "string" || baseTyp == "bytes" || regexArgArray.MatchString(baseTyp) {
+			return nil, fmt.Errorf("type '%s' is not supported in packed encoding", typ)
+		}
+	}
+	return SolidityPack(argTypes, argValues)
+}
+
 func solidityArgumentPackHex(typ string, val interface{}, isArray bool) (string, error) {
 	b, err := solidityArgumentPack(typ, val, isArray)
 	if err != nil {
@@ -98,20 +126,24 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 
 	// numbers
 	if match := regexArgNumber.FindStringSubmatch(typ); len(match) > 0 {
-		size, err := strconv.ParseInt(match[2], 10, 64)
-		if err != nil {
-			return nil, err
+		size := int64(256)
+		if match[2] != "" {
+			var err error
+			size, err = strconv.ParseInt(match[2], 10, 64)
+			if err != nil {
+				return nil, err
+			}
 		}
 		if (size%8 != 0) || size == 0 || size > 256 {
 			return nil, fmt.Errorf("invalid number type '%s'", typ)
 		}
-		if isArray {
-			size = 256
-		}
 
 		num := big.NewInt(0)
 		switch v := val.(type) {
 		case *big.Int:
+			if v == nil {
+				return nil, fmt.Errorf("expecting *big.Int or (u)intX value for type '%s'", typ)
+			}
 			num = v
 		case uint8:
 			num.SetUint64(uint64(v))
@@ -133,6 +165,25 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 			return nil, fmt.Errorf("expecting *big.Int or (u)intX value for type '%s'", typ)
 		}
 
+		// check the value fits the type
+		minNum, maxNum := big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), uint(size))
+		if match[1] == "int" {
+			maxNum.Rsh(maxNum, 1)
+			minNum.Neg(maxNum)
+		}
+		if num.Cmp(minNum) < 0 || num.Cmp(maxNum) >= 0 {
+			return nil, fmt.Errorf("value %s is out of range for type '%s'", num, typ)
+		}
+
+		if isArray {
+			size = 256
+		}
+
+		// two's complement of negative values
+		if num.Sign() < 0 {
+			num = new(big.Int).Add(num, new(big.Int).Lsh(big.NewInt(1), uint(size)))
+		}
+
 		b := math.PaddedBigBytes(num, int(size/8))
 		return b, nil
 	}
@@ -147,11 +198,6 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 			return nil, fmt.Errorf("invalid number type '%s'", typ)
 		}
 
-		if isArray {
-			// if (isArray) { return arrayify((value + Zeros).substring(0, 66)); }
-			return nil, fmt.Errorf("unsupported, file ticket.")
-		}
-
 		rv := reflect.ValueOf(val)
 		if rv.Type().Kind() != reflect.Array && rv.Type().Kind() != reflect.Slice {
 			return nil, fmt.Errorf("not an array")
@@ -171,6 +217,10 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 				return nil, fmt.Errorf("unable to set byte")
 			}
 		}
+		if isArray {
+			// right-padded, as in arrayify((value + Zeros).substring(0, 66))
+			return append(v, make([]byte, 32-size)...), nil
+		}
 		return v, nil
 	}
 
@@ -2,9 +2,11 @@ package ethcoder
 
 import (
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -121,3 +123,70 @@ func TestSolidityPack(t *testing.T) {
 		assert.Equal(t, "0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001", h)
 	}
 }
+
+func TestABIPackedEncode(t *testing.T) {
+	// example of the solidity docs:
+	// abi.encodePacked(int16(-1), bytes1(0x42), uint16(0x03), string("Hello, world!"))
+	{
+		b, err := ABIPackedEncode(
+			[]string{"int16", "bytes1", "uint16", "string"},
+			[]interface{}{int16(-1), [1]byte{0x42}, uint16(0x03), "Hello, world!"},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "0xffff42000348656c6c6f2c20776f726c6421", hexutil.Encode(b))
+	}
+
+	// create2 address of example 1 of EIP-1014:
+	// keccak256(abi.encodePacked(bytes1(0xff), deployer, salt, keccak256(initCode)))
+	{
+		b, err := ABIPackedEncode(
+			[]string{"bytes1", "address", "bytes32", "bytes32"},
+			[]interface{}{[1]byte{0xff}, common.HexToAddress("0xdeadbeef00000000000000000000000000000000"), common.Hash{}, Keccak256Hash([]byte{0x00})},
+		)
+		assert.NoError(t, err)
+		assert.Len(t, b, 85)
+		assert.Equal(t, common.HexToAddress("0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3"), common.BytesToAddress(Keccak256(b)[12:]))
+	}
+
+	// uint is an alias of uint256
+	{
+		b, err := ABIPackedEncode([]string{"uint", "int"}, []interface{}{big.NewInt(1), big.NewInt(-1)})
+		assert.NoError(t, err)
+		assert.Equal(t, "0x"+strings.Repeat("0", 63)+"1"+strings.Repeat("f", 64), hexutil.Encode(b))
+	}
+
+	// array elements are padded to 32 bytes
+	{
+		b, err := ABIPackedEncode([]string{"uint16[]"}, []interface{}{[]uint16{1, 2}})
+		assert.NoError(t, err)
+		assert.Equal(t, "0x"+strings.Repeat("0", 63)+"1"+strings.Repeat("0", 63)+"2", hexutil.Encode(b))
+
+		// negative elements are sign-extended
+		b, err = ABIPackedEncode([]string{"int8[1]"}, []interface{}{[]int8{-1}})
+		assert.NoError(t, err)
+		assert.Equal(t, "0x"+strings.Repeat("f", 64), hexutil.Encode(b))
+
+		// bytesN elements are right-padded
+		b, err = ABIPackedEncode([]string{"bytes2[]"}, []interface{}{[][2]byte{{0x12, 0x34}}})
+		assert.NoError(t, err)
+		assert.Equal(t, "0x1234"+strings.Repeat("0", 60), hexutil.Encode(b))
+	}
+
+	// unsupported types and out of range values
+	{
+		_, err := ABIPackedEncode([]string{"string[]"}, []interface{}{[]string{"a", "b"}})
+		assert.Error(t, err)
+
+		_, err = ABIPackedEncode([]string{"uint8[][]"}, []interface{}{[][]uint8{{1}}})
+		assert.Error(t, err)
+
+		_, err = ABIPackedEncode([]string{"uint8"}, []interface{}{big.NewInt(256)})
+		assert.Error(t, err)
+
+		_, err = ABIPackedEncode([]string{"int8"}, []interface{}{big.NewInt(-129)})
+		assert.Error(t, err)
+
+		_, err = ABIPackedEncode([]string{"uint256"}, []interface{}{big.NewInt(-1)})
+		assert.Error(t, err)
+	}
+}
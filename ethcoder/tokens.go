@@ -0,0 +1,84 @@
+package ethcoder
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// ERC20Transfer encodes the calldata of an ERC20 transfer(address,uint256) call.
+func ERC20Transfer(to common.Address, amount *big.Int) ([]byte, error) {
+	return encodeTokenCall("transfer(address,uint256)", to.Hex(), amount.String())
+}
+
+// ERC20TransferFrom encodes the calldata of an ERC20 transferFrom(address,address,uint256) call.
+func ERC20TransferFrom(from, to common.Address, amount *big.Int) ([]byte, error) {
+	return encodeTokenCall("transferFrom(address,address,uint256)", from.Hex(), to.Hex(), amount.String())
+}
+
+// ERC20Approve encodes the calldata of an ERC20 approve(address,uint256) call.
+func ERC20Approve(spender common.Address, amount *big.Int) ([]byte, error) {
+	return encodeTokenCall("approve(address,uint256)", spender.Hex(), amount.String())
+}
+
+// ERC721TransferFrom encodes the calldata of an ERC721 transferFrom(address,address,uint256) call.
+func ERC721TransferFrom(from, to common.Address, tokenID *big.Int) ([]byte, error) {
+	return encodeTokenCall("transferFrom(address,address,uint256)", from.Hex(), to.Hex(), tokenID.String())
+}
+
+// ERC721SafeTransferFrom encodes the calldata of an ERC721 safeTransferFrom(address,address,uint256)
+// call, or of safeTransferFrom(address,address,uint256,bytes) if data is passed.
+func ERC721SafeTransferFrom(from, to common.Address, tokenID *big.Int, optData ...[]byte) ([]byte, error) {
+	if len(optData) > 0 {
+		return encodeTokenCall("safeTransferFrom(address,address,uint256,bytes)", from.Hex(), to.Hex(), tokenID.String(), HexEncode(optData[0]))
+	}
+	return encodeTokenCall("safeTransferFrom(address,address,uint256)", from.Hex(), to.Hex(), tokenID.String())
+}
+
+// ERC721Approve encodes the calldata of an ERC721 approve(address,uint256) call.
+func ERC721Approve(to common.Address, tokenID *big.Int) ([]byte, error) {
+	return encodeTokenCall("approve(address,uint256)", to.Hex(), tokenID.String())
+}
+
+// ERC721SetApprovalForAll encodes the calldata of an ERC721 setApprovalForAll(address,bool) call.
+func ERC721SetApprovalForAll(operator common.Address, approved bool) ([]byte, error) {
+	return encodeTokenCall("setApprovalForAll(address,bool)", operator.Hex(), strconv.FormatBool(approved))
+}
+
+// ERC1155SafeTransferFrom encodes the calldata of an ERC1155
+// safeTransferFrom(address,address,uint256,uint256,bytes) call.
+func ERC1155SafeTransferFrom(from, to common.Address, id, amount *big.Int, data []byte) ([]byte, error) {
+	return encodeTokenCall("safeTransferFrom(address,address,uint256,uint256,bytes)", from.Hex(), to.Hex(), id.String(), amount.String(), HexEncode(data))
+}
+
+// ERC1155SafeBatchTransferFrom encodes the calldata of an ERC1155
+// safeBatchTransferFrom(address,address,uint256[],uint256[],bytes) call.
+func ERC1155SafeBatchTransferFrom(from, to common.Address, ids, amounts []*big.Int, data []byte) ([]byte, error) {
+	idValues := make([]string, len(ids))
+	for i, id := range ids {
+		idValues[i] = id.String()
+	}
+	amountValues := make([]string, len(amounts))
+	for i, amount := range amounts {
+		amountValues[i] = amount.String()
+	}
+	return encodeTokenCall("safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)", from.Hex(), to.Hex(), idValues, amountValues, HexEncode(data))
+}
+
+// ERC1155SetApprovalForAll encodes the calldata of an ERC1155 setApprovalForAll(address,bool) call.
+func ERC1155SetApprovalForAll(operator common.Address, approved bool) ([]byte, error) {
+	return ERC721SetApprovalForAll(operator, approved)
+}
+
+// encodeTokenCall encodes the calldata of a token method call. As the method signatures
+// are fixed, encoding only fails on an invalid amount or token id, ie. a nil or negative
+// value.
+func encodeTokenCall(methodSig string, args ...any) ([]byte, error) {
+	calldata, err := EncodeContractCall(ContractCallDef{ABI: methodSig, Args: args})
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to encode %s: %w", methodSig, err)
+	}
+	return HexDecode(calldata)
+}
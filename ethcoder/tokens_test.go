@@ -0,0 +1,55 @@
+package ethcoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCalldata(t *testing.T) {
+	from := common.HexToAddress("0x6615e4e985bf0d137196897dfa182dbd7127f54f")
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	data := []byte{0x12, 0x34}
+
+	encode := func(calldata []byte, err error) []byte {
+		require.NoError(t, err)
+		return calldata
+	}
+
+	tests := []struct {
+		calldata  []byte
+		methodSig string
+		args      []interface{}
+	}{
+		{encode(ERC20Transfer(to, big.NewInt(100))), "transfer(address,uint256)", []interface{}{to, big.NewInt(100)}},
+		{encode(ERC20TransferFrom(from, to, big.NewInt(100))), "transferFrom(address,address,uint256)", []interface{}{from, to, big.NewInt(100)}},
+		{encode(ERC20Approve(to, big.NewInt(100))), "approve(address,uint256)", []interface{}{to, big.NewInt(100)}},
+		{encode(ERC721TransferFrom(from, to, big.NewInt(7))), "transferFrom(address,address,uint256)", []interface{}{from, to, big.NewInt(7)}},
+		{encode(ERC721SafeTransferFrom(from, to, big.NewInt(7))), "safeTransferFrom(address,address,uint256)", []interface{}{from, to, big.NewInt(7)}},
+		{encode(ERC721SafeTransferFrom(from, to, big.NewInt(7), data)), "safeTransferFrom(address,address,uint256,bytes)", []interface{}{from, to, big.NewInt(7), data}},
+		{encode(ERC721Approve(to, big.NewInt(7))), "approve(address,uint256)", []interface{}{to, big.NewInt(7)}},
+		{encode(ERC721SetApprovalForAll(to, true)), "setApprovalForAll(address,bool)", []interface{}{to, true}},
+		{encode(ERC1155SafeTransferFrom(from, to, big.NewInt(7), big.NewInt(100), data)), "safeTransferFrom(address,address,uint256,uint256,bytes)", []interface{}{from, to, big.NewInt(7), big.NewInt(100), data}},
+		{encode(ERC1155SafeBatchTransferFrom(from, to, []*big.Int{big.NewInt(7), big.NewInt(8)}, []*big.Int{big.NewInt(100), big.NewInt(200)}, nil)), "safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)", []interface{}{from, to, []*big.Int{big.NewInt(7), big.NewInt(8)}, []*big.Int{big.NewInt(100), big.NewInt(200)}, []byte{}}},
+		{encode(ERC1155SetApprovalForAll(to, false)), "setApprovalForAll(address,bool)", []interface{}{to, false}},
+	}
+	for _, tt := range tests {
+		expected, err := ABIEncodeMethodCalldata(tt.methodSig, tt.args)
+		require.NoError(t, err)
+		require.Equal(t, HexEncode(expected), HexEncode(tt.calldata), tt.methodSig)
+	}
+
+	require.Equal(t, "0xa9059cbb", HexEncode(encode(ERC20Transfer(to, big.NewInt(1)))[:4]))
+
+	// invalid amounts and token ids return an error
+	_, err := ERC20Transfer(to, nil)
+	require.Error(t, err)
+	_, err = ERC20Approve(to, big.NewInt(-1))
+	require.Error(t, err)
+	_, err = ERC721TransferFrom(from, to, nil)
+	require.Error(t, err)
+	_, err = ERC1155SafeBatchTransferFrom(from, to, []*big.Int{big.NewInt(7)}, []*big.Int{nil}, nil)
+	require.Error(t, err)
+}
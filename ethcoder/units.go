@@ -0,0 +1,86 @@
+package ethcoder
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// FormatUnits formats the amount in base units, ie. wei, as a decimal string of the unit
+// with the number of decimals, ie. FormatUnits(big.NewInt(1500000000000000000), 18) is
+// "1.5". It's the equivalent of formatUnits of ethers.js, so trailing zeros of the
+// fraction are trimmed, while whole amounts keep a single one, ie. "1.0". Amounts with 0
+// decimals are formatted as integers, and negative decimals are treated as 0.
+func FormatUnits(amount *big.Int, decimals int) string {
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	if decimals <= 0 {
+		return amount.String()
+	}
+
+	digits := new(big.Int).Abs(amount).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if fraction == "" {
+		fraction = "0"
+	}
+
+	if amount.Sign() < 0 {
+		return "-" + whole + "." + fraction
+	}
+	return whole + "." + fraction
+}
+
+// ParseUnits parses the decimal string of an amount of the unit with the number of
+// decimals into base units, ie. ParseUnits("1.5", 18) is 1500000000000000000 wei. It's
+// the equivalent of parseUnits of ethers.js, and parses the digits exactly, without any
+// float rounding. An error is returned if the amount has more fractional digits than
+// decimals, unless the extra digits are trailing zeros, ie. "1.50" with 1 decimal.
+func ParseUnits(s string, decimals int) (*big.Int, error) {
+	if decimals < 0 {
+		return nil, fmt.Errorf("ethcoder: invalid decimals %d", decimals)
+	}
+
+	value := strings.TrimSpace(s)
+	negative := strings.HasPrefix(value, "-")
+	if negative {
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if (whole == "" && fraction == "") || !isDecimalDigits(whole) || !isDecimalDigits(fraction) {
+		return nil, fmt.Errorf("ethcoder: invalid decimal amount %q", s)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > decimals {
+		return nil, fmt.Errorf("ethcoder: amount %q has more than %d decimals", s, decimals)
+	}
+	fraction += strings.Repeat("0", decimals-len(fraction))
+
+	amount, ok := new(big.Int).SetString(whole+fraction, 10)
+	if !ok {
+		if whole+fraction != "" {
+			return nil, fmt.Errorf("ethcoder: invalid decimal amount %q", s)
+		}
+		// ie. ".0" with 0 decimals
+		amount = big.NewInt(0)
+	}
+	if negative {
+		amount.Neg(amount)
+	}
+	return amount, nil
+}
+
+// isDecimalDigits reports whether s only has the digits 0-9, where an empty s does.
+func isDecimalDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
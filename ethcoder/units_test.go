@@ -0,0 +1,81 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatUnits(t *testing.T) {
+	maxUint256, _ := new(big.Int).SetString("115792089237316195423570985008687907853269984665640564039457584007913129639935", 10)
+
+	cases := []struct {
+		amount   *big.Int
+		decimals int
+		expected string
+	}{
+		{big.NewInt(1500000000000000000), 18, "1.5"},
+		{big.NewInt(1000000000000000000), 18, "1.0"},
+		{big.NewInt(1), 18, "0.000000000000000001"},
+		{big.NewInt(0), 18, "0.0"},
+		{nil, 18, "0.0"},
+		{big.NewInt(-1250000), 6, "-1.25"},
+		{big.NewInt(-1), 6, "-0.000001"},
+		{big.NewInt(123456789), 6, "123.456789"},
+		{big.NewInt(1000), 0, "1000"},
+		{maxUint256, 18, "115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, ethcoder.FormatUnits(c.amount, c.decimals), "%v %d", c.amount, c.decimals)
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	maxUint256, _ := new(big.Int).SetString("115792089237316195423570985008687907853269984665640564039457584007913129639935", 10)
+
+	cases := []struct {
+		s        string
+		decimals int
+		expected *big.Int
+	}{
+		{"1.5", 18, big.NewInt(1500000000000000000)},
+		{"1", 18, big.NewInt(1000000000000000000)},
+		{"1.", 18, big.NewInt(1000000000000000000)},
+		{".5", 1, big.NewInt(5)},
+		{"0.000000000000000001", 18, big.NewInt(1)},
+		{"-1.25", 6, big.NewInt(-1250000)},
+		{"-0.000001", 6, big.NewInt(-1)},
+		{"123.456789", 6, big.NewInt(123456789)},
+		{"1.50", 1, big.NewInt(15)},
+		{"1000", 0, big.NewInt(1000)},
+		{"1000.000", 0, big.NewInt(1000)},
+		{"0", 6, big.NewInt(0)},
+		{"115792089237316195423570985008687907853269984665640564039457.584007913129639935", 18, maxUint256},
+	}
+	for _, c := range cases {
+		amount, err := ethcoder.ParseUnits(c.s, c.decimals)
+		require.NoError(t, err, c.s)
+		assert.Equal(t, c.expected.String(), amount.String(), c.s)
+
+		// round trip
+		parsed, err := ethcoder.ParseUnits(ethcoder.FormatUnits(amount, c.decimals), c.decimals)
+		require.NoError(t, err, c.s)
+		assert.Equal(t, amount, parsed, c.s)
+	}
+
+	for _, s := range []string{"", ".", "-", "1.2.3", "1e18", "0x10", "+1", "--1", "1,5", "1.5 ETH", "1.0000001"} {
+		_, err := ethcoder.ParseUnits(s, 6)
+		assert.Error(t, err, s)
+	}
+
+	// more fractional digits than decimals
+	_, err := ethcoder.ParseUnits("1.25", 1)
+	assert.Error(t, err)
+	_, err = ethcoder.ParseUnits("1.5", 0)
+	assert.Error(t, err)
+	_, err = ethcoder.ParseUnits("1", -1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package ethgas
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// GasPriceDistribution is the percentile breakdown of the effective gas prices paid by
+// the transactions of the recent blocks tracked by the gauge, see Options.DistributionNumBlocks.
+type GasPriceDistribution struct {
+	P10Wei *big.Int `json:"p10Wei"`
+	P50Wei *big.Int `json:"p50Wei"`
+	P90Wei *big.Int `json:"p90Wei"`
+	P99Wei *big.Int `json:"p99Wei"`
+
+	MinWei *big.Int `json:"minWei"`
+	MaxWei *big.Int `json:"maxWei"`
+
+	NumBlocks  int `json:"numBlocks"`
+	NumSamples int `json:"numSamples"`
+
+	BlockNum  *big.Int `json:"blockNum"`
+	BlockTime uint64   `json:"blockTime"`
+}
+
+// GasPriceDistribution returns the percentile breakdown of the effective gas prices paid
+// over the last Options.DistributionNumBlocks blocks, which is updated along with the
+// SuggestedGasPrice on every new block. It's computed from the blocks the gauge already
+// reads, so it doesn't make any additional requests to the node.
+func (g *GasGauge) GasPriceDistribution() GasPriceDistribution {
+	g.distributionMu.RLock()
+	defer g.distributionMu.RUnlock()
+	return g.gasPriceDistribution
+}
+
+// distributionBlock are the sorted paid gas prices of a block in the distribution window.
+type distributionBlock struct {
+	hash   common.Hash
+	prices []*big.Int
+}
+
+// updateDistribution adds the sorted paid gas prices of the block to the window of
+// recent blocks, and recomputes the distribution over the window.
+func (g *GasGauge) updateDistribution(block *ethmonitor.Block, paidGasPrices []*big.Int) {
+	g.distributionMu.Lock()
+	defer g.distributionMu.Unlock()
+
+	g.distributionWindow = append(g.distributionWindow, distributionBlock{hash: block.Hash(), prices: paidGasPrices})
+	if n := int(g.options.DistributionNumBlocks); len(g.distributionWindow) > n {
+		g.distributionWindow = g.distributionWindow[len(g.distributionWindow)-n:]
+	}
+
+	distribution := gasPriceDistribution(g.distributionWindow)
+	distribution.BlockNum = block.Number()
+	distribution.BlockTime = block.Time()
+	g.gasPriceDistribution = distribution
+}
+
+// removeFromDistribution drops the Removed blocks of a reorg from the window of recent
+// blocks, so the prices of reorged blocks aren't counted along with their replacements.
+func (g *GasGauge) removeFromDistribution(blocks ethmonitor.Blocks) {
+	g.distributionMu.Lock()
+	defer g.distributionMu.Unlock()
+
+	removed := map[common.Hash]bool{}
+	for _, block := range blocks {
+		if block.Event == ethmonitor.Removed {
+			removed[block.Hash()] = true
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	window := g.distributionWindow[:0]
+	for _, b := range g.distributionWindow {
+		if !removed[b.hash] {
+			window = append(window, b)
+		}
+	}
+	if len(window) == len(g.distributionWindow) {
+		return
+	}
+	g.distributionWindow = window
+
+	distribution := gasPriceDistribution(g.distributionWindow)
+	distribution.BlockNum = g.gasPriceDistribution.BlockNum
+	distribution.BlockTime = g.gasPriceDistribution.BlockTime
+	g.gasPriceDistribution = distribution
+}
+
+func gasPriceDistribution(window []distributionBlock) GasPriceDistribution {
+	var samples []*big.Int
+	for _, b := range window {
+		samples = append(samples, b.prices...)
+	}
+
+	distribution := GasPriceDistribution{
+		NumBlocks:  len(window),
+		NumSamples: len(samples),
+	}
+	if len(samples) == 0 {
+		return distribution
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Cmp(samples[j]) < 0
+	})
+
+	percentile := func(p float64) *big.Int {
+		return new(big.Int).Set(samples[int(float64(len(samples)-1)*p)])
+	}
+	distribution.P10Wei = percentile(0.1)
+	distribution.P50Wei = percentile(0.5)
+	distribution.P90Wei = percentile(0.9)
+	distribution.P99Wei = percentile(0.99)
+	distribution.MinWei = new(big.Int).Set(samples[0])
+	distribution.MaxWei = new(big.Int).Set(samples[len(samples)-1])
+
+	return distribution
+}
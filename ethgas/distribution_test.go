@@ -0,0 +1,98 @@
+package ethgas
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasPriceDistribution(t *testing.T) {
+	g := &GasGauge{options: Options{DistributionNumBlocks: 2}}
+
+	prices := func(from, to int64) []*big.Int {
+		var list []*big.Int
+		for i := from; i <= to; i++ {
+			list = append(list, big.NewInt(i*int64(ONE_GWEI)))
+		}
+		return list
+	}
+	block := func(num int64) *ethmonitor.Block {
+		return &ethmonitor.Block{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(num), Time: uint64(num)})}
+	}
+
+	d := g.GasPriceDistribution()
+	require.Zero(t, d.NumSamples)
+	require.Nil(t, d.P50Wei)
+
+	g.updateDistribution(block(1), prices(1000, 1100))
+	g.updateDistribution(block(2), prices(1, 100))
+	d = g.GasPriceDistribution()
+	require.Equal(t, 2, d.NumBlocks)
+	require.Equal(t, 201, d.NumSamples)
+	require.Equal(t, uint64(1)*ONE_GWEI, d.MinWei.Uint64())
+	require.Equal(t, uint64(1100)*ONE_GWEI, d.MaxWei.Uint64())
+	require.Equal(t, uint64(1000)*ONE_GWEI, d.P50Wei.Uint64())
+	require.Equal(t, big.NewInt(2), d.BlockNum)
+
+	// the oldest block falls out of the window
+	g.updateDistribution(block(3), prices(1, 100))
+	d = g.GasPriceDistribution()
+	require.Equal(t, 2, d.NumBlocks)
+	require.Equal(t, 200, d.NumSamples)
+	require.Equal(t, uint64(100)*ONE_GWEI, d.MaxWei.Uint64())
+	require.Equal(t, uint64(10)*ONE_GWEI, d.P10Wei.Uint64())
+	require.Equal(t, uint64(90)*ONE_GWEI, d.P90Wei.Uint64())
+	require.Equal(t, uint64(99)*ONE_GWEI, d.P99Wei.Uint64())
+}
+
+func TestGasPriceDistributionReorg(t *testing.T) {
+	g := &GasGauge{options: Options{DistributionNumBlocks: 10}}
+
+	block := func(num int64, extra string) *ethmonitor.Block {
+		hash := common.BytesToHash([]byte(fmt.Sprintf("%d%s", num, extra)))
+		return &ethmonitor.Block{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(num), BlockHash: hash}), Event: ethmonitor.Added}
+	}
+	price := func(gwei int64) []*big.Int {
+		return []*big.Int{big.NewInt(gwei * int64(ONE_GWEI))}
+	}
+
+	block1, block2, fork2 := block(1, ""), block(2, ""), block(2, "fork")
+	g.updateDistribution(block1, price(1))
+	g.updateDistribution(block2, price(100))
+
+	// block 2 is reorged, so only the prices of its replacement are counted
+	g.removeFromDistribution(ethmonitor.Blocks{{Block: block2.Block, Event: ethmonitor.Removed}, fork2})
+	g.updateDistribution(fork2, price(2))
+
+	d := g.GasPriceDistribution()
+	require.Equal(t, 2, d.NumBlocks)
+	require.Equal(t, 2, d.NumSamples)
+	require.Equal(t, uint64(2)*ONE_GWEI, d.MaxWei.Uint64())
+}
+
+func TestSubscribeUpdates(t *testing.T) {
+	g := &GasGauge{options: Options{DistributionNumBlocks: 10}, minGasPrice: big.NewInt(1)}
+	block := &ethmonitor.Block{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})}
+
+	sub := g.SubscribeUpdates()
+	g.updateDistribution(block, []*big.Int{big.NewInt(5)})
+	g.publishUpdate()
+	g.updateDistribution(block, []*big.Int{big.NewInt(7)})
+	g.publishUpdate()
+
+	// only the latest update is kept for a subscriber which hasn't read it
+	update := <-sub.Updates()
+	require.Equal(t, 2, update.GasPriceDistribution.NumSamples)
+	require.Equal(t, big.NewInt(1), update.GasPriceDistribution.BlockNum)
+	require.Equal(t, big.NewInt(1), update.SuggestedGasPrice.InstantWei)
+
+	sub.Unsubscribe()
+	<-sub.Done()
+	_, ok := <-sub.Updates()
+	require.False(t, ok)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"sync"
 	"sync/atomic"
 
 	"github.com/0xsequence/ethkit/ethmonitor"
@@ -22,7 +23,33 @@ var (
 	BUCKET_RANGE               = big.NewInt(int64(5 * ONE_GWEI))
 )
 
+var DefaultOptions = Options{
+	PriorityFeePercentiles: PriorityFeePercentiles{
+		Instant:  90,
+		Fast:     75,
+		Standard: 50,
+		Slow:     25,
+	},
+	FeeHistoryNumBlocks:   10,
+	DistributionNumBlocks: 50,
+}
+
+type Options struct {
+	// PriorityFeePercentiles are the eth_feeHistory reward percentiles used to compute
+	// each tier of the suggested priority fees on EIP-1559 chains.
+	PriorityFeePercentiles PriorityFeePercentiles
+
+	// FeeHistoryNumBlocks is the number of recent blocks to sample the priority fee
+	// rewards from via eth_feeHistory.
+	FeeHistoryNumBlocks uint64
+
+	// DistributionNumBlocks is the number of recent blocks the GasPriceDistribution
+	// is computed over.
+	DistributionNumBlocks uint64
+}
+
 type GasGauge struct {
+	options               Options
 	log                   logger.Logger
 	monitor               *ethmonitor.Monitor
 	chainID               uint64
@@ -33,6 +60,19 @@ type GasGauge struct {
 	useEIP1559            bool // TODO: currently not in use, but once we think about block utilization, then will be useful
 	minGasPrice           *big.Int
 
+	// EIP-1559 fee suggestions, which are only set on chains with a block base fee
+	suggestedPriorityFee SuggestedPriorityFee
+	baseFee              *big.Int
+	feesMu               sync.RWMutex
+
+	// distribution of the paid gas prices over the recent blocks
+	gasPriceDistribution GasPriceDistribution
+	distributionWindow   []distributionBlock
+	distributionMu       sync.RWMutex
+
+	updateSubscribers   []*updateSubscriber
+	updateSubscribersMu sync.Mutex
+
 	ctx     context.Context
 	ctxStop context.CancelFunc
 	running int32
@@ -79,7 +119,18 @@ func (p SuggestedGasPrice) WithMin(minWei *big.Int) SuggestedGasPrice {
 	return p
 }
 
-func NewGasGaugeWei(log logger.Logger, monitor *ethmonitor.Monitor, minGasPriceInWei uint64, useEIP1559 bool) (*GasGauge, error) {
+func NewGasGaugeWei(log logger.Logger, monitor *ethmonitor.Monitor, minGasPriceInWei uint64, useEIP1559 bool, options ...Options) (*GasGauge, error) {
+	opts := DefaultOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.FeeHistoryNumBlocks == 0 {
+		opts.FeeHistoryNumBlocks = DefaultOptions.FeeHistoryNumBlocks
+	}
+	if opts.DistributionNumBlocks == 0 {
+		opts.DistributionNumBlocks = DefaultOptions.DistributionNumBlocks
+	}
+
 	if minGasPriceInWei == 0 {
 		return nil, fmt.Errorf("minGasPriceInWei cannot be 0, pass at least 1")
 	}
@@ -96,6 +147,7 @@ func NewGasGaugeWei(log logger.Logger, monitor *ethmonitor.Monitor, minGasPriceI
 		gasPricePaidReader = DefaultGasPricePaidReader
 	}
 	return &GasGauge{
+		options:            opts,
 		log:                log,
 		monitor:            monitor,
 		chainID:            chainID.Uint64(),
@@ -106,14 +158,14 @@ func NewGasGaugeWei(log logger.Logger, monitor *ethmonitor.Monitor, minGasPriceI
 	}, nil
 }
 
-func NewGasGauge(log logger.Logger, monitor *ethmonitor.Monitor, minGasPriceInGwei uint64, useEIP1559 bool) (*GasGauge, error) {
+func NewGasGauge(log logger.Logger, monitor *ethmonitor.Monitor, minGasPriceInGwei uint64, useEIP1559 bool, options ...Options) (*GasGauge, error) {
 	if minGasPriceInGwei >= ONE_GWEI {
 		return nil, fmt.Errorf("minGasPriceInGwei argument expected to be passed as Gwei, but your units look like wei")
 	}
 	if minGasPriceInGwei == 0 {
 		return nil, fmt.Errorf("minGasPriceInGwei cannot be 0, pass at least 1")
 	}
-	gasGauge, err := NewGasGaugeWei(log, monitor, minGasPriceInGwei*ONE_GWEI, useEIP1559)
+	gasGauge, err := NewGasGaugeWei(log, monitor, minGasPriceInGwei*ONE_GWEI, useEIP1559, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -154,6 +206,9 @@ func (g *GasGauge) SuggestedPaidGasPrice() SuggestedGasPrice {
 	return g.suggestedPaidGasPrice.WithMin(g.minGasPrice)
 }
 
+// Subscribe to the blocks of the gauge's monitor, as the SuggestedGasPrice and
+// GasPriceDistribution of the gauge are updated on every new block. See SubscribeUpdates
+// to receive them once updated instead.
 func (g *GasGauge) Subscribe() ethmonitor.Subscription {
 	return g.monitor.Subscribe("ethgas")
 }
@@ -178,6 +233,11 @@ func (g *GasGauge) run() error {
 
 		// received new mined block from ethmonitor
 		case blocks := <-sub.Blocks():
+			// the prices of reorged blocks are replaced by the prices of the new blocks
+			g.removeFromDistribution(blocks)
+
+			// batches without an Added block are skipped, ie. of Updated blocks, whose
+			// re-fetched logs don't change the gas prices of the block
 			latestBlock := blocks.LatestBlock()
 			if latestBlock == nil {
 				continue
@@ -211,6 +271,8 @@ func (g *GasGauge) run() error {
 				return paidGasPrices[i].Cmp(paidGasPrices[j]) < 0
 			})
 
+			g.updateDistribution(latestBlock, paidGasPrices)
+
 			updatedGasPriceBid := bidEMA.update(gasPriceBids, g.minGasPrice)
 			updatedPaidGasPrice := paidEMA.update(paidGasPrices, g.minGasPrice)
 			if updatedGasPriceBid != nil || updatedPaidGasPrice != nil {
@@ -225,6 +287,16 @@ func (g *GasGauge) run() error {
 					g.suggestedPaidGasPrice = *updatedPaidGasPrice
 				}
 			}
+
+			// update priority fee suggestions on EIP-1559 chains
+			if latestBlock.BaseFee() != nil {
+				err := g.updatePriorityFees(latestBlock)
+				if err != nil {
+					g.log.Warnf("ethgas: failed to update priority fees: %v", err)
+				}
+			}
+
+			g.publishUpdate()
 		}
 	}
 }
@@ -0,0 +1,172 @@
+package ethgas
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+)
+
+// PriorityFeePercentiles are the reward percentiles, in the range of 0 to 100,
+// which are used to compute each tier of the suggested priority fees.
+type PriorityFeePercentiles struct {
+	Instant  float64 `json:"instant"`
+	Fast     float64 `json:"fast"`
+	Standard float64 `json:"standard"`
+	Slow     float64 `json:"slow"`
+}
+
+// SuggestedPriorityFee are the tiers of suggested EIP-1559 max priority fee per gas.
+type SuggestedPriorityFee struct {
+	InstantWei  *big.Int `json:"instantWei"`
+	FastWei     *big.Int `json:"fastWei"`
+	StandardWei *big.Int `json:"standardWei"`
+	SlowWei     *big.Int `json:"slowWei"`
+
+	BlockNum  *big.Int `json:"blockNum"`
+	BlockTime uint64   `json:"blockTime"`
+}
+
+// GasFee is a pair of EIP-1559 max fee and max priority fee values. On legacy
+// chains both values are set to the suggested gas price.
+type GasFee struct {
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+}
+
+// SuggestedGasFees are the tiers of suggested EIP-1559 gas fees, combining the
+// base fee of the latest block with the suggested priority fees.
+type SuggestedGasFees struct {
+	Instant  GasFee `json:"instant"`
+	Fast     GasFee `json:"fast"`
+	Standard GasFee `json:"standard"`
+	Slow     GasFee `json:"slow"`
+
+	BaseFeeWei *big.Int `json:"baseFeeWei"`
+	EIP1559    bool     `json:"eip1559"`
+
+	BlockNum  *big.Int `json:"blockNum"`
+	BlockTime uint64   `json:"blockTime"`
+}
+
+// IsEIP1559 returns true if the gauge has seen a block with a base fee,
+// in which case EIP-1559 fee suggestions are available.
+func (g *GasGauge) IsEIP1559() bool {
+	g.feesMu.RLock()
+	defer g.feesMu.RUnlock()
+	return g.baseFee != nil
+}
+
+// SuggestedPriorityFee returns the tiers of suggested max priority fee per gas, computed
+// from the eth_feeHistory reward percentiles of recent blocks. On legacy chains, the
+// suggested gas price tiers are returned instead.
+func (g *GasGauge) SuggestedPriorityFee() SuggestedPriorityFee {
+	g.feesMu.RLock()
+	defer g.feesMu.RUnlock()
+
+	if g.baseFee == nil {
+		gasPrice := g.SuggestedGasPrice()
+		return SuggestedPriorityFee{
+			InstantWei:  gasPrice.InstantWei,
+			FastWei:     gasPrice.FastWei,
+			StandardWei: gasPrice.StandardWei,
+			SlowWei:     gasPrice.SlowWei,
+			BlockNum:    gasPrice.BlockNum,
+			BlockTime:   gasPrice.BlockTime,
+		}
+	}
+	return g.suggestedPriorityFee
+}
+
+// SuggestedGasFees returns the tiers of suggested max fee and max priority fee per gas.
+// The max fee per gas allows for the base fee to double, as in 2 * baseFee + priorityFee.
+// On legacy chains, both values are set to the suggested gas price tiers.
+func (g *GasGauge) SuggestedGasFees() SuggestedGasFees {
+	g.feesMu.RLock()
+	baseFee := g.baseFee
+	priorityFee := g.suggestedPriorityFee
+	g.feesMu.RUnlock()
+
+	if baseFee == nil {
+		gasPrice := g.SuggestedGasPrice()
+		legacyFee := func(price *big.Int) GasFee {
+			return GasFee{MaxFeePerGas: price, MaxPriorityFeePerGas: price}
+		}
+		return SuggestedGasFees{
+			Instant:   legacyFee(gasPrice.InstantWei),
+			Fast:      legacyFee(gasPrice.FastWei),
+			Standard:  legacyFee(gasPrice.StandardWei),
+			Slow:      legacyFee(gasPrice.SlowWei),
+			BlockNum:  gasPrice.BlockNum,
+			BlockTime: gasPrice.BlockTime,
+		}
+	}
+
+	maxFee := func(tip *big.Int) GasFee {
+		fee := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+		return GasFee{MaxFeePerGas: fee, MaxPriorityFeePerGas: new(big.Int).Set(tip)}
+	}
+	return SuggestedGasFees{
+		Instant:    maxFee(priorityFee.InstantWei),
+		Fast:       maxFee(priorityFee.FastWei),
+		Standard:   maxFee(priorityFee.StandardWei),
+		Slow:       maxFee(priorityFee.SlowWei),
+		BaseFeeWei: new(big.Int).Set(baseFee),
+		EIP1559:    true,
+		BlockNum:   priorityFee.BlockNum,
+		BlockTime:  priorityFee.BlockTime,
+	}
+}
+
+func (g *GasGauge) updatePriorityFees(block *ethmonitor.Block) error {
+	p := g.options.PriorityFeePercentiles
+	percentiles := []float64{p.Slow, p.Standard, p.Fast, p.Instant}
+
+	ctx, cancel := context.WithTimeout(g.ctx, 5*time.Second)
+	defer cancel()
+
+	feeHistory, err := g.monitor.Provider().FeeHistory(ctx, g.options.FeeHistoryNumBlocks, block.Number(), percentiles)
+	if err != nil {
+		return err
+	}
+	if len(feeHistory.Reward) == 0 {
+		return fmt.Errorf("fee history returned no rewards")
+	}
+
+	// average the rewards of each percentile across the sampled blocks
+	tiers := make([]*big.Int, len(percentiles))
+	for i := range percentiles {
+		sum, n := new(big.Int), int64(0)
+		for _, rewards := range feeHistory.Reward {
+			if i < len(rewards) && rewards[i] != nil {
+				sum.Add(sum, rewards[i])
+				n++
+			}
+		}
+		if n > 0 {
+			sum.Div(sum, big.NewInt(n))
+		}
+		tiers[i] = sum
+	}
+
+	// ensure tiers are non-decreasing
+	for i := 1; i < len(tiers); i++ {
+		tiers[i] = bigIntMax(tiers[i-1], tiers[i])
+	}
+
+	g.feesMu.Lock()
+	defer g.feesMu.Unlock()
+
+	g.baseFee = new(big.Int).Set(block.BaseFee())
+	g.suggestedPriorityFee = SuggestedPriorityFee{
+		SlowWei:     tiers[0],
+		StandardWei: tiers[1],
+		FastWei:     tiers[2],
+		InstantWei:  tiers[3],
+		BlockNum:    block.Number(),
+		BlockTime:   block.Time(),
+	}
+	return nil
+}
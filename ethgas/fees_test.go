@@ -0,0 +1,46 @@
+package ethgas
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestedGasFees(t *testing.T) {
+	g := &GasGauge{
+		minGasPrice: big.NewInt(1),
+		suggestedPaidGasPrice: SuggestedGasPrice{
+			InstantWei:  big.NewInt(40),
+			FastWei:     big.NewInt(30),
+			StandardWei: big.NewInt(20),
+			SlowWei:     big.NewInt(10),
+		},
+	}
+
+	// legacy chain, fees fall back to the gas price
+	require.False(t, g.IsEIP1559())
+	fees := g.SuggestedGasFees()
+	require.False(t, fees.EIP1559)
+	require.Equal(t, int64(40), fees.Instant.MaxFeePerGas.Int64())
+	require.Equal(t, int64(40), fees.Instant.MaxPriorityFeePerGas.Int64())
+	require.Equal(t, int64(10), g.SuggestedPriorityFee().SlowWei.Int64())
+
+	// EIP-1559 chain
+	g.baseFee = big.NewInt(100)
+	g.suggestedPriorityFee = SuggestedPriorityFee{
+		InstantWei:  big.NewInt(4),
+		FastWei:     big.NewInt(3),
+		StandardWei: big.NewInt(2),
+		SlowWei:     big.NewInt(1),
+	}
+
+	require.True(t, g.IsEIP1559())
+	fees = g.SuggestedGasFees()
+	require.True(t, fees.EIP1559)
+	require.Equal(t, int64(100), fees.BaseFeeWei.Int64())
+	require.Equal(t, int64(204), fees.Instant.MaxFeePerGas.Int64())
+	require.Equal(t, int64(4), fees.Instant.MaxPriorityFeePerGas.Int64())
+	require.Equal(t, int64(201), fees.Slow.MaxFeePerGas.Int64())
+	require.Equal(t, int64(1), g.SuggestedPriorityFee().SlowWei.Int64())
+}
@@ -0,0 +1,83 @@
+package ethgas
+
+// GasGaugeUpdate are the suggestions and distribution of the gauge, as updated once the
+// gauge has processed a new block, see SubscribeUpdates.
+type GasGaugeUpdate struct {
+	SuggestedGasPrice    SuggestedGasPrice    `json:"suggestedGasPrice"`
+	SuggestedGasPriceBid SuggestedGasPrice    `json:"suggestedGasPriceBid"`
+	GasPriceDistribution GasPriceDistribution `json:"gasPriceDistribution"`
+}
+
+type UpdateSubscription interface {
+	Updates() <-chan GasGaugeUpdate
+	Done() <-chan struct{}
+	Unsubscribe()
+}
+
+type updateSubscriber struct {
+	ch          chan GasGaugeUpdate
+	done        chan struct{}
+	unsubscribe func()
+}
+
+func (s *updateSubscriber) Updates() <-chan GasGaugeUpdate {
+	return s.ch
+}
+
+func (s *updateSubscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *updateSubscriber) Unsubscribe() {
+	s.unsubscribe()
+}
+
+// SubscribeUpdates subscribes to the updates of the gauge, which are published once the
+// gauge has processed a new block, so consumers get the GasPriceDistribution alongside
+// the SuggestedGasPrice. A subscriber which doesn't keep up only receives the latest update.
+func (g *GasGauge) SubscribeUpdates() UpdateSubscription {
+	g.updateSubscribersMu.Lock()
+	defer g.updateSubscribersMu.Unlock()
+
+	subscriber := &updateSubscriber{
+		ch:   make(chan GasGaugeUpdate, 1),
+		done: make(chan struct{}),
+	}
+
+	subscriber.unsubscribe = func() {
+		g.updateSubscribersMu.Lock()
+		defer g.updateSubscribersMu.Unlock()
+		for i, sub := range g.updateSubscribers {
+			if sub == subscriber {
+				g.updateSubscribers = append(g.updateSubscribers[:i], g.updateSubscribers[i+1:]...)
+				close(subscriber.done)
+				close(subscriber.ch)
+				return
+			}
+		}
+	}
+
+	g.updateSubscribers = append(g.updateSubscribers, subscriber)
+
+	return subscriber
+}
+
+func (g *GasGauge) publishUpdate() {
+	update := GasGaugeUpdate{
+		SuggestedGasPrice:    g.SuggestedGasPrice(),
+		SuggestedGasPriceBid: g.SuggestedGasPriceBid(),
+		GasPriceDistribution: g.GasPriceDistribution(),
+	}
+
+	g.updateSubscribersMu.Lock()
+	defer g.updateSubscribersMu.Unlock()
+
+	for _, sub := range g.updateSubscribers {
+		// replace the update the subscriber hasn't read yet
+		select {
+		case <-sub.ch:
+		default:
+		}
+		sub.ch <- update
+	}
+}
@@ -7,14 +7,26 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/0xsequence/ethkit/go-ethereum/rpc"
 	"github.com/goware/logger"
 )
 
 type Options struct {
 	Logger logger.Logger
+
+	// FullPendingTransactions subscribes to newPendingTransactions with full
+	// transaction objects, for nodes which support it. Otherwise, the transactions
+	// for SubscribeTransactions are fetched by hash via eth_getTransactionByHash.
+	FullPendingTransactions bool
+
+	// MaxConcurrentFetches is the maximum number of concurrent eth_getTransactionByHash
+	// requests made for SubscribeTransactions. Defaults to 10.
+	MaxConcurrentFetches int
 }
 
+const defaultMaxConcurrentFetches = 10
+
 type Mempool struct {
 	options Options
 
@@ -22,6 +34,7 @@ type Mempool struct {
 	nodeWebsocketURL string
 	client           *rpc.Client
 	subscribers      []*subscriber
+	txnSubscribers   []*txnSubscriber
 
 	ctx     context.Context
 	ctxStop context.CancelFunc
@@ -34,11 +47,16 @@ func NewMempool(nodeWebsocketURL string, opts ...Options) (*Mempool, error) {
 	if len(opts) > 0 {
 		options = opts[0]
 	}
+	if options.MaxConcurrentFetches <= 0 {
+		options.MaxConcurrentFetches = defaultMaxConcurrentFetches
+	}
 
 	return &Mempool{
 		options:          options,
+		log:              options.Logger,
 		nodeWebsocketURL: nodeWebsocketURL,
 		subscribers:      make([]*subscriber, 0),
+		txnSubscribers:   make([]*txnSubscriber, 0),
 	}, nil
 }
 
@@ -114,7 +132,42 @@ func (m *Mempool) subscribe(notifyFilterFunc NotifyFilterFunc) Subscription {
 	return subscriber
 }
 
+// SubscribeTransactions returns a subscription to the decoded pending transactions.
+//
+// Transactions which are dropped or replaced before they could be fetched are skipped,
+// as well as transactions which the subscriber is unable to keep up with.
+func (m *Mempool) SubscribeTransactions() TransactionSubscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subscriber := &txnSubscriber{
+		ch:   make(chan *types.Transaction, 1024),
+		done: make(chan struct{}),
+	}
+
+	subscriber.unsubscribe = func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for i, sub := range m.txnSubscribers {
+			if sub == subscriber {
+				m.txnSubscribers = append(m.txnSubscribers[:i], m.txnSubscribers[i+1:]...)
+				close(subscriber.done)
+				close(subscriber.ch)
+				return
+			}
+		}
+	}
+
+	m.txnSubscribers = append(m.txnSubscribers, subscriber)
+
+	return subscriber
+}
+
 func (m *Mempool) stream() error {
+	if m.options.FullPendingTransactions {
+		return m.streamTransactions()
+	}
+
 	ch := make(chan string) // txn hash strings
 
 	sub, err := m.client.EthSubscribe(m.ctx, ch, "newPendingTransactions")
@@ -123,23 +176,120 @@ func (m *Mempool) stream() error {
 	}
 	defer sub.Unsubscribe()
 
+	// fetch the transactions of pending txn hashes for txn subscribers, dropping
+	// hashes when the fetchers are unable to keep up with the stream. The fetchers
+	// are stopped once the stream ends.
+	fetchCtx, fetchCancel := context.WithCancel(m.ctx)
+	fetchCh := make(chan string, 1024)
+	var wg sync.WaitGroup
+	defer func() {
+		fetchCancel()
+		wg.Wait()
+	}()
+	for i := 0; i < m.options.MaxConcurrentFetches; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.fetchTransactions(fetchCtx, fetchCh)
+		}()
+	}
+
 	for {
 		select {
 
 		case <-m.ctx.Done():
 			return nil
 
-		case <-sub.Err():
+		case err := <-sub.Err():
 			return fmt.Errorf("ethmempool: websocket error %w", err)
 
 		case pendingTxnHash := <-ch:
 			// notify all subscribers..
 			pendingTxnHash = strings.ToLower(pendingTxnHash)
-			for _, sub := range m.subscribers {
-				if sub.notifyFilterFunc == nil || sub.notifyFilterFunc(pendingTxnHash) {
-					sub.ch <- pendingTxnHash
+			m.notifyTxnHash(pendingTxnHash)
+
+			if m.hasTxnSubscribers() {
+				select {
+				case fetchCh <- pendingTxnHash:
+				default:
 				}
 			}
 		}
 	}
 }
+
+func (m *Mempool) streamTransactions() error {
+	ch := make(chan *types.Transaction)
+
+	sub, err := m.client.EthSubscribe(m.ctx, ch, "newPendingTransactions", true)
+	if err != nil {
+		return fmt.Errorf("ethmempool: stream failed to subscribe %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+
+		case <-m.ctx.Done():
+			return nil
+
+		case err := <-sub.Err():
+			return fmt.Errorf("ethmempool: websocket error %w", err)
+
+		case txn := <-ch:
+			m.notifyTxnHash(strings.ToLower(txn.Hash().Hex()))
+			m.notifyTxn(txn)
+		}
+	}
+}
+
+func (m *Mempool) fetchTransactions(ctx context.Context, fetchCh <-chan string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case pendingTxnHash := <-fetchCh:
+			var txn *types.Transaction
+			err := m.client.CallContext(ctx, &txn, "eth_getTransactionByHash", pendingTxnHash)
+			if err != nil {
+				if m.log != nil {
+					m.log.Warnf("ethmempool: failed to fetch pending txn %s: %v", pendingTxnHash, err)
+				}
+				continue
+			}
+			if txn == nil {
+				// txn was dropped or replaced before we could fetch it
+				continue
+			}
+			m.notifyTxn(txn)
+		}
+	}
+}
+
+func (m *Mempool) notifyTxnHash(pendingTxnHash string) {
+	for _, sub := range m.subscribers {
+		if sub.notifyFilterFunc == nil || sub.notifyFilterFunc(pendingTxnHash) {
+			sub.ch <- pendingTxnHash
+		}
+	}
+}
+
+func (m *Mempool) notifyTxn(txn *types.Transaction) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, sub := range m.txnSubscribers {
+		select {
+		case sub.ch <- txn:
+		default:
+			// subscriber is not keeping up, skip
+		}
+	}
+}
+
+func (m *Mempool) hasTxnSubscribers() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.txnSubscribers) > 0
+}
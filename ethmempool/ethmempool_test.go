@@ -0,0 +1,189 @@
+package ethmempool
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// mockMempoolNode serves a newPendingTransactions subscription of the txns over
+// a websocket, and the txns by hash for eth_getTransactionByHash. The connection
+// is dropped once the drop channel is closed.
+func mockMempoolNode(t *testing.T, txns []*types.Transaction, drop <-chan struct{}) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reqs := make(chan map[string]json.RawMessage)
+		go func() {
+			defer close(reqs)
+			for {
+				var req map[string]json.RawMessage
+				if err := conn.ReadJSON(&req); err != nil {
+					return
+				}
+				reqs <- req
+			}
+		}()
+
+		for {
+			select {
+			case <-drop:
+				return
+			case req, ok := <-reqs:
+				if !ok {
+					return
+				}
+				var method string
+				json.Unmarshal(req["method"], &method)
+
+				switch method {
+				case "eth_subscribe":
+					conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req["id"], "result": "0x1"})
+					for _, txn := range txns {
+						conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "method": "eth_subscription", "params": map[string]any{"subscription": "0x1", "result": txn.Hash()}})
+					}
+				case "eth_getTransactionByHash":
+					var params []common.Hash
+					json.Unmarshal(req["params"], &params)
+					var result *types.Transaction
+					for _, txn := range txns {
+						if txn.Hash() == params[0] {
+							result = txn
+						}
+					}
+					conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req["id"], "result": result})
+				default:
+					conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req["id"], "result": true})
+				}
+			}
+		}
+	}))
+}
+
+func mockTxns(t *testing.T, n int) []*types.Transaction {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	txns := make([]*types.Transaction, 0, n)
+	for i := 0; i < n; i++ {
+		txn, err := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(i),
+			GasPrice: big.NewInt(1),
+			Gas:      21000,
+			To:       &common.Address{},
+			Value:    big.NewInt(1),
+		}), types.HomesteadSigner{}, key)
+		require.NoError(t, err)
+		txns = append(txns, txn)
+	}
+	return txns
+}
+
+func wsURL(node *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(node.URL, "http")
+}
+
+func TestSubscribeTransactions(t *testing.T) {
+	txns := mockTxns(t, 3)
+	node := mockMempoolNode(t, txns, nil)
+	defer node.Close()
+
+	mempool, err := NewMempool(wsURL(node), Options{MaxConcurrentFetches: 1})
+	require.NoError(t, err)
+
+	hashSub := mempool.Subscribe()
+	txnSub := mempool.SubscribeTransactions()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- mempool.Run(context.Background())
+	}()
+
+	for _, txn := range txns {
+		select {
+		case hash := <-hashSub.PendingTransactionHash():
+			require.Equal(t, strings.ToLower(txn.Hash().Hex()), hash)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for pending txn hash")
+		}
+		select {
+		case pendingTxn := <-txnSub.PendingTransactions():
+			require.Equal(t, txn.Hash(), pendingTxn.Hash())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for pending txn")
+		}
+	}
+
+	hashSub.Unsubscribe()
+	txnSub.Unsubscribe()
+	<-hashSub.Done()
+	<-txnSub.Done()
+
+	_, ok := <-txnSub.PendingTransactions()
+	require.False(t, ok)
+
+	mempool.Stop()
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for mempool to stop")
+	}
+}
+
+func TestStreamStopsFetchers(t *testing.T) {
+	drop := make(chan struct{})
+	node := mockMempoolNode(t, mockTxns(t, 1), drop)
+	defer node.Close()
+
+	numGoroutines := runtime.NumGoroutine()
+
+	mempool, err := NewMempool(wsURL(node), Options{MaxConcurrentFetches: 20})
+	require.NoError(t, err)
+
+	sub := mempool.SubscribeTransactions()
+	defer sub.Unsubscribe()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- mempool.Run(context.Background())
+	}()
+
+	select {
+	case <-sub.PendingTransactions():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for pending txn")
+	}
+
+	// the stream ends once the websocket drops, along with its fetchers
+	close(drop)
+	select {
+	case err := <-runErr:
+		require.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for stream to end")
+	}
+
+	require.Eventually(t, func() bool {
+		return runtime.NumGoroutine() < numGoroutines+mempool.Options().MaxConcurrentFetches
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mempool.Stop()
+}
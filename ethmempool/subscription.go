@@ -1,5 +1,7 @@
 package ethmempool
 
+import "github.com/0xsequence/ethkit/go-ethereum/core/types"
+
 type Subscription interface {
 	PendingTransactionHash() <-chan string
 	Done() <-chan struct{}
@@ -26,3 +28,27 @@ func (s *subscriber) Unsubscribe() {
 }
 
 type NotifyFilterFunc func(pendingTxnHash string) bool
+
+type TransactionSubscription interface {
+	PendingTransactions() <-chan *types.Transaction
+	Done() <-chan struct{}
+	Unsubscribe()
+}
+
+type txnSubscriber struct {
+	ch          chan *types.Transaction
+	done        chan struct{}
+	unsubscribe func()
+}
+
+func (s *txnSubscriber) PendingTransactions() <-chan *types.Transaction {
+	return s.ch
+}
+
+func (s *txnSubscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *txnSubscriber) Unsubscribe() {
+	s.unsubscribe()
+}
@@ -32,13 +32,26 @@ func (c *Chain) bootstrapBlocks(blocks Blocks) error {
 	if c.blocks != nil {
 		return fmt.Errorf("ethmonitor: chain has already been bootstrapped")
 	}
+	return c.replayBlocks(blocks)
+}
+
+// restoreBlocks rebuilds the canonical chain from block events loaded from a ChainStore.
+// Unlike bootstrapping, restoring is allowed as long as the chain is still empty.
+func (c *Chain) restoreBlocks(blocks Blocks) error {
+	if c.Head() != nil {
+		return fmt.Errorf("ethmonitor: chain has already been initialized")
+	}
+	return c.replayBlocks(blocks)
+}
 
+// replayBlocks builds the canonical chain by replaying the block events in order.
+func (c *Chain) replayBlocks(blocks Blocks) error {
 	if len(blocks) == 0 {
 		c.blocks = make(Blocks, 0, c.retentionLimit)
 		return nil
 	}
 
-	if len(blocks) == 1 {
+	if len(blocks) == 1 && blocks[0].Event == Added {
 		c.blocks = blocks.Copy()
 		return nil
 	}
@@ -49,13 +62,16 @@ func (c *Chain) bootstrapBlocks(blocks Blocks) error {
 		blocks = blocks[len(blocks)-c.retentionLimit-1:]
 	}
 
+	// only Added blocks make up the canonical chain, so a trailing Removed event is never
+	// restored as the head
 	for _, b := range blocks {
-		if b.Event == Added {
+		switch b.Event {
+		case Added:
 			err := c.push(b)
 			if err != nil {
 				return fmt.Errorf("ethmonitor: bootstrap failed to build canonical chain: %w", err)
 			}
-		} else {
+		case Removed:
 			c.pop()
 		}
 	}
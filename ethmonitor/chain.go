@@ -155,6 +155,26 @@ func (c *Chain) GetBlock(hash common.Hash) *Block {
 	return block
 }
 
+// retainedBlock returns the retained block of the hash and its index, or nil and -1 if
+// it's not retained. Must be called with c.mu held.
+func (c *Chain) retainedBlock(hash common.Hash) (*Block, int) {
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if c.blocks[i].Hash() == hash {
+			return c.blocks[i], i
+		}
+	}
+	return nil, -1
+}
+
+// IsCanonical reports whether the block of the hash is in the retained canonical chain.
+// Blocks which were reorged out are popped from the chain, so they're not canonical.
+func (c *Chain) IsCanonical(hash common.Hash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.blocks.FindBlock(hash, Added)
+	return ok
+}
+
 func (c *Chain) GetBlockByNumber(blockNum uint64, event Event) *Block {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -170,6 +190,8 @@ func (c *Chain) GetBlockByNumber(blockNum uint64, event Event) *Block {
 // and returns the transaction. Aka, searches our chain for mined transactions. Keep in mind
 // transactions can still be reorged, but you can check the blockNumber and compare it against
 // the head to determine if its final.
+//
+// NOTE: with block sampling, transactions of sampled blocks are not found.
 func (c *Chain) GetTransaction(txnHash common.Hash) (*types.Transaction, Event) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -186,6 +208,34 @@ func (c *Chain) GetTransaction(txnHash common.Hash) (*types.Transaction, Event)
 	return nil, 0
 }
 
+// sampleBlocks drops the transactions, logs and payloads of the retained blocks which
+// are more than keepRecent blocks behind the head, except for every nth block, keeping
+// only their headers for reorg detection. Blocks which are not yet OK are kept, so their
+// logs can still be backfilled.
+//
+// Sampled blocks are replaced rather than modified, as the same block objects may still
+// be queued for publishing to subscribers.
+func (c *Chain) sampleBlocks(n int, keepRecent int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n <= 1 {
+		return
+	}
+	for i := len(c.blocks) - 1 - keepRecent; i >= 0; i-- {
+		b := c.blocks[i]
+		if b.Sampled || !b.OK || b.NumberU64()%uint64(n) == 0 {
+			continue
+		}
+		c.blocks[i] = &Block{
+			Block:   types.NewBlockWithHeader(b.Header()),
+			Event:   b.Event,
+			OK:      b.OK,
+			Sampled: true,
+		}
+	}
+}
+
 func (c *Chain) PrintAllBlocks() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -206,12 +256,17 @@ type Event uint32
 const (
 	Added Event = iota
 	Removed
+
+	// Updated is published for a block which was already published as Added, once its
+	// logs have been re-fetched with Monitor.RefetchBlockLogs. It does not change the
+	// canonical chain, so consumers tracking Added and Removed events may skip it.
+	Updated
 )
 
 type Block struct {
 	*types.Block
 
-	// Event type where Block is Added or Removed (ie. reorged)
+	// Event type where Block is Added or Removed (ie. reorged), or Updated
 	Event Event
 
 	// Logs in the block, grouped by transactions:
@@ -226,6 +281,11 @@ type Block struct {
 	// The values are only set if RetainPayloads is set to true on monitor.
 	BlockPayload []byte
 	LogsPayload  []byte
+
+	// Sampled flag which represents the block's transactions, logs and payloads have
+	// been dropped from the retained chain, and only its header is kept, see
+	// the BlockSamplingInterval option of the monitor.
+	Sampled bool
 }
 
 type Blocks []*Block
@@ -271,6 +331,54 @@ func (b Blocks) Reorg() bool {
 	return false
 }
 
+// Reorg describes a chain reorganization within a batch of block events, where the
+// Removed blocks were reverted back to the CommonAncestor block, and the Added
+// blocks have been built on top of it as the new canonical chain.
+type Reorg struct {
+	// CommonAncestorHash and CommonAncestorNum identify the last block which is
+	// shared by both the reverted and the new canonical chain.
+	CommonAncestorHash common.Hash
+	CommonAncestorNum  uint64
+
+	// Removed blocks ordered from newest to oldest, as they were reverted.
+	Removed Blocks
+
+	// Added blocks ordered from oldest to newest, starting from the block
+	// after the common ancestor.
+	Added Blocks
+}
+
+// Reorgs returns each reorg which occurred within the block events, in the order they
+// occurred. A reorg is a run of Removed events followed by the run of Added events
+// which replace them.
+func (b Blocks) Reorgs() []Reorg {
+	var reorgs []Reorg
+
+	for i := 0; i < len(b); {
+		if b[i].Event != Removed {
+			i++
+			continue
+		}
+
+		reorg := Reorg{}
+		for ; i < len(b) && b[i].Event == Removed; i++ {
+			reorg.Removed = append(reorg.Removed, b[i])
+		}
+		for ; i < len(b) && b[i].Event == Added; i++ {
+			reorg.Added = append(reorg.Added, b[i])
+		}
+
+		// the oldest removed block is built on top of the common ancestor
+		oldest := reorg.Removed[len(reorg.Removed)-1]
+		reorg.CommonAncestorHash = oldest.ParentHash()
+		reorg.CommonAncestorNum = oldest.NumberU64() - 1
+
+		reorgs = append(reorgs, reorg)
+	}
+
+	return reorgs
+}
+
 func (blocks Blocks) FindBlock(blockHash common.Hash, optEvent ...Event) (*Block, bool) {
 	for i := len(blocks) - 1; i >= 0; i-- {
 		if blocks[i].Hash() == blockHash {
@@ -321,6 +429,7 @@ func (blocks Blocks) Copy() Blocks {
 			OK:           b.OK,
 			BlockPayload: blockPayload,
 			LogsPayload:  logsPayload,
+			Sampled:      b.Sampled,
 		}
 	}
 
@@ -0,0 +1,176 @@
+package ethmonitor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocksReorgs(t *testing.T) {
+	chain := mockBlockchain(5)
+
+	// fork at block 2, so blocks 3, 4 and 5 are replaced
+	fork := []*Block{}
+	parentHash := chain[1].Hash()
+	for i := 3; i <= 6; i++ {
+		b := mockForkBlock(parentHash, i)
+		fork = append(fork, &Block{Block: b, Event: Added, OK: true})
+		parentHash = b.Hash()
+	}
+
+	events := Blocks{
+		{Block: chain[4], Event: Added, OK: true},
+		{Block: chain[4], Event: Removed, OK: true},
+		{Block: chain[3], Event: Removed, OK: true},
+		{Block: chain[2], Event: Removed, OK: true},
+	}
+	events = append(events, fork...)
+
+	require.Empty(t, Blocks{{Block: chain[0], Event: Added}}.Reorgs())
+
+	reorgs := events.Reorgs()
+	require.Len(t, reorgs, 1)
+
+	reorg := reorgs[0]
+	require.Equal(t, chain[1].Hash(), reorg.CommonAncestorHash)
+	require.Equal(t, uint64(2), reorg.CommonAncestorNum)
+	require.Len(t, reorg.Removed, 3)
+	require.Equal(t, uint64(5), reorg.Removed[0].NumberU64())
+	require.Equal(t, uint64(3), reorg.Removed[2].NumberU64())
+	require.Len(t, reorg.Added, 4)
+	require.Equal(t, reorg.CommonAncestorHash, reorg.Added[0].ParentHash())
+	require.NotEqual(t, chain[2].Hash(), reorg.Added[0].Hash())
+}
+
+func TestChainIsCanonical(t *testing.T) {
+	chain := newChain(10, false)
+
+	var blocks []*types.Block
+	parentHash := common.Hash{}
+	for i := 1; i <= 12; i++ {
+		b := mockForkBlock(parentHash, i)
+		require.NoError(t, chain.push(&Block{Block: b, Event: Added, OK: true}))
+		blocks = append(blocks, b)
+		parentHash = b.Hash()
+	}
+	require.True(t, chain.IsCanonical(blocks[11].Hash()))
+	require.True(t, chain.IsCanonical(blocks[2].Hash()))
+
+	// beyond retention
+	require.False(t, chain.IsCanonical(blocks[1].Hash()))
+
+	// reorged out, and replaced by a fork block of the same number
+	chain.pop()
+	fork := types.NewBlockWithHeader(&types.Header{
+		ParentHash: blocks[10].Hash(),
+		Number:     big.NewInt(12),
+		BlockHash:  common.HexToHash("0x12"),
+	})
+	require.NoError(t, chain.push(&Block{Block: fork, Event: Added, OK: true}))
+	require.False(t, chain.IsCanonical(blocks[11].Hash()))
+	require.True(t, chain.IsCanonical(fork.Hash()))
+
+	require.False(t, chain.IsCanonical(common.Hash{}))
+}
+
+func TestChainSampleBlocks(t *testing.T) {
+	chain := newChain(20, false)
+
+	parentHash := common.Hash{}
+	for i := 1; i <= 10; i++ {
+		txn := types.NewTransaction(uint64(i), common.Address{}, nil, 21000, nil, nil)
+		b := mockForkBlock(parentHash, i).WithBody(types.Body{Transactions: types.Transactions{txn}})
+		require.NoError(t, chain.push(&Block{Block: b, Event: Added, Logs: []types.Log{{}}, OK: i != 2}))
+		parentHash = b.Hash()
+	}
+	published := chain.GetBlock(chain.blocks[0].Hash())
+
+	chain.sampleBlocks(4, 3)
+
+	for _, b := range chain.blocks {
+		num := b.NumberU64()
+		if num <= 7 && num != 2 && num%4 != 0 {
+			require.True(t, b.Sampled, "block %d", num)
+			require.Empty(t, b.Transactions())
+			require.Nil(t, b.Logs)
+		} else {
+			require.False(t, b.Sampled, "block %d", num)
+			require.Len(t, b.Transactions(), 1)
+		}
+	}
+
+	// headers are kept, so the chain can still be extended and reorged
+	require.Equal(t, uint64(1), chain.blocks[0].NumberU64())
+	require.Equal(t, chain.blocks[0].Hash(), chain.blocks[1].ParentHash())
+	require.NoError(t, chain.push(&Block{Block: mockForkBlock(chain.Head().Hash(), 11), Event: Added, OK: true}))
+
+	// blocks already handed out are not modified
+	require.False(t, published.Sampled)
+	require.Len(t, published.Transactions(), 1)
+
+	// transactions of sampled blocks are no longer found
+	txn, _ := chain.GetTransaction(published.Transactions()[0].Hash())
+	require.Nil(t, txn)
+}
+
+func TestBloomMatchesTopics(t *testing.T) {
+	transferTopic := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	approvalTopic := common.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
+
+	receipt := &types.Receipt{Logs: []*types.Log{{Topics: []common.Hash{transferTopic}}}}
+	bloom := types.CreateBloom(types.Receipts{receipt})
+
+	require.True(t, bloomMatchesTopics(bloom, nil))
+	require.True(t, bloomMatchesTopics(bloom, []common.Hash{transferTopic}))
+	require.True(t, bloomMatchesTopics(bloom, []common.Hash{approvalTopic, transferTopic}))
+	require.False(t, bloomMatchesTopics(bloom, []common.Hash{approvalTopic}))
+
+	// nodes which don't populate the bloom always need to fetch logs
+	require.True(t, bloomMatchesTopics(types.Bloom{}, []common.Hash{approvalTopic}))
+}
+
+func TestBloomMatchesAddresses(t *testing.T) {
+	tokenAddress := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	otherAddress := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+
+	receipt := &types.Receipt{Logs: []*types.Log{{Address: tokenAddress}}}
+	bloom := types.CreateBloom(types.Receipts{receipt})
+
+	require.True(t, bloomMatchesAddresses(bloom, nil))
+	require.True(t, bloomMatchesAddresses(bloom, []common.Address{tokenAddress}))
+	require.True(t, bloomMatchesAddresses(bloom, []common.Address{otherAddress, tokenAddress}))
+	require.False(t, bloomMatchesAddresses(bloom, []common.Address{otherAddress}))
+
+	// nodes which don't populate the bloom always need to fetch logs
+	require.True(t, bloomMatchesAddresses(types.Bloom{}, []common.Address{otherAddress}))
+}
+
+func TestLatestSafeBlock(t *testing.T) {
+	m := &Monitor{
+		options: Options{NumBlocksToSafe: 3},
+		chain:   newChain(20, false),
+	}
+	require.Nil(t, m.LatestSafeBlock())
+
+	for _, b := range mockBlockchain(10) {
+		require.NoError(t, m.chain.push(&Block{Block: b, Event: Added, OK: true}))
+	}
+
+	// fallback to the NumBlocksToSafe depth
+	require.Equal(t, uint64(7), m.LatestSafeBlock().NumberU64())
+
+	// safe head polled from the node
+	m.safeBlockNum.Store(5)
+	require.Equal(t, uint64(5), m.LatestSafeBlock().NumberU64())
+
+	// the node is ahead of the monitor
+	m.safeBlockNum.Store(12)
+	require.Equal(t, uint64(10), m.LatestSafeBlock().NumberU64())
+
+	m.safeBlockNum.Store(0)
+	m.options.NumBlocksToSafe = 0
+	require.Nil(t, m.LatestSafeBlock())
+}
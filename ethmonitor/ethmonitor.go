@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,7 +21,6 @@ import (
 	"github.com/goware/cachestore"
 	"github.com/goware/cachestore/cachestorectl"
 	"github.com/goware/calc"
-	"github.com/goware/channel"
 	"github.com/goware/logger"
 	"github.com/goware/superr"
 )
@@ -37,10 +37,17 @@ var DefaultOptions = Options{
 	StartBlockNumber:                 nil, // latest
 	TrailNumBlocksBehindHead:         0,   // latest
 	BlockRetentionLimit:              200,
+	SafeBlockPollInterval:            0, // disabled
+	HeadPollInterval:                 0, // disabled
+	CaughtUpThreshold:                2,
+	NumBlocksToSafe:                  32,
 	WithLogs:                         false,
-	LogTopics:                        []common.Hash{}, // all logs
+	LogTopics:                        []common.Hash{},    // all logs
+	LogAddresses:                     []common.Address{}, // all contracts
 	DebugLogging:                     false,
 	CacheExpiry:                      300 * time.Second,
+	SubscriberQueueCap:               5000,
+	SubscriberBackpressure:           BackpressureDropOldest,
 	Alerter:                          util.NoopAlerter(),
 }
 
@@ -77,6 +84,25 @@ type Options struct {
 	// StartBlockNumber to begin the monitor from.
 	StartBlockNumber *big.Int
 
+	// StartBlockHash is the hash of the last block processed, ie. before a restart, to
+	// resume the monitor from the block after it. Unlike StartBlockNumber, it's verified
+	// to still be on the canonical chain, and if it was reorged out while the monitor was
+	// offline, the monitor resumes from the block after the common ancestor of the
+	// block and the canonical chain instead. Run fails with ErrStartBlockUnresolvable if
+	// the block, or its ancestors up to BlockRetentionLimit blocks deep, can't be fetched
+	// from the node. Takes precedence over StartBlockNumber when set.
+	StartBlockHash *common.Hash
+
+	// MaxStartupCatchupBlocks is the max number of blocks the StartBlockNumber can be
+	// behind the head of the chain at startup, to prevent accidentally backfilling a long
+	// history, ie. of days on a fast chain. StartupCatchupPolicy sets what happens when
+	// the start is further behind. A value of 0 sets no limit.
+	MaxStartupCatchupBlocks int
+
+	// StartupCatchupPolicy is the behaviour when the StartBlockNumber is more than
+	// MaxStartupCatchupBlocks behind the head of the chain, see StartupCatchupPolicy.
+	StartupCatchupPolicy StartupCatchupPolicy
+
 	// Bootstrap flag which indicates the monitor will expect the monitor's
 	// events to be bootstrapped, and will continue from that point. This also
 	// takes precedence over StartBlockNumber when set to true.
@@ -86,19 +112,82 @@ type Options struct {
 	// the head of the chain before broadcasting new events to the subscribers.
 	TrailNumBlocksBehindHead int
 
+	// PublishFinalizedOnly will only publish blocks once they've reached finality, ie.
+	// once they are NumBlocksToFinality blocks behind the head of the chain. Subscribers
+	// will never receive Removed events, as reorgs are resolved before blocks are
+	// published. The tradeoff is latency, where each block is only published after
+	// NumBlocksToFinality more blocks have been mined on top of it.
+	//
+	// NOTE: in the rare case of a reorg deeper than NumBlocksToFinality, the Removed
+	// events are dropped and the monitor will alert.
+	PublishFinalizedOnly bool
+
+	// NumBlocksToFinality is the number of blocks until a block is considered final,
+	// and is required when PublishFinalizedOnly is set.
+	NumBlocksToFinality int
+
 	// BlockRetentionLimit is the number of blocks we keep on the canonical chain
 	// cache.
 	BlockRetentionLimit int
 
+	// (optional) SafeBlockPollInterval is how often the node's "safe" head is polled,
+	// see LatestSafeBlock. It's disabled by default with a value of 0, in which case
+	// LatestSafeBlock always uses the NumBlocksToSafe depth.
+	SafeBlockPollInterval time.Duration
+
+	// (optional) HeadPollInterval is how often the network head block number is polled,
+	// to tell whether the monitor is caught up with the head, see IsCaughtUp. It's disabled
+	// by default with a value of 0, in which case the network head is only known from the
+	// blocks fetched and the heads streamed by the node.
+	HeadPollInterval time.Duration
+
+	// CaughtUpThreshold is the max number of blocks the monitor can be behind the
+	// network head to be considered caught up, see IsCaughtUp.
+	CaughtUpThreshold int
+
+	// NumBlocksToSafe is the number of blocks behind the head for a block to be
+	// considered safe by LatestSafeBlock, on chains whose node doesn't support the
+	// "safe" block tag.
+	NumBlocksToSafe int
+
+	// VerifyBlockHashes recomputes the hash of each block fetched from the node from its
+	// header, and verifies it matches the hash reported by the node, as well as the hash
+	// or number of the block requested. On mismatch, the block is discarded and refetched,
+	// and the monitor will alert. This guards the canonical chain against faulty nodes
+	// returning inconsistent block data.
+	//
+	// NOTE: only enable this on chains whose block headers hash as on Ethereum, or the
+	// monitor will never make progress.
+	VerifyBlockHashes bool
+
 	// Retain block and logs payloads
 	RetainPayloads bool
 
+	// BlockSamplingInterval bounds the memory of the retained chain on high-throughput
+	// chains, by only retaining the full data of every Nth block, ie. its transactions,
+	// logs and payloads, while the headers of all blocks are retained for reorg
+	// detection. Blocks are only sampled once they're TrailNumBlocksBehindHead behind
+	// the head, and subscribers still receive the full data of every block.
+	//
+	// NOTE: GetBlock will return only the header of sampled-out blocks, with the Sampled
+	// flag set, and GetTransaction will not find their transactions. As well, Removed
+	// events for sampled-out blocks in a reorg carry only the block header.
+	//
+	// A value of 0 or 1 retains the full data of all blocks (default).
+	BlockSamplingInterval int
+
 	// WithLogs will include logs with the blocks if specified true.
 	WithLogs bool
 
-	// LogTopics will filter only specific log topics to include.
+	// LogTopics will filter only specific log topics to include. Logs are not
+	// fetched for blocks whose logsBloom does not match any of the topics.
 	LogTopics []common.Hash
 
+	// LogAddresses will filter only logs emitted by specific contract addresses to
+	// include. When combined with LogTopics, logs must match both, as with eth_getLogs.
+	// Logs are not fetched for blocks whose logsBloom does not match any of the addresses.
+	LogAddresses []common.Address
+
 	// CacheBackend to use for caching block data
 	// NOTE: do not use this unless you know what you're doing.
 	// In most cases leave this nil.
@@ -107,39 +196,92 @@ type Options struct {
 	// CacheExpiry is how long to keep each record in cache
 	CacheExpiry time.Duration
 
+	// DedupeSubscribers will track the last block delivered to each labelled
+	// subscriber in the CacheBackend, and skip re-delivering older blocks to a
+	// subscriber with the same label after a restart. Reorg events are always
+	// delivered. Requires CacheBackend to be set.
+	DedupeSubscribers bool
+
+	// SubscriberQueueCap is the max number of published event batches queued for each
+	// subscriber which hasn't read them yet, ie. a slow consumer, after which the
+	// SubscriberBackpressure policy applies. A value of 0 sets no limit.
+	SubscriberQueueCap int
+
+	// SubscriberBackpressure is the policy applied to subscribers whose queue is at
+	// SubscriberQueueCap, see BackpressurePolicy. Defaults to BackpressureDropOldest.
+	SubscriberBackpressure BackpressurePolicy
+
 	// Alerter config via github.com/goware/alerter
 	Alerter util.Alerter
 
+	// (optional) ChainStore to persist the canonical chain to. When set, the monitor
+	// will save new block events to the store as they're published, and on Run
+	// will restore the chain from the store, continuing from its head block.
+	ChainStore ChainStore
+
+	// (optional) EventWriter to write each published batch of block events to as
+	// newline-delimited json, for consumption by external processes.
+	EventWriter *EventWriter
+
+	// (optional) OnBeforePublish is called with each batch of block events before it's
+	// published to subscribers and the EventWriter, ie. to persist or enrich the blocks
+	// before anyone else sees them. When it returns an error, the batch is held back and
+	// the hook is retried after the polling interval until it succeeds or the monitor
+	// stops, so subscribers never receive blocks the hook hasn't processed.
+	//
+	// The hook is called synchronously and without any monitor locks held, so it may call
+	// back into the monitor. Note that a long-running hook slows down the whole pipeline,
+	// as no further blocks are published while it runs.
+	OnBeforePublish func(ctx context.Context, blocks Blocks) error
+
 	// DebugLogging toggle
 	DebugLogging bool
 }
 
 var (
-	ErrFatal                 = errors.New("ethmonitor: fatal error, stopping")
-	ErrReorg                 = errors.New("ethmonitor: block reorg")
-	ErrUnexpectedParentHash  = errors.New("ethmonitor: unexpected parent hash")
-	ErrUnexpectedBlockNumber = errors.New("ethmonitor: unexpected block number")
-	ErrQueueFull             = errors.New("ethmonitor: publish queue is full")
-	ErrMaxAttempts           = errors.New("ethmonitor: max attempts hit")
-	ErrMonitorStopped        = errors.New("ethmonitor: stopped")
+	ErrFatal                  = errors.New("ethmonitor: fatal error, stopping")
+	ErrReorg                  = errors.New("ethmonitor: block reorg")
+	ErrUnexpectedParentHash   = errors.New("ethmonitor: unexpected parent hash")
+	ErrUnexpectedBlockNumber  = errors.New("ethmonitor: unexpected block number")
+	ErrQueueFull              = errors.New("ethmonitor: publish queue is full")
+	ErrMaxAttempts            = errors.New("ethmonitor: max attempts hit")
+	ErrMonitorStopped         = errors.New("ethmonitor: stopped")
+	ErrInvalidBlockHash       = errors.New("ethmonitor: invalid block hash")
+	ErrStartBlockTooOld       = errors.New("ethmonitor: start block is too far behind the head")
+	ErrStartBlockUnresolvable = errors.New("ethmonitor: start block hash is unresolvable")
+	ErrSlowSubscriber         = errors.New("ethmonitor: subscriber queue is full")
+	ErrBlockNotFound          = errors.New("ethmonitor: block not found in retained chain")
+	ErrLogsUnavailable        = errors.New("ethmonitor: block logs are unavailable")
 )
 
 type Monitor struct {
 	options Options
 
-	log      logger.Logger
-	alert    util.Alerter
-	provider ethrpc.RawInterface
+	log             logger.Logger
+	alert           util.Alerter
+	provider        ethrpc.RawInterface
+	providerMu      sync.RWMutex
+	providerChanged chan struct{}
 
 	chain             *Chain
 	chainID           *big.Int
 	nextBlockNumber   *big.Int
 	nextBlockNumberMu sync.Mutex
-	pollInterval      atomic.Int64
+	pollInterval      atomic.Int64  // effective interval between polls, sped up while catching up
+	pollingInterval   atomic.Int64  // base polling interval, see SetPollingInterval
+	pollingChanged    chan struct{} // closed once the polling interval is changed
+	pollingMu         sync.Mutex
 	isStreamingMode   atomic.Bool
+	networkHeadNum    atomic.Uint64 // latest head block number seen from the network
+	safeBlockNum      atomic.Uint64 // latest safe block number polled from the node
+	lastPublishedAt   atomic.Int64  // unix nano time of the last published events
 
 	cache cachestore.Store[[]byte]
 
+	// lastDeliveredBlockNums are the latest block numbers delivered to each subscriber
+	// label, used with the DedupeSubscribers option. Only accessed by broadcast.
+	lastDeliveredBlockNums map[string]uint64
+
 	publishCh    chan Blocks
 	publishQueue *queue
 	subscribers  []*subscriber
@@ -163,6 +305,15 @@ func NewMonitor(provider ethrpc.RawInterface, options ...Options) (*Monitor, err
 		opts.Alerter = util.NoopAlerter()
 	}
 
+	if opts.PublishFinalizedOnly {
+		if opts.NumBlocksToFinality <= 0 {
+			return nil, fmt.Errorf("ethmonitor: NumBlocksToFinality is required with PublishFinalizedOnly")
+		}
+		if opts.TrailNumBlocksBehindHead < opts.NumBlocksToFinality {
+			opts.TrailNumBlocksBehindHead = opts.NumBlocksToFinality
+		}
+	}
+
 	opts.BlockRetentionLimit += opts.TrailNumBlocksBehindHead
 	if opts.BlockRetentionLimit < 2 {
 		// minimum 2 blocks to track, as we need the previous
@@ -190,23 +341,27 @@ func NewMonitor(provider ethrpc.RawInterface, options ...Options) (*Monitor, err
 		}
 	}
 
-	return &Monitor{
-		options:      opts,
-		log:          opts.Logger,
-		alert:        opts.Alerter,
-		provider:     provider,
-		chain:        newChain(opts.BlockRetentionLimit, opts.Bootstrap),
-		chainID:      nil,
-		cache:        cache,
-		publishCh:    make(chan Blocks),
-		publishQueue: newQueue(opts.BlockRetentionLimit * 2),
-		subscribers:  make([]*subscriber, 0),
-	}, nil
+	m := &Monitor{
+		options:         opts,
+		log:             opts.Logger,
+		alert:           opts.Alerter,
+		provider:        provider,
+		providerChanged: make(chan struct{}, 1),
+		chain:           newChain(opts.BlockRetentionLimit, opts.Bootstrap),
+		chainID:         nil,
+		cache:           cache,
+		publishCh:       make(chan Blocks),
+		publishQueue:    newQueue(opts.BlockRetentionLimit * 2),
+		subscribers:     make([]*subscriber, 0),
+	}
+	m.pollingInterval.Store(int64(opts.PollingInterval))
+
+	return m, nil
 }
 
 func (m *Monitor) lazyInit(ctx context.Context) error {
 	var err error
-	m.chainID, err = getChainID(ctx, m.provider)
+	m.chainID, err = getChainID(ctx, m.rawProvider())
 	if err != nil {
 		// Allow monitor to use a manually set chainID if provided, in case
 		// the provider is faulty.
@@ -240,6 +395,13 @@ func (m *Monitor) Run(ctx context.Context) error {
 		return err
 	}
 
+	// Restore the canonical chain from the store, if one is set
+	if m.options.ChainStore != nil && m.chain.Head() == nil {
+		if err := m.restoreChain(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Check if in bootstrap mode -- in which case we expect nextBlockNumber
 	// to already be set.
 	if m.options.Bootstrap && m.chain.blocks == nil {
@@ -250,13 +412,26 @@ func (m *Monitor) Run(ctx context.Context) error {
 	if m.chain.Head() != nil {
 		// starting from last block of our canonical chain
 		m.nextBlockNumber = big.NewInt(0).Add(m.chain.Head().Number(), big.NewInt(1))
+	} else if m.options.StartBlockHash != nil {
+		// resuming from the block after the last processed block, or its common
+		// ancestor with the canonical chain if it has been reorged out
+		nextBlockNumber, err := m.resolveStartBlockHash(m.ctx, *m.options.StartBlockHash)
+		if err != nil {
+			return err
+		}
+		m.nextBlockNumber = nextBlockNumber
+		if m.options.MaxStartupCatchupBlocks > 0 {
+			if err := m.checkStartupCatchup(m.ctx); err != nil {
+				return err
+			}
+		}
 	} else if m.options.StartBlockNumber != nil {
 		if m.options.StartBlockNumber.Cmp(big.NewInt(0)) >= 0 {
 			// starting from specific block number
 			m.nextBlockNumber = m.options.StartBlockNumber
 		} else {
 			// starting some number blocks behind the latest block num
-			latestBlock, _ := m.provider.BlockByNumber(m.ctx, nil)
+			latestBlock, _ := m.rawProvider().BlockByNumber(m.ctx, nil)
 			if latestBlock != nil && latestBlock.Number() != nil {
 				m.nextBlockNumber = big.NewInt(0).Add(latestBlock.Number(), m.options.StartBlockNumber)
 				if m.nextBlockNumber.Cmp(big.NewInt(0)) < 0 {
@@ -264,6 +439,11 @@ func (m *Monitor) Run(ctx context.Context) error {
 				}
 			}
 		}
+		if m.nextBlockNumber != nil && m.options.MaxStartupCatchupBlocks > 0 {
+			if err := m.checkStartupCatchup(m.ctx); err != nil {
+				return err
+			}
+		}
 	} else {
 		// noop, starting from the latest block on the network
 	}
@@ -274,23 +454,41 @@ func (m *Monitor) Run(ctx context.Context) error {
 		m.log.Infof("ethmonitor: starting from block=%d", m.nextBlockNumber)
 	}
 
-	// Broadcast published events to all subscribers
+	// Broadcast published events to all subscribers, until the monitor is stopped
 	go func() {
 		for {
 			select {
-			case <-ctx.Done():
+			case <-m.ctx.Done():
 				return
 			case blocks := <-m.publishCh:
 				if m.options.DebugLogging {
-					m.log.Debug("ethmonitor: publishing block", blocks.LatestBlock().NumberU64(), "# events:", len(blocks))
+					m.log.Debug("ethmonitor: publishing block", blocks.Head().NumberU64(), "# events:", len(blocks))
+				}
+
+				// hook to process the blocks before anyone else sees them
+				if !m.beforePublish(m.ctx, blocks) {
+					return
 				}
 
 				// broadcast to subscribers
 				m.broadcast(blocks)
+
+				// write to event stream
+				m.writeEvents(blocks)
 			}
 		}
 	}()
 
+	// Poll the safe head of the node
+	if m.options.SafeBlockPollInterval > 0 {
+		go m.pollSafeBlock(m.ctx)
+	}
+
+	// Poll the network head, to tell if the monitor is caught up
+	if m.options.HeadPollInterval > 0 {
+		go m.pollNetworkHead(m.ctx)
+	}
+
 	// Monitor the chain for canonical representation
 	err := m.monitor()
 	if m.options.UnsubscribeOnStop {
@@ -299,6 +497,101 @@ func (m *Monitor) Run(ctx context.Context) error {
 	return err
 }
 
+// resolveStartBlockHash returns the number of the block to resume from after the block
+// of the hash. If the block is no longer canonical, its ancestors are walked back until
+// the common ancestor with the canonical chain, up to BlockRetentionLimit blocks deep.
+func (m *Monitor) resolveStartBlockHash(ctx context.Context, hash common.Hash) (*big.Int, error) {
+	header, err := m.fetchStartHeader(ctx, func(ctx context.Context) (*types.Header, error) {
+		return m.rawProvider().HeaderByHash(ctx, hash)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch start block %s: %w", ErrStartBlockUnresolvable, hash, err)
+	}
+
+	for depth := 0; ; depth++ {
+		canonical, err := m.fetchStartHeader(ctx, func(ctx context.Context) (*types.Header, error) {
+			return m.rawProvider().HeaderByNumber(ctx, header.Number)
+		})
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("ethmonitor: failed to fetch block %d: %w", header.Number, err)
+		}
+
+		if canonical != nil && canonical.Hash() == header.Hash() {
+			if depth > 0 {
+				m.log.Warnf("ethmonitor: start block=%d hash=%s was reorged out, resuming from common ancestor block=%d hash=%s", header.Number.Uint64()+uint64(depth), hash, header.Number, header.Hash())
+				m.alert.Alert(context.Background(), "ethmonitor (chain %s): start block %s was reorged out %d blocks deep while offline", m.chainID.String(), hash, depth)
+			}
+			return new(big.Int).Add(header.Number, big.NewInt(1)), nil
+		}
+
+		if depth >= m.options.BlockRetentionLimit || header.Number.Sign() == 0 {
+			return nil, fmt.Errorf("%w: no common ancestor of start block %s with the canonical chain within %d blocks", ErrStartBlockUnresolvable, hash, depth)
+		}
+
+		parentHash := header.ParentHash
+		header, err = m.fetchStartHeader(ctx, func(ctx context.Context) (*types.Header, error) {
+			return m.rawProvider().HeaderByHash(ctx, parentHash)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to fetch reorged block %s: %w", ErrStartBlockUnresolvable, parentHash, err)
+		}
+	}
+}
+
+// fetchStartHeader fetches a header within the Timeout option.
+func (m *Monitor) fetchStartHeader(ctx context.Context, fetchFn func(ctx context.Context) (*types.Header, error)) (*types.Header, error) {
+	tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+	defer cancel()
+	return fetchFn(tctx)
+}
+
+// StartupCatchupPolicy is the behaviour of the monitor when starting from a block more
+// than Options.MaxStartupCatchupBlocks behind the head of the chain.
+type StartupCatchupPolicy int
+
+const (
+	// StartupCatchupProceed logs a warning, and catches up from the start block (default).
+	StartupCatchupProceed StartupCatchupPolicy = iota
+
+	// StartupCatchupFastForward skips the older blocks, and starts from MaxStartupCatchupBlocks
+	// behind the head instead.
+	StartupCatchupFastForward
+
+	// StartupCatchupError fails Run with ErrStartBlockTooOld.
+	StartupCatchupError
+)
+
+// checkStartupCatchup applies the StartupCatchupPolicy if the next block number is more
+// than MaxStartupCatchupBlocks behind the head of the chain.
+func (m *Monitor) checkStartupCatchup(ctx context.Context) error {
+	tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+	defer cancel()
+
+	headNum, err := m.rawProvider().BlockNumber(tctx)
+	if err != nil {
+		m.log.Warnf("ethmonitor: unable to check how far the start block=%d is behind the head: %v", m.nextBlockNumber, err)
+		return nil
+	}
+
+	maxCatchup := uint64(m.options.MaxStartupCatchupBlocks)
+	startNum := m.nextBlockNumber.Uint64()
+	if startNum+maxCatchup >= headNum {
+		return nil
+	}
+
+	switch m.options.StartupCatchupPolicy {
+	case StartupCatchupFastForward:
+		m.log.Warnf("ethmonitor: start block=%d is %d blocks behind head=%d, fast-forwarding to block=%d", startNum, headNum-startNum, headNum, headNum-maxCatchup)
+		m.nextBlockNumber = new(big.Int).SetUint64(headNum - maxCatchup)
+		return nil
+	case StartupCatchupError:
+		return fmt.Errorf("%w: start block=%d is %d blocks behind head=%d, max is %d", ErrStartBlockTooOld, startNum, headNum-startNum, headNum, maxCatchup)
+	default:
+		m.log.Warnf("ethmonitor: start block=%d is %d blocks behind head=%d, catching up", startNum, headNum-startNum, headNum)
+		return nil
+	}
+}
+
 func (m *Monitor) Stop() {
 	m.log.Info("ethmonitor: stop")
 	if m.ctxStop != nil {
@@ -314,20 +607,207 @@ func (m *Monitor) IsRunning() bool {
 }
 
 func (m *Monitor) Options() Options {
-	return m.options
+	opts := m.options
+	opts.PollingInterval = m.PollingInterval()
+	return opts
 }
 
 func (m *Monitor) Provider() ethrpc.Interface {
+	return m.rawProvider()
+}
+
+func (m *Monitor) rawProvider() ethrpc.RawInterface {
+	m.providerMu.RLock()
+	defer m.providerMu.RUnlock()
 	return m.provider
 }
 
+// SetProvider replaces the provider of the monitor at runtime, ie. to rotate the node
+// endpoint or its credentials, without restarting the monitor, so the canonical chain
+// and subscribers are kept and the monitor continues from its current head. The provider
+// must be of the same chain, which is verified before it's replaced.
+//
+// Requests already in-flight on the previous provider are completed with it, while all
+// following requests use the new provider. In streaming mode, the new heads stream is
+// re-subscribed with the new provider.
+func (m *Monitor) SetProvider(provider ethrpc.RawInterface) error {
+	if provider == nil {
+		return fmt.Errorf("ethmonitor: provider is nil")
+	}
+
+	m.mu.RLock()
+	expectedChainID := m.chainID
+	m.mu.RUnlock()
+	if expectedChainID == nil {
+		expectedChainID = m.options.ChainID
+	}
+
+	if expectedChainID != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), m.options.Timeout)
+		defer cancel()
+
+		chainID, err := getChainID(ctx, provider)
+		if err != nil {
+			return fmt.Errorf("ethmonitor: SetProvider failed to get chainID from provider: %w", err)
+		}
+		if chainID.Cmp(expectedChainID) != 0 {
+			return fmt.Errorf("ethmonitor: SetProvider provider chainID %s does not match monitor chainID %s", chainID.String(), expectedChainID.String())
+		}
+	}
+
+	m.providerMu.Lock()
+	m.provider = provider
+	m.providerMu.Unlock()
+
+	m.log.Info("ethmonitor: provider replaced")
+
+	// notify the head listener to re-subscribe with the new provider
+	select {
+	case m.providerChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
 func (m *Monitor) IsStreamingEnabled() bool {
-	return !m.options.StreamingDisabled && m.provider.IsStreamingEnabled()
+	return !m.options.StreamingDisabled && m.rawProvider().IsStreamingEnabled()
 }
 
 func (m *Monitor) IsStreamingMode() bool {
 	return m.isStreamingMode.Load()
 }
+
+// Status is a snapshot of the progress and health of the monitor, see Monitor.Status.
+type Status struct {
+	// Running is set if the monitor is running.
+	Running bool
+
+	// StreamingMode is set if the monitor is listening for new heads via the
+	// websocket stream, and unset if it's polling for new blocks.
+	StreamingMode bool
+
+	// HeadBlockNum is the latest block number of the network head. In polling mode with
+	// HeadPollInterval disabled, the head is only known from the blocks fetched, so it's
+	// the same as LatestBlockNum.
+	HeadBlockNum uint64
+
+	// LatestBlockNum is the latest block number processed by the monitor.
+	LatestBlockNum uint64
+
+	// Lag is the number of blocks the monitor is behind the network head.
+	Lag uint64
+
+	// LastPublishedAt is the time events were last published to subscribers, and
+	// SinceLastPublished is the time elapsed since. Both are zero if no events have
+	// been published yet, ie. when there are no subscribers.
+	LastPublishedAt    time.Time
+	SinceLastPublished time.Duration
+
+	// PollInterval is the current interval between polls for new blocks in polling mode,
+	// which is shorter than the PollingInterval while the monitor is catching up with the
+	// head, and reset to it once the monitor polls for a block which isn't mined yet.
+	PollInterval time.Duration
+}
+
+// SetPollingInterval changes the interval between polls for new blocks while the monitor
+// is running, ie. to poll less often during idle periods to reduce rpc costs, and more
+// often once activity resumes. It replaces the PollingInterval option, which is also the
+// pause before retrying failed requests, and applies to the next poll.
+//
+// NOTE: the monitor polls faster than the interval while it's catching up with the head
+// of the chain, see Status().PollInterval.
+func (m *Monitor) SetPollingInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("ethmonitor: polling interval must be greater than 0")
+	}
+	m.pollingInterval.Store(int64(interval))
+	m.pollInterval.Store(int64(interval))
+
+	// wake up any poll waiting on the previous interval
+	m.pollingMu.Lock()
+	if m.pollingChanged != nil {
+		close(m.pollingChanged)
+	}
+	m.pollingChanged = make(chan struct{})
+	m.pollingMu.Unlock()
+	return nil
+}
+
+// PollingInterval returns the base interval between polls for new blocks, see
+// SetPollingInterval.
+func (m *Monitor) PollingInterval() time.Duration {
+	return time.Duration(m.pollingInterval.Load())
+}
+
+// pollingChangedCh returns a channel which is closed once the polling interval is
+// changed with SetPollingInterval.
+func (m *Monitor) pollingChangedCh() <-chan struct{} {
+	m.pollingMu.Lock()
+	defer m.pollingMu.Unlock()
+	if m.pollingChanged == nil {
+		m.pollingChanged = make(chan struct{})
+	}
+	return m.pollingChanged
+}
+
+// waitPollingInterval pauses for the polling interval, or until it's changed.
+func (m *Monitor) waitPollingInterval(ctx context.Context) {
+	pollingChanged := m.pollingChangedCh()
+	select {
+	case <-time.After(m.PollingInterval()):
+	case <-pollingChanged:
+	case <-ctx.Done():
+	}
+}
+
+// Status returns the progress and health of the monitor, ie. to alert when the monitor
+// falls behind the head of the chain.
+func (m *Monitor) Status() Status {
+	status := Status{
+		Running:        m.IsRunning(),
+		StreamingMode:  m.IsStreamingMode(),
+		HeadBlockNum:   m.networkHeadNum.Load(),
+		LatestBlockNum: m.LatestBlockNum().Uint64(),
+		PollInterval:   time.Duration(m.pollInterval.Load()),
+	}
+	if status.HeadBlockNum < status.LatestBlockNum {
+		status.HeadBlockNum = status.LatestBlockNum
+	}
+	status.Lag = status.HeadBlockNum - status.LatestBlockNum
+
+	if lastPublishedAt := m.lastPublishedAt.Load(); lastPublishedAt > 0 {
+		status.LastPublishedAt = time.Unix(0, lastPublishedAt)
+		status.SinceLastPublished = time.Since(status.LastPublishedAt)
+	}
+	return status
+}
+
+// IsCaughtUp reports whether the monitor is running and the latest block it processed is
+// within CaughtUpThreshold blocks of the network head, ie. for readiness probes. The
+// network head is refreshed every HeadPollInterval if set, so the check makes no requests.
+// It's false until the network head is known.
+func (m *Monitor) IsCaughtUp() bool {
+	if !m.IsRunning() {
+		return false
+	}
+	latest := m.LatestBlock()
+	head := m.networkHeadNum.Load()
+	if latest == nil || head == 0 {
+		return false
+	}
+	return latest.NumberU64()+uint64(max(m.options.CaughtUpThreshold, 0)) >= head
+}
+
+// setNetworkHeadNum stores the network head block number, if it's newer than the
+// one stored.
+func (m *Monitor) setNetworkHeadNum(num uint64) {
+	for {
+		head := m.networkHeadNum.Load()
+		if num <= head || m.networkHeadNum.CompareAndSwap(head, num) {
+			return
+		}
+	}
+}
 func (m *Monitor) listenNewHead() <-chan uint64 {
 	ch := make(chan uint64)
 
@@ -372,7 +852,7 @@ func (m *Monitor) listenNewHead() <-chan uint64 {
 			m.isStreamingMode.Store(true)
 
 			newHeads := make(chan *types.Header)
-			sub, err := m.provider.SubscribeNewHeads(m.ctx, newHeads)
+			sub, err := m.rawProvider().SubscribeNewHeads(m.ctx, newHeads)
 			if err != nil {
 				m.log.Warnf("ethmonitor (chain %s): websocket connect failed: %v", m.chainID.String(), err)
 				m.alert.Alert(context.Background(), "ethmonitor (chain %s): websocket connect failed: %v", m.chainID.String(), err)
@@ -389,6 +869,12 @@ func (m *Monitor) listenNewHead() <-chan uint64 {
 					close(nextBlock)
 					return
 
+				case <-m.providerChanged:
+					// the provider has been replaced, so we re-subscribe with the new one
+					m.log.Info("ethmonitor: provider replaced, re-subscribing stream head listener")
+					sub.Unsubscribe()
+					goto reconnect
+
 				case err := <-sub.Err():
 					// if we have an error, we'll reconnect
 					m.log.Warnf("ethmonitor (chain %s): websocket subscription closed, error: %v", m.chainID.String(), err)
@@ -400,6 +886,7 @@ func (m *Monitor) listenNewHead() <-chan uint64 {
 
 				case newHead := <-newHeads:
 					latestHeadBlock.Store(newHead.Number.Uint64())
+					m.setNetworkHeadNum(newHead.Number.Uint64())
 					select {
 					case nextBlock <- newHead.Number.Uint64():
 					default:
@@ -428,6 +915,7 @@ func (m *Monitor) listenNewHead() <-chan uint64 {
 				}
 
 				// Polling mode, where we poll for the latest block number
+				pollingChanged := m.pollingChangedCh()
 				select {
 				case <-m.ctx.Done():
 					// if we're done, we'll close the nextBlock channel
@@ -435,6 +923,15 @@ func (m *Monitor) listenNewHead() <-chan uint64 {
 					retryStreamingTimer.Stop()
 					return
 
+				case <-m.providerChanged:
+					// the provider has been replaced, so we check whether it supports streaming
+					retryStreamingTimer.Stop()
+					streamingErrLastTime = time.Now().Add(-m.options.StreamingErrorResetInterval * 2)
+					goto reconnect
+
+				case <-pollingChanged:
+					// the polling interval has been changed, so we wait with the new one
+
 				case <-time.After(time.Duration(m.pollInterval.Load())):
 					nextBlock <- 0
 				}
@@ -520,15 +1017,38 @@ func (m *Monitor) monitor() error {
 					m.log.Warnf("ethmonitor: fetchNextBlock error reported '%v', for blockNum:%v, retrying..", err, m.nextBlockNumber)
 				}
 
-				// pause, then retry
-				time.Sleep(m.options.PollingInterval)
+				// pause, then retry, unless the monitor is stopped meanwhile
+				m.waitPollingInterval(ctx)
 				continue
 			}
 
+			if m.options.VerifyBlockHashes {
+				var requestedNum *big.Int
+				m.nextBlockNumberMu.Lock()
+				if m.nextBlockNumber != nil {
+					requestedNum = big.NewInt(0).Set(m.nextBlockNumber)
+				}
+				m.nextBlockNumberMu.Unlock()
+
+				var cacheKey string
+				if requestedNum != nil {
+					cacheKey = cacheKeyBlockNum(m.chainID, requestedNum)
+				}
+
+				if err := m.verifyBlockHash(ctx, nextBlock, cacheKey, common.Hash{}, requestedNum); err != nil {
+					// pause, then retry, unless the monitor is stopped meanwhile
+					m.waitPollingInterval(ctx)
+					continue
+				}
+			}
+
+			// in polling mode, the head of the network is only known from the blocks we fetch
+			m.setNetworkHeadNum(nextBlock.NumberU64())
+
 			// if we hit a miss between calls, then we reset the pollInterval, otherwise
 			// we speed up the polling interval
 			if miss {
-				m.pollInterval.Store(int64(m.options.PollingInterval))
+				m.pollInterval.Store(int64(m.PollingInterval()))
 			} else {
 				m.pollInterval.Store(int64(clampDuration(minLoopInterval, time.Duration(m.pollInterval.Load())/4)))
 			}
@@ -540,7 +1060,7 @@ func (m *Monitor) monitor() error {
 					err, nextBlock.NumberU64(), nextBlock.Hash().Hex())
 
 				// pause, then retry
-				time.Sleep(m.options.PollingInterval)
+				m.waitPollingInterval(ctx)
 				continue
 			}
 
@@ -556,6 +1076,9 @@ func (m *Monitor) monitor() error {
 			}
 			m.chain.mu.Unlock()
 
+			// persist events to the chain store
+			m.saveChain(ctx, events)
+
 			// publish events
 			err = m.publish(ctx, events)
 			if err != nil {
@@ -564,6 +1087,11 @@ func (m *Monitor) monitor() error {
 				return superr.New(ErrFatal, err)
 			}
 
+			// drop the full data of older blocks, if sampling is enabled
+			if m.options.BlockSamplingInterval > 1 {
+				m.chain.sampleBlocks(m.options.BlockSamplingInterval, m.options.TrailNumBlocksBehindHead)
+			}
+
 			// clear events sink
 			events = Blocks{}
 		}
@@ -613,7 +1141,7 @@ func (m *Monitor) buildCanonicalChain(ctx context.Context, nextBlock *types.Bloc
 
 	// let's always take a pause between any reorg for the polling interval time
 	// to allow nodes to sync to the correct chain
-	pause := calc.Max(2*m.options.PollingInterval, 2*time.Second)
+	pause := calc.Max(2*m.PollingInterval(), 2*time.Second)
 	time.Sleep(pause)
 
 	// Fetch/connect the broken chain backwards by traversing recursively via parent hashes
@@ -622,6 +1150,13 @@ func (m *Monitor) buildCanonicalChain(ctx context.Context, nextBlock *types.Bloc
 		// NOTE: this is okay, it will auto-retry
 		return events, err
 	}
+	if m.options.VerifyBlockHashes {
+		err = m.verifyBlockHash(ctx, nextParentBlock, cacheKeyBlockHash(m.chainID, nextBlock.ParentHash()), nextBlock.ParentHash(), nil)
+		if err != nil {
+			// NOTE: this is okay, it will auto-retry
+			return events, err
+		}
+	}
 
 	events, err = m.buildCanonicalChain(ctx, nextParentBlock, nextParentBlockPayload, events)
 	if err != nil {
@@ -662,19 +1197,23 @@ func (m *Monitor) addLogs(ctx context.Context, blocks Blocks) {
 			continue
 		}
 
-		blockHash := block.Hash()
-
-		topics := [][]common.Hash{}
-		if len(m.options.LogTopics) > 0 {
-			topics = append(topics, m.options.LogTopics)
+		// skip fetching logs when the logsBloom guarantees none of the logs in
+		// the block match the topics or addresses we're filtering for
+		if !bloomMatchesTopics(block.Bloom(), m.options.LogTopics) || !bloomMatchesAddresses(block.Bloom(), m.options.LogAddresses) {
+			block.Logs = []types.Log{}
+			block.OK = true
+			continue
 		}
 
-		logs, logsPayload, err := m.filterLogs(tctx, blockHash, topics)
+		blockHash := block.Hash()
+
+		logs, logsPayload, err := m.filterLogs(tctx, blockHash, m.options.LogAddresses, m.logTopics())
 
 		if err == nil {
 			// check the logsBloom from the block to check if we should be expecting logs. logsBloom
-			// will be included for any indexed logs.
-			if len(logs) > 0 || block.Bloom() == (types.Bloom{}) {
+			// will be included for any indexed logs. When filtering by LogTopics or LogAddresses,
+			// the bloom may match logs which the filter doesn't, so an empty result is final.
+			if len(logs) > 0 || block.Bloom() == (types.Bloom{}) || m.filtersLogs() {
 				// successful backfill
 				if logs == nil {
 					block.Logs = []types.Log{}
@@ -697,7 +1236,37 @@ func (m *Monitor) addLogs(ctx context.Context, blocks Blocks) {
 	}
 }
 
-func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, topics [][]common.Hash) ([]types.Log, []byte, error) {
+// bloomMatchesTopics reports whether the logsBloom may contain a log with any of the
+// topics. As blooms have false positives, a match only means the logs need to be fetched,
+// where no match guarantees none of the logs have the topics. An empty bloom always
+// matches, as some nodes don't populate the logsBloom, and so do an empty list of topics.
+func bloomMatchesTopics(bloom types.Bloom, topics []common.Hash) bool {
+	if len(topics) == 0 || bloom == (types.Bloom{}) {
+		return true
+	}
+	for _, topic := range topics {
+		if types.BloomLookup(bloom, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// bloomMatchesAddresses is like bloomMatchesTopics, but for the contract addresses
+// which emitted the logs.
+func bloomMatchesAddresses(bloom types.Bloom, addresses []common.Address) bool {
+	if len(addresses) == 0 || bloom == (types.Bloom{}) {
+		return true
+	}
+	for _, address := range addresses {
+		if types.BloomLookup(bloom, address) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, addresses []common.Address, topics [][]common.Hash) ([]types.Log, []byte, error) {
 	getter := func(ctx context.Context, _ string) ([]byte, error) {
 		if m.options.DebugLogging {
 			m.log.Debugf("ethmonitor: filterLogs is calling origin for block hash %s", blockHash)
@@ -706,8 +1275,9 @@ func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, topics
 		tctx, cancel := context.WithTimeout(ctx, 4*time.Second)
 		defer cancel()
 
-		logsPayload, err := m.provider.RawFilterLogs(tctx, ethereum.FilterQuery{
+		logsPayload, err := m.rawProvider().RawFilterLogs(tctx, ethereum.FilterQuery{
 			BlockHash: &blockHash,
+			Addresses: addresses,
 			Topics:    topics,
 		})
 		return logsPayload, err
@@ -722,6 +1292,30 @@ func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, topics
 		return logs, resp, err
 	}
 
+	key := m.logsCacheKey(blockHash, addresses, topics)
+	resp, err := m.cache.GetOrSetWithLockEx(ctx, key, getter, m.options.CacheExpiry)
+	if err != nil {
+		return nil, resp, err
+	}
+	logs, err := m.unmarshalLogs(resp)
+	return logs, resp, err
+}
+
+// filtersLogs reports whether the logs of blocks are filtered by LogTopics or LogAddresses.
+func (m *Monitor) filtersLogs() bool {
+	return len(m.options.LogTopics) > 0 || len(m.options.LogAddresses) > 0
+}
+
+// logTopics returns the topics filter of the logs fetched by the monitor.
+func (m *Monitor) logTopics() [][]common.Hash {
+	topics := [][]common.Hash{}
+	if len(m.options.LogTopics) > 0 {
+		topics = append(topics, m.options.LogTopics)
+	}
+	return topics
+}
+
+func (m *Monitor) logsCacheKey(blockHash common.Hash, addresses []common.Address, topics [][]common.Hash) string {
 	topicsDigest := xxhash.New()
 	for _, hashes := range topics {
 		for _, hash := range hashes {
@@ -730,13 +1324,89 @@ func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, topics
 		topicsDigest.Write([]byte{'\n'})
 	}
 
-	key := fmt.Sprintf("ethmonitor:%s:Logs:hash=%s;topics=%d", m.chainID.String(), blockHash.String(), topicsDigest.Sum64())
-	resp, err := m.cache.GetOrSetWithLockEx(ctx, key, getter, m.options.CacheExpiry)
-	if err != nil {
-		return nil, resp, err
+	addressesDigest := xxhash.New()
+	for _, address := range addresses {
+		addressesDigest.Write(address.Bytes())
 	}
-	logs, err := m.unmarshalLogs(resp)
-	return logs, resp, err
+
+	return fmt.Sprintf("ethmonitor:%s:Logs:hash=%s;addresses=%d;topics=%d", m.chainID.String(), blockHash.String(), addressesDigest.Sum64(), topicsDigest.Sum64())
+}
+
+// RefetchBlockLogs fetches the logs of a retained block from the node again, bypassing
+// the cache, ie. when a node served incomplete logs for the block which later became
+// available. The logs of the retained block are replaced, and if the block was already
+// published, it's published to subscribers again as an Updated event.
+//
+// The logsBloom of the block is checked against the logs like for any new block, so
+// ErrLogsUnavailable is returned, and the retained block is left as is, if the node
+// still serves no logs for a block whose logsBloom expects some.
+func (m *Monitor) RefetchBlockLogs(ctx context.Context, blockHash common.Hash) (*Block, error) {
+	if !m.options.WithLogs {
+		return nil, fmt.Errorf("ethmonitor: RefetchBlockLogs requires the WithLogs option")
+	}
+
+	m.chain.mu.Lock()
+	block, _ := m.chain.retainedBlock(blockHash)
+	m.chain.mu.Unlock()
+	if block == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, blockHash.Hex())
+	}
+	if block.Sampled {
+		return nil, fmt.Errorf("ethmonitor: block %s has been sampled, and its logs can't be refetched", blockHash.Hex())
+	}
+
+	if m.cache != nil {
+		err := m.cache.Delete(ctx, m.logsCacheKey(blockHash, m.options.LogAddresses, m.logTopics()))
+		if err != nil {
+			m.log.Warnf("ethmonitor: failed to delete cached logs of block %s: %v", blockHash.Hex(), err)
+		}
+	}
+
+	// the retained block is replaced rather than updated in place, as it may already
+	// be held by subscribers. The logs are fetched without holding the chain lock, so
+	// the block is looked up again after, in case it was reorged meanwhile.
+	refetched := &Block{
+		Block:        block.Block,
+		Event:        Added,
+		BlockPayload: block.BlockPayload,
+	}
+	m.addLogs(ctx, Blocks{refetched})
+	if !refetched.OK {
+		return nil, fmt.Errorf("%w: %s", ErrLogsUnavailable, blockHash.Hex())
+	}
+
+	m.chain.mu.Lock()
+	block, idx := m.chain.retainedBlock(blockHash)
+	if block == nil {
+		m.chain.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, blockHash.Hex())
+	}
+	m.chain.blocks[idx] = refetched
+
+	// blocks which are still queued are published with their new logs as usual
+	queued := m.publishQueue.replace(refetched)
+	m.chain.mu.Unlock()
+
+	if queued || !block.OK {
+		return refetched, nil
+	}
+
+	m.mu.Lock()
+	if len(m.subscribers) == 0 && m.options.EventWriter == nil && m.options.OnBeforePublish == nil {
+		m.mu.Unlock()
+		return refetched, nil
+	}
+	m.mu.Unlock()
+
+	updated := *refetched
+	updated.Event = Updated
+	select {
+	case m.publishCh <- Blocks{&updated}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return refetched, nil
 }
 
 func (m *Monitor) backfillChainLogs(ctx context.Context, newBlocks Blocks) {
@@ -799,14 +1469,14 @@ func (m *Monitor) fetchNextBlock(ctx context.Context) (*types.Block, []byte, boo
 					// in streaming mode, we'll use a shorter time to pause before we refetch
 					time.Sleep(200 * time.Millisecond)
 				} else {
-					time.Sleep(m.options.PollingInterval)
+					m.waitPollingInterval(ctx)
 				}
 				continue
 			}
 			if err != nil {
 				m.log.Warnf("ethmonitor: [retrying] failed to fetch next block # %d, due to: %v", m.nextBlockNumber, err)
 				miss = true
-				time.Sleep(m.options.PollingInterval)
+				m.waitPollingInterval(ctx)
 				continue
 			}
 
@@ -845,6 +1515,39 @@ func cacheKeyBlockNum(chainID *big.Int, num *big.Int) string {
 	return fmt.Sprintf("ethmonitor:%s:BlockNum:%s", chainID.String(), num.String())
 }
 
+func cacheKeyBlockHash(chainID *big.Int, hash common.Hash) string {
+	return fmt.Sprintf("ethmonitor:%s:BlockHash:%s", chainID.String(), hash.String())
+}
+
+// verifyBlockHash verifies the block returned by the node hashes to the hash reported by
+// the node, and is the block requested by hash or number, if any. On mismatch, the block
+// is purged from the cache under cacheKey so it's refetched from the node on retry.
+func (m *Monitor) verifyBlockHash(ctx context.Context, block *types.Block, cacheKey string, requestedHash common.Hash, requestedNum *big.Int) error {
+	var err error
+	computedHash := block.ComputedBlockHash()
+	switch {
+	case computedHash != block.Hash():
+		err = fmt.Errorf("%w: block #%d hash %s does not match its computed hash %s", ErrInvalidBlockHash, block.NumberU64(), block.Hash().Hex(), computedHash.Hex())
+	case requestedHash != (common.Hash{}) && block.Hash() != requestedHash:
+		err = fmt.Errorf("%w: requested block %s, but got block #%d hash %s", ErrInvalidBlockHash, requestedHash.Hex(), block.NumberU64(), block.Hash().Hex())
+	case requestedNum != nil && block.NumberU64() != requestedNum.Uint64():
+		err = fmt.Errorf("%w: requested block #%d, but got block #%d hash %s", ErrInvalidBlockHash, requestedNum.Uint64(), block.NumberU64(), block.Hash().Hex())
+	}
+	if err == nil {
+		return nil
+	}
+
+	m.log.Warnf("ethmonitor: %v, retrying..", err)
+	m.alert.Alert(context.Background(), "ethmonitor (chain %s): %v", m.chainID.String(), err)
+
+	if m.cache != nil && cacheKey != "" {
+		if err := m.cache.Delete(ctx, cacheKey); err != nil {
+			m.log.Warnf("ethmonitor: error deleting block cache %s due to: '%v'", cacheKey, err)
+		}
+	}
+	return err
+}
+
 func (m *Monitor) fetchRawBlockByNumber(ctx context.Context, num *big.Int) ([]byte, error) {
 	if m.options.DebugLogging {
 		m.log.Debugf("ethmonitor: fetchRawBlockByNumber is calling origin for number %s", num)
@@ -869,7 +1572,7 @@ func (m *Monitor) fetchRawBlockByNumber(ctx context.Context, num *big.Int) ([]by
 		tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
 		defer cancel()
 
-		blockPayload, err = m.provider.RawBlockByNumber(tctx, num)
+		blockPayload, err = m.rawProvider().RawBlockByNumber(tctx, num)
 		if err != nil {
 			if errors.Is(err, ethereum.NotFound) {
 				return nil, ethereum.NotFound
@@ -910,7 +1613,7 @@ func (m *Monitor) fetchBlockByHash(ctx context.Context, hash common.Hash) (*type
 				return nil, superr.New(ErrMaxAttempts, err)
 			}
 
-			blockPayload, err = m.provider.RawBlockByHash(ctx, hash)
+			blockPayload, err = m.rawProvider().RawBlockByHash(ctx, hash)
 			if err != nil {
 				if errors.Is(err, ethereum.NotFound) {
 					notFoundAttempts++
@@ -939,7 +1642,7 @@ func (m *Monitor) fetchBlockByHash(ctx context.Context, hash common.Hash) (*type
 	}
 
 	// fetch with distributed mutex
-	key := fmt.Sprintf("ethmonitor:%s:BlockHash:%s", m.chainID.String(), hash.String())
+	key := cacheKeyBlockHash(m.chainID, hash)
 	resp, err := m.cache.GetOrSetWithLockEx(ctx, key, getter, m.options.CacheExpiry)
 	if err != nil {
 		return nil, nil, err
@@ -948,10 +1651,51 @@ func (m *Monitor) fetchBlockByHash(ctx context.Context, hash common.Hash) (*type
 	return block, resp, err
 }
 
+func (m *Monitor) restoreChain(ctx context.Context) error {
+	events, err := m.options.ChainStore.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("ethmonitor: failed to load chain from store: %w", err)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	// an empty, but initialized, chain is needed in case we're in bootstrap mode
+	m.chain.mu.Lock()
+	if m.chain.blocks == nil {
+		m.chain.blocks = make(Blocks, 0, m.chain.retentionLimit)
+	}
+	m.chain.mu.Unlock()
+
+	if err := m.chain.restoreBlocks(events); err != nil {
+		return fmt.Errorf("ethmonitor: failed to restore chain from store: %w", err)
+	}
+
+	m.log.Infof("ethmonitor: restored chain from store with head block=%d", m.chain.Head().NumberU64())
+	return nil
+}
+
+func (m *Monitor) saveChain(ctx context.Context, events Blocks) {
+	if m.options.ChainStore == nil || len(events) == 0 {
+		return
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+	defer cancel()
+
+	// NOTE: failing to save is not fatal, as the monitor can continue, but a
+	// restart would resume from an older point of the chain.
+	err := m.options.ChainStore.Save(tctx, events)
+	if err != nil {
+		m.log.Warnf("ethmonitor: failed to save chain to store: %v", err)
+		m.alert.Alert(context.Background(), "ethmonitor (chain %s): failed to save chain to store: %v", m.chainID.String(), err)
+	}
+}
+
 func (m *Monitor) publish(ctx context.Context, events Blocks) error {
 	// skip publish enqueuing if there are no subscribers
 	m.mu.Lock()
-	if len(m.subscribers) == 0 {
+	if len(m.subscribers) == 0 && m.options.EventWriter == nil && m.options.OnBeforePublish == nil {
 		m.mu.Unlock()
 		return nil
 	}
@@ -959,8 +1703,12 @@ func (m *Monitor) publish(ctx context.Context, events Blocks) error {
 
 	// Check for trail-behind-head mode and set maxBlockNum if applicable
 	maxBlockNum := uint64(0)
+	trail := false
 	if m.options.TrailNumBlocksBehindHead > 0 {
-		maxBlockNum = m.LatestBlock().NumberU64() - uint64(m.options.TrailNumBlocksBehindHead)
+		trail = true
+		if head := m.LatestBlock().NumberU64(); head > uint64(m.options.TrailNumBlocksBehindHead) {
+			maxBlockNum = head - uint64(m.options.TrailNumBlocksBehindHead)
+		}
 	}
 
 	// Enqueue
@@ -969,24 +1717,203 @@ func (m *Monitor) publish(ctx context.Context, events Blocks) error {
 		return err
 	}
 
+	// nothing is far enough behind the head to publish yet
+	if trail && maxBlockNum == 0 {
+		return nil
+	}
+
 	// Publish events existing in the queue
 	pubEvents, ok := m.publishQueue.dequeue(maxBlockNum)
-	if ok {
-		m.publishCh <- pubEvents
+	if !ok {
+		return nil
+	}
+
+	if m.options.PublishFinalizedOnly {
+		pubEvents = m.dropRemovedEvents(pubEvents)
+		if len(pubEvents) == 0 {
+			return nil
+		}
+	}
+
+	// the broadcast of the previous events may be retrying OnBeforePublish, which ends
+	// once the monitor is stopped, so the events are dropped then
+	select {
+	case m.publishCh <- pubEvents:
+	case <-ctx.Done():
+		return nil
 	}
+	m.lastPublishedAt.Store(time.Now().UnixNano())
 
 	return nil
 }
 
+// dropRemovedEvents filters out Removed events of blocks which have already been
+// published as final, which only happens when a reorg is deeper than NumBlocksToFinality.
+func (m *Monitor) dropRemovedEvents(events Blocks) Blocks {
+	out := make(Blocks, 0, len(events))
+	for _, ev := range events {
+		if ev.Event == Removed {
+			m.log.Warnf("ethmonitor: finalized block %d %s was removed by a reorg deeper than NumBlocksToFinality", ev.NumberU64(), ev.Hash().Hex())
+			m.alert.Alert(context.Background(), "ethmonitor (chain %s): finalized block %d was removed by a reorg deeper than NumBlocksToFinality=%d", m.chainID.String(), ev.NumberU64(), m.options.NumBlocksToFinality)
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
 func (m *Monitor) broadcast(events Blocks) {
+	// subscribers are sent to without holding the lock, as sending to a slow subscriber
+	// with BackpressureBlock blocks until it reads, which would otherwise also block
+	// Subscribe, Unsubscribe and the other subscribers' calls meanwhile
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	subscribers := make([]*subscriber, len(m.subscribers))
+	copy(subscribers, m.subscribers)
+	m.mu.Unlock()
 
-	for _, sub := range m.subscribers {
-		sub.ch.Send(events)
+	// events are deduped once per label for the whole batch, rather than per subscriber
+	dedupe := m.options.DedupeSubscribers && m.cache != nil
+	var dedupedEvents map[string]Blocks
+	if dedupe {
+		dedupedEvents = map[string]Blocks{}
+	}
+
+	for _, sub := range subscribers {
+		subEvents := events
+		if dedupe && sub.label != "" {
+			deduped, ok := dedupedEvents[sub.label]
+			if !ok {
+				deduped = m.dedupeEvents(sub.label, events)
+				dedupedEvents[sub.label] = deduped
+			}
+			if len(deduped) == 0 {
+				continue
+			}
+			subEvents = deduped
+		}
+
+		err := m.send(sub, subEvents)
+		if err != nil {
+			// slow subscriber with BackpressureUnsubscribe
+			m.log.Warnf("ethmonitor: unsubscribing slow subscriber %s: %v", sub.label, err)
+			m.alert.Alert(context.Background(), "ethmonitor: unsubscribing slow subscriber %s: %v", sub.label, err)
+			sub.setErr(err)
+			sub.Unsubscribe()
+		}
+	}
+
+	for label, deduped := range dedupedEvents {
+		m.setLastDeliveredBlockNum(label, deduped)
+	}
+}
+
+// subscriberQueueWarnDepth is the queue depth of a subscriber above which the monitor
+// warns that the subscriber is slow.
+const subscriberQueueWarnDepth = 10
+
+func (m *Monitor) send(sub *subscriber, events Blocks) error {
+	err := sub.queue.send(events)
+	if err != nil {
+		return err
+	}
+	if depth := sub.queue.depth(); depth > subscriberQueueWarnDepth {
+		m.log.Warnf("ethmonitor: subscriber %s queue holds %d > %d event batches", sub.label, depth, subscriberQueueWarnDepth)
+	}
+	return nil
+}
+
+// beforePublish calls the OnBeforePublish hook with the events, retrying after the polling
+// interval for as long as it fails. It returns false if the context is done first.
+func (m *Monitor) beforePublish(ctx context.Context, events Blocks) bool {
+	if m.options.OnBeforePublish == nil {
+		return true
+	}
+
+	for {
+		err := m.options.OnBeforePublish(ctx, events)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		m.log.Warnf("ethmonitor: OnBeforePublish failed for block %d: %v, retrying..", events[len(events)-1].NumberU64(), err)
+		m.alert.Alert(context.Background(), "ethmonitor (chain %s): OnBeforePublish failed: %v", m.chainID.String(), err)
+
+		m.waitPollingInterval(ctx)
+		if ctx.Err() != nil {
+			return false
+		}
 	}
 }
 
+func (m *Monitor) writeEvents(events Blocks) {
+	if m.options.EventWriter == nil {
+		return
+	}
+
+	var head uint64
+	if headBlock := m.chain.Head(); headBlock != nil {
+		head = headBlock.NumberU64()
+	}
+
+	err := m.options.EventWriter.Write(m.chainID, head, events)
+	if err != nil {
+		m.log.Warnf("ethmonitor: failed to write events: %v", err)
+	}
+}
+
+// dedupeEvents returns the events to deliver to the subscribers with the label, skipping
+// any block which was already delivered to a subscriber with the same label, as tracked
+// in the cache.
+func (m *Monitor) dedupeEvents(label string, events Blocks) Blocks {
+	if m.lastDeliveredBlockNums == nil {
+		m.lastDeliveredBlockNums = map[string]uint64{}
+	}
+
+	lastBlockNum, ok := m.lastDeliveredBlockNums[label]
+	if !ok {
+		data, ok, err := m.cache.Get(m.ctx, m.lastDeliveredBlockNumKey(label))
+		if err != nil {
+			m.log.Warnf("ethmonitor: failed to get last delivered block for subscriber %s: %v", label, err)
+		} else if ok {
+			lastBlockNum, _ = strconv.ParseUint(string(data), 10, 64)
+		}
+		m.lastDeliveredBlockNums[label] = lastBlockNum
+	}
+
+	// skip blocks which have been delivered, until we see a reorg, as every
+	// block after it must be delivered again
+	var i int
+	for i = 0; i < len(events); i++ {
+		if events[i].Event != Added || events[i].NumberU64() > lastBlockNum {
+			break
+		}
+	}
+	return events[i:]
+}
+
+// setLastDeliveredBlockNum records the latest block of the events delivered to the
+// subscribers with the label, in memory and in the cache.
+func (m *Monitor) setLastDeliveredBlockNum(label string, events Blocks) {
+	latestBlock := events.LatestBlock()
+	if latestBlock == nil {
+		return
+	}
+	lastBlockNum := latestBlock.NumberU64()
+	m.lastDeliveredBlockNums[label] = lastBlockNum
+
+	err := m.cache.Set(m.ctx, m.lastDeliveredBlockNumKey(label), []byte(strconv.FormatUint(lastBlockNum, 10)))
+	if err != nil {
+		m.log.Warnf("ethmonitor: failed to set last delivered block for subscriber %s: %v", label, err)
+	}
+}
+
+func (m *Monitor) lastDeliveredBlockNumKey(label string) string {
+	return fmt.Sprintf("ethmonitor:%s:Subscriber:%s:LastBlockNum", m.chainID.String(), label)
+}
+
 func (m *Monitor) Subscribe(optLabel ...string) Subscription {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -997,18 +1924,14 @@ func (m *Monitor) Subscribe(optLabel ...string) Subscription {
 	}
 
 	subscriber := &subscriber{
-		ch: channel.NewUnboundedChan[Blocks](10, 5000, channel.Options{
-			Logger:  m.log,
-			Alerter: m.alert,
-			Label:   label,
-		}),
-		done: make(chan struct{}),
+		label: label,
+		queue: newSubscriberQueue(m.options.SubscriberQueueCap, m.options.SubscriberBackpressure),
+		done:  make(chan struct{}),
 	}
 
 	subscriber.unsubscribe = func() {
 		close(subscriber.done)
-		subscriber.ch.Close()
-		subscriber.ch.Flush()
+		subscriber.queue.close()
 
 		m.mu.Lock()
 		defer m.mu.Unlock()
@@ -1026,6 +1949,44 @@ func (m *Monitor) Subscribe(optLabel ...string) Subscription {
 	return subscriber
 }
 
+// WaitBlocks subscribes to the monitor and waits for the next n blocks to be published,
+// returning the published events in order. Removed events of reorgs are included in the
+// returned blocks, but only Added blocks count towards n.
+//
+// If the context is done or the monitor stops before n blocks are published, the blocks
+// collected so far are returned along with the error.
+func (m *Monitor) WaitBlocks(ctx context.Context, n int) (Blocks, error) {
+	sub := m.Subscribe()
+	defer sub.Unsubscribe()
+
+	blocks := Blocks{}
+	numAdded := 0
+	for numAdded < n {
+		select {
+		case <-ctx.Done():
+			return blocks, ctx.Err()
+
+		case <-sub.Done():
+			if err := sub.Err(); err != nil {
+				return blocks, err
+			}
+			return blocks, ErrMonitorStopped
+
+		case events := <-sub.Blocks():
+			for _, event := range events {
+				if numAdded == n {
+					break
+				}
+				blocks = append(blocks, event)
+				if event.Event == Added {
+					numAdded++
+				}
+			}
+		}
+	}
+	return blocks, nil
+}
+
 func (m *Monitor) Chain() *Chain {
 	return m.chain
 }
@@ -1083,6 +2044,91 @@ func (m *Monitor) LatestFinalBlock(numBlocksToFinality int) *Block {
 	}
 }
 
+// LatestSafeBlock returns the latest block which the node considers safe, as in unlikely
+// to be reorged, which is a middle ground between LatestBlock and LatestFinalBlock. The
+// node's "safe" head is polled every SafeBlockPollInterval if set. Otherwise, or on chains
+// which don't support the "safe" block tag, the block NumBlocksToSafe behind the head is
+// returned.
+//
+// nil is returned if the safe block is no longer retained in the canonical chain, or
+// not enough blocks have been monitored yet.
+func (m *Monitor) LatestSafeBlock() *Block {
+	head := m.chain.Head()
+	if head == nil {
+		return nil
+	}
+
+	safeBlockNum := m.safeBlockNum.Load()
+	if safeBlockNum == 0 {
+		numBlocksToSafe := uint64(m.options.NumBlocksToSafe)
+		if numBlocksToSafe == 0 || head.NumberU64() < numBlocksToSafe {
+			return nil
+		}
+		safeBlockNum = head.NumberU64() - numBlocksToSafe
+	}
+
+	// the monitor is behind the safe head of the node
+	if safeBlockNum >= head.NumberU64() {
+		return head
+	}
+	return m.chain.GetBlockByNumber(safeBlockNum, Added)
+}
+
+// pollSafeBlock polls the node's "safe" head every SafeBlockPollInterval. If the node
+// fails to return it, ie. the chain doesn't support the "safe" block tag, the safe block
+// number is cleared so LatestSafeBlock falls back to NumBlocksToSafe.
+func (m *Monitor) pollSafeBlock(ctx context.Context) {
+	ticker := time.NewTicker(m.options.SafeBlockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+		header, err := m.rawProvider().HeaderByNumber(tctx, ethrpc.Safe)
+		cancel()
+
+		if err != nil || header == nil || header.Number == nil {
+			if m.options.DebugLogging {
+				m.log.Debugf("ethmonitor: failed to fetch safe head, falling back to NumBlocksToSafe: %v", err)
+			}
+			m.safeBlockNum.Store(0)
+		} else {
+			m.safeBlockNum.Store(header.Number.Uint64())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollNetworkHead polls the network head block number every HeadPollInterval.
+func (m *Monitor) pollNetworkHead(ctx context.Context) {
+	ticker := time.NewTicker(m.options.HeadPollInterval)
+	defer ticker.Stop()
+
+	for {
+		tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+		headNum, err := m.rawProvider().BlockNumber(tctx)
+		cancel()
+
+		if err != nil {
+			if m.options.DebugLogging {
+				m.log.Debugf("ethmonitor: failed to fetch network head: %v", err)
+			}
+		} else {
+			m.setNetworkHeadNum(headNum)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func (m *Monitor) OldestBlockNum() *big.Int {
 	oldestBlock := m.chain.Tail()
 	if oldestBlock == nil {
@@ -1092,13 +2138,23 @@ func (m *Monitor) OldestBlockNum() *big.Int {
 	}
 }
 
-// GetBlock will search the retained blocks for the hash
+// GetBlock will search the retained blocks for the hash. With BlockSamplingInterval,
+// only the header is returned for sampled-out blocks.
 func (m *Monitor) GetBlock(blockHash common.Hash) *Block {
 	return m.chain.GetBlock(blockHash)
 }
 
+// IsCanonical reports whether the block hash is still on the canonical chain, ie. to
+// validate state derived from a block retained earlier, after any reorgs since. It's false
+// for blocks which were reorged out, and for blocks older than the retained chain, see
+// BlockRetentionLimit.
+func (m *Monitor) IsCanonical(blockHash common.Hash) bool {
+	return m.chain.IsCanonical(blockHash)
+}
+
 // GetBlock will search within the retained canonical chain for the txn hash. Passing `optMined true`
 // will only return transaction which have not been removed from the chain via a reorg.
+// With BlockSamplingInterval, transactions of sampled-out blocks return nil.
 func (m *Monitor) GetTransaction(txnHash common.Hash) (*types.Transaction, Event) {
 	return m.chain.GetTransaction(txnHash)
 }
@@ -1114,6 +2170,24 @@ func (m *Monitor) NumSubscribers() int {
 	return len(m.subscribers)
 }
 
+// SubscriberStats returns the queue metrics of each subscriber, in order of subscription,
+// so slow subscribers can be spotted by their queue depth.
+func (m *Monitor) SubscriberStats() []SubscriberStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]SubscriberStats, 0, len(m.subscribers))
+	for _, sub := range m.subscribers {
+		depth, dropped := sub.queue.stats()
+		stats = append(stats, SubscriberStats{
+			Label:      sub.label,
+			QueueDepth: depth,
+			Dropped:    dropped,
+		})
+	}
+	return stats
+}
+
 func (m *Monitor) UnsubscribeAll(err error) {
 	m.mu.Lock()
 	var subs []*subscriber
@@ -1121,7 +2195,7 @@ func (m *Monitor) UnsubscribeAll(err error) {
 	m.mu.Unlock()
 
 	for _, sub := range subs {
-		sub.err = err
+		sub.setErr(err)
 		sub.Unsubscribe()
 	}
 }
@@ -1179,7 +2253,7 @@ func (m *Monitor) unmarshalBlock(blockPayload []byte) (*types.Block, error) {
 	var block *types.Block
 
 	var strictness ethrpc.StrictnessLevel
-	getStrictnessLevel, ok := m.provider.(ethrpc.StrictnessLevelGetter)
+	getStrictnessLevel, ok := m.rawProvider().(ethrpc.StrictnessLevelGetter)
 	if !ok {
 		// default to no validation if provider does not support strictness
 		// level interface
@@ -2,19 +2,28 @@ package ethmonitor_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/0xsequence/ethkit/ethmonitor"
 	"github.com/0xsequence/ethkit/ethrpc"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/0xsequence/ethkit/util"
 	"github.com/go-chi/httpvcr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMonitorBasic(t *testing.T) {
@@ -295,3 +304,561 @@ func TestMonitorWithReorgme(t *testing.T) {
 
 	monitor.Stop()
 }
+
+func TestMonitorSetProvider(t *testing.T) {
+	newNode := func(chainID string) *ethrpc.Provider {
+		node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				ID uint64 `json:"id"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, chainID)
+		}))
+		t.Cleanup(node.Close)
+
+		provider, err := ethrpc.NewProvider(node.URL)
+		require.NoError(t, err)
+		return provider
+	}
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.ChainID = big.NewInt(1)
+
+	provider := newNode("0x1")
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+
+	// provider of another chain
+	err = monitor.SetProvider(newNode("0x89"))
+	require.Error(t, err)
+	require.Same(t, provider, monitor.Provider())
+
+	provider = newNode("0x1")
+	require.NoError(t, monitor.SetProvider(provider))
+	require.Same(t, provider, monitor.Provider())
+}
+
+func TestMonitorMaxStartupCatchupBlocks(t *testing.T) {
+	requestedBlockNums := make(chan string, 10)
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "eth_chainId":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+		case "eth_blockNumber":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x3e8"}`, req.ID) // 1000
+		case "eth_getBlockByNumber":
+			select {
+			case requestedBlockNums <- string(req.Params[0]):
+			default:
+			}
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":null}`, req.ID)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.StartBlockNumber = big.NewInt(100)
+	monitorOptions.MaxStartupCatchupBlocks = 50
+	monitorOptions.PollingInterval = 10 * time.Millisecond
+
+	// fail to start from 900 blocks behind the head
+	monitorOptions.StartupCatchupPolicy = ethmonitor.StartupCatchupError
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+	err = monitor.Run(context.Background())
+	require.ErrorIs(t, err, ethmonitor.ErrStartBlockTooOld)
+
+	// start from 50 blocks behind the head instead
+	monitorOptions.StartupCatchupPolicy = ethmonitor.StartupCatchupFastForward
+	monitor, err = ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+	go monitor.Run(context.Background())
+	defer monitor.Stop()
+
+	select {
+	case blockNum := <-requestedBlockNums:
+		require.Equal(t, `"0x3b6"`, blockNum) // 950
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the monitor to fetch a block")
+	}
+}
+
+func TestMonitorStartBlockHash(t *testing.T) {
+	// canonical chain of blocks 0..5, and a fork of blocks 3..4 off block 2
+	var canonical, fork []*types.Header
+	for i := 0; i <= 5; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(0)}
+		header.SetHash(common.BigToHash(big.NewInt(int64(0xa0 + i))))
+		if i > 0 {
+			header.ParentHash = canonical[i-1].Hash()
+		}
+		canonical = append(canonical, header)
+	}
+	for i := 3; i <= 4; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(0)}
+		header.SetHash(common.BigToHash(big.NewInt(int64(0xb0 + i))))
+		if i == 3 {
+			header.ParentHash = canonical[2].Hash()
+		} else {
+			header.ParentHash = fork[0].Hash()
+		}
+		fork = append(fork, header)
+	}
+
+	requestedBlockNums := make(chan string, 10)
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		respond := func(result any) {
+			data, _ := json.Marshal(result)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, data)
+		}
+
+		switch req.Method {
+		case "eth_chainId":
+			respond("0x1")
+		case "eth_getBlockByHash":
+			var hash common.Hash
+			json.Unmarshal(req.Params[0], &hash)
+			for _, header := range append(canonical, fork...) {
+				if header.Hash() == hash {
+					respond(header)
+					return
+				}
+			}
+			respond(nil)
+		case "eth_getBlockByNumber":
+			// full blocks are fetched by the monitor once started
+			if string(req.Params[1]) == "true" {
+				select {
+				case requestedBlockNums <- string(req.Params[0]):
+				default:
+				}
+				respond(nil)
+				return
+			}
+			var num hexutil.Uint64
+			json.Unmarshal(req.Params[0], &num)
+			if int(num) < len(canonical) {
+				respond(canonical[num])
+				return
+			}
+			respond(nil)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	run := func(startBlockHash common.Hash) (string, error) {
+		monitorOptions := ethmonitor.DefaultOptions
+		monitorOptions.StartBlockHash = &startBlockHash
+		monitorOptions.PollingInterval = 10 * time.Millisecond
+
+		monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+		require.NoError(t, err)
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- monitor.Run(context.Background()) }()
+		defer monitor.Stop()
+
+		select {
+		case blockNum := <-requestedBlockNums:
+			return blockNum, nil
+		case err := <-errCh:
+			return "", err
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the monitor to fetch a block")
+			return "", nil
+		}
+	}
+
+	// resumes after the canonical start block
+	blockNum, err := run(canonical[4].Hash())
+	require.NoError(t, err)
+	require.Equal(t, `"0x5"`, blockNum)
+
+	// resumes after the common ancestor of the reorged start block
+	blockNum, err = run(fork[1].Hash())
+	require.NoError(t, err)
+	require.Equal(t, `"0x3"`, blockNum)
+
+	// fails on an unknown start block
+	_, err = run(common.HexToHash("0x1234"))
+	require.ErrorIs(t, err, ethmonitor.ErrStartBlockUnresolvable)
+}
+
+func TestMonitorIsCaughtUp(t *testing.T) {
+	var headers []*types.Header
+	for i := 0; i <= 10; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(0)}
+		header.SetHash(common.BigToHash(big.NewInt(int64(0xa0 + i))))
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash()
+		}
+		headers = append(headers, header)
+	}
+
+	// the node has a head of 10, but only serves blocks up to numBlocks
+	var numBlocks atomic.Int64
+	numBlocks.Store(5)
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		respond := func(result any) {
+			data, _ := json.Marshal(result)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, data)
+		}
+
+		switch req.Method {
+		case "eth_chainId":
+			respond("0x1")
+		case "eth_blockNumber":
+			respond("0xa")
+		case "eth_getBlockByNumber":
+			var num hexutil.Uint64
+			json.Unmarshal(req.Params[0], &num)
+			if int64(num) > numBlocks.Load() {
+				respond(nil)
+				return
+			}
+			block := map[string]any{}
+			data, _ := json.Marshal(headers[num])
+			json.Unmarshal(data, &block)
+			block["transactions"] = []any{}
+			block["uncles"] = []any{}
+			respond(block)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.StartBlockNumber = big.NewInt(0)
+	monitorOptions.HeadPollInterval = 10 * time.Millisecond
+	monitorOptions.CaughtUpThreshold = 2
+	monitorOptions.PollingInterval = 10 * time.Millisecond
+
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+	require.False(t, monitor.IsCaughtUp())
+
+	go monitor.Run(context.Background())
+	defer monitor.Stop()
+
+	// 5 blocks behind the head
+	require.Eventually(t, func() bool {
+		return monitor.LatestBlockNum().Int64() == 5 && monitor.Status().HeadBlockNum == 10
+	}, 5*time.Second, 10*time.Millisecond)
+	require.False(t, monitor.IsCaughtUp())
+
+	// within 2 blocks of the head
+	numBlocks.Store(8)
+	require.Eventually(t, monitor.IsCaughtUp, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMonitorSetPollingInterval(t *testing.T) {
+	var headers []*types.Header
+	for i := 0; i <= 10; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(0)}
+		header.SetHash(common.BigToHash(big.NewInt(int64(0xb0 + i))))
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash()
+		}
+		headers = append(headers, header)
+	}
+
+	// the node only serves blocks up to numBlocks
+	var numBlocks atomic.Int64
+	numBlocks.Store(3)
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		respond := func(result any) {
+			data, _ := json.Marshal(result)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, data)
+		}
+
+		switch req.Method {
+		case "eth_chainId":
+			respond("0x1")
+		case "eth_blockNumber":
+			respond(hexutil.Uint64(numBlocks.Load()))
+		case "eth_getBlockByNumber":
+			var num hexutil.Uint64
+			json.Unmarshal(req.Params[0], &num)
+			if int64(num) > numBlocks.Load() {
+				respond(nil)
+				return
+			}
+			block := map[string]any{}
+			data, _ := json.Marshal(headers[num])
+			json.Unmarshal(data, &block)
+			block["transactions"] = []any{}
+			block["uncles"] = []any{}
+			respond(block)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.StartBlockNumber = big.NewInt(0)
+	monitorOptions.PollingInterval = 10 * time.Millisecond
+
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+	require.Error(t, monitor.SetPollingInterval(0))
+
+	go monitor.Run(context.Background())
+	defer monitor.Stop()
+
+	require.Eventually(t, func() bool {
+		return monitor.LatestBlockNum().Int64() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// idle, so new blocks are not picked up until the next poll
+	require.NoError(t, monitor.SetPollingInterval(time.Hour))
+	require.Equal(t, time.Hour, monitor.Options().PollingInterval)
+	require.Equal(t, time.Hour, monitor.Status().PollInterval)
+
+	time.Sleep(50 * time.Millisecond)
+	numBlocks.Store(5)
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, int64(3), monitor.LatestBlockNum().Int64())
+
+	// active again, where the poll waiting on the previous interval is woken up
+	require.NoError(t, monitor.SetPollingInterval(10*time.Millisecond))
+	require.Eventually(t, func() bool {
+		return monitor.LatestBlockNum().Int64() == 5
+	}, 5*time.Second, 10*time.Millisecond)
+	require.LessOrEqual(t, monitor.Status().PollInterval, 10*time.Millisecond)
+}
+
+// newMockChainNode returns a node of the chain, which serves blocks up to numBlocks.
+func newMockChainNode(chainID uint64, numBlocks int) *httptest.Server {
+	var headers []*types.Header
+	for i := 0; i <= numBlocks; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(0), Extra: []byte{byte(chainID)}}
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash()
+		}
+		headers = append(headers, header)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		respond := func(result any) {
+			data, _ := json.Marshal(result)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, data)
+		}
+
+		switch req.Method {
+		case "eth_chainId":
+			respond(hexutil.Uint64(chainID))
+		case "eth_blockNumber":
+			respond(hexutil.Uint64(numBlocks))
+		case "eth_getBlockByNumber":
+			var num hexutil.Uint64
+			json.Unmarshal(req.Params[0], &num)
+			if int(num) > numBlocks {
+				respond(nil)
+				return
+			}
+			block := map[string]any{}
+			data, _ := json.Marshal(headers[num])
+			json.Unmarshal(data, &block)
+			block["transactions"] = []any{}
+			block["uncles"] = []any{}
+			respond(block)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+		}
+	}))
+}
+
+func TestMultiMonitor(t *testing.T) {
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.StartBlockNumber = big.NewInt(0)
+	monitorOptions.PollingInterval = 10 * time.Millisecond
+
+	multi := ethmonitor.NewMultiMonitor()
+	for chainID, numBlocks := range map[uint64]int{1: 3, 137: 5} {
+		node := newMockChainNode(chainID, numBlocks)
+		defer node.Close()
+
+		provider, err := ethrpc.NewProvider(node.URL)
+		require.NoError(t, err)
+		_, err = multi.AddChain(chainID, provider, monitorOptions)
+		require.NoError(t, err)
+	}
+
+	// the provider of the chain 10 monitor is of another chain
+	node := newMockChainNode(1, 3)
+	defer node.Close()
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+	_, err = multi.AddChain(10, provider, monitorOptions)
+	require.NoError(t, err)
+
+	_, err = multi.AddChain(1, provider, monitorOptions)
+	require.Error(t, err)
+	require.Equal(t, []uint64{1, 10, 137}, multi.ChainIDs())
+
+	sub := multi.Subscribe()
+	defer sub.Unsubscribe()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- multi.Run(context.Background())
+	}()
+
+	// blocks of each chain are delivered in order along with their chain id
+	next := map[uint64]uint64{}
+	for next[1] <= 3 || next[137] <= 5 {
+		select {
+		case chainBlocks := <-sub.Blocks():
+			for _, block := range chainBlocks.Blocks {
+				require.Equal(t, byte(chainBlocks.ChainID), block.Extra()[0])
+				require.Equal(t, next[chainBlocks.ChainID], block.NumberU64())
+				next[chainBlocks.ChainID] = block.NumberU64() + 1
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for blocks, next %v", next)
+		}
+	}
+
+	// the failed chain doesn't stop the others, and is reported as unhealthy
+	require.Eventually(t, func() bool {
+		status := multi.Status()
+		return status.Chains[10].Err != nil && status.Chains[1].CaughtUp && status.Chains[137].CaughtUp
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status := multi.Status()
+	assert.False(t, status.Healthy)
+	assert.Equal(t, []uint64{10}, status.Unhealthy)
+	assert.True(t, status.Chains[1].Running)
+	assert.Equal(t, uint64(3), status.Chains[1].LatestBlockNum)
+	assert.Equal(t, uint64(5), status.Chains[137].LatestBlockNum)
+	assert.False(t, status.Chains[10].Running)
+
+	multi.Stop()
+	select {
+	case err := <-runErr:
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "chain 10")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the multi monitor to stop")
+	}
+	assert.False(t, multi.IsRunning())
+	assert.False(t, multi.Monitor(1).IsRunning())
+}
+
+func TestMonitorOnBeforePublish(t *testing.T) {
+	node := newMockChainNode(1, 5)
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	var monitor *ethmonitor.Monitor
+	var mu sync.Mutex
+	processed := map[uint64]bool{}
+	failures := 2
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.StartBlockNumber = big.NewInt(0)
+	monitorOptions.PollingInterval = 10 * time.Millisecond
+	monitorOptions.OnBeforePublish = func(ctx context.Context, blocks ethmonitor.Blocks) error {
+		// calling back into the monitor must not deadlock
+		_ = monitor.LatestBlock()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			return fmt.Errorf("store unavailable")
+		}
+		for _, block := range blocks {
+			processed[block.NumberU64()] = true
+		}
+		return nil
+	}
+
+	monitor, err = ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+
+	sub := monitor.Subscribe()
+	defer sub.Unsubscribe()
+
+	go monitor.Run(context.Background())
+	defer monitor.Stop()
+
+	// every block is processed by the hook before it's delivered to subscribers, even
+	// though the hook failed at first
+	next := uint64(0)
+	for next <= 5 {
+		select {
+		case blocks := <-sub.Blocks():
+			for _, block := range blocks {
+				mu.Lock()
+				assert.True(t, processed[block.NumberU64()], "block %d delivered before the hook", block.NumberU64())
+				mu.Unlock()
+				require.Equal(t, next, block.NumberU64())
+				next++
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for blocks, next %d", next)
+		}
+	}
+
+	mu.Lock()
+	assert.Equal(t, 0, failures)
+	mu.Unlock()
+}
@@ -0,0 +1,115 @@
+package ethmonitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// EventSchemaVersion is the version of the EventBatchMessage schema written by
+// EventWriter. It is bumped on any backwards-incompatible change to the schema,
+// while new fields may be added without a version change.
+const EventSchemaVersion = 1
+
+// EventBatchMessage is the json message written for each published batch of block events.
+type EventBatchMessage struct {
+	Version int    `json:"v"`
+	ChainID string `json:"chainId"`
+
+	// FinalBlockNum is the latest block number which has reached finality, and is only
+	// set when the number of blocks to finality is known.
+	FinalBlockNum *uint64 `json:"finalBlockNum,omitempty"`
+
+	Events []EventMessage `json:"events"`
+}
+
+// EventMessage is the json message for a single block event within an EventBatchMessage.
+type EventMessage struct {
+	Event      string      `json:"event"` // "added", "removed" or "updated"
+	Final      bool        `json:"final"`
+	Number     uint64      `json:"number"`
+	Hash       common.Hash `json:"hash"`
+	ParentHash common.Hash `json:"parentHash"`
+	Timestamp  uint64      `json:"timestamp"`
+	NumTxns    int         `json:"numTxns"`
+	Logs       []types.Log `json:"logs"`
+}
+
+func (e Event) String() string {
+	switch e {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Updated:
+		return "updated"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint32(e))
+	}
+}
+
+// EventWriter writes batches of block events as newline-delimited json to an io.Writer,
+// one EventBatchMessage per line, for consumption by external processes.
+type EventWriter struct {
+	w                   io.Writer
+	numBlocksToFinality int
+	mu                  sync.Mutex
+}
+
+// NewEventWriter returns an EventWriter which writes to w. Blocks are flagged as final
+// once they are numBlocksToFinality behind the head, where 0 disables finality flags.
+func NewEventWriter(w io.Writer, numBlocksToFinality int) *EventWriter {
+	return &EventWriter{w: w, numBlocksToFinality: numBlocksToFinality}
+}
+
+// Write encodes the block events as an EventBatchMessage, where head is the current
+// head block number of the chain, and writes it as a single line.
+func (e *EventWriter) Write(chainID *big.Int, head uint64, events Blocks) error {
+	msg := EventBatchMessage{
+		Version: EventSchemaVersion,
+		ChainID: chainID.String(),
+		Events:  make([]EventMessage, 0, len(events)),
+	}
+
+	if e.numBlocksToFinality > 0 && head >= uint64(e.numBlocksToFinality) {
+		finalBlockNum := head - uint64(e.numBlocksToFinality)
+		msg.FinalBlockNum = &finalBlockNum
+	}
+
+	for _, b := range events {
+		logs := b.Logs
+		if logs == nil {
+			logs = []types.Log{}
+		}
+		msg.Events = append(msg.Events, EventMessage{
+			Event:      b.Event.String(),
+			Final:      msg.FinalBlockNum != nil && b.Event == Added && b.NumberU64() <= *msg.FinalBlockNum,
+			Number:     b.NumberU64(),
+			Hash:       b.Hash(),
+			ParentHash: b.ParentHash(),
+			Timestamp:  b.Time(),
+			NumTxns:    len(b.Transactions()),
+			Logs:       logs,
+		})
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("ethmonitor: EventWriter failed to marshal events: %w", err)
+	}
+	data = append(data, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, err = e.w.Write(data)
+	if err != nil {
+		return fmt.Errorf("ethmonitor: EventWriter failed to write events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package ethmonitor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewEventWriter(&buf, 2)
+
+	blocks := mockBlockchain(5)
+
+	err := w.Write(big.NewInt(1), 3, Blocks{
+		{Block: blocks[0], Event: Added, OK: true},
+		{Block: blocks[1], Event: Added, OK: true, Logs: []types.Log{{Address: blocks[1].Coinbase(), BlockNumber: 2}}},
+		{Block: blocks[2], Event: Added, OK: true},
+	})
+	require.NoError(t, err)
+
+	err = w.Write(big.NewInt(1), 3, Blocks{{Block: blocks[2], Event: Removed, OK: true}})
+	require.NoError(t, err)
+
+	var msgs []EventBatchMessage
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var msg EventBatchMessage
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		msgs = append(msgs, msg)
+	}
+	require.Len(t, msgs, 2)
+
+	msg := msgs[0]
+	require.Equal(t, EventSchemaVersion, msg.Version)
+	require.Equal(t, "1", msg.ChainID)
+	require.NotNil(t, msg.FinalBlockNum)
+	require.Equal(t, uint64(1), *msg.FinalBlockNum)
+	require.Len(t, msg.Events, 3)
+	require.Equal(t, "added", msg.Events[0].Event)
+	require.True(t, msg.Events[0].Final)
+	require.False(t, msg.Events[1].Final)
+	require.Len(t, msg.Events[1].Logs, 1)
+	require.Equal(t, blocks[1].Hash(), msg.Events[1].Hash)
+	require.Equal(t, blocks[0].Hash(), msg.Events[1].ParentHash)
+
+	require.Equal(t, "removed", msgs[1].Events[0].Event)
+	require.False(t, msgs[1].Events[0].Final)
+}
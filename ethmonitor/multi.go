@@ -0,0 +1,330 @@
+package ethmonitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+// MultiMonitor runs the monitors of many chains together, keyed by chain id, with a single
+// Run and Stop, a combined Subscribe of the blocks of all chains, and an aggregated Status.
+//
+// The monitors of each chain run independently, so a monitor which fails doesn't stop the
+// monitors of the other chains. Its error is reported by Status, and returned by Run once
+// the MultiMonitor is stopped.
+type MultiMonitor struct {
+	monitors map[uint64]*Monitor
+	errs     map[uint64]error
+
+	ctx     context.Context
+	ctxStop context.CancelFunc
+	running int32
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+}
+
+func NewMultiMonitor() *MultiMonitor {
+	return &MultiMonitor{
+		monitors: map[uint64]*Monitor{},
+		errs:     map[uint64]error{},
+	}
+}
+
+// AddChain creates a monitor of the chain with its own options, and adds it to the
+// MultiMonitor, see AddMonitor. The ChainID option is set to chainID, so the monitor
+// fails to run if the provider is of another chain.
+func (mm *MultiMonitor) AddChain(chainID uint64, provider ethrpc.RawInterface, options ...Options) (*Monitor, error) {
+	opts := DefaultOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	opts.ChainID = new(big.Int).SetUint64(chainID)
+
+	monitor, err := NewMonitor(provider, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := mm.AddMonitor(chainID, monitor); err != nil {
+		return nil, err
+	}
+	return monitor, nil
+}
+
+// AddMonitor adds the monitor of the chain, which is started right away if the MultiMonitor
+// is running. Subscriptions made before the monitor is added don't include its blocks.
+func (mm *MultiMonitor) AddMonitor(chainID uint64, monitor *Monitor) error {
+	if monitor == nil {
+		return fmt.Errorf("ethmonitor: monitor is required")
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if _, ok := mm.monitors[chainID]; ok {
+		return fmt.Errorf("ethmonitor: monitor of chain %d already exists", chainID)
+	}
+	mm.monitors[chainID] = monitor
+
+	if mm.IsRunning() {
+		mm.runMonitor(mm.ctx, chainID, monitor)
+	}
+	return nil
+}
+
+// Monitor returns the monitor of the chain, or nil if there is none.
+func (mm *MultiMonitor) Monitor(chainID uint64) *Monitor {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	return mm.monitors[chainID]
+}
+
+// ChainIDs returns the chain ids of the monitors, in ascending order.
+func (mm *MultiMonitor) ChainIDs() []uint64 {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	return sortedKeys(mm.monitors)
+}
+
+// Run runs the monitors of all chains, until the context is done or Stop is called, and
+// then waits for all of them to stop. The errors of the monitors which failed are returned.
+func (mm *MultiMonitor) Run(ctx context.Context) error {
+	if mm.IsRunning() {
+		return fmt.Errorf("ethmonitor: already running")
+	}
+
+	mm.mu.Lock()
+	ctx, mm.ctxStop = context.WithCancel(ctx)
+	mm.ctx = ctx
+	atomic.StoreInt32(&mm.running, 1)
+	for chainID, monitor := range mm.monitors {
+		mm.runMonitor(ctx, chainID, monitor)
+	}
+	mm.mu.Unlock()
+
+	<-ctx.Done()
+
+	mm.mu.Lock()
+	atomic.StoreInt32(&mm.running, 0)
+	mm.mu.Unlock()
+
+	mm.wg.Wait()
+
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	var errs []error
+	for _, chainID := range sortedKeys(mm.errs) {
+		errs = append(errs, fmt.Errorf("ethmonitor: chain %d: %w", chainID, mm.errs[chainID]))
+	}
+	return errors.Join(errs...)
+}
+
+// runMonitor runs the monitor in the background with the context of Run, and records its
+// error once it stops. Must be called with mm.mu held.
+func (mm *MultiMonitor) runMonitor(ctx context.Context, chainID uint64, monitor *Monitor) {
+	delete(mm.errs, chainID)
+
+	mm.wg.Add(1)
+	go func() {
+		defer mm.wg.Done()
+
+		err := monitor.Run(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			mm.mu.Lock()
+			mm.errs[chainID] = err
+			mm.mu.Unlock()
+		}
+	}()
+}
+
+func (mm *MultiMonitor) Stop() {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	for _, monitor := range mm.monitors {
+		monitor.Stop()
+	}
+	if mm.ctxStop != nil {
+		mm.ctxStop()
+	}
+}
+
+func (mm *MultiMonitor) IsRunning() bool {
+	return atomic.LoadInt32(&mm.running) == 1
+}
+
+// ChainBlocks are the blocks published by the monitor of a chain, see MultiMonitor#Subscribe.
+type ChainBlocks struct {
+	ChainID uint64
+	Blocks  Blocks
+}
+
+// MultiSubscription is a subscription to the blocks of all the chains of a MultiMonitor.
+type MultiSubscription interface {
+	Blocks() <-chan ChainBlocks
+	Done() <-chan struct{}
+	Err() error
+	Unsubscribe()
+}
+
+// Subscribe subscribes to the monitors of all chains, and delivers the blocks published
+// by each of them along with its chain id. Blocks of a chain are delivered in order, while
+// there is no ordering across chains. The subscription of each chain has the backpressure
+// of its monitor's options, so a slow subscriber only holds back the chains it isn't
+// keeping up with.
+//
+// The subscription is done once any of the subscriptions of its chains is done, ie. when
+// its monitor unsubscribes all subscribers on stop, with the error of that chain.
+func (mm *MultiMonitor) Subscribe(optLabel ...string) MultiSubscription {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	sub := &multiSubscriber{
+		subs: make(map[uint64]Subscription, len(mm.monitors)),
+		ch:   make(chan ChainBlocks),
+		done: make(chan struct{}),
+	}
+	for chainID, monitor := range mm.monitors {
+		sub.subs[chainID] = monitor.Subscribe(optLabel...)
+	}
+	for chainID, chainSub := range sub.subs {
+		go sub.forward(chainID, chainSub)
+	}
+	return sub
+}
+
+type multiSubscriber struct {
+	subs            map[uint64]Subscription
+	ch              chan ChainBlocks
+	done            chan struct{}
+	err             error
+	mu              sync.Mutex
+	unsubscribeOnce sync.Once
+}
+
+func (s *multiSubscriber) Blocks() <-chan ChainBlocks {
+	return s.ch
+}
+
+func (s *multiSubscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *multiSubscriber) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *multiSubscriber) Unsubscribe() {
+	s.close(nil)
+}
+
+func (s *multiSubscriber) close(err error) {
+	s.unsubscribeOnce.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+
+		close(s.done)
+		for _, sub := range s.subs {
+			sub.Unsubscribe()
+		}
+	})
+}
+
+// forward delivers the blocks of the subscription of the chain, until either the
+// subscription or the chain's subscription is done.
+func (s *multiSubscriber) forward(chainID uint64, sub Subscription) {
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case <-sub.Done():
+			s.close(chainSubscriptionErr(chainID, sub.Err()))
+			return
+
+		case blocks, ok := <-sub.Blocks():
+			if !ok {
+				s.close(chainSubscriptionErr(chainID, sub.Err()))
+				return
+			}
+			select {
+			case s.ch <- ChainBlocks{ChainID: chainID, Blocks: blocks}:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+func chainSubscriptionErr(chainID uint64, err error) error {
+	if err == nil {
+		return fmt.Errorf("ethmonitor: chain %d subscription closed", chainID)
+	}
+	return fmt.Errorf("ethmonitor: chain %d subscription closed: %w", chainID, err)
+}
+
+// ChainStatus is the Status of the monitor of a chain, see MultiMonitor#Status.
+type ChainStatus struct {
+	Status
+
+	// CaughtUp is set if the monitor is within CaughtUpThreshold blocks of the network
+	// head, see Monitor#IsCaughtUp.
+	CaughtUp bool
+
+	// Err is the error the monitor failed with, if it stopped running.
+	Err error
+}
+
+// MultiStatus is a snapshot of the progress and health of the monitors of all chains.
+type MultiStatus struct {
+	// Chains are the statuses of the monitors by chain id.
+	Chains map[uint64]ChainStatus
+
+	// Healthy is set if the monitors of all chains are running and caught up, while
+	// Unhealthy are the chain ids of the monitors which are not, in ascending order.
+	Healthy   bool
+	Unhealthy []uint64
+}
+
+// Status returns the progress and health of the monitors of all chains, ie. for a single
+// readiness probe across chains.
+func (mm *MultiMonitor) Status() MultiStatus {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	status := MultiStatus{Chains: make(map[uint64]ChainStatus, len(mm.monitors))}
+	for _, chainID := range sortedKeys(mm.monitors) {
+		monitor := mm.monitors[chainID]
+		chainStatus := ChainStatus{
+			Status:   monitor.Status(),
+			CaughtUp: monitor.IsCaughtUp(),
+			Err:      mm.errs[chainID],
+		}
+		status.Chains[chainID] = chainStatus
+
+		if !chainStatus.Running || !chainStatus.CaughtUp {
+			status.Unhealthy = append(status.Unhealthy, chainID)
+		}
+	}
+	status.Healthy = len(status.Unhealthy) == 0
+	return status
+}
+
+func sortedKeys[T any](m map[uint64]T) []uint64 {
+	keys := make([]uint64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
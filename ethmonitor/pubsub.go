@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/goware/channel"
 	"github.com/goware/superr"
 )
 
@@ -18,15 +17,17 @@ type Subscription interface {
 var _ Subscription = &subscriber{}
 
 type subscriber struct {
-	ch              channel.Channel[Blocks]
+	label           string
+	queue           *subscriberQueue
 	done            chan struct{}
 	err             error
+	mu              sync.Mutex
 	unsubscribe     func()
 	unsubscribeOnce sync.Once
 }
 
 func (s *subscriber) Blocks() <-chan Blocks {
-	return s.ch.ReadChannel()
+	return s.queue.out
 }
 
 func (s *subscriber) Done() <-chan struct{} {
@@ -34,13 +35,188 @@ func (s *subscriber) Done() <-chan struct{} {
 }
 
 func (s *subscriber) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.err
 }
 
+func (s *subscriber) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
 func (s *subscriber) Unsubscribe() {
 	s.unsubscribeOnce.Do(s.unsubscribe)
 }
 
+// BackpressurePolicy is the policy applied to a subscriber which doesn't keep up with
+// the published events, once its queue is at Options#SubscriberQueueCap.
+type BackpressurePolicy int
+
+const (
+	// BackpressureDropOldest drops the oldest events queued for the subscriber to make
+	// room for the new events, which the subscriber will then miss.
+	BackpressureDropOldest BackpressurePolicy = iota
+
+	// BackpressureBlock blocks the monitor from publishing to all of the subscribers
+	// until the subscriber reads from its queue or unsubscribes, so a slow subscriber
+	// slows down all of them.
+	BackpressureBlock
+
+	// BackpressureUnsubscribe unsubscribes the subscriber, with ErrSlowSubscriber.
+	BackpressureUnsubscribe
+)
+
+// SubscriberStats are the queue metrics of a subscriber, see Monitor#SubscriberStats.
+type SubscriberStats struct {
+	Label string
+
+	// QueueDepth is the number of published event batches the subscriber hasn't read yet.
+	QueueDepth int
+
+	// Dropped is the number of event batches dropped by BackpressureDropOldest.
+	Dropped uint64
+}
+
+// subscriberQueue queues the events published to a subscriber, and delivers them on
+// its out channel as the subscriber reads them.
+type subscriberQueue struct {
+	events   []Blocks
+	inflight bool // the oldest events, popped from events while being delivered
+	cap      int
+	policy   BackpressurePolicy
+	dropped  uint64
+
+	out       chan Blocks
+	ready     chan struct{} // signals events were queued
+	space     chan struct{} // signals events were read
+	done      chan struct{}
+	closeOnce sync.Once
+	mu        sync.Mutex
+}
+
+func newSubscriberQueue(cap int, policy BackpressurePolicy) *subscriberQueue {
+	q := &subscriberQueue{
+		cap:    cap,
+		policy: policy,
+		out:    make(chan Blocks),
+		ready:  make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *subscriberQueue) run() {
+	defer close(q.out)
+
+	for {
+		q.mu.Lock()
+		if len(q.events) == 0 {
+			q.mu.Unlock()
+			select {
+			case <-q.ready:
+				continue
+			case <-q.done:
+				return
+			}
+		}
+		events := q.events[0]
+		q.events = q.events[1:]
+		q.inflight = true
+		q.mu.Unlock()
+
+		select {
+		case q.out <- events:
+		case <-q.done:
+			return
+		}
+
+		q.mu.Lock()
+		q.inflight = false
+		q.mu.Unlock()
+		signal(q.space)
+	}
+}
+
+// send queues the events, applying the backpressure policy if the queue is full, where
+// ErrSlowSubscriber is returned with BackpressureUnsubscribe. Events sent to a closed
+// queue are discarded.
+func (q *subscriberQueue) send(events Blocks) error {
+	q.mu.Lock()
+	for q.cap > 0 && q.depthLocked() >= q.cap {
+		switch q.policy {
+		case BackpressureUnsubscribe:
+			q.mu.Unlock()
+			return ErrSlowSubscriber
+
+		case BackpressureBlock:
+			q.mu.Unlock()
+			select {
+			case <-q.space:
+			case <-q.done:
+				return nil
+			}
+			q.mu.Lock()
+			continue
+		}
+
+		// BackpressureDropOldest, where the events being delivered can't be dropped
+		if len(q.events) == 0 {
+			break
+		}
+		q.events = q.events[1:]
+		q.dropped++
+	}
+
+	select {
+	case <-q.done:
+		q.mu.Unlock()
+		return nil
+	default:
+	}
+	q.events = append(q.events, events)
+	q.mu.Unlock()
+
+	signal(q.ready)
+	return nil
+}
+
+func (q *subscriberQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depthLocked()
+}
+
+func (q *subscriberQueue) depthLocked() int {
+	if q.inflight {
+		return len(q.events) + 1
+	}
+	return len(q.events)
+}
+
+func (q *subscriberQueue) stats() (int, uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depthLocked(), q.dropped
+}
+
+func (q *subscriberQueue) close() {
+	q.closeOnce.Do(func() {
+		close(q.done)
+	})
+}
+
+// signal notifies ch without blocking, where ch is buffered with a size of 1.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
 // queue is the publish event queue
 type queue struct {
 	events Blocks
@@ -167,3 +343,17 @@ func (c *queue) tail() *Block {
 	}
 	return c.events[len(c.events)-1]
 }
+
+// replace swaps the queued Added event of the same block for block, and reports whether
+// the block is still queued, as in it has not been published yet.
+func (c *queue) replace(block *Block) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.events) - 1; i >= 0; i-- {
+		if c.events[i].Event == Added && c.events[i].Hash() == block.Hash() {
+			c.events[i] = block
+			return true
+		}
+	}
+	return false
+}
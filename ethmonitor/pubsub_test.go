@@ -1,12 +1,23 @@
 package ethmonitor
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/0xsequence/ethkit/ethrpc"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/util"
+	"github.com/goware/cachestore/memlru"
+	"github.com/goware/logger"
 	"github.com/stretchr/testify/require"
 )
 
@@ -95,3 +106,564 @@ func mockBlock(parentHash string, blockNum int) *types.Block {
 		Number:     big.NewInt(int64(blockNum)),
 	})
 }
+
+// mockForkBlock returns a block with a distinct hash from the mockBlock of the same number
+func mockForkBlock(parentHash common.Hash, blockNum int) *types.Block {
+	return types.NewBlockWithHeader(&types.Header{
+		ParentHash: parentHash,
+		Number:     big.NewInt(int64(blockNum)),
+		BlockHash:  common.BytesToHash([]byte(fmt.Sprintf("fork-%d", blockNum))),
+	})
+}
+
+func TestPublishFinalizedOnly(t *testing.T) {
+	m := &Monitor{
+		options:      Options{PublishFinalizedOnly: true, NumBlocksToFinality: 2, TrailNumBlocksBehindHead: 2},
+		log:          logger.Nop(),
+		alert:        util.NoopAlerter(),
+		chainID:      big.NewInt(1),
+		chain:        newChain(20, false),
+		publishQueue: newQueue(100),
+		publishCh:    make(chan Blocks, 100),
+	}
+	m.Subscribe("finalized")
+
+	add := func(b *types.Block) {
+		block := &Block{Block: b, Event: Added, OK: true}
+		require.NoError(t, m.chain.push(block))
+		require.NoError(t, m.publish(context.Background(), Blocks{block}))
+	}
+
+	blocks := mockBlockchain(5)
+	for _, b := range blocks {
+		add(b)
+	}
+
+	// reorg the head block before it reaches finality
+	removed := m.chain.pop()
+	require.NoError(t, m.publish(context.Background(), Blocks{{Block: removed.Block, Event: Removed, OK: true}}))
+	fork5 := mockForkBlock(blocks[3].Hash(), 5)
+	add(fork5)
+	fork6 := mockForkBlock(fork5.Hash(), 6)
+	add(fork6)
+	add(mockForkBlock(fork6.Hash(), 7))
+
+	published := Blocks{}
+	for len(m.publishCh) > 0 {
+		published = append(published, <-m.publishCh...)
+	}
+
+	require.Len(t, published, 5)
+	for i, b := range published {
+		require.Equal(t, Added, b.Event)
+		require.Equal(t, uint64(i+1), b.NumberU64())
+	}
+	require.Equal(t, fork5.Hash(), published[4].Hash())
+
+	// reorgs deeper than finality never publish removals
+	require.Empty(t, m.dropRemovedEvents(Blocks{{Block: blocks[0], Event: Removed, OK: true}}))
+}
+
+func TestBroadcastDedupeSubscribers(t *testing.T) {
+	cache, err := memlru.NewWithSize[[]byte](100)
+	require.NoError(t, err)
+
+	newMonitor := func() *Monitor {
+		return &Monitor{
+			options: Options{DedupeSubscribers: true},
+			log:     logger.Nop(),
+			alert:   util.NoopAlerter(),
+			chainID: big.NewInt(1),
+			cache:   cache,
+			ctx:     context.Background(),
+		}
+	}
+
+	blocks := mockBlockchain(5)
+	events := func(from, to int) Blocks {
+		out := Blocks{}
+		for _, b := range blocks[from:to] {
+			out = append(out, &Block{Block: b, Event: Added, OK: true})
+		}
+		return out
+	}
+
+	m := newMonitor()
+	sub := m.Subscribe("indexer")
+	m.broadcast(events(0, 3))
+	require.Len(t, <-sub.Blocks(), 3)
+
+	// restarted monitor skips blocks already delivered to the same label
+	m = newMonitor()
+	sub = m.Subscribe("indexer")
+	sub2 := m.Subscribe("indexer")
+	other := m.Subscribe("other")
+
+	m.broadcast(events(1, 4))
+	delivered := <-sub.Blocks()
+	require.Len(t, delivered, 1)
+	require.Equal(t, uint64(4), delivered[0].NumberU64())
+	require.Equal(t, delivered, <-sub2.Blocks())
+	require.Len(t, <-other.Blocks(), 3)
+	require.Equal(t, uint64(4), m.lastDeliveredBlockNums["indexer"])
+
+	// reorgs are always delivered
+	reorg := Blocks{{Block: blocks[3], Event: Removed, OK: true}}
+	reorg = append(reorg, events(3, 4)...)
+	m.broadcast(reorg)
+	require.Len(t, <-sub.Blocks(), 2)
+	require.Len(t, <-sub2.Blocks(), 2)
+}
+
+func TestBroadcastBackpressure(t *testing.T) {
+	newMonitor := func(policy BackpressurePolicy) *Monitor {
+		return &Monitor{
+			options: Options{SubscriberQueueCap: 2, SubscriberBackpressure: policy},
+			log:     logger.Nop(),
+			alert:   util.NoopAlerter(),
+		}
+	}
+
+	blocks := mockBlockchain(5)
+	event := func(i int) Blocks {
+		return Blocks{{Block: blocks[i], Event: Added, OK: true}}
+	}
+
+	// drop oldest
+	m := newMonitor(BackpressureDropOldest)
+	sub := m.Subscribe("slow")
+	for i := 0; i < 5; i++ {
+		m.broadcast(event(i))
+	}
+	stats := m.SubscriberStats()
+	require.Len(t, stats, 1)
+	require.Equal(t, "slow", stats[0].Label)
+	require.Equal(t, 2, stats[0].QueueDepth)
+	require.Equal(t, uint64(3), stats[0].Dropped)
+
+	// the latest events are kept
+	<-sub.Blocks()
+	require.Equal(t, blocks[4].NumberU64(), (<-sub.Blocks())[0].NumberU64())
+
+	// unsubscribe
+	m = newMonitor(BackpressureUnsubscribe)
+	sub = m.Subscribe("slow")
+	for i := 0; i < 5; i++ {
+		m.broadcast(event(i))
+	}
+	<-sub.Done()
+	require.ErrorIs(t, sub.Err(), ErrSlowSubscriber)
+	require.Equal(t, 0, m.NumSubscribers())
+
+	// block
+	m = newMonitor(BackpressureBlock)
+	sub = m.Subscribe("slow")
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			m.broadcast(event(i))
+		}
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("expected the monitor to block on the slow subscriber")
+	case <-time.After(100 * time.Millisecond):
+	}
+	for i := 0; i < 5; i++ {
+		require.Equal(t, blocks[i].NumberU64(), (<-sub.Blocks())[0].NumberU64())
+	}
+	<-published
+	require.Zero(t, m.SubscriberStats()[0].Dropped)
+}
+
+func TestAddLogsFilteredEmpty(t *testing.T) {
+	contract := common.HexToAddress("0x1234")
+	otherContract := common.HexToAddress("0x5678")
+	var bloom types.Bloom
+	bloom.Add(contract.Bytes())
+	bloom.Add(otherContract.Bytes())
+
+	// the node has no logs of the contract in the block, as the bloom matches the
+	// logs of the other contract
+	var hits atomic.Int32
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		var req struct {
+			ID uint64 `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":[]}`, req.ID)
+	}))
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+	cache, err := memlru.NewWithSize[[]byte](100)
+	require.NoError(t, err)
+
+	m := &Monitor{
+		options:  Options{WithLogs: true, LogAddresses: []common.Address{contract}, Timeout: time.Second, CacheExpiry: time.Minute},
+		log:      logger.Nop(),
+		provider: provider,
+		chainID:  big.NewInt(1),
+		cache:    cache,
+	}
+
+	block := &Block{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Bloom: bloom}), Event: Added}
+	m.addLogs(context.Background(), Blocks{block})
+	require.True(t, block.OK)
+	require.Empty(t, block.Logs)
+	require.Equal(t, int32(1), hits.Load())
+}
+
+func TestRefetchBlockLogs(t *testing.T) {
+	contract := common.HexToAddress("0x1234")
+	var bloom types.Bloom
+	bloom.Add(contract.Bytes())
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Bloom: bloom})
+	mockLog := func(index uint) types.Log {
+		return types.Log{Address: contract, Topics: []common.Hash{}, Data: []byte{}, BlockNumber: 1, BlockHash: block.Hash(), Index: index}
+	}
+
+	// the node serves no logs first, then a partial list of them, then all of them
+	var logs atomic.Value
+	logs.Store([]types.Log{})
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_getLogs" {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+			return
+		}
+		data, _ := json.Marshal(logs.Load())
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, data)
+	}))
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+	cache, err := memlru.NewWithSize[[]byte](100)
+	require.NoError(t, err)
+
+	m := &Monitor{
+		options:      Options{WithLogs: true, Timeout: time.Second, CacheExpiry: time.Minute},
+		log:          logger.Nop(),
+		alert:        util.NoopAlerter(),
+		chainID:      big.NewInt(1),
+		provider:     provider,
+		cache:        cache,
+		chain:        newChain(20, false),
+		publishQueue: newQueue(100),
+		publishCh:    make(chan Blocks, 100),
+	}
+	sub := m.Subscribe()
+	defer sub.Unsubscribe()
+
+	retained := &Block{Block: block, Event: Added}
+	require.NoError(t, m.chain.push(retained))
+	m.addLogs(context.Background(), Blocks{retained})
+	require.False(t, retained.OK)
+
+	_, err = m.RefetchBlockLogs(context.Background(), common.Hash{0x01})
+	require.ErrorIs(t, err, ErrBlockNotFound)
+
+	// the logsBloom expects logs
+	_, err = m.RefetchBlockLogs(context.Background(), block.Hash())
+	require.ErrorIs(t, err, ErrLogsUnavailable)
+	require.Equal(t, retained, m.GetBlock(block.Hash()))
+
+	// a block which was never published is not published as updated
+	logs.Store([]types.Log{mockLog(0)})
+	refetched, err := m.RefetchBlockLogs(context.Background(), block.Hash())
+	require.NoError(t, err)
+	require.True(t, refetched.OK)
+	require.Len(t, refetched.Logs, 1)
+	require.Equal(t, refetched, m.GetBlock(block.Hash()))
+	require.Empty(t, m.publishCh)
+
+	// the logs of a published block are refetched from the node rather than the
+	// cache, and the block is published as updated
+	logs.Store([]types.Log{mockLog(0), mockLog(1)})
+	refetched, err = m.RefetchBlockLogs(context.Background(), block.Hash())
+	require.NoError(t, err)
+	require.Len(t, refetched.Logs, 2)
+	require.Equal(t, Added, m.GetBlock(block.Hash()).Event)
+	require.Len(t, m.GetBlock(block.Hash()).Logs, 2)
+
+	published := <-m.publishCh
+	require.Len(t, published, 1)
+	require.Equal(t, Updated, published[0].Event)
+	require.Equal(t, block.Hash(), published[0].Hash())
+	require.Len(t, published[0].Logs, 2)
+}
+
+func TestWaitBlocks(t *testing.T) {
+	newMonitor := func() *Monitor {
+		return &Monitor{log: logger.Nop(), alert: util.NoopAlerter()}
+	}
+	blocks := mockBlockchain(3)
+	fork2 := mockForkBlock(blocks[0].Hash(), 2)
+
+	type result struct {
+		blocks Blocks
+		err    error
+	}
+	waitBlocks := func(ctx context.Context, m *Monitor, n int) <-chan result {
+		done := make(chan result, 1)
+		go func() {
+			blocks, err := m.WaitBlocks(ctx, n)
+			done <- result{blocks, err}
+		}()
+		require.Eventually(t, func() bool { return m.NumSubscribers() == 1 }, time.Second, time.Millisecond)
+		return done
+	}
+
+	// only Added blocks count towards n, and the rest of the batch is left out
+	m := newMonitor()
+	done := waitBlocks(context.Background(), m, 3)
+	m.broadcast(Blocks{
+		{Block: blocks[0], Event: Added, OK: true},
+		{Block: blocks[1], Event: Added, OK: true},
+	})
+	m.broadcast(Blocks{
+		{Block: blocks[1], Event: Removed, OK: true},
+		{Block: fork2, Event: Added, OK: true},
+		{Block: blocks[2], Event: Added, OK: true},
+	})
+	res := <-done
+	require.NoError(t, res.err)
+	require.Len(t, res.blocks, 4)
+	require.Equal(t, Removed, res.blocks[2].Event)
+	require.Equal(t, fork2.Hash(), res.blocks[3].Hash())
+	require.Zero(t, m.NumSubscribers())
+
+	// the blocks so far are returned once the context is done
+	m = newMonitor()
+	ctx, cancel := context.WithCancel(context.Background())
+	done = waitBlocks(ctx, m, 2)
+	m.broadcast(Blocks{{Block: blocks[0], Event: Added, OK: true}})
+	require.Eventually(t, func() bool { return m.subscribers[0].queue.depth() == 0 }, time.Second, time.Millisecond)
+	cancel()
+	res = <-done
+	require.ErrorIs(t, res.err, context.Canceled)
+	require.Len(t, res.blocks, 1)
+
+	// or once the monitor stops
+	m = newMonitor()
+	done = waitBlocks(context.Background(), m, 1)
+	m.subscribers[0].Unsubscribe()
+	res = <-done
+	require.ErrorIs(t, res.err, ErrMonitorStopped)
+	require.Empty(t, res.blocks)
+}
+
+func TestStatus(t *testing.T) {
+	m := &Monitor{
+		log:          logger.Nop(),
+		alert:        util.NoopAlerter(),
+		chainID:      big.NewInt(1),
+		chain:        newChain(20, false),
+		publishQueue: newQueue(100),
+		publishCh:    make(chan Blocks, 100),
+	}
+	m.Subscribe("status")
+
+	status := m.Status()
+	require.False(t, status.Running)
+	require.Zero(t, status.HeadBlockNum)
+	require.Zero(t, status.Lag)
+	require.True(t, status.LastPublishedAt.IsZero())
+
+	for _, b := range mockBlockchain(5) {
+		block := &Block{Block: b, Event: Added, OK: true}
+		require.NoError(t, m.chain.push(block))
+		require.NoError(t, m.publish(context.Background(), Blocks{block}))
+	}
+	m.setNetworkHeadNum(8)
+
+	status = m.Status()
+	require.Equal(t, uint64(5), status.LatestBlockNum)
+	require.Equal(t, uint64(8), status.HeadBlockNum)
+	require.Equal(t, uint64(3), status.Lag)
+	require.False(t, status.LastPublishedAt.IsZero())
+	require.GreaterOrEqual(t, status.SinceLastPublished, time.Duration(0))
+
+	// an older head doesn't replace a newer one
+	m.setNetworkHeadNum(6)
+	require.Equal(t, uint64(8), m.Status().HeadBlockNum)
+
+	// the head is never behind the latest block, ie. while polling without the head known
+	for _, b := range mockBlockchain(10)[5:] {
+		require.NoError(t, m.chain.push(&Block{Block: b, Event: Added, OK: true}))
+	}
+	status = m.Status()
+	require.Equal(t, uint64(10), status.LatestBlockNum)
+	require.Equal(t, uint64(10), status.HeadBlockNum)
+	require.Zero(t, status.Lag)
+}
+
+func TestVerifyBlockHash(t *testing.T) {
+	cache, err := memlru.NewWithSize[[]byte](100)
+	require.NoError(t, err)
+
+	m := &Monitor{
+		log:     logger.Nop(),
+		alert:   util.NoopAlerter(),
+		chainID: big.NewInt(1),
+		cache:   cache,
+	}
+	ctx := context.Background()
+
+	header := &types.Header{Number: big.NewInt(5), Difficulty: big.NewInt(1)}
+	header.BlockHash = header.ComputedBlockHash()
+	block := types.NewBlockWithHeader(header)
+	require.NoError(t, m.verifyBlockHash(ctx, block, "", block.Hash(), big.NewInt(5)))
+
+	// the block isn't the one requested
+	err = m.verifyBlockHash(ctx, block, "", common.Hash{0x01}, nil)
+	require.ErrorIs(t, err, ErrInvalidBlockHash)
+	err = m.verifyBlockHash(ctx, block, "", common.Hash{}, big.NewInt(6))
+	require.ErrorIs(t, err, ErrInvalidBlockHash)
+
+	// the node reports a hash which the header doesn't hash to, and the block is purged
+	// from the cache so it's refetched
+	tampered := types.CopyHeader(header)
+	tampered.BlockHash = common.Hash{0x01}
+	cacheKey := cacheKeyBlockNum(m.chainID, big.NewInt(5))
+	require.NoError(t, cache.Set(ctx, cacheKey, []byte("block")))
+
+	err = m.verifyBlockHash(ctx, types.NewBlockWithHeader(tampered), cacheKey, common.Hash{}, big.NewInt(5))
+	require.ErrorIs(t, err, ErrInvalidBlockHash)
+	_, ok, err := cache.Get(ctx, cacheKey)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestVerifyBlockHashStop(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), BlockHash: common.Hash{0x01}}
+	headerJSON, err := json.Marshal(header)
+	require.NoError(t, err)
+	blockJSON := strings.TrimSuffix(string(headerJSON), "}") + `,"transactions":[],"uncles":[]}`
+
+	// the node always serves a block with a tampered hash
+	var fetched atomic.Int32
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			fetched.Add(1)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, blockJSON)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	m, err := NewMonitor(provider, Options{
+		Logger:            logger.Nop(),
+		StartBlockNumber:  big.NewInt(1),
+		PollingInterval:   time.Minute,
+		Timeout:           time.Second,
+		StreamingDisabled: true,
+		VerifyBlockHashes: true,
+	})
+	require.NoError(t, err)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- m.Run(context.Background())
+	}()
+	require.Eventually(t, func() bool { return fetched.Load() > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// the monitor stops while pausing to retry the tampered block
+	m.Stop()
+	select {
+	case <-runErr:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for monitor to stop")
+	}
+	require.Nil(t, m.LatestBlock())
+}
+
+func TestBeforePublishStop(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), BlockHash: common.Hash{0x01}}
+	headerJSON, err := json.Marshal(header)
+	require.NoError(t, err)
+	blockJSON := strings.TrimSuffix(string(headerJSON), "}") + `,"transactions":[],"uncles":[]}`
+
+	// the node only has block 1
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case req.Method == "eth_getBlockByNumber" && req.Params[0] == "0x1":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, blockJSON)
+		case req.Method == "eth_getBlockByNumber":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":null}`, req.ID)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	provider, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	// the hook always fails, so the monitor keeps retrying to publish the block
+	hookCtx := make(chan context.Context, 100)
+	m, err := NewMonitor(provider, Options{
+		Logger:            logger.Nop(),
+		StartBlockNumber:  big.NewInt(1),
+		PollingInterval:   time.Minute,
+		Timeout:           time.Second,
+		StreamingDisabled: true,
+		OnBeforePublish: func(ctx context.Context, blocks Blocks) error {
+			hookCtx <- ctx
+			return fmt.Errorf("failed")
+		},
+	})
+	require.NoError(t, err)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- m.Run(context.Background())
+	}()
+
+	var ctx context.Context
+	select {
+	case ctx = <-hookCtx:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for OnBeforePublish")
+	}
+
+	// stopping the monitor ends the retries of the hook, even though the context
+	// passed to Run is never done
+	m.Stop()
+	select {
+	case <-runErr:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for monitor to stop")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the context of OnBeforePublish is not done after Stop")
+	}
+}
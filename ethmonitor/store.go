@@ -0,0 +1,165 @@
+package ethmonitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ChainStore persists the block events of the monitor's canonical chain, so that a
+// restarted monitor is able to resume from where it left off, without re-scanning
+// and without losing reorg detection across restarts.
+//
+// The store is written to incrementally, WAL-style, where each call to Save
+// appends the newly published events. Load returns all of the saved events from
+// oldest to newest, which are replayed to rebuild the canonical chain.
+type ChainStore interface {
+	// Save appends the block events to the store.
+	Save(ctx context.Context, events Blocks) error
+
+	// Load returns the block events in the store ordered from oldest to newest.
+	Load(ctx context.Context) (Blocks, error)
+}
+
+// FileChainStore is a ChainStore which appends block events as json lines to a file.
+// Once the file has grown past twice the maxEvents number of lines, it is compacted
+// by rewriting it with just the most recent maxEvents lines.
+type FileChainStore struct {
+	path      string
+	maxEvents int
+	numEvents int
+	mu        sync.Mutex
+}
+
+var _ ChainStore = &FileChainStore{}
+
+// NewFileChainStore returns a ChainStore backed by the file at path. The maxEvents
+// value should be at least the monitor's BlockRetentionLimit.
+func NewFileChainStore(path string, maxEvents int) (*FileChainStore, error) {
+	if maxEvents < 1 {
+		return nil, fmt.Errorf("ethmonitor: FileChainStore maxEvents must be greater than 0")
+	}
+
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileChainStore{
+		path:      path,
+		maxEvents: maxEvents,
+		numEvents: len(lines),
+	}, nil
+}
+
+func (s *FileChainStore) Save(ctx context.Context, events Blocks) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("ethmonitor: FileChainStore failed to marshal block event: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("ethmonitor: FileChainStore failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("ethmonitor: FileChainStore failed to write file: %w", err)
+	}
+	s.numEvents += len(events)
+
+	if s.numEvents > s.maxEvents*2 {
+		return s.compact()
+	}
+	return nil
+}
+
+func (s *FileChainStore) Load(ctx context.Context) (Blocks, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines, err := readLines(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(Blocks, 0, len(lines))
+	for _, line := range lines {
+		var block Block
+		if err := json.Unmarshal(line, &block); err != nil {
+			return nil, fmt.Errorf("ethmonitor: FileChainStore failed to unmarshal block event: %w", err)
+		}
+		events = append(events, &block)
+	}
+	return events, nil
+}
+
+func (s *FileChainStore) compact() error {
+	lines, err := readLines(s.path)
+	if err != nil {
+		return err
+	}
+	if len(lines) > s.maxEvents {
+		lines = lines[len(lines)-s.maxEvents:]
+	}
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	// write to a temp file first, so the store is never left half-written
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("ethmonitor: FileChainStore failed to compact file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("ethmonitor: FileChainStore failed to compact file: %w", err)
+	}
+	s.numEvents = len(lines)
+	return nil
+}
+
+func readLines(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethmonitor: FileChainStore failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		lines = append(lines, bytes.Clone(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ethmonitor: FileChainStore failed to read file: %w", err)
+	}
+	return lines, nil
+}
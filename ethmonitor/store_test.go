@@ -0,0 +1,80 @@
+package ethmonitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileChainStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "chain.jsonl")
+
+	store, err := NewFileChainStore(path, 5)
+	require.NoError(t, err)
+
+	events, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	blocks := mockBlockchain(12)
+	for _, b := range blocks[:8] {
+		err := store.Save(ctx, Blocks{{Block: b, Event: Added, OK: true}})
+		require.NoError(t, err)
+	}
+
+	// reorg the last block
+	err = store.Save(ctx, Blocks{{Block: blocks[7], Event: Removed, OK: true}})
+	require.NoError(t, err)
+
+	for _, b := range blocks[7:] {
+		err := store.Save(ctx, Blocks{{Block: b, Event: Added, OK: true}})
+		require.NoError(t, err)
+	}
+
+	// file has been compacted to the most recent events
+	events, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(events), 10)
+	require.Equal(t, blocks[11].Hash(), events[len(events)-1].Hash())
+
+	// re-opening the store reads the same events
+	store, err = NewFileChainStore(path, 5)
+	require.NoError(t, err)
+	events2, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, events2, len(events))
+
+	// restore a chain from the store events
+	chain := newChain(10, false)
+	err = chain.restoreBlocks(events2)
+	require.NoError(t, err)
+	require.Equal(t, blocks[11].Hash(), chain.Head().Hash())
+	require.Equal(t, uint64(12), chain.Head().NumberU64())
+
+	err = chain.restoreBlocks(events2)
+	require.Error(t, err)
+}
+
+func TestRestoreBlocksTrailingRemoved(t *testing.T) {
+	blocks := mockBlockchain(3)
+
+	// a single Removed event doesn't restore a head
+	chain := newChain(10, false)
+	err := chain.restoreBlocks(Blocks{{Block: blocks[2], Event: Removed, OK: true}})
+	require.NoError(t, err)
+	require.Nil(t, chain.Head())
+
+	// a trailing Removed event pops the block it removes
+	chain = newChain(10, false)
+	err = chain.restoreBlocks(Blocks{
+		{Block: blocks[0], Event: Added, OK: true},
+		{Block: blocks[1], Event: Added, OK: true},
+		{Block: blocks[2], Event: Added, OK: true},
+		{Block: blocks[2], Event: Removed, OK: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, blocks[1].Hash(), chain.Head().Hash())
+}
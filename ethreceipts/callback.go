@@ -0,0 +1,89 @@
+package ethreceipts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStopReceipts can be returned by an OnReceipt callback to stop receiving receipts,
+// which unsubscribes its subscription.
+var ErrStopReceipts = errors.New("ethreceipts: stop receipts")
+
+// OnReceipt subscribes to the filter like Subscribe, but calls fn with every matching
+// receipt instead, from its own goroutine, so the channel select loop of Subscribe can be
+// skipped in simple flows, ie. waiting for a single txn:
+//
+//	listener.OnReceipt(ethreceipts.FilterTxnHash(txnHash), func(receipt ethreceipts.Receipt) error {
+//		// ..
+//		return ethreceipts.ErrStopReceipts
+//	})
+//
+// The subscription is unsubscribed once fn returns ErrStopReceipts, or once all of its
+// filters are done, ie. a LimitOne filter has matched or a filter has exhausted its
+// MaxWait. Other errors returned by fn are logged, and receipts continue to be delivered.
+// A panic in fn is recovered, logged and alerted, and unsubscribes the subscription.
+//
+// The returned subscription may be used to unsubscribe early, or to wait for Done.
+func (l *ReceiptsListener) OnReceipt(filter FilterQuery, fn func(receipt Receipt) error) Subscription {
+	sub := l.Subscribe(filter).(*subscriber)
+
+	go func() {
+		// idle is set once the subscription has no filters left, and the subscription
+		// is only unsubscribed on the next tick, to deliver the receipts sent right
+		// after the last filter was removed
+		idle := false
+
+		for {
+			select {
+			case <-sub.Done():
+				return
+
+			case <-time.After(500 * time.Millisecond):
+				if len(sub.Filters()) > 0 || sub.finalizer.len() > 0 {
+					continue
+				}
+				if idle {
+					sub.Unsubscribe()
+					return
+				}
+				idle = true
+
+			case receipt, ok := <-sub.TransactionReceipt():
+				if !ok {
+					return
+				}
+				idle = false
+
+				err := l.callReceiptFn(fn, receipt)
+				if errors.Is(err, ErrStopReceipts) {
+					sub.Unsubscribe()
+					return
+				}
+				if errors.Is(err, errReceiptFnPanic) {
+					l.log.Error(err.Error())
+					l.alert.Alert(context.Background(), err.Error())
+					sub.Unsubscribe()
+					return
+				}
+				if err != nil {
+					l.log.Warnf("ethreceipts: OnReceipt callback failed for txn %s: %v", receipt.TransactionHash(), err)
+				}
+			}
+		}
+	}()
+
+	return sub
+}
+
+var errReceiptFnPanic = errors.New("ethreceipts: OnReceipt callback panic")
+
+func (l *ReceiptsListener) callReceiptFn(fn func(receipt Receipt) error, receipt Receipt) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w for txn %s: %v", errReceiptFnPanic, receipt.TransactionHash(), r)
+		}
+	}()
+	return fn(receipt)
+}
@@ -32,6 +32,7 @@ var DefaultOptions = Options{
 	PastReceiptsCacheSize:            5_000,
 	NumBlocksToFinality:              0, // value of <=0 here will select from ethrpc.Networks[chainID].NumBlocksToFinality
 	FilterMaxWaitNumBlocks:           0, // value of 0 here means no limit, and will listen until manually unsubscribed
+	MaxHistoryScanNumBlocks:          5_000,
 	Alerter:                          util.NoopAlerter(),
 }
 
@@ -58,6 +59,14 @@ type Options struct {
 	// * value of N will set the N number of blocks without results before unsubscribing between iterations
 	FilterMaxWaitNumBlocks int
 
+	// MaxHistoryScanNumBlocks is the maximum number of blocks behind the latest block which
+	// SubscribeFromBlock will search on-chain for filter matches. Requests with a fromBlock
+	// further behind will return ErrFromBlockOutOfRange, as nodes will likely have pruned
+	// that state, and the scan would be too expensive anyways.
+	//
+	// NOTE: value of 0 will set no limit.
+	MaxHistoryScanNumBlocks int
+
 	// Cache backend ...
 	// CacheBackend cachestore.Backend
 
@@ -73,8 +82,9 @@ type ReceiptsListener struct {
 	monitor  *ethmonitor.Monitor
 	br       *breaker.Breaker
 
-	// fetchSem is used to limit amount of concurrenct fetch requests
-	fetchSem chan struct{}
+	// fetchQueue is used to limit amount of concurrenct fetch requests, where
+	// fetches waiting for a worker are served by priority
+	fetchQueue *fetchQueue
 
 	// pastReceipts is a cache of past requested receipts
 	pastReceipts cachestore.Store[*types.Receipt]
@@ -84,6 +94,13 @@ type ReceiptsListener struct {
 	// for us if they end up turning up.
 	notFoundTxnHashes cachestore.Store[uint64]
 
+	// tracingUnsupported flags that the node supports neither debug_traceTransaction
+	// nor trace_transaction, so the TraceTo filter cond can only match the txn "to" address
+	tracingUnsupported int32
+
+	// stats are the counters reported by Stats
+	stats listenerStats
+
 	// ...
 	subscribers       []*subscriber
 	registerFiltersCh chan registerFilters
@@ -96,10 +113,11 @@ type ReceiptsListener struct {
 }
 
 var (
-	ErrFilterMatch        = errors.New("ethreceipts: filter match fail")
-	ErrFilterCond         = errors.New("ethreceipts: missing filter condition")
-	ErrFilterExhausted    = errors.New("ethreceipts: filter exhausted after maxWait blocks")
-	ErrSubscriptionClosed = errors.New("ethreceipts: subscription closed")
+	ErrFilterMatch         = errors.New("ethreceipts: filter match fail")
+	ErrFilterCond          = errors.New("ethreceipts: missing filter condition")
+	ErrFilterExhausted     = errors.New("ethreceipts: filter exhausted after maxWait blocks")
+	ErrSubscriptionClosed  = errors.New("ethreceipts: subscription closed")
+	ErrFromBlockOutOfRange = errors.New("ethreceipts: fromBlock is out of range")
 )
 
 func NewReceiptsListener(log logger.Logger, provider ethrpc.Interface, monitor *ethmonitor.Monitor, options ...Options) (*ReceiptsListener, error) {
@@ -141,7 +159,7 @@ func NewReceiptsListener(log logger.Logger, provider ethrpc.Interface, monitor *
 		provider:          provider,
 		monitor:           monitor,
 		br:                breaker.New(log, 1*time.Second, 2, 4), // max 4 retries
-		fetchSem:          make(chan struct{}, opts.MaxConcurrentFetchReceiptWorkers),
+		fetchQueue:        newFetchQueue(opts.MaxConcurrentFetchReceiptWorkers),
 		pastReceipts:      pastReceipts,
 		notFoundTxnHashes: notFoundTxnHashes,
 		subscribers:       make([]*subscriber, 0),
@@ -201,6 +219,31 @@ func (l *ReceiptsListener) IsRunning() bool {
 }
 
 func (l *ReceiptsListener) Subscribe(filterQueries ...FilterQuery) Subscription {
+	return l.subscribe(0, filterQueries...)
+}
+
+// SubscribeFromBlock is like Subscribe, except the listener will first search on-chain
+// for filter matches from fromBlock up until the blocks retained by the monitor, before
+// continuing to follow new blocks as usual. This is useful for indexers which know
+// exactly which block they left off at.
+//
+// The on-chain search is bounded by Options#MaxHistoryScanNumBlocks.
+func (l *ReceiptsListener) SubscribeFromBlock(fromBlock uint64, filterQueries ...FilterQuery) (Subscription, error) {
+	if fromBlock == 0 {
+		return nil, superr.Wrap(ErrFromBlockOutOfRange, fmt.Errorf("fromBlock must be greater than 0"))
+	}
+
+	latestBlockNum := l.latestBlockNum().Uint64()
+	maxScan := uint64(l.options.MaxHistoryScanNumBlocks)
+
+	if maxScan > 0 && latestBlockNum > fromBlock && latestBlockNum-fromBlock > maxScan {
+		return nil, superr.Wrap(ErrFromBlockOutOfRange, fmt.Errorf("fromBlock=%d latestBlock=%d maxHistoryScan=%d", fromBlock, latestBlockNum, maxScan))
+	}
+
+	return l.subscribe(fromBlock, filterQueries...), nil
+}
+
+func (l *ReceiptsListener) subscribe(fromBlock uint64, filterQueries ...FilterQuery) Subscription {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -238,7 +281,7 @@ func (l *ReceiptsListener) Subscribe(filterQueries ...FilterQuery) Subscription
 	l.subscribers = append(l.subscribers, subscriber)
 
 	// Subscribe to the filters
-	subscriber.AddFilter(filterQueries...)
+	subscriber.addFilter(fromBlock, filterQueries...)
 
 	return subscriber
 }
@@ -258,6 +301,9 @@ func (l *ReceiptsListener) PurgeHistory() {
 
 type WaitReceiptFinalityFunc func(ctx context.Context) (*Receipt, error)
 
+// FetchTransactionReceipt waits for the receipt of the txn hash to be mined. For urgent fetches, such
+// as user-facing flows on a listener shared with background work, pass a filter with a higher priority
+// to FetchTransactionReceiptWithFilter instead, ie. FilterTxnHash(txnHash).Priority(FetchPriorityHigh).
 func (l *ReceiptsListener) FetchTransactionReceipt(ctx context.Context, txnHash common.Hash, optMaxBlockWait ...int) (*Receipt, WaitReceiptFinalityFunc, error) {
 	maxWait := -1 // default use -1 maxWait, which is finality*2 value
 	if len(optMaxBlockWait) > 0 {
@@ -267,6 +313,10 @@ func (l *ReceiptsListener) FetchTransactionReceipt(ctx context.Context, txnHash
 	return l.FetchTransactionReceiptWithFilter(ctx, filter)
 }
 
+// FetchTransactionReceiptWithFilter waits for the receipt matched by the filter to be mined. The returned
+// WaitReceiptFinalityFunc waits for the receipt to be final, which is after the NumBlocksToFinality of the
+// listener, or the FinalityDepth or Confirmations of the filter, ie. to wait for exactly 3 confirmations
+// with FilterTxnHash(txnHash).Confirmations(3).
 func (l *ReceiptsListener) FetchTransactionReceiptWithFilter(ctx context.Context, filter FilterQuery) (*Receipt, WaitReceiptFinalityFunc, error) {
 	// Fetch method searches for just a single filter match. If you'd like to keep the filter
 	// open to listen to many similar receipts, use .Subscribe(filter) directly instead.
@@ -378,9 +428,11 @@ func (l *ReceiptsListener) FetchTransactionReceiptWithFilter(ctx context.Context
 
 // fetchTransactionReceipt from the rpc provider, up to some amount of concurrency. When forceFetch is passed,
 // it indicates that we have high conviction that the receipt should be available, as the monitor has found
-// this transaction hash.
-func (l *ReceiptsListener) fetchTransactionReceipt(ctx context.Context, txnHash common.Hash, forceFetch bool) (*types.Receipt, error) {
-	l.fetchSem <- struct{}{}
+// this transaction hash. Fetches with a higher priority are given the next available fetch worker first.
+func (l *ReceiptsListener) fetchTransactionReceipt(ctx context.Context, txnHash common.Hash, forceFetch bool, priority FetchPriority) (*types.Receipt, error) {
+	if err := l.fetchQueue.acquire(ctx, priority); err != nil {
+		return nil, err
+	}
 
 	resultCh := make(chan *types.Receipt)
 	errCh := make(chan error)
@@ -389,17 +441,17 @@ func (l *ReceiptsListener) fetchTransactionReceipt(ctx context.Context, txnHash
 	defer close(errCh)
 
 	go func() {
-		defer func() {
-			<-l.fetchSem
-		}()
+		defer l.fetchQueue.release()
 
 		txnHashHex := txnHash.String()
 
 		receipt, ok, _ := l.pastReceipts.Get(ctx, txnHashHex)
 		if ok {
+			l.stats.cacheHits.Add(1)
 			resultCh <- receipt
 			return
 		}
+		l.stats.cacheMisses.Add(1)
 
 		latestBlockNum := l.monitor.LatestBlockNum().Uint64()
 		oldestBlockNum := l.monitor.OldestBlockNum().Uint64()
@@ -428,6 +480,7 @@ func (l *ReceiptsListener) fetchTransactionReceipt(ctx context.Context, txnHash
 			tctx, clearTimeout := context.WithTimeout(ctx, 4*time.Second)
 			defer clearTimeout()
 
+			l.stats.onChainFetches.Add(1)
 			receipt, err := l.provider.TransactionReceipt(tctx, txnHash)
 
 			if !forceFetch && errors.Is(err, ethereum.NotFound) {
@@ -470,6 +523,107 @@ func (l *ReceiptsListener) fetchTransactionReceipt(ctx context.Context, txnHash
 	}
 }
 
+// traceReceipts sets the call targets of the receipts by tracing their txns, if any of the
+// filterers has a TraceTo cond, skipping the txns whose "to" address matches all of them.
+// A txn which fails to be traced is left without call targets, and is only matched by
+// its "to" address.
+func (l *ReceiptsListener) traceReceipts(ctx context.Context, receipts []Receipt, filterers [][]Filterer) {
+	traceTo := map[common.Address]struct{}{}
+	priority := FetchPriorityNormal
+	for _, subFilterers := range filterers {
+		for _, filterer := range subFilterers {
+			if to := filterer.Cond().TraceTo; to != nil {
+				traceTo[*to] = struct{}{}
+				priority = max(priority, filterer.Options().Priority)
+			}
+		}
+	}
+	if len(traceTo) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range receipts {
+		receipt := &receipts[i]
+		if _, ok := traceTo[receipt.To()]; ok && len(traceTo) == 1 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			callTargets, err := l.fetchCallTargets(ctx, receipt.TransactionHash(), priority)
+			if err != nil {
+				l.log.Warnf("ethreceipts: failed to trace txn %s, matching TraceTo filters on its to address only: %v", receipt.TransactionHash(), err)
+				return
+			}
+			receipt.callTargets = callTargets
+		}()
+	}
+	wg.Wait()
+}
+
+// fetchCallTargets traces the transaction and returns the "to" addresses of all of its calls,
+// using debug_traceTransaction, or trace_transaction if the provider or node does not support
+// it. If they support neither, an empty list is returned.
+func (l *ReceiptsListener) fetchCallTargets(ctx context.Context, txnHash common.Hash, priority FetchPriority) ([]common.Address, error) {
+	callTargets := []common.Address{}
+
+	debugProvider, debugOK := l.provider.(ethrpc.DebugInterface)
+	traceProvider, traceOK := l.provider.(ethrpc.TraceInterface)
+	if !(debugOK || traceOK) || atomic.LoadInt32(&l.tracingUnsupported) == 1 {
+		return callTargets, nil
+	}
+
+	if err := l.fetchQueue.acquire(ctx, priority); err != nil {
+		return nil, err
+	}
+	defer l.fetchQueue.release()
+
+	tctx, clearTimeout := context.WithTimeout(ctx, 10*time.Second)
+	defer clearTimeout()
+
+	if debugOK {
+		debugTrace, err := debugProvider.DebugTraceTransaction(tctx, txnHash)
+		if err == nil {
+			var walk func(call *ethrpc.CallDebugTrace)
+			walk = func(call *ethrpc.CallDebugTrace) {
+				if call == nil {
+					return
+				}
+				callTargets = append(callTargets, call.To)
+				for _, c := range call.Calls {
+					walk(c)
+				}
+			}
+			walk(debugTrace)
+			return callTargets, nil
+		}
+		if !errors.Is(err, ethrpc.ErrUnsupportedMethodOnChain) {
+			return nil, err
+		}
+	}
+
+	if traceOK {
+		traces, err := traceProvider.TraceTransaction(tctx, txnHash)
+		if err == nil {
+			for _, trace := range traces {
+				if trace != nil {
+					callTargets = append(callTargets, trace.Action.To)
+				}
+			}
+			return callTargets, nil
+		}
+		if !errors.Is(err, ethrpc.ErrUnsupportedMethodOnChain) {
+			return nil, err
+		}
+	}
+
+	l.log.Warn("ethreceipts: node does not support transaction tracing, TraceTo filters will only match the txn to address")
+	atomic.StoreInt32(&l.tracingUnsupported, 1)
+	return callTargets, nil
+}
+
 func (l *ReceiptsListener) listener() error {
 	monitor := l.monitor.Subscribe("ethreceipts")
 	defer monitor.Unsubscribe()
@@ -497,40 +651,7 @@ func (l *ReceiptsListener) listener() error {
 				if !ok {
 					continue
 				}
-				if len(reg.filters) == 0 {
-					continue
-				}
-
-				// check if filters asking to search cache / on-chain
-				filters := make([]Filterer, 0, len(reg.filters))
-				for _, f := range reg.filters {
-					if f.Options().SearchCache || f.Options().SearchOnChain {
-						filters = append(filters, f)
-					}
-				}
-				if len(filters) == 0 {
-					continue
-				}
-
-				// fetch blocks data from the monitor cache. aka the up to some number
-				// of blocks which are retained by the monitor. the blocks are ordered
-				// from oldest to newest order.
-				l.mu.Lock()
-				blocks := l.monitor.Chain().Blocks()
-				l.mu.Unlock()
-
-				// Search our local blocks cache from monitor retention list
-				matchedList, err := l.processBlocks(blocks, []*subscriber{reg.subscriber}, [][]Filterer{filters})
-				if err != nil {
-					l.log.Warnf("ethreceipts: failed to process blocks during new filter registration: %v", err)
-				}
-
-				// Finally, search on chain with filters which have had no results. Note, this strategy only
-				// works for txnHash conditions as other filters could have multiple matches.
-				err = l.searchFilterOnChain(ctx, reg.subscriber, collectOk(filters, matchedList[0], false))
-				if err != nil {
-					l.log.Warnf("ethreceipts: failed to search filter on-chain during new filter registration: %v", err)
-				}
+				l.searchRegisteredFilters(ctx, reg)
 			}
 		}
 	})
@@ -553,6 +674,7 @@ func (l *ReceiptsListener) listener() error {
 				if len(blocks) == 0 {
 					continue
 				}
+				arrivedAt := time.Now()
 
 				latestBlockNum = l.latestBlockNum().Uint64()
 
@@ -601,7 +723,7 @@ func (l *ReceiptsListener) listener() error {
 				}
 
 				// Match blocks against subscribers[i] X filters[i][..]
-				matchedList, err := l.processBlocks(blocks, subscribers, filters)
+				matchedList, err := l.processBlocks(blocks, subscribers, filters, arrivedAt)
 				if err != nil {
 					l.log.Warnf("ethreceipts: failed to process blocks: %v", err)
 				}
@@ -656,8 +778,9 @@ func (l *ReceiptsListener) listener() error {
 }
 
 // processBlocks attempts to match blocks against subscriber[i] X filterers[i].. list of filters. There is
-// a corresponding list of filters[i] for each subscriber[i].
-func (l *ReceiptsListener) processBlocks(blocks ethmonitor.Blocks, subscribers []*subscriber, filterers [][]Filterer) ([][]bool, error) {
+// a corresponding list of filters[i] for each subscriber[i]. The latency of matches is recorded
+// in the listener stats from arrivedAt, unless it's zero.
+func (l *ReceiptsListener) processBlocks(blocks ethmonitor.Blocks, subscribers []*subscriber, filterers [][]Filterer, arrivedAt time.Time) ([][]bool, error) {
 	// oks is the 'ok' match of the filterers [][]Filterer results
 	oks := make([][]bool, len(filterers))
 	for i, f := range filterers {
@@ -670,6 +793,12 @@ func (l *ReceiptsListener) processBlocks(blocks ethmonitor.Blocks, subscribers [
 
 	// check each block against each subscriber X filter
 	for _, block := range blocks {
+		// Updated blocks only carry the re-fetched logs of a block whose receipts were
+		// already matched when it was Added, so they're skipped to not match them twice
+		if block.Event == ethmonitor.Updated {
+			continue
+		}
+
 		// report if the txn was removed
 		reorged := block.Event == ethmonitor.Removed
 
@@ -700,6 +829,12 @@ func (l *ReceiptsListener) processBlocks(blocks ethmonitor.Blocks, subscribers [
 			}
 		}
 
+		// trace the txns of the block once for all subscribers, and only if any of them
+		// has a TraceTo filter, which matches on the internal calls of the txns as well
+		if !reorged {
+			l.traceReceipts(l.ctx, receipts, filterers)
+		}
+
 		// match the receipts against the filters
 		var wg sync.WaitGroup
 		for i, sub := range subscribers {
@@ -718,6 +853,16 @@ func (l *ReceiptsListener) processBlocks(blocks ethmonitor.Blocks, subscribers [
 				}
 				oks[i] = matched
 
+				if !arrivedAt.IsZero() {
+					n := 0
+					for _, ok := range matched {
+						if ok {
+							n++
+						}
+					}
+					l.stats.recordMatches(n, time.Since(arrivedAt))
+				}
+
 				// check subscriber to finalize any receipts
 				err = sub.finalizeReceipts(block.Number())
 				if err != nil {
@@ -731,6 +876,69 @@ func (l *ReceiptsListener) processBlocks(blocks ethmonitor.Blocks, subscribers [
 	return oks, nil
 }
 
+// searchRegisteredFilters searches the blocks retained by the monitor, and the on-chain
+// history or receipts if the filters ask for it, for matches of newly registered filters.
+func (l *ReceiptsListener) searchRegisteredFilters(ctx context.Context, reg registerFilters) {
+	// the filters are matched against new blocks from here on
+	defer reg.subscriber.searchDone(reg.filters)
+
+	if len(reg.filters) == 0 {
+		return
+	}
+
+	// check if filters asking to search cache / on-chain. Filters registered
+	// with a fromBlock will always search the cache, as it continues the history.
+	filters := make([]Filterer, 0, len(reg.filters))
+	for _, f := range reg.filters {
+		if reg.fromBlock > 0 || f.Options().SearchCache || f.Options().SearchOnChain {
+			filters = append(filters, f)
+		}
+	}
+	if len(filters) == 0 {
+		return
+	}
+
+	// fetch blocks data from the monitor cache. aka the up to some number
+	// of blocks which are retained by the monitor. the blocks are ordered
+	// from oldest to newest order.
+	l.mu.Lock()
+	blocks := l.monitor.Chain().Blocks()
+	l.mu.Unlock()
+
+	// Search on-chain history from fromBlock up to the oldest block retained
+	// by the monitor, so the history and cache ranges never overlap.
+	if reg.fromBlock > 0 && (len(blocks) == 0 || blocks[0].NumberU64() > reg.fromBlock) {
+		toBlock := l.latestBlockNum().Uint64()
+		if len(blocks) > 0 {
+			toBlock = blocks[0].NumberU64() - 1
+		}
+
+		historyMatched, err := l.searchFilterHistory(ctx, reg.subscriber, reg.fromBlock, toBlock, filters)
+		if err != nil {
+			l.log.Warnf("ethreceipts: failed to search filter history during new filter registration: %v", err)
+		}
+
+		// LimitOne filters which matched in the history are done
+		filters = collectLimitOneUnmatched(filters, historyMatched)
+		if len(filters) == 0 {
+			return
+		}
+	}
+
+	// Search our local blocks cache from monitor retention list
+	matchedList, err := l.processBlocks(blocks, []*subscriber{reg.subscriber}, [][]Filterer{filters}, time.Time{})
+	if err != nil {
+		l.log.Warnf("ethreceipts: failed to process blocks during new filter registration: %v", err)
+	}
+
+	// Finally, search on chain with filters which have had no results. Note, this strategy only
+	// works for txnHash conditions as other filters could have multiple matches.
+	err = l.searchFilterOnChain(ctx, reg.subscriber, collectOk(filters, matchedList[0], false))
+	if err != nil {
+		l.log.Warnf("ethreceipts: failed to search filter on-chain during new filter registration: %v", err)
+	}
+}
+
 func (l *ReceiptsListener) searchFilterOnChain(ctx context.Context, subscriber *subscriber, filterers []Filterer) error {
 	for _, filterer := range filterers {
 		if !filterer.Options().SearchOnChain {
@@ -744,7 +952,7 @@ func (l *ReceiptsListener) searchFilterOnChain(ctx context.Context, subscriber *
 			continue
 		}
 
-		r, err := l.fetchTransactionReceipt(ctx, *txnHashCond, false)
+		r, err := l.fetchTransactionReceipt(ctx, *txnHashCond, false, filterer.Options().Priority)
 		if !errors.Is(err, ethereum.NotFound) && err != nil {
 			l.log.Errorf("searchFilterOnChain fetchTransactionReceipt failed: %v", err)
 		}
@@ -789,16 +997,48 @@ func (l *ReceiptsListener) getMaxWaitBlocks(maxWait *int) uint64 {
 }
 
 func (l *ReceiptsListener) isBlockFinal(blockNum *big.Int) bool {
+	l.mu.RLock()
+	numBlocksToFinality := l.options.NumBlocksToFinality
+	l.mu.RUnlock()
+
+	return l.isBlockFinalAt(blockNum, numBlocksToFinality)
+}
+
+// isFilterBlockFinal is isBlockFinal with the confirmations or finality depth of the
+// filter, if it overrides the listener's NumBlocksToFinality.
+func (l *ReceiptsListener) isFilterBlockFinal(filterer Filterer, blockNum *big.Int) bool {
+	numBlocks, ok := filterFinalityDepth(filterer)
+	if !ok {
+		return l.isBlockFinal(blockNum)
+	}
+	return l.isBlockFinalAt(blockNum, numBlocks)
+}
+
+// filterFinalityDepth returns the number of blocks on top of the block of a receipt
+// matched by the filter after which it's final, by its Confirmations or FinalityDepth
+// options, or false if the filter uses the listener's NumBlocksToFinality.
+func filterFinalityDepth(filterer Filterer) (int, bool) {
+	if filterer == nil {
+		return 0, false
+	}
+	options := filterer.Options()
+	if options.Confirmations > 0 {
+		return options.Confirmations - 1, true
+	}
+	if options.FinalityDepth > 0 {
+		return options.FinalityDepth, true
+	}
+	return 0, false
+}
+
+func (l *ReceiptsListener) isBlockFinalAt(blockNum *big.Int, numBlocksToFinality int) bool {
 	latestBlockNum := l.latestBlockNum()
 	if latestBlockNum == nil || blockNum == nil {
 		return false
 	}
 	diff := big.NewInt(0).Sub(latestBlockNum, blockNum)
 
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-
-	return diff.Cmp(big.NewInt(int64(l.options.NumBlocksToFinality))) >= 0
+	return diff.Cmp(big.NewInt(int64(numBlocksToFinality))) >= 0
 }
 
 func (l *ReceiptsListener) latestBlockNum() *big.Int {
@@ -167,6 +167,15 @@ func TestFetchTransactionReceiptBasic(t *testing.T) {
 	require.Zero(t, receiptsListener.NumSubscribers())
 	require.Equal(t, 1, monitor.NumSubscribers())
 
+	// Check stats
+	stats := receiptsListener.Stats()
+	require.Zero(t, stats.Subscribers)
+	require.Zero(t, stats.ActiveFilters)
+	require.GreaterOrEqual(t, stats.FilterMatches, uint64(numTxns))
+	require.Greater(t, stats.AvgMatchLatency, time.Duration(0))
+	require.Greater(t, stats.PastReceiptsCacheMisses, uint64(0))
+	require.Greater(t, stats.OnChainFetches, uint64(0))
+
 	// Testing exhausted filter after maxWait period is unable to find non-existant txn hash
 	receipt, waitFinality, err := receiptsListener.FetchTransactionReceipt(ctx, ethkit.Hash{1, 2, 3, 4}, 5)
 	require.Error(t, err)
@@ -310,6 +319,64 @@ func TestFetchTransactionReceiptBlast(t *testing.T) {
 	require.Equal(t, int(count), len(txnHashes))
 }
 
+func TestFetchTransactionReceiptConfirmations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	provider := testchain.Provider
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.WithLogs = true
+	monitorOptions.BlockRetentionLimit = 1000
+
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	assert.NoError(t, err)
+
+	go func() {
+		err := monitor.Run(ctx)
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			t.Error(err)
+		}
+	}()
+
+	// the listener's finality is far deeper than the confirmations of the filter
+	listenerOptions := ethreceipts.DefaultOptions
+	listenerOptions.NumBlocksToFinality = 20
+
+	receiptsListener, err := ethreceipts.NewReceiptsListener(log, provider, monitor, listenerOptions)
+	assert.NoError(t, err)
+
+	go func() {
+		err := receiptsListener.Run(ctx)
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			t.Error(err)
+		}
+	}()
+
+	wallet, _ := testchain.DummyWallet(300)
+	testchain.MustFundAddress(wallet.Address())
+
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: ethtest.ETHValue(0.1), GasLimit: 120_000})
+	require.NoError(t, err)
+	_, _, err = wallet.SendTransaction(ctx, txn)
+	require.NoError(t, err)
+
+	receipt, waitConfirmations, err := receiptsListener.FetchTransactionReceiptWithFilter(ctx, ethreceipts.FilterTxnHash(txn.Hash()).Confirmations(3))
+	require.NoError(t, err)
+	require.NotNil(t, receipt)
+
+	confirmedReceipt, err := waitConfirmations(ctx)
+	require.NoError(t, err)
+	require.True(t, confirmedReceipt.Final)
+
+	// the receipt's block and at least two blocks on top of it, but not the listener's finality
+	head := monitor.LatestBlockNum()
+	confirmations := new(big.Int).Sub(head, confirmedReceipt.BlockNumber()).Int64() + 1
+	require.GreaterOrEqual(t, confirmations, int64(3))
+	require.Less(t, confirmations, int64(listenerOptions.NumBlocksToFinality))
+}
+
 func TestReceiptsListenerFilters(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -618,3 +685,255 @@ loop:
 
 	require.Equal(t, matchedCount, len(erc20Receipts)*2)
 }
+
+func TestReceiptsListenerSubscribeFromBlock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	//
+	// Send a txn before the ReceiptsListener is setup
+	//
+	provider := testchain.Provider
+
+	wallet, _ := testchain.DummyWallet(1)
+	testchain.MustFundAddress(wallet.Address())
+
+	toWallet, _ := testchain.DummyWallet(300)
+	to := toWallet.Address()
+
+	txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{
+		To:       &to,
+		ETHValue: ethtest.ETHValue(0.1),
+		GasLimit: 120_000,
+	})
+	require.NoError(t, err)
+
+	_, waitReceipt, err := wallet.SendTransaction(ctx, txn)
+	require.NoError(t, err)
+
+	txnReceipt, err := waitReceipt(ctx)
+	require.NoError(t, err)
+
+	//
+	// Setup ReceiptsListener
+	//
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.WithLogs = true
+	monitorOptions.BlockRetentionLimit = 50
+
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	assert.NoError(t, err)
+
+	go func() {
+		err := monitor.Run(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	listenerOptions := ethreceipts.DefaultOptions
+	listenerOptions.NumBlocksToFinality = 10
+	listenerOptions.MaxHistoryScanNumBlocks = 100
+
+	receiptsListener, err := ethreceipts.NewReceiptsListener(log, provider, monitor, listenerOptions)
+	assert.NoError(t, err)
+
+	go func() {
+		err := receiptsListener.Run(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	time.Sleep(2 * time.Second)
+
+	// fromBlock further behind than MaxHistoryScanNumBlocks is rejected
+	latestBlockNum := monitor.LatestBlockNum().Uint64()
+	if latestBlockNum > 101 {
+		_, err = receiptsListener.SubscribeFromBlock(latestBlockNum-101, ethreceipts.FilterTo(to))
+		require.ErrorIs(t, err, ethreceipts.ErrFromBlockOutOfRange)
+	}
+
+	// subscribe from the block of the txn, and we should find it
+	sub, err := receiptsListener.SubscribeFromBlock(txnReceipt.BlockNumber.Uint64(), ethreceipts.FilterTo(to))
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	select {
+	case receipt := <-sub.TransactionReceipt():
+		require.Equal(t, txn.Hash(), receipt.TransactionHash())
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for receipt")
+	}
+}
+
+func TestReceiptsListenerOnReceipt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	//
+	// Setup ReceiptsListener
+	//
+	provider := testchain.Provider
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.WithLogs = true
+	monitorOptions.BlockRetentionLimit = 50
+
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	assert.NoError(t, err)
+
+	go func() {
+		err := monitor.Run(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	listenerOptions := ethreceipts.DefaultOptions
+	listenerOptions.NumBlocksToFinality = 10
+
+	receiptsListener, err := ethreceipts.NewReceiptsListener(log, provider, monitor, listenerOptions)
+	assert.NoError(t, err)
+
+	go func() {
+		err := receiptsListener.Run(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	time.Sleep(2 * time.Second)
+
+	//
+	// Send txns and wait for them with callbacks
+	//
+	wallet, _ := testchain.DummyWallet(1)
+	testchain.MustFundAddress(wallet.Address())
+
+	toWallet, _ := testchain.DummyWallet(301)
+	to := toWallet.Address()
+
+	nonce, err := wallet.GetNonce(ctx)
+	require.NoError(t, err)
+
+	var txns []*types.Transaction
+	for i := 0; i < 2; i++ {
+		txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{
+			To:       &to,
+			Nonce:    big.NewInt(int64(nonce) + int64(i)),
+			ETHValue: ethtest.ETHValue(0.1),
+			GasLimit: 120_000,
+		})
+		require.NoError(t, err)
+		txns = append(txns, txn)
+	}
+
+	// the LimitOne filter is done after its match, which unsubscribes
+	var received atomic.Int32
+	sub := receiptsListener.OnReceipt(ethreceipts.FilterTxnHash(txns[0].Hash()).Finalize(false), func(receipt ethreceipts.Receipt) error {
+		require.Equal(t, txns[0].Hash(), receipt.TransactionHash())
+		received.Add(1)
+		return nil
+	})
+
+	// a panic in the callback is recovered, and unsubscribes
+	panicSub := receiptsListener.OnReceipt(ethreceipts.FilterTo(to), func(receipt ethreceipts.Receipt) error {
+		panic("boom")
+	})
+
+	for _, txn := range txns {
+		_, _, err := wallet.SendTransaction(ctx, txn)
+		require.NoError(t, err)
+	}
+
+	for _, s := range []ethreceipts.Subscription{sub, panicSub} {
+		select {
+		case <-s.Done():
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for unsubscribe")
+		}
+	}
+	require.Equal(t, int32(1), received.Load())
+}
+
+func TestFetchReceiptsByAddress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	//
+	// Setup ReceiptsListener
+	//
+	provider := testchain.Provider
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.WithLogs = true
+	monitorOptions.BlockRetentionLimit = 1000
+
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+
+	go func() {
+		err := monitor.Run(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	listenerOptions := ethreceipts.DefaultOptions
+	listenerOptions.NumBlocksToFinality = 10
+
+	receiptsListener, err := ethreceipts.NewReceiptsListener(log, provider, monitor, listenerOptions)
+	require.NoError(t, err)
+
+	go func() {
+		err := receiptsListener.Run(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// wait for the monitor to start, so the txns are in its retained blocks
+	require.Eventually(t, func() bool { return monitor.LatestBlock() != nil }, 10*time.Second, 100*time.Millisecond)
+
+	//
+	// Send txns from and to a few wallets
+	//
+	fromWallets, _ := testchain.DummyWallets(2, 300)
+	testchain.FundAddresses(ethtest.WalletAddresses(fromWallets), 10)
+
+	toWallets, _ := testchain.DummyWallets(2, 400)
+
+	values := []*big.Int{ethtest.ETHValue(0.1), ethtest.ETHValue(0.1)}
+	_, txns, err := ethtest.PrepareBlastSendTransactions(ctx, fromWallets, ethtest.WalletAddresses(toWallets), values)
+	require.NoError(t, err)
+
+	var lastBlockNum uint64
+	for _, txn := range txns {
+		_, waitReceipt, err := ethtxn.SendTransaction(ctx, provider, txn)
+		require.NoError(t, err)
+		receipt, err := waitReceipt(ctx)
+		require.NoError(t, err)
+		lastBlockNum = max(lastBlockNum, receipt.BlockNumber.Uint64())
+	}
+	require.Eventually(t, func() bool { return monitor.LatestBlockNum().Uint64() >= lastBlockNum }, 10*time.Second, 100*time.Millisecond)
+
+	// sender
+	receipts, err := receiptsListener.FetchReceiptsByAddress(ctx, fromWallets[0].Address(), ethreceipts.FetchReceiptsOptions{})
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	require.Equal(t, txns[0].Hash(), receipts[0].TransactionHash())
+	require.NotNil(t, receipts[0].Receipt())
+
+	// recipient
+	receipts, err = receiptsListener.FetchReceiptsByAddress(ctx, toWallets[1].Address(), ethreceipts.FetchReceiptsOptions{})
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	require.Equal(t, txns[1].Hash(), receipts[0].TransactionHash())
+
+	// before the oldest retained block
+	_, err = receiptsListener.FetchReceiptsByAddress(ctx, fromWallets[0].Address(), ethreceipts.FetchReceiptsOptions{FromBlock: 1, ToBlock: lastBlockNum})
+	if monitor.OldestBlockNum().Uint64() > 1 {
+		require.ErrorIs(t, err, ethreceipts.ErrFromBlockOutOfRange)
+	}
+}
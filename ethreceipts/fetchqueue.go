@@ -0,0 +1,130 @@
+package ethreceipts
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// FetchPriority is the priority of the receipt fetches of a filter, where fetches with a
+// higher priority are given the next available fetch worker ahead of any lower priority
+// fetches waiting for one. Fetches of the same priority are served in order.
+type FetchPriority int
+
+const (
+	FetchPriorityNormal FetchPriority = 0
+	FetchPriorityHigh   FetchPriority = 10
+)
+
+// fetchQueue limits the number of concurrent fetches to the node, like a semaphore, where
+// fetches waiting for a worker are queued by priority.
+type fetchQueue struct {
+	size    int
+	active  int
+	waiting fetchWaiters
+	seq     uint64
+	mu      sync.Mutex
+}
+
+func newFetchQueue(size int) *fetchQueue {
+	if size < 1 {
+		size = 1
+	}
+	return &fetchQueue{size: size}
+}
+
+// acquire waits for a fetch worker to be available, and must be followed by a call to
+// release once the fetch is done. An error is returned if the context is done first.
+func (q *fetchQueue) acquire(ctx context.Context, priority FetchPriority) error {
+	q.mu.Lock()
+	if q.active < q.size && len(q.waiting) == 0 {
+		q.active++
+		q.mu.Unlock()
+		return nil
+	}
+
+	q.seq++
+	w := &fetchWaiter{priority: priority, seq: q.seq, ready: make(chan struct{})}
+	heap.Push(&q.waiting, w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-w.ready:
+			// the worker was handed to us in the meantime, so pass it on
+			q.releaseLocked()
+		default:
+			heap.Remove(&q.waiting, w.index)
+		}
+		return ctx.Err()
+	}
+}
+
+// release returns the fetch worker, handing it over to the highest priority waiting fetch.
+func (q *fetchQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.releaseLocked()
+}
+
+// stats returns the number of fetches holding a worker, and the number waiting for one.
+func (q *fetchQueue) stats() (active, waiting int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.active, len(q.waiting)
+}
+
+func (q *fetchQueue) releaseLocked() {
+	if len(q.waiting) > 0 {
+		w := heap.Pop(&q.waiting).(*fetchWaiter)
+		close(w.ready)
+		return
+	}
+	q.active--
+}
+
+type fetchWaiter struct {
+	priority FetchPriority
+	seq      uint64
+	index    int
+	ready    chan struct{}
+}
+
+// fetchWaiters is a heap of waiting fetches, ordered by highest priority and then
+// by the order they started waiting in.
+type fetchWaiters []*fetchWaiter
+
+func (h fetchWaiters) Len() int { return len(h) }
+
+func (h fetchWaiters) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h fetchWaiters) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *fetchWaiters) Push(x any) {
+	w := x.(*fetchWaiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *fetchWaiters) Pop() any {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return w
+}
@@ -0,0 +1,71 @@
+package ethreceipts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchQueuePriority(t *testing.T) {
+	ctx := context.Background()
+	q := newFetchQueue(1)
+
+	// hold the only worker, so the next fetches have to wait
+	require.NoError(t, q.acquire(ctx, FetchPriorityNormal))
+
+	served := make(chan string, 3)
+	waitFor := func(label string, priority FetchPriority, numWaiting int) {
+		go func() {
+			if err := q.acquire(ctx, priority); err != nil {
+				return
+			}
+			served <- label
+		}()
+		require.Eventually(t, func() bool {
+			_, waiting := q.stats()
+			return waiting == numWaiting
+		}, time.Second, time.Millisecond)
+	}
+	waitFor("normal-1", FetchPriorityNormal, 1)
+	waitFor("normal-2", FetchPriorityNormal, 2)
+	waitFor("high", FetchPriorityHigh, 3)
+
+	// the high priority fetch is served first, and then the rest in order
+	for _, label := range []string{"high", "normal-1", "normal-2"} {
+		q.release()
+		select {
+		case got := <-served:
+			require.Equal(t, label, got)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", label)
+		}
+	}
+
+	q.release()
+	active, waiting := q.stats()
+	require.Equal(t, 0, active)
+	require.Equal(t, 0, waiting)
+}
+
+func TestFetchQueueCancel(t *testing.T) {
+	q := newFetchQueue(1)
+	require.NoError(t, q.acquire(context.Background(), FetchPriorityNormal))
+
+	// a cancelled fetch stops waiting, and doesn't take the worker
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, q.acquire(ctx, FetchPriorityHigh), context.DeadlineExceeded)
+
+	active, waiting := q.stats()
+	require.Equal(t, 1, active)
+	require.Equal(t, 0, waiting)
+
+	q.release()
+	require.NoError(t, q.acquire(context.Background(), FetchPriorityNormal))
+	q.release()
+
+	active, _ = q.stats()
+	require.Equal(t, 0, active)
+}
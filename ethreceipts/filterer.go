@@ -2,13 +2,16 @@ package ethreceipts
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/0xsequence/ethkit"
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 )
 
 // Filter the transaction payload for specific txn "hash"
-func FilterTxnHash(txnHash ethkit.Hash) FilterQuery {
+func FilterTxnHash(txnHash ethkit.Hash) ExtendedFilterQuery {
 	return &filter{
 		cond: FilterCond{
 			TxnHash: ethkit.ToPtr(txnHash),
@@ -32,7 +35,7 @@ func FilterTxnHash(txnHash ethkit.Hash) FilterQuery {
 }
 
 // Filter the transaction payload for "from" address.
-func FilterFrom(from ethkit.Address) FilterQuery {
+func FilterFrom(from ethkit.Address) ExtendedFilterQuery {
 	return &filter{
 		cond: FilterCond{
 			From: ethkit.ToPtr(from),
@@ -45,7 +48,7 @@ func FilterFrom(from ethkit.Address) FilterQuery {
 }
 
 // Filter the transaction payload for "to" address.
-func FilterTo(to ethkit.Address) FilterQuery {
+func FilterTo(to ethkit.Address) ExtendedFilterQuery {
 	return &filter{
 		cond: FilterCond{
 			To: ethkit.ToPtr(to),
@@ -57,10 +60,31 @@ func FilterTo(to ethkit.Address) FilterQuery {
 	}
 }
 
+// Filter the transaction for a "to" address, which matches the "to" address of the
+// transaction payload, or any internal call of the transaction to the address, such as
+// a value transfer through a proxy contract which emits no logs.
+//
+// NOTE: internal calls are found by tracing the transaction with debug_traceTransaction,
+// or trace_transaction, which is done for every transaction whose "to" address does not
+// match, in the blocks matched while a TraceTo filter is registered. On nodes which support
+// neither, or for transactions which fail to be traced, the filter only matches the "to"
+// address of the transaction payload, same as FilterTo.
+func FilterTraceTo(to ethkit.Address) ExtendedFilterQuery {
+	return &filter{
+		cond: FilterCond{
+			TraceTo: ethkit.ToPtr(to),
+		},
+
+		// no default options for TraceTo filter
+		options:   FilterOptions{},
+		exhausted: make(chan struct{}),
+	}
+}
+
 // Filter the logs of a transaction and search for an event log
 // from a specific contract address.
-func FilterLogContract(contractAddress ethkit.Address) FilterQuery {
-	return FilterLogs(func(logs []*types.Log) bool {
+func FilterLogContract(contractAddress ethkit.Address) ExtendedFilterQuery {
+	return filterContractLogs(contractAddress, func(logs []*types.Log) bool {
 		for _, log := range logs {
 			if log.Address == contractAddress {
 				return true
@@ -71,7 +95,7 @@ func FilterLogContract(contractAddress ethkit.Address) FilterQuery {
 }
 
 // Filter the log topics for a transaction
-func FilterLogTopic(eventTopicHash ethkit.Hash) FilterQuery {
+func FilterLogTopic(eventTopicHash ethkit.Hash) ExtendedFilterQuery {
 	return &filter{
 		cond: FilterCond{
 			LogTopic: ethkit.ToPtr(eventTopicHash),
@@ -83,8 +107,44 @@ func FilterLogTopic(eventTopicHash ethkit.Hash) FilterQuery {
 	}
 }
 
+// Filter the logs of a transaction for an event emitted by a specific contract address,
+// where eventSig is the event signature, ie. "Transfer(address,address,uint256)". An error
+// is returned if the event signature is invalid.
+func FilterLogEvent(contractAddress ethkit.Address, eventSig string) (ExtendedFilterQuery, error) {
+	topicHash, _, err := ethcoder.EventTopicHash(eventSig)
+	if err != nil {
+		return nil, fmt.Errorf("ethreceipts: invalid event signature %q: %w", eventSig, err)
+	}
+	return filterContractLogs(contractAddress, func(logs []*types.Log) bool {
+		for _, log := range logs {
+			if log.Address == contractAddress && len(log.Topics) > 0 && log.Topics[0] == topicHash {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// Filter the transaction receipt for a gasUsed amount within the inclusive range
+// of min and max, where a max of 0 has no upper limit.
+//
+// NOTE: gasUsed is only known from the transaction receipt, and not from the transaction
+// payload, so the receipt of every transaction will be fetched in order to match this filter.
+// Transactions whose receipt fails to be fetched are skipped.
+func FilterGasUsed(min, max uint64) ExtendedFilterQuery {
+	return &filter{
+		cond: FilterCond{
+			GasUsed: &GasUsedRange{Min: min, Max: max},
+		},
+
+		// no default options for GasUsed filter
+		options:   FilterOptions{},
+		exhausted: make(chan struct{}),
+	}
+}
+
 // Filter logs of a transaction
-func FilterLogs(logFn func([]*types.Log) bool) FilterQuery {
+func FilterLogs(logFn func([]*types.Log) bool) ExtendedFilterQuery {
 	return &filter{
 		cond: FilterCond{
 			Logs: logFn,
@@ -96,6 +156,21 @@ func FilterLogs(logFn func([]*types.Log) bool) FilterQuery {
 	}
 }
 
+// filterContractLogs is FilterLogs for a logFn which only matches the logs of the contract
+// address, which is set as the LogAddress of the filter cond.
+func filterContractLogs(contractAddress ethkit.Address, logFn func([]*types.Log) bool) ExtendedFilterQuery {
+	return &filter{
+		cond: FilterCond{
+			Logs:       logFn,
+			LogAddress: ethkit.ToPtr(contractAddress),
+		},
+
+		// no default options for Log filter
+		options:   FilterOptions{},
+		exhausted: make(chan struct{}),
+	}
+}
+
 type Filterer interface {
 	FilterQuery
 
@@ -118,6 +193,21 @@ type FilterQuery interface {
 	MaxWait(int) FilterQuery
 }
 
+// ExtendedFilterQuery is a FilterQuery with the filter options which were added after the
+// FilterQuery interface, and are kept off of it so that existing implementations of it
+// don't break. The filters returned by the Filter functions implement it, where the
+// options of ExtendedFilterQuery are set before the options of FilterQuery, ie.
+//
+//	FilterTxnHash(txnHash).Priority(FetchPriorityHigh).MaxWait(0)
+type ExtendedFilterQuery interface {
+	FilterQuery
+
+	Priority(FetchPriority) ExtendedFilterQuery
+	FinalityDepth(int) ExtendedFilterQuery
+	Confirmations(int) ExtendedFilterQuery
+	DecodeLogs(abi.ABI) ExtendedFilterQuery
+}
+
 type FilterOptions struct {
 	// ..
 	ID uint64
@@ -144,6 +234,36 @@ type FilterOptions struct {
 	// 0   : option is disabled, and has no limit on wait. filters need to be manually unsubscribed
 	// N   : a specified number of blocks without a match before unsusbcribe
 	MaxWait *int
+
+	// Priority of the receipt fetches for the filter. When all fetch workers of the
+	// ReceiptsListener are busy, fetches for filters of a higher priority are served
+	// first, so urgent fetches don't wait behind bulk work. Default is FetchPriorityNormal.
+	Priority FetchPriority
+
+	// FinalityDepth is the number of blocks after which a receipt matched by the filter
+	// is considered final, overriding the ReceiptsListener option NumBlocksToFinality, so
+	// a single listener can serve filters with different finality requirements, ie. a
+	// high-value transfer waiting for more confirmations than a UI update.
+	//
+	// NOTE: value of 0 will use the ReceiptsListener option NumBlocksToFinality [default]
+	FinalityDepth int
+
+	// Confirmations is the number of confirmations after which a receipt matched by the
+	// filter is considered final, where the block of the receipt is its first confirmation,
+	// ie. a value of 3 is final once two more blocks are mined on top of it. It's a custom
+	// confirmation policy independent of the chain's finality, and overrides FinalityDepth
+	// and the ReceiptsListener option NumBlocksToFinality.
+	//
+	// NOTE: value of 0 will use FinalityDepth [default]
+	Confirmations int
+
+	// LogDecoder decodes the logs of the receipts matched by the filter, which are then
+	// available from Receipt.DecodedLogs, so subscribers don't have to decode them. Logs
+	// are decoded by their event topic, regardless of the contract which emitted them,
+	// and logs of events unknown to the decoder are skipped. See DecodeLogs.
+	//
+	// NOTE: value of nil will not decode logs [default]
+	LogDecoder *ethcoder.EventDecoder
 }
 
 type FilterCond struct {
@@ -152,6 +272,19 @@ type FilterCond struct {
 	To       *ethkit.Address
 	LogTopic *ethkit.Hash // event signature topic hash
 	Logs     func([]*types.Log) bool
+	GasUsed  *GasUsedRange   // receipt gasUsed range
+	TraceTo  *ethkit.Address // txn "to" or traced internal call "to" address
+
+	// LogAddress is the contract address of the logs matched by the Logs cond, if they're
+	// only of a single contract. It isn't matched on its own, and only narrows the logs
+	// fetched from the node when searching filter history, see SubscribeFromBlock.
+	LogAddress *ethkit.Address
+}
+
+// GasUsedRange is an inclusive range of gasUsed, where a Max of 0 has no upper limit.
+type GasUsedRange struct {
+	Min uint64
+	Max uint64
 }
 
 type filter struct {
@@ -169,8 +302,9 @@ type filter struct {
 }
 
 var (
-	_ Filterer    = &filter{}
-	_ FilterQuery = &filter{}
+	_ Filterer            = &filter{}
+	_ FilterQuery         = &filter{}
+	_ ExtendedFilterQuery = &filter{}
 )
 
 func (f *filter) ID(id uint64) FilterQuery {
@@ -203,6 +337,42 @@ func (f *filter) MaxWait(maxWait int) FilterQuery {
 	return f
 }
 
+func (f *filter) Priority(priority FetchPriority) ExtendedFilterQuery {
+	f.options.Priority = priority
+	return f
+}
+
+func (f *filter) FinalityDepth(numBlocks int) ExtendedFilterQuery {
+	f.options.FinalityDepth = numBlocks
+	return f
+}
+
+func (f *filter) Confirmations(confirmations int) ExtendedFilterQuery {
+	f.options.Confirmations = confirmations
+	return f
+}
+
+// DecodeLogs sets the LogDecoder option to decode the logs of matched receipts against
+// the events of the contract ABI. Logs are matched to the events by their topic only, so
+// the logs of other contracts emitting the same events are decoded too. Anonymous events
+// are skipped, as they have no topic to be identified by.
+func (f *filter) DecodeLogs(contractABI abi.ABI) ExtendedFilterQuery {
+	eventNames := []string{}
+	for name, event := range contractABI.Events {
+		if !event.Anonymous {
+			eventNames = append(eventNames, name)
+		}
+	}
+
+	decoder := ethcoder.NewEventDecoder()
+	if len(eventNames) > 0 {
+		// can't fail, as the events are in the abi and aren't anonymous
+		decoder.RegisterContractABI(contractABI, eventNames...)
+	}
+	f.options.LogDecoder = decoder
+	return f
+}
+
 func (f *filter) FilterID() uint64 {
 	return f.options.ID
 }
@@ -250,6 +420,27 @@ func (f *filter) Match(ctx context.Context, receipt Receipt) (bool, error) {
 		return ok, nil
 	}
 
+	if c.GasUsed != nil {
+		if receipt.receipt == nil {
+			return false, nil
+		}
+		gasUsed := receipt.GasUsed()
+		ok := gasUsed >= c.GasUsed.Min && (c.GasUsed.Max == 0 || gasUsed <= c.GasUsed.Max)
+		return ok, nil
+	}
+
+	if c.TraceTo != nil {
+		if receipt.To() == *c.TraceTo {
+			return true, nil
+		}
+		for _, to := range receipt.callTargets {
+			if to == *c.TraceTo {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
 	return false, ErrFilterCond
 }
 
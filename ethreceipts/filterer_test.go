@@ -0,0 +1,89 @@
+package ethreceipts
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit"
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterLogEvent(t *testing.T) {
+	contract := common.HexToAddress("0x1")
+	topic := ethcoder.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	query, err := FilterLogEvent(contract, "Transfer(address,address,uint256)")
+	require.NoError(t, err)
+
+	match := query.(Filterer).Cond().Logs
+	require.True(t, match([]*types.Log{{Address: contract, Topics: []ethkit.Hash{topic}}}))
+	require.False(t, match([]*types.Log{{Address: common.HexToAddress("0x2"), Topics: []ethkit.Hash{topic}}}))
+	require.False(t, match([]*types.Log{{Address: contract, Topics: []ethkit.Hash{{}}}}))
+
+	_, err = FilterLogEvent(contract, "Transfer(address,address")
+	require.Error(t, err)
+}
+
+func TestFilterGasUsed(t *testing.T) {
+	match := func(query FilterQuery, receipt Receipt) bool {
+		ok, err := query.(Filterer).Match(context.Background(), receipt)
+		require.NoError(t, err)
+		return ok
+	}
+	gasUsed := func(gas uint64) Receipt {
+		return Receipt{receipt: &types.Receipt{GasUsed: gas}}
+	}
+
+	// the range is inclusive of min and max
+	query := FilterGasUsed(100, 200)
+	require.False(t, match(query, gasUsed(99)))
+	require.True(t, match(query, gasUsed(100)))
+	require.True(t, match(query, gasUsed(200)))
+	require.False(t, match(query, gasUsed(201)))
+
+	// gasUsed is unknown without the txn receipt
+	require.False(t, match(query, Receipt{}))
+
+	// a max of 0 has no upper limit
+	query = FilterGasUsed(100, 0)
+	require.False(t, match(query, gasUsed(99)))
+	require.True(t, match(query, gasUsed(100)))
+	require.True(t, match(query, gasUsed(math.MaxUint64)))
+}
+
+func TestDecodeLogs(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}]`))
+	require.NoError(t, err)
+
+	query := FilterLogTopic(contractABI.Events["Transfer"].ID).DecodeLogs(contractABI)
+	decoder := query.(Filterer).Options().LogDecoder
+	require.NotNil(t, decoder)
+
+	transfer := func(contract common.Address) *types.Log {
+		return &types.Log{
+			Address: contract,
+			Topics: []ethkit.Hash{
+				contractABI.Events["Transfer"].ID,
+				common.BytesToHash(common.HexToAddress("0xa").Bytes()),
+				common.BytesToHash(common.HexToAddress("0xb").Bytes()),
+			},
+			Data: common.LeftPadBytes(big.NewInt(100).Bytes(), 32),
+		}
+	}
+	other := &types.Log{Address: common.HexToAddress("0x1"), Topics: []ethkit.Hash{{0x01}}}
+
+	// logs are decoded by their topic, whichever contract emitted them
+	decoded := decodeLogs(decoder, []*types.Log{transfer(common.HexToAddress("0x1")), other, nil, transfer(common.HexToAddress("0x2"))})
+	require.Len(t, decoded, 2)
+	require.Equal(t, common.HexToAddress("0x1"), decoded[0].Log.Address)
+	require.Equal(t, common.HexToAddress("0x2"), decoded[1].Log.Address)
+	require.Equal(t, "Transfer(address,address,uint256)", decoded[0].Event.Signature)
+	require.Equal(t, big.NewInt(100), decoded[0].Values[2])
+}
@@ -16,8 +16,10 @@ type finalizer struct {
 }
 
 type finalTxn struct {
-	receipt  Receipt
-	blockNum *big.Int
+	receipt             Receipt
+	blockNum            *big.Int
+	filterID            uint64
+	numBlocksToFinality *big.Int
 }
 
 func (f *finalizer) len() int {
@@ -54,19 +56,32 @@ func (f *finalizer) enqueue(filterID uint64, receipt Receipt, blockNum *big.Int)
 		}
 	}
 
+	// the filter may override the finality depth of the listener
+	numBlocksToFinality := f.numBlocksToFinality
+	if receipt.Filter != nil {
+		if confirmations := receipt.Filter.Options().Confirmations; confirmations > 0 {
+			// dequeue finalizes once the current block is past blockNum+numBlocksToFinality,
+			// so the receipt has the confirmations, counting its own block, at that point
+			numBlocksToFinality = big.NewInt(int64(confirmations - 2))
+		} else if receipt.Filter.Options().FinalityDepth > 0 {
+			numBlocksToFinality = big.NewInt(int64(receipt.Filter.Options().FinalityDepth))
+		}
+	}
+	txn := finalTxn{receipt, blockNum, filterID, numBlocksToFinality}
+
 	if _, ok := f.txns[txnID]; ok {
 		// update the blockNum if we already have this txn, as it could have been included
 		// again after a reorg in a new block
 		for i, entry := range f.queue {
-			if entry.receipt.TransactionHash() == txnHash {
-				f.queue[i] = finalTxn{receipt, blockNum}
+			if entry.receipt.TransactionHash() == txnHash && entry.filterID == filterID {
+				f.queue[i] = txn
 			}
 		}
 		return
 	}
 
 	// append new
-	f.queue = append(f.queue, finalTxn{receipt, blockNum})
+	f.queue = append(f.queue, txn)
 	f.txns[txnID] = struct{}{}
 
 	// sort block order from oldest to newest in case of a reorg
@@ -83,15 +98,16 @@ func (f *finalizer) dequeue(currentBlockNum *big.Int) []finalTxn {
 
 	finalTxns := []finalTxn{}
 
+	// txns finalize out of block order, as each one may have its own finality depth
+	pending := f.queue[:0]
 	for _, txn := range f.queue {
-		if currentBlockNum.Cmp(big.NewInt(0).Add(txn.blockNum, f.numBlocksToFinality)) > 0 {
+		if currentBlockNum.Cmp(big.NewInt(0).Add(txn.blockNum, txn.numBlocksToFinality)) > 0 {
 			finalTxns = append(finalTxns, txn)
+		} else {
+			pending = append(pending, txn)
 		}
 	}
-
-	if len(finalTxns) > 0 {
-		f.queue = f.queue[len(finalTxns):]
-	}
+	f.queue = pending
 
 	return finalTxns
 }
@@ -0,0 +1,121 @@
+package ethreceipts
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFinalizer(numBlocksToFinality int64) *finalizer {
+	return &finalizer{
+		numBlocksToFinality: big.NewInt(numBlocksToFinality),
+		queue:               []finalTxn{},
+		txns:                map[ethkit.Hash]struct{}{},
+	}
+}
+
+func testReceipt(txnHash ethkit.Hash, filter FilterQuery) Receipt {
+	return Receipt{Filter: filter.(Filterer), receipt: &types.Receipt{TxHash: txnHash}}
+}
+
+func dequeuedFilterIDs(f *finalizer, blockNum int64) []uint64 {
+	ids := []uint64{}
+	for _, txn := range f.dequeue(big.NewInt(blockNum)) {
+		ids = append(ids, txn.filterID)
+	}
+	return ids
+}
+
+func TestFilterFinalityDepth(t *testing.T) {
+	_, ok := filterFinalityDepth(nil)
+	require.False(t, ok)
+
+	_, ok = filterFinalityDepth(FilterTxnHash(ethkit.Hash{1}).(Filterer))
+	require.False(t, ok)
+
+	depth, ok := filterFinalityDepth(FilterTxnHash(ethkit.Hash{1}).FinalityDepth(5).(Filterer))
+	require.True(t, ok)
+	require.Equal(t, 5, depth)
+
+	// the block of the receipt is its first confirmation
+	for _, confirmations := range []int{1, 2, 3} {
+		depth, ok := filterFinalityDepth(FilterTxnHash(ethkit.Hash{1}).Confirmations(confirmations).(Filterer))
+		require.True(t, ok)
+		require.Equal(t, confirmations-1, depth)
+	}
+
+	// confirmations override the finality depth
+	depth, ok = filterFinalityDepth(FilterTxnHash(ethkit.Hash{1}).FinalityDepth(5).Confirmations(2).(Filterer))
+	require.True(t, ok)
+	require.Equal(t, 1, depth)
+}
+
+func TestFinalizerFinalityDepth(t *testing.T) {
+	f := newTestFinalizer(10)
+
+	// the same txn is matched by a filter using the listener's finality, and by a
+	// filter with its own finality depth
+	txnHash := ethkit.Hash{1}
+	f.enqueue(1, testReceipt(txnHash, FilterTxnHash(txnHash).ID(1)), big.NewInt(100))
+	f.enqueue(2, testReceipt(txnHash, FilterTxnHash(txnHash).FinalityDepth(3).ID(2)), big.NewInt(100))
+	require.Equal(t, 2, f.len())
+
+	require.Empty(t, dequeuedFilterIDs(f, 103))
+	require.Equal(t, []uint64{2}, dequeuedFilterIDs(f, 104))
+	require.Empty(t, dequeuedFilterIDs(f, 110))
+	require.Equal(t, []uint64{1}, dequeuedFilterIDs(f, 111))
+	require.Zero(t, f.len())
+}
+
+func TestFinalizerReorgedFinalityDepth(t *testing.T) {
+	f := newTestFinalizer(10)
+
+	// a txn included again in a later block after a reorg keeps its filter's depth
+	txnHash := ethkit.Hash{1}
+	receipt := testReceipt(txnHash, FilterTxnHash(txnHash).FinalityDepth(3).ID(1))
+	f.enqueue(1, receipt, big.NewInt(100))
+	f.enqueue(1, receipt, big.NewInt(102))
+	require.Equal(t, 1, f.len())
+
+	require.Empty(t, dequeuedFilterIDs(f, 104))
+	require.Equal(t, []uint64{1}, dequeuedFilterIDs(f, 106))
+}
+
+func TestFinalizerConfirmations(t *testing.T) {
+	f := newTestFinalizer(10)
+
+	// the same txn mined in block 100 is matched by filters waiting for 1, 2 and 3
+	// confirmations, where block 100 is its first confirmation
+	txnHash := ethkit.Hash{1}
+	for _, confirmations := range []int{1, 2, 3} {
+		filter := FilterTxnHash(txnHash).Confirmations(confirmations).ID(uint64(confirmations))
+		f.enqueue(uint64(confirmations), testReceipt(txnHash, filter), big.NewInt(100))
+	}
+	require.Equal(t, 3, f.len())
+
+	require.Empty(t, dequeuedFilterIDs(f, 99))
+	require.Equal(t, []uint64{1}, dequeuedFilterIDs(f, 100))
+	require.Equal(t, []uint64{2}, dequeuedFilterIDs(f, 101))
+	require.Equal(t, []uint64{3}, dequeuedFilterIDs(f, 102))
+	require.Zero(t, f.len())
+}
+
+func TestFinalizerConfirmationsFinalityDepth(t *testing.T) {
+	f := newTestFinalizer(10)
+
+	// the confirmations of a filter override its finality depth, and the listener's
+	txnHash := ethkit.Hash{1}
+	f.enqueue(1, testReceipt(txnHash, FilterTxnHash(txnHash).FinalityDepth(5).Confirmations(2).ID(1)), big.NewInt(100))
+	f.enqueue(2, testReceipt(txnHash, FilterTxnHash(txnHash).FinalityDepth(5).ID(2)), big.NewInt(100))
+	f.enqueue(3, testReceipt(txnHash, FilterTxnHash(txnHash).ID(3)), big.NewInt(100))
+
+	require.Equal(t, []uint64{1}, dequeuedFilterIDs(f, 101))
+	require.Empty(t, dequeuedFilterIDs(f, 105))
+	require.Equal(t, []uint64{2}, dequeuedFilterIDs(f, 106))
+	require.Empty(t, dequeuedFilterIDs(f, 110))
+	require.Equal(t, []uint64{3}, dequeuedFilterIDs(f, 111))
+	require.Zero(t, f.len())
+}
@@ -0,0 +1,415 @@
+package ethreceipts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/goware/superr"
+	"golang.org/x/sync/errgroup"
+)
+
+// historyScanChunkSize is the number of blocks fetched per batch, including the
+// block range of each eth_getLogs request, when searching filter history on-chain.
+const historyScanChunkSize = 100
+
+// errHistoryScanDone stops the log search of searchFilterHistory once all LimitOne
+// filters have found their match.
+var errHistoryScanDone = errors.New("ethreceipts: history scan done")
+
+// searchFilterHistory fetches blocks and their logs from the node between fromBlock and
+// toBlock (inclusive) in chunks, and matches them against the subscriber's filters. Only
+// the logs matched by the filters are fetched, see historyLogQuery, and when the filters
+// only match on logs, only the blocks with such logs are fetched.
+// The returned list marks which of the filterers had at least one match.
+func (l *ReceiptsListener) searchFilterHistory(ctx context.Context, sub *subscriber, fromBlock, toBlock uint64, filterers []Filterer) ([]bool, error) {
+	oks := make([]bool, len(filterers))
+
+	// processHistoryBlocks matches the blocks against the filters, and reports whether
+	// all LimitOne filters have found their match, so the search can stop early.
+	processHistoryBlocks := func(blocks ethmonitor.Blocks) (bool, error) {
+		// process one block at a time, as processBlocks only reports the
+		// matches of the last block it was given.
+		for _, block := range blocks {
+			matchedList, err := l.processBlocks(ethmonitor.Blocks{block}, []*subscriber{sub}, [][]Filterer{filterers}, time.Time{})
+			if err != nil {
+				return false, err
+			}
+			for i, matched := range matchedList[0] {
+				oks[i] = oks[i] || matched
+			}
+		}
+		return len(collectLimitOneUnmatched(filterers, oks)) == 0, nil
+	}
+
+	query, allBlocks := historyLogQuery(filterers)
+
+	// the filters only match on logs, so only the blocks with logs can match
+	if query != nil && !allBlocks {
+		query.FromBlock = new(big.Int).SetUint64(fromBlock)
+		query.ToBlock = new(big.Int).SetUint64(toBlock)
+
+		err := l.filterLogsRange(ctx, *query, func(logs []types.Log) error {
+			blocks, err := l.fetchBlocksOfLogs(ctx, logs)
+			if err != nil {
+				return err
+			}
+			done, err := processHistoryBlocks(blocks)
+			if err != nil {
+				return err
+			}
+			if done {
+				return errHistoryScanDone
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errHistoryScanDone) {
+			return oks, superr.Wrap(fmt.Errorf("failed to search logs of blocks %d to %d", fromBlock, toBlock), err)
+		}
+		return oks, nil
+	}
+
+	for start := fromBlock; start <= toBlock; start += historyScanChunkSize {
+		end := start + historyScanChunkSize - 1
+		if end > toBlock {
+			end = toBlock
+		}
+
+		blocks, err := l.fetchBlocksWithLogs(ctx, query, start, end)
+		if err != nil {
+			return oks, superr.Wrap(fmt.Errorf("failed to fetch blocks %d to %d", start, end), err)
+		}
+
+		done, err := processHistoryBlocks(blocks)
+		if err != nil {
+			return oks, err
+		}
+		if done {
+			break
+		}
+	}
+
+	return oks, nil
+}
+
+// historyLogQuery returns the eth_getLogs query of the logs matched by the filterers, by
+// the addresses and topics of their conds, or nil if none of the filterers match on logs.
+// The returned bool reports whether any of the filterers match on the transactions of
+// the blocks instead, in which case every block has to be fetched, and not only the
+// blocks with logs.
+//
+// NOTE: an eth_getLogs query matches the logs of any of its addresses which also have
+// any of its topics, so filters by address and filters by topic can't be combined into
+// one query, in which case all logs are fetched, same as for Logs conds without a LogAddress.
+func historyLogQuery(filterers []Filterer) (*ethereum.FilterQuery, bool) {
+	var addresses []common.Address
+	var topics []common.Hash
+	var hasLogs, allLogs, allBlocks bool
+
+	// NOTE: the conds are checked in the same order as filter.Match
+	for _, f := range filterers {
+		c := f.Cond()
+		switch {
+		case c.TxnHash != nil || c.From != nil || c.To != nil:
+			allBlocks = true
+		case c.LogTopic != nil:
+			hasLogs = true
+			if !slices.Contains(topics, *c.LogTopic) {
+				topics = append(topics, *c.LogTopic)
+			}
+		case c.Logs != nil:
+			hasLogs = true
+			if c.LogAddress == nil {
+				allLogs = true
+			} else if !slices.Contains(addresses, *c.LogAddress) {
+				addresses = append(addresses, *c.LogAddress)
+			}
+		default:
+			allBlocks = true
+		}
+	}
+
+	if !hasLogs {
+		return nil, allBlocks
+	}
+
+	query := &ethereum.FilterQuery{}
+	if !allLogs && (len(addresses) == 0 || len(topics) == 0) {
+		query.Addresses = addresses
+		if len(topics) > 0 {
+			query.Topics = [][]common.Hash{topics}
+		}
+	}
+	return query, allBlocks
+}
+
+// filterLogsRange calls fn with the logs of the query in chunks of historyScanChunkSize
+// blocks, using the provider's FilterLogsRange if supported, which halves the chunks
+// the node rejects for returning too many results.
+func (l *ReceiptsListener) filterLogsRange(ctx context.Context, q ethereum.FilterQuery, fn func(logs []types.Log) error) error {
+	if provider, ok := l.provider.(ethrpc.FilterLogsRangeInterface); ok {
+		return provider.FilterLogsRange(ctx, q, historyScanChunkSize, fn)
+	}
+
+	fromBlock, toBlock := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	for start := fromBlock; start <= toBlock; start += historyScanChunkSize {
+		chunk := q
+		chunk.FromBlock = new(big.Int).SetUint64(start)
+		chunk.ToBlock = new(big.Int).SetUint64(min(start+historyScanChunkSize-1, toBlock))
+
+		var logs []types.Log
+		err := l.br.Do(ctx, func() error {
+			var err error
+			logs, err = l.provider.FilterLogs(ctx, chunk)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := fn(logs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchBlocksWithLogs fetches blocks between fromBlock and toBlock (inclusive) along with
+// their logs of the query, if any, and returns them as monitor blocks ordered from oldest
+// to newest.
+func (l *ReceiptsListener) fetchBlocksWithLogs(ctx context.Context, query *ethereum.FilterQuery, fromBlock, toBlock uint64) (ethmonitor.Blocks, error) {
+	var blocks []*types.Block
+	err := l.br.Do(ctx, func() error {
+		var err error
+		blocks, err = l.provider.BlocksByNumberRange(ctx, new(big.Int).SetUint64(fromBlock), new(big.Int).SetUint64(toBlock+1))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []types.Log
+	if query != nil {
+		q := *query
+		q.FromBlock = new(big.Int).SetUint64(fromBlock)
+		q.ToBlock = new(big.Int).SetUint64(toBlock)
+
+		err = l.filterLogsRange(ctx, q, func(chunk []types.Log) error {
+			logs = append(logs, chunk...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return historyBlocks(blocks, logs), nil
+}
+
+// fetchBlocksOfLogs fetches the blocks of the logs, and returns them along with their logs
+// as monitor blocks ordered from oldest to newest.
+func (l *ReceiptsListener) fetchBlocksOfLogs(ctx context.Context, logs []types.Log) (ethmonitor.Blocks, error) {
+	blockNums := []*big.Int{}
+	for i, log := range logs {
+		if i > 0 && logs[i-1].BlockNumber == log.BlockNumber {
+			continue
+		}
+		blockNums = append(blockNums, new(big.Int).SetUint64(log.BlockNumber))
+	}
+	if len(blockNums) == 0 {
+		return nil, nil
+	}
+
+	var blocks []*types.Block
+	err := l.br.Do(ctx, func() error {
+		var err error
+		blocks, err = l.provider.BlocksByNumbers(ctx, blockNums)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return historyBlocks(blocks, logs), nil
+}
+
+// historyBlocks returns the blocks along with their logs as monitor blocks.
+func historyBlocks(blocks []*types.Block, logs []types.Log) ethmonitor.Blocks {
+	blockLogs := make(map[uint64][]types.Log, len(blocks))
+	for _, log := range logs {
+		blockLogs[log.BlockNumber] = append(blockLogs[log.BlockNumber], log)
+	}
+
+	out := make(ethmonitor.Blocks, 0, len(blocks))
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		out = append(out, &ethmonitor.Block{
+			Block: block,
+			Event: ethmonitor.Added,
+			Logs:  blockLogs[block.NumberU64()],
+			OK:    true,
+		})
+	}
+	return out
+}
+
+// collectLimitOneUnmatched returns the filterers which are still waiting for a match,
+// which is every filter except LimitOne filters which have already matched.
+func collectLimitOneUnmatched(filterers []Filterer, oks []bool) []Filterer {
+	var out []Filterer
+	for i, f := range filterers {
+		if oks[i] && f.Options().LimitOne {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// FetchReceiptsOptions are the options of FetchReceiptsByAddress.
+type FetchReceiptsOptions struct {
+	// FromBlock is the first block number to search, inclusive. It must be within the
+	// blocks retained by the monitor, where 0 searches from the oldest retained block.
+	FromBlock uint64
+
+	// ToBlock is the last block number to search, inclusive, where 0 searches up to
+	// the latest block of the monitor.
+	ToBlock uint64
+
+	// MaxCount is the maximum number of receipts returned, keeping the most recent
+	// ones. A value of 0 will set no limit.
+	MaxCount int
+
+	// CacheOnly only returns the receipts found in the listener's receipts cache, without
+	// fetching the missing receipts from the node.
+	CacheOnly bool
+
+	// Priority of the receipt fetches, see FilterOptions#Priority.
+	Priority FetchPriority
+}
+
+// FetchReceiptsByAddress returns the receipts of the transactions sent from, or sent to the
+// address within the blocks retained by the monitor, ordered from oldest to newest. Unlike
+// Subscribe, this searches the blocks already seen and returns right away.
+//
+// NOTE: the transactions of blocks sampled by the monitor, see ethmonitor Options#BlockSamplingInterval,
+// are no longer retained, and will not be found.
+func (l *ReceiptsListener) FetchReceiptsByAddress(ctx context.Context, address common.Address, opts FetchReceiptsOptions) ([]Receipt, error) {
+	blocks := l.monitor.Chain().Blocks()
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	oldestBlockNum := blocks[0].NumberU64()
+	latestBlockNum := blocks[len(blocks)-1].NumberU64()
+
+	fromBlock, toBlock := opts.FromBlock, opts.ToBlock
+	if fromBlock == 0 {
+		fromBlock = oldestBlockNum
+	}
+	if toBlock == 0 || toBlock > latestBlockNum {
+		toBlock = latestBlockNum
+	}
+	if fromBlock < oldestBlockNum {
+		return nil, superr.Wrap(ErrFromBlockOutOfRange, fmt.Errorf("fromBlock=%d oldestRetainedBlock=%d", fromBlock, oldestBlockNum))
+	}
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("ethreceipts: fromBlock %d is after toBlock %d", fromBlock, toBlock)
+	}
+
+	// search from the newest block, so MaxCount keeps the most recent receipts
+	var receipts []Receipt
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		if block.NumberU64() > toBlock {
+			continue
+		}
+		if block.NumberU64() < fromBlock {
+			break
+		}
+
+		txns := block.Transactions()
+		logs := groupLogsByTransaction(block.Logs)
+
+		for j := len(txns) - 1; j >= 0; j-- {
+			txn := txns[j]
+
+			txnMsg, err := ethtxn.AsMessage(txn)
+			if err != nil {
+				l.log.Warnf("unexpected failure of txn (%s index %d) on block %d AsMessage(..): %s", txn.Hash(), j, block.NumberU64(), err)
+				continue
+			}
+			if txnMsg.From != address && (txn.To() == nil || *txn.To() != address) {
+				continue
+			}
+
+			txnLogs, ok := logs[txn.Hash().Hex()]
+			if !ok {
+				txnLogs = []*types.Log{}
+			}
+			receipts = append(receipts, Receipt{
+				transaction: txn,
+				message:     txnMsg,
+				logs:        txnLogs,
+			})
+		}
+
+		if opts.MaxCount > 0 && len(receipts) >= opts.MaxCount {
+			break
+		}
+	}
+
+	if opts.MaxCount > 0 && len(receipts) > opts.MaxCount {
+		receipts = receipts[:opts.MaxCount]
+	}
+	slices.Reverse(receipts)
+
+	// fetch the transaction receipts
+	g, gctx := errgroup.WithContext(ctx)
+	for i := range receipts {
+		receipt := &receipts[i]
+		txnHash := receipt.TransactionHash()
+
+		if opts.CacheOnly {
+			r, ok, _ := l.pastReceipts.Get(ctx, txnHash.String())
+			if ok {
+				receipt.receipt = r
+			}
+			continue
+		}
+
+		g.Go(func() error {
+			r, err := l.fetchTransactionReceipt(gctx, txnHash, true, opts.Priority)
+			if err != nil {
+				return superr.Wrap(fmt.Errorf("failed to fetch txn %s receipt", txnHash), err)
+			}
+			receipt.receipt = r
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	out := receipts[:0]
+	for _, receipt := range receipts {
+		if receipt.receipt == nil {
+			// not in the cache
+			continue
+		}
+		receipt.logs = receipt.receipt.Logs
+		receipt.Final = l.isBlockFinal(receipt.BlockNumber())
+		out = append(out, receipt)
+	}
+	return out, nil
+}
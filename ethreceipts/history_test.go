@@ -0,0 +1,296 @@
+package ethreceipts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/goware/breaker"
+	"github.com/goware/logger"
+	"github.com/stretchr/testify/require"
+)
+
+// testNode is a mock node, which serves the json-rpc requests with handle, and records
+// the requested methods.
+type testNode struct {
+	handle func(method string, params []json.RawMessage) (any, error)
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (n *testNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		ID     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	serve := func(req request) map[string]any {
+		n.mu.Lock()
+		n.calls = append(n.calls, req.Method)
+		n.mu.Unlock()
+
+		result, err := n.handle(req.Method, req.Params)
+		if err != nil {
+			return map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": -32000, "message": err.Error()}}
+		}
+		return map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result}
+	}
+
+	var body json.RawMessage
+	json.NewDecoder(r.Body).Decode(&body)
+
+	var batch []request
+	if json.Unmarshal(body, &batch) == nil {
+		out := []map[string]any{}
+		for _, req := range batch {
+			out = append(out, serve(req))
+		}
+		json.NewEncoder(w).Encode(out)
+		return
+	}
+	var req request
+	json.Unmarshal(body, &req)
+	json.NewEncoder(w).Encode(serve(req))
+}
+
+func (n *testNode) called(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	count := 0
+	for _, m := range n.calls {
+		if m == method {
+			count++
+		}
+	}
+	return count
+}
+
+// newTestListener returns a listener of the mock node, with a monitor which isn't running
+// so that the listener's methods can be called directly. The latest block is latestBlock.
+func newTestListener(t *testing.T, latestBlock uint64, handle func(method string, params []json.RawMessage) (any, error)) (*ReceiptsListener, *testNode) {
+	node := &testNode{handle: func(method string, params []json.RawMessage) (any, error) {
+		if method == "eth_getBlockByNumber" && string(params[0]) == `"latest"` {
+			return testBlockJSON(testBlock(latestBlock), common.Address{}), nil
+		}
+		return handle(method, params)
+	}}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.Logger = logger.Nop()
+	monitorOptions.WithLogs = true
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+
+	options := DefaultOptions
+	options.NumBlocksToFinality = 10
+	listener, err := NewReceiptsListener(logger.Nop(), provider, monitor, options)
+	require.NoError(t, err)
+
+	// as set by Run
+	listener.ctx = context.Background()
+
+	// fail fast on node errors
+	listener.br = breaker.New(logger.Nop(), time.Millisecond, 1, 1)
+
+	return listener, node
+}
+
+func testBlock(num uint64, txns ...*types.Transaction) *types.Block {
+	header := &types.Header{Number: new(big.Int).SetUint64(num), Difficulty: big.NewInt(0)}
+	return types.NewBlockWithHeader(header).WithBody(types.Body{Transactions: txns})
+}
+
+// testBlockJSON returns the json-rpc response of the block, with its transactions sent from.
+func testBlockJSON(block *types.Block, from common.Address) map[string]any {
+	out := map[string]any{}
+	data, _ := json.Marshal(block.Header())
+	json.Unmarshal(data, &out)
+
+	txns := []map[string]any{}
+	for i, txn := range block.Transactions() {
+		data, _ := json.Marshal(txn)
+		txnJSON := map[string]any{}
+		json.Unmarshal(data, &txnJSON)
+		txnJSON["from"] = from
+		txnJSON["blockHash"] = block.Hash()
+		txnJSON["blockNumber"] = hexutil.Uint64(block.NumberU64())
+		txnJSON["transactionIndex"] = hexutil.Uint64(i)
+		txns = append(txns, txnJSON)
+	}
+	out["transactions"] = txns
+	out["uncles"] = []common.Hash{}
+	return out
+}
+
+// testSignedTxns returns n signed transactions, and their sender.
+func testSignedTxns(t *testing.T, n int) ([]*types.Transaction, common.Address) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	txns := []*types.Transaction{}
+	for i := 0; i < n; i++ {
+		to := common.HexToAddress("0x1234")
+		txns = append(txns, types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: uint64(i), To: &to, Gas: 21000, GasPrice: big.NewInt(1)}))
+	}
+	return txns, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// readReceipts returns the receipts delivered to the subscription within a short wait.
+func readReceipts(sub Subscription) []Receipt {
+	receipts := []Receipt{}
+	for {
+		select {
+		case receipt := <-sub.TransactionReceipt():
+			receipts = append(receipts, receipt)
+		case <-time.After(200 * time.Millisecond):
+			return receipts
+		}
+	}
+}
+
+func TestHistoryLogQuery(t *testing.T) {
+	contract := common.HexToAddress("0x1234")
+	topic := common.HexToHash("0xabcd")
+
+	filterEvent, err := FilterLogEvent(contract, "Transfer(address,address,uint256)")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		filters   []FilterQuery
+		query     *ethereum.FilterQuery
+		allBlocks bool
+	}{
+		{"txn", []FilterQuery{FilterFrom(contract)}, nil, true},
+		{"address", []FilterQuery{FilterLogContract(contract), filterEvent}, &ethereum.FilterQuery{Addresses: []common.Address{contract}}, false},
+		{"topic", []FilterQuery{FilterLogTopic(topic)}, &ethereum.FilterQuery{Topics: [][]common.Hash{{topic}}}, false},
+		{"address and topic", []FilterQuery{FilterLogContract(contract), FilterLogTopic(topic)}, &ethereum.FilterQuery{}, false},
+		{"logs", []FilterQuery{FilterLogs(func([]*types.Log) bool { return true }), FilterLogTopic(topic)}, &ethereum.FilterQuery{}, false},
+		{"txn and topic", []FilterQuery{FilterTo(contract), FilterLogTopic(topic)}, &ethereum.FilterQuery{Topics: [][]common.Hash{{topic}}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filterers := []Filterer{}
+			for _, f := range test.filters {
+				filterers = append(filterers, f.(Filterer))
+			}
+			query, allBlocks := historyLogQuery(filterers)
+			require.Equal(t, test.query, query)
+			require.Equal(t, test.allBlocks, allBlocks)
+		})
+	}
+}
+
+func TestSearchFilterHistoryLogs(t *testing.T) {
+	contract := common.HexToAddress("0x1234")
+	txns, from := testSignedTxns(t, 3)
+	blocks := []*types.Block{testBlock(10, txns[0]), testBlock(11, txns[1]), testBlock(12, txns[2])}
+
+	var getLogs []map[string]any
+	var getBlocks []uint64
+	listener, _ := newTestListener(t, 100, func(method string, params []json.RawMessage) (any, error) {
+		switch method {
+		case "eth_getLogs":
+			var q map[string]any
+			json.Unmarshal(params[0], &q)
+			getLogs = append(getLogs, q)
+
+			// only the txn of block 11 has a log of the contract
+			return []types.Log{{Address: contract, Topics: []common.Hash{}, Data: []byte{}, BlockNumber: 11, BlockHash: blocks[1].Hash(), TxHash: txns[1].Hash()}}, nil
+		case "eth_getBlockByNumber":
+			var num hexutil.Uint64
+			json.Unmarshal(params[0], &num)
+			getBlocks = append(getBlocks, uint64(num))
+			return testBlockJSON(blocks[num-10], from), nil
+		}
+		return nil, fmt.Errorf("unexpected method %s", method)
+	})
+	ctx := context.Background()
+	listener.pastReceipts.Set(ctx, txns[1].Hash().String(), &types.Receipt{TxHash: txns[1].Hash(), BlockNumber: big.NewInt(11), Logs: []*types.Log{}})
+
+	sub := listener.subscribe(0, FilterLogContract(contract)).(*subscriber)
+	oks, err := listener.searchFilterHistory(ctx, sub, 10, 12, sub.Filters())
+	require.NoError(t, err)
+	require.Equal(t, []bool{true}, oks)
+
+	// the logs are queried by the address of the filter, and only the block of the log is fetched
+	require.Len(t, getLogs, 1)
+	require.Equal(t, []any{contract.Hex()}, getLogs[0]["address"])
+	require.Equal(t, "0xa", getLogs[0]["fromBlock"])
+	require.Equal(t, "0xc", getLogs[0]["toBlock"])
+	require.Equal(t, []uint64{11}, getBlocks)
+
+	receipts := readReceipts(sub)
+	require.Len(t, receipts, 1)
+	require.Equal(t, txns[1].Hash(), receipts[0].TransactionHash())
+}
+
+func TestSearchFilterHistoryOverlap(t *testing.T) {
+	txns, from := testSignedTxns(t, 2)
+	block := testBlock(11, txns...)
+
+	listener, _ := newTestListener(t, 100, func(method string, params []json.RawMessage) (any, error) {
+		switch method {
+		case "eth_getBlockByNumber":
+			return testBlockJSON(block, from), nil
+		}
+		return nil, fmt.Errorf("unexpected method %s", method)
+	})
+	ctx := context.Background()
+	for _, txn := range txns {
+		listener.pastReceipts.Set(ctx, txn.Hash().String(), &types.Receipt{TxHash: txn.Hash(), BlockNumber: big.NewInt(11), Logs: []*types.Log{}})
+	}
+
+	sub := listener.subscribe(11, FilterFrom(from)).(*subscriber)
+	filters := sub.Filters()
+
+	// the new block is matched while the history search of the filter is in progress,
+	// which then matches the same block
+	_, err := listener.processBlocks(ethmonitor.Blocks{{Block: block, Event: ethmonitor.Added, OK: true}}, []*subscriber{sub}, [][]Filterer{filters}, time.Now())
+	require.NoError(t, err)
+	oks, err := listener.searchFilterHistory(ctx, sub, 11, 11, filters)
+	require.NoError(t, err)
+	require.Equal(t, []bool{true}, oks)
+
+	receipts := readReceipts(sub)
+	require.Len(t, receipts, 2)
+	require.Equal(t, txns[0].Hash(), receipts[0].TransactionHash())
+	require.Equal(t, txns[1].Hash(), receipts[1].TransactionHash())
+
+	// a reorg of the block is delivered, and the receipts once they're re-mined
+	_, err = listener.processBlocks(ethmonitor.Blocks{{Block: block, Event: ethmonitor.Removed, OK: true}}, []*subscriber{sub}, [][]Filterer{filters}, time.Now())
+	require.NoError(t, err)
+	_, err = listener.searchFilterHistory(ctx, sub, 11, 11, filters)
+	require.NoError(t, err)
+
+	receipts = readReceipts(sub)
+	require.Len(t, receipts, 4)
+	require.True(t, receipts[0].Reorged)
+	require.True(t, receipts[1].Reorged)
+	require.False(t, receipts[2].Reorged)
+	require.False(t, receipts[3].Reorged)
+
+	// once the search is done, the filter is only matched against new blocks
+	sub.searchDone(filters)
+	require.Empty(t, sub.searching)
+}
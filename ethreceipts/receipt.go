@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"github.com/0xsequence/ethkit"
+	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
@@ -18,6 +19,20 @@ type Receipt struct {
 	message     *core.Message // TODO: this intermediate type is lame.. with new ethrpc we can remove
 	receipt     *types.Receipt
 	logs        []*types.Log
+
+	// callTargets are the "to" addresses of the internal calls of the txn, which are
+	// only set once traced for the TraceTo filter cond
+	callTargets []common.Address
+
+	// decodedLogs are the logs decoded by the LogDecoder filter option
+	decodedLogs []DecodedLog
+}
+
+// DecodedLog is a log of a receipt decoded against the ABI of its event.
+type DecodedLog struct {
+	Log    *types.Log
+	Event  ethcoder.ABISignature // the event of the log, ie. Transfer(address,address,uint256)
+	Values []interface{}         // the event argument values, in order of the event inputs
 }
 
 func (r *Receipt) Receipt() *types.Receipt {
@@ -140,6 +155,16 @@ func (r *Receipt) Logs() []*types.Log {
 	}
 }
 
+// DecodedLogs returns the logs of the receipt decoded by the LogDecoder option of the
+// filter which matched it, see ExtendedFilterQuery.DecodeLogs. Logs are matched to the
+// events of the decoder by their topic only, so the logs of any contract emitting an
+// event of the same signature are decoded too, and can be told apart by Log.Address.
+// Logs of other events, or which fail to decode, are omitted. Returns nil if the filter
+// has no LogDecoder.
+func (r *Receipt) DecodedLogs() []DecodedLog {
+	return r.decodedLogs
+}
+
 func (r *Receipt) From() common.Address {
 	if r.receipt != nil {
 		return r.receipt.From
@@ -164,3 +189,20 @@ func (r *Receipt) To() common.Address {
 		return common.Address{}
 	}
 }
+
+// decodeLogs decodes the logs with the decoder, skipping the logs which don't match any
+// of its events or fail to decode.
+func decodeLogs(decoder *ethcoder.EventDecoder, logs []*types.Log) []DecodedLog {
+	decodedLogs := []DecodedLog{}
+	for _, log := range logs {
+		if log == nil {
+			continue
+		}
+		event, values, ok, err := decoder.DecodeLog(*log)
+		if err != nil || !ok {
+			continue
+		}
+		decodedLogs = append(decodedLogs, DecodedLog{Log: log, Event: event, Values: values})
+	}
+	return decodedLogs
+}
@@ -0,0 +1,78 @@
+package ethreceipts
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the activity of the listener, see ReceiptsListener#Stats.
+type Stats struct {
+	// Subscribers is the number of active subscriptions, and ActiveFilters the number
+	// of filters across all of them which are still listening for matches.
+	Subscribers   int
+	ActiveFilters int
+
+	// PastReceiptsCacheHits and PastReceiptsCacheMisses count the receipt fetches served
+	// from the past receipts cache, and those which had to be fetched from the node.
+	PastReceiptsCacheHits   uint64
+	PastReceiptsCacheMisses uint64
+
+	// OnChainFetches counts the receipts requested from the node, including retries.
+	OnChainFetches uint64
+
+	// ActiveFetches is the number of fetches currently using a fetch worker, and
+	// QueuedFetches the number waiting for one, see MaxConcurrentFetchReceiptWorkers.
+	ActiveFetches int
+	QueuedFetches int
+
+	// FilterMatches counts the filter matches of new blocks from the monitor, and
+	// AvgMatchLatency is their average time from the arrival of the block to the match,
+	// which includes fetching the receipt. Matches of cached and historical blocks, when
+	// registering a filter, are not included.
+	FilterMatches   uint64
+	AvgMatchLatency time.Duration
+}
+
+// Stats returns a snapshot of the activity of the listener, ie. to export as metrics.
+// Counters are totals since the listener was created.
+func (l *ReceiptsListener) Stats() Stats {
+	l.mu.Lock()
+	stats := Stats{Subscribers: len(l.subscribers)}
+	subscribers := make([]*subscriber, len(l.subscribers))
+	copy(subscribers, l.subscribers)
+	l.mu.Unlock()
+
+	for _, sub := range subscribers {
+		stats.ActiveFilters += len(sub.Filters())
+	}
+
+	stats.PastReceiptsCacheHits = l.stats.cacheHits.Load()
+	stats.PastReceiptsCacheMisses = l.stats.cacheMisses.Load()
+	stats.OnChainFetches = l.stats.onChainFetches.Load()
+	stats.ActiveFetches, stats.QueuedFetches = l.fetchQueue.stats()
+
+	stats.FilterMatches = l.stats.matches.Load()
+	if stats.FilterMatches > 0 {
+		stats.AvgMatchLatency = time.Duration(l.stats.matchLatency.Load() / int64(stats.FilterMatches))
+	}
+	return stats
+}
+
+// listenerStats are the counters of Stats, updated as the listener runs.
+type listenerStats struct {
+	cacheHits      atomic.Uint64
+	cacheMisses    atomic.Uint64
+	onChainFetches atomic.Uint64
+	matches        atomic.Uint64
+	matchLatency   atomic.Int64 // total, in nanoseconds
+}
+
+func (s *listenerStats) recordMatches(n int, latency time.Duration) {
+	if n <= 0 {
+		return
+	}
+	// NOTE: latency is added before the count, so the average read by Stats may be
+	// momentarily high, but never divided by a count without its latency
+	s.matchLatency.Add(int64(latency) * int64(n))
+	s.matches.Add(uint64(n))
+}
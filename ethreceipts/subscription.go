@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"sync"
 
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/goware/channel"
 	"github.com/goware/superr"
 )
@@ -31,11 +32,17 @@ type subscriber struct {
 	filters     []Filterer
 	finalizer   *finalizer
 	mu          sync.Mutex
+
+	// searching holds the txn hashes of the receipts delivered for each filter whose
+	// search of past blocks, see registerFilters, is still in progress. The search runs
+	// concurrently with the matching of new blocks, so both may match the same receipts.
+	searching map[Filterer]map[common.Hash]struct{}
 }
 
 type registerFilters struct {
 	subscriber *subscriber
 	filters    []Filterer
+	fromBlock  uint64
 }
 
 func (s *subscriber) TransactionReceipt() <-chan Receipt {
@@ -59,6 +66,10 @@ func (s *subscriber) Filters() []Filterer {
 }
 
 func (s *subscriber) AddFilter(filterQueries ...FilterQuery) {
+	s.addFilter(0, filterQueries...)
+}
+
+func (s *subscriber) addFilter(fromBlock uint64, filterQueries ...FilterQuery) {
 	if len(filterQueries) == 0 {
 		return
 	}
@@ -77,8 +88,49 @@ func (s *subscriber) AddFilter(filterQueries ...FilterQuery) {
 
 	s.filters = append(s.filters, filters...)
 
+	if s.searching == nil {
+		s.searching = map[Filterer]map[common.Hash]struct{}{}
+	}
+	for _, filterer := range filters {
+		s.searching[filterer] = map[common.Hash]struct{}{}
+	}
+
 	// TODO: maybe add non-blocking push structure like in relayer queue
-	s.listener.registerFiltersCh <- registerFilters{subscriber: s, filters: filters}
+	s.listener.registerFiltersCh <- registerFilters{subscriber: s, filters: filters, fromBlock: fromBlock}
+}
+
+// searchDone stops tracking the delivered receipts of the filters, once their search of
+// past blocks is done, as from then on they're only matched against new blocks.
+func (s *subscriber) searchDone(filters []Filterer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, filterer := range filters {
+		delete(s.searching, filterer)
+	}
+}
+
+// markDelivered records the delivery of the receipt for the filter while its search of
+// past blocks is in progress, and reports false if the receipt was already delivered.
+// Reorged receipts are always delivered, and clear the record so that the receipt is
+// delivered again once re-mined.
+func (s *subscriber) markDelivered(filterer Filterer, receipt Receipt) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivered, ok := s.searching[filterer]
+	if !ok {
+		return true
+	}
+	txnHash := receipt.TransactionHash()
+	if receipt.Reorged {
+		delete(delivered, txnHash)
+		return true
+	}
+	if _, ok := delivered[txnHash]; ok {
+		return false
+	}
+	delivered[txnHash] = struct{}{}
+	return true
 }
 
 func (s *subscriber) RemoveFilter(filter Filterer) {
@@ -104,6 +156,19 @@ func (s *subscriber) matchFilters(ctx context.Context, filterers []Filterer, rec
 
 	for _, receipt := range receipts {
 		for i, filterer := range filterers {
+			// the GasUsed filter cond can only be matched against the txn receipt,
+			// so we fetch it ahead of matching. A failed fetch only skips the txn,
+			// so the other txns of the block are still matched.
+			if filterer.Cond().GasUsed != nil && receipt.receipt == nil && !receipt.Reorged {
+				r, err := s.listener.fetchTransactionReceipt(ctx, receipt.TransactionHash(), true, filterer.Options().Priority)
+				if err != nil {
+					s.listener.log.Warnf("ethreceipts: failed to fetch txn %s receipt to match gasUsed, skipping: %v", receipt.TransactionHash(), err)
+					continue
+				}
+				receipt.receipt = r
+				receipt.logs = r.Logs
+			}
+
 			matched, err := filterer.Match(ctx, receipt)
 			if err != nil {
 				return oks, superr.New(ErrFilterMatch, err)
@@ -121,7 +186,7 @@ func (s *subscriber) matchFilters(ctx context.Context, filterers []Filterer, rec
 
 			// fetch transaction receipt if its not been marked as reorged
 			if !receipt.Reorged {
-				r, err := s.listener.fetchTransactionReceipt(ctx, receipt.TransactionHash(), true)
+				r, err := s.listener.fetchTransactionReceipt(ctx, receipt.TransactionHash(), true, filterer.Options().Priority)
 				if err != nil {
 					// TODO: is this fine to return error..? its a bit abrupt.
 					// Options are to set FailedFetch bool on the Receipt, and still send to s.ch,
@@ -132,6 +197,23 @@ func (s *subscriber) matchFilters(ctx context.Context, filterers []Filterer, rec
 				receipt.logs = r.Logs
 			}
 
+			// skip receipts already delivered by the concurrent search of past blocks,
+			// or by the matching of new blocks during the search
+			if !s.markDelivered(filterer, receipt) {
+				continue
+			}
+
+			// Decode the logs of the receipt, once its txn receipt has been fetched
+			if decoder := filterer.Options().LogDecoder; decoder != nil {
+				receipt.decodedLogs = decodeLogs(decoder, receipt.Logs())
+			}
+
+			// Re-check finality against the filter's own confirmations or finality depth,
+			// as the receipt was marked final with the listener's NumBlocksToFinality
+			if _, ok := filterFinalityDepth(filterer); ok && !receipt.Reorged {
+				receipt.Final = s.listener.isFilterBlockFinal(filterer, receipt.BlockNumber())
+			}
+
 			// Finality enqueue if filter asked to Finalize, and receipt isn't already final
 			if !receipt.Reorged && !receipt.Final && filterer.Options().Finalize {
 				s.finalizer.enqueue(filterer.FilterID(), receipt, receipt.BlockNumber())
@@ -151,7 +233,7 @@ func (s *subscriber) matchFilters(ctx context.Context, filterers []Filterer, rec
 
 			// Check if receipt is already final, in case comes from cache when
 			// previously final was not toggled.
-			if s.listener.isBlockFinal(receipt.BlockNumber()) {
+			if s.listener.isFilterBlockFinal(filterer, receipt.BlockNumber()) {
 				receipt.Final = true
 			}
 
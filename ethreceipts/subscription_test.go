@@ -0,0 +1,205 @@
+package ethreceipts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchFiltersGasUsedFetchError(t *testing.T) {
+	txns, _ := testSignedTxns(t, 3)
+	block := testBlock(11, txns...)
+
+	// the receipt of the first txn fails to be fetched from the node
+	listener, _ := newTestListener(t, 100, func(method string, params []json.RawMessage) (any, error) {
+		return nil, fmt.Errorf("unexpected method %s", method)
+	})
+	ctx := context.Background()
+	listener.pastReceipts.Set(ctx, txns[1].Hash().String(), &types.Receipt{TxHash: txns[1].Hash(), BlockNumber: big.NewInt(11), GasUsed: 150, Logs: []*types.Log{}})
+	listener.pastReceipts.Set(ctx, txns[2].Hash().String(), &types.Receipt{TxHash: txns[2].Hash(), BlockNumber: big.NewInt(11), GasUsed: 50, Logs: []*types.Log{}})
+
+	sub := listener.subscribe(0, FilterGasUsed(100, 0)).(*subscriber)
+
+	matched, err := listener.processBlocks(ethmonitor.Blocks{{Block: block, Event: ethmonitor.Added, OK: true}}, []*subscriber{sub}, [][]Filterer{sub.Filters()}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, [][]bool{{true}}, matched)
+
+	// the other txns of the block are still matched
+	receipts := readReceipts(sub)
+	require.Len(t, receipts, 1)
+	require.Equal(t, txns[1].Hash(), receipts[0].TransactionHash())
+	require.Equal(t, uint64(150), receipts[0].GasUsed())
+}
+
+func TestFilterTraceTo(t *testing.T) {
+	txns, from := testSignedTxns(t, 3)
+	block := testBlock(11, txns...)
+	proxy, target := *txns[0].To(), common.HexToAddress("0xbeef")
+
+	// the first txn calls the target through the proxy, the second calls another
+	// contract, and the third fails to be traced
+	listener, node := newTestListener(t, 100, func(method string, params []json.RawMessage) (any, error) {
+		if method != "debug_traceTransaction" {
+			return nil, fmt.Errorf("unexpected method %s", method)
+		}
+		var txnHash common.Hash
+		json.Unmarshal(params[0], &txnHash)
+		switch txnHash {
+		case txns[0].Hash():
+			return ethrpc.CallDebugTrace{From: from, To: proxy, Calls: []*ethrpc.CallDebugTrace{{From: proxy, To: target}}}, nil
+		case txns[1].Hash():
+			return ethrpc.CallDebugTrace{From: from, To: proxy, Calls: []*ethrpc.CallDebugTrace{{From: proxy, To: common.HexToAddress("0x5678")}}}, nil
+		}
+		return nil, fmt.Errorf("execution timeout")
+	})
+	ctx := context.Background()
+	for _, txn := range txns {
+		listener.pastReceipts.Set(ctx, txn.Hash().String(), &types.Receipt{TxHash: txn.Hash(), BlockNumber: big.NewInt(11), Logs: []*types.Log{}})
+	}
+
+	sub1 := listener.subscribe(0, FilterTraceTo(target)).(*subscriber)
+	sub2 := listener.subscribe(0, FilterTraceTo(target)).(*subscriber)
+
+	matched, err := listener.processBlocks(ethmonitor.Blocks{{Block: block, Event: ethmonitor.Added, OK: true}}, []*subscriber{sub1, sub2}, [][]Filterer{sub1.Filters(), sub2.Filters()}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, [][]bool{{true}, {true}}, matched)
+
+	for _, sub := range []*subscriber{sub1, sub2} {
+		receipts := readReceipts(sub)
+		require.Len(t, receipts, 1)
+		require.Equal(t, txns[0].Hash(), receipts[0].TransactionHash())
+	}
+
+	// the txns are traced once for all subscribers
+	require.Equal(t, 3, node.called("debug_traceTransaction"))
+
+	// the txns aren't traced without a TraceTo filter
+	sub3 := listener.subscribe(0, FilterFrom(from)).(*subscriber)
+	_, err = listener.processBlocks(ethmonitor.Blocks{{Block: block, Event: ethmonitor.Added, OK: true}}, []*subscriber{sub3}, [][]Filterer{sub3.Filters()}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, readReceipts(sub3), 3)
+	require.Equal(t, 3, node.called("debug_traceTransaction"))
+}
+
+func TestFilterTraceToUnsupported(t *testing.T) {
+	txns, from := testSignedTxns(t, 2)
+	block := testBlock(11, txns...)
+	proxy, target := *txns[0].To(), common.HexToAddress("0xbeef")
+
+	// the node doesn't support debug_traceTransaction, and falls back to trace_transaction
+	var traceUnsupported atomic.Bool
+	listener, node := newTestListener(t, 100, func(method string, params []json.RawMessage) (any, error) {
+		switch method {
+		case "debug_traceTransaction":
+			return nil, fmt.Errorf("the method debug_traceTransaction does not exist/is not available")
+		case "trace_transaction":
+			if traceUnsupported.Load() {
+				return nil, fmt.Errorf("method not found")
+			}
+			var txnHash common.Hash
+			json.Unmarshal(params[0], &txnHash)
+			if txnHash != txns[0].Hash() {
+				return []ethrpc.TransactionTrace{{Action: ethrpc.TransactionTraceAction{From: from, To: proxy}}}, nil
+			}
+			return []ethrpc.TransactionTrace{
+				{Action: ethrpc.TransactionTraceAction{From: from, To: proxy}},
+				{Action: ethrpc.TransactionTraceAction{From: proxy, To: target}, TraceAddress: []int{0}},
+			}, nil
+		}
+		return nil, fmt.Errorf("unexpected method %s", method)
+	})
+	ctx := context.Background()
+	for _, txn := range txns {
+		listener.pastReceipts.Set(ctx, txn.Hash().String(), &types.Receipt{TxHash: txn.Hash(), BlockNumber: big.NewInt(11), Logs: []*types.Log{}})
+	}
+
+	sub := listener.subscribe(0, FilterTraceTo(target)).(*subscriber)
+	blocks := ethmonitor.Blocks{{Block: block, Event: ethmonitor.Added, OK: true}}
+
+	matched, err := listener.processBlocks(blocks, []*subscriber{sub}, [][]Filterer{sub.Filters()}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, [][]bool{{true}}, matched)
+
+	receipts := readReceipts(sub)
+	require.Len(t, receipts, 1)
+	require.Equal(t, txns[0].Hash(), receipts[0].TransactionHash())
+	require.Equal(t, 2, node.called("trace_transaction"))
+
+	// without any tracing support, the txns are matched by their "to" address only
+	traceUnsupported.Store(true)
+	matched, err = listener.processBlocks(blocks, []*subscriber{sub}, [][]Filterer{sub.Filters()}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, [][]bool{{false}}, matched)
+	require.Equal(t, int32(1), atomic.LoadInt32(&listener.tracingUnsupported))
+
+	// and tracing isn't tried again
+	calls := node.called("debug_traceTransaction") + node.called("trace_transaction")
+	_, err = listener.processBlocks(blocks, []*subscriber{sub}, [][]Filterer{sub.Filters()}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, calls, node.called("debug_traceTransaction")+node.called("trace_transaction"))
+}
+
+func TestMatchFiltersDecodeLogs(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}]`))
+	require.NoError(t, err)
+	transferTopic := contractABI.Events["Transfer"].ID
+
+	txns, _ := testSignedTxns(t, 2)
+	block := testBlock(11, txns...)
+
+	// the first txn emits a transfer, and the second another event
+	transfer := &types.Log{
+		Address: common.HexToAddress("0x1234"),
+		Topics: []common.Hash{
+			transferTopic,
+			common.BytesToHash(common.HexToAddress("0xa").Bytes()),
+			common.BytesToHash(common.HexToAddress("0xb").Bytes()),
+		},
+		Data:        common.LeftPadBytes(big.NewInt(100).Bytes(), 32),
+		BlockNumber: 11,
+		TxHash:      txns[0].Hash(),
+	}
+	other := &types.Log{Address: common.HexToAddress("0x1234"), Topics: []common.Hash{{0x01}}, BlockNumber: 11, TxHash: txns[1].Hash(), Index: 1}
+
+	listener, _ := newTestListener(t, 100, func(method string, params []json.RawMessage) (any, error) {
+		return nil, fmt.Errorf("unexpected method %s", method)
+	})
+	ctx := context.Background()
+	listener.pastReceipts.Set(ctx, txns[0].Hash().String(), &types.Receipt{TxHash: txns[0].Hash(), BlockNumber: big.NewInt(11), Logs: []*types.Log{transfer}})
+	listener.pastReceipts.Set(ctx, txns[1].Hash().String(), &types.Receipt{TxHash: txns[1].Hash(), BlockNumber: big.NewInt(11), Logs: []*types.Log{other}})
+
+	sub := listener.subscribe(0, FilterLogTopic(transferTopic).DecodeLogs(contractABI)).(*subscriber)
+	sub2 := listener.subscribe(0, FilterLogTopic(transferTopic)).(*subscriber)
+
+	blocks := ethmonitor.Blocks{{Block: block, Logs: []types.Log{*transfer, *other}, Event: ethmonitor.Added, OK: true}}
+	matched, err := listener.processBlocks(blocks, []*subscriber{sub, sub2}, [][]Filterer{sub.Filters(), sub2.Filters()}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, [][]bool{{true}, {true}}, matched)
+
+	// the logs are delivered decoded against the abi of the filter
+	receipts := readReceipts(sub)
+	require.Len(t, receipts, 1)
+	require.Equal(t, txns[0].Hash(), receipts[0].TransactionHash())
+	decoded := receipts[0].DecodedLogs()
+	require.Len(t, decoded, 1)
+	require.Equal(t, "Transfer", decoded[0].Event.Name)
+	require.Equal(t, transfer.Address, decoded[0].Log.Address)
+	require.Equal(t, []any{common.HexToAddress("0xa"), common.HexToAddress("0xb"), big.NewInt(100)}, decoded[0].Values)
+
+	// and aren't decoded without the option
+	receipts = readReceipts(sub2)
+	require.Len(t, receipts, 1)
+	require.Nil(t, receipts[0].DecodedLogs())
+}
@@ -1,10 +1,14 @@
 package ethrpc
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 )
 
 type BatchCall []*Call
@@ -39,7 +43,7 @@ func (b *BatchCall) UnmarshalJSON(data []byte) error {
 	for i, msg := range results {
 		(*b)[i].response = msg
 		if msg.Error != nil {
-			(*b)[i].err = msg.Error
+			(*b)[i].err = callError(msg.Error)
 		}
 	}
 	return nil
@@ -84,3 +88,135 @@ func (e BatchError) Unwrap() error {
 	}
 	return nil
 }
+
+// BatchElement is a single JSON-RPC call sent as part of a Provider#BatchCall request.
+// After the batch has been executed, Result will hold the unmarshalled response and
+// Error will hold the error returned by the node for this call, if any.
+type BatchElement struct {
+	Method string
+	Params []any
+
+	// Result is a pointer to the value the response will be unmarshalled into.
+	// Leave as nil to ignore the result.
+	Result any
+
+	// ArchiveRequired routes the call to the archive node, see Call#ArchiveRequired.
+	ArchiveRequired bool
+
+	// Error is set after the batch is executed, if this call failed.
+	Error error
+}
+
+// BatchCall sends all elems as JSON-RPC batch requests, packing up to the provider's max
+// batch size calls into each http request, see WithMaxBatchSize. The returned error is
+// only set if a request as a whole failed, and errors of individual calls are set on
+// their respective elems[i].Error.
+func (p *Provider) BatchCall(ctx context.Context, elems []BatchElement) error {
+	calls := make([]Call, len(elems))
+	for i := range elems {
+		elem := &elems[i]
+		elem.Error = nil
+		calls[i] = Call{
+			request: jsonrpc.NewRequest(0, elem.Method, elem.Params),
+			resultFn: func(message json.RawMessage) error {
+				if elem.Result == nil {
+					return nil
+				}
+				return json.Unmarshal(message, elem.Result)
+			},
+			archive: elem.ArchiveRequired,
+		}
+	}
+
+	err := p.doBatch(ctx, calls)
+	if err == nil {
+		return nil
+	}
+
+	var batchErr BatchError
+	if !errors.As(err, &batchErr) {
+		return err
+	}
+	for i, call := range batchErr {
+		elems[i].Error = call.err
+	}
+	return nil
+}
+
+// BatchBalanceAt = batch of eth_getBalance
+func (p *Provider) BatchBalanceAt(ctx context.Context, accounts []common.Address, blockNum *big.Int) ([]*big.Int, error) {
+	balances := make([]*big.Int, len(accounts))
+
+	calls := make([]Call, len(accounts))
+	for i, account := range accounts {
+		calls[i] = BalanceAt(account, blockNum).Strict(p.strictness).Into(&balances[i])
+	}
+
+	err := p.doBatch(ctx, calls)
+	return balances, err
+}
+
+// AccountCode is the code of an account, see CodesAt.
+type AccountCode struct {
+	Address common.Address
+	Code    []byte
+}
+
+// IsContract reports whether the account has code, as opposed to an EOA.
+//
+// NOTE: an EOA with an EIP-7702 delegation has the delegation designator as its code,
+// and is reported as a contract.
+func (c AccountCode) IsContract() bool {
+	return len(c.Code) > 0
+}
+
+// CodesAt = batch of eth_getCode, to classify many accounts as EOAs or contracts in a
+// single round-trip. The codes are returned in the order of the accounts, and errors
+// of individual calls are returned as a BatchError indexed by the position in accounts.
+func (p *Provider) CodesAt(ctx context.Context, accounts []common.Address, blockNum *big.Int) ([]AccountCode, error) {
+	codes := make([]AccountCode, len(accounts))
+
+	calls := make([]Call, len(accounts))
+	for i, account := range accounts {
+		codes[i].Address = account
+		calls[i] = CodeAt(account, blockNum).Strict(p.strictness).Into(&codes[i].Code)
+	}
+
+	err := p.doBatch(ctx, calls)
+	return codes, err
+}
+
+// doBatch executes calls in chunks of up to the provider's max batch size. Call errors
+// are returned as a single BatchError indexed by the position in calls.
+func (p *Provider) doBatch(ctx context.Context, calls []Call) error {
+	maxBatchSize := p.maxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = len(calls)
+	}
+
+	batchErr := make(BatchError)
+	for start := 0; start < len(calls); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+
+		_, err := p.Do(ctx, calls[start:end]...)
+		if err == nil {
+			continue
+		}
+
+		var chunkErr BatchError
+		if !errors.As(err, &chunkErr) {
+			return err
+		}
+		for i, call := range chunkErr {
+			batchErr[start+i] = call
+		}
+	}
+
+	if len(batchErr) > 0 {
+		return batchErr
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package ethrpc
+
+import (
+	"context"
+	"strings"
+)
+
+// Capabilities are the client of the node and the optional features it supports, so
+// callers can pick the best code path for the node, see Provider#Capabilities.
+type Capabilities struct {
+	// ClientVersion is the web3_clientVersion of the node, ie.
+	// "Geth/v1.13.5-stable/linux-amd64/go1.21.4", and Client is its client name
+	// lowercased, ie. "geth", "erigon", "nethermind", "besu", "reth" or "anvil". Both are
+	// empty if the node doesn't support web3_clientVersion.
+	ClientVersion string
+	Client        string
+
+	// SupportsBlockReceipts is set if the node supports eth_getBlockReceipts, see
+	// BlockReceipts.
+	SupportsBlockReceipts bool
+
+	// SupportsDebugTracing is set if the node supports the debug_trace* methods, ie.
+	// debug_traceTransaction, and SupportsParityTracing if it supports the parity style
+	// trace_* methods, ie. trace_transaction. SupportsTracing is set if it supports either.
+	SupportsDebugTracing  bool
+	SupportsParityTracing bool
+	SupportsTracing       bool
+
+	// SupportsRawReceipts is set if the node supports debug_getRawReceipts.
+	SupportsRawReceipts bool
+
+	// SupportsOtterscan is set if the node supports the Otterscan ots_* methods.
+	SupportsOtterscan bool
+
+	// SupportsTxpool is set if the node supports txpool_content.
+	SupportsTxpool bool
+
+	// SupportsFeeHistory is set if the node supports eth_feeHistory.
+	SupportsFeeHistory bool
+
+	// Supports1559 is set if the chain has EIP-1559 fees, ie. its latest block has a
+	// base fee, so dynamic fee transactions can be sent.
+	Supports1559 bool
+}
+
+// capabilityMethods are the methods probed by Capabilities, with the field of
+// Capabilities they set.
+var capabilityMethods = []struct {
+	method string
+	set    func(c *Capabilities, supported bool)
+}{
+	{"eth_getBlockReceipts", func(c *Capabilities, ok bool) { c.SupportsBlockReceipts = ok }},
+	{"debug_traceTransaction", func(c *Capabilities, ok bool) { c.SupportsDebugTracing = ok }},
+	{"trace_transaction", func(c *Capabilities, ok bool) { c.SupportsParityTracing = ok }},
+	{"debug_getRawReceipts", func(c *Capabilities, ok bool) { c.SupportsRawReceipts = ok }},
+	{"ots_getApiLevel", func(c *Capabilities, ok bool) { c.SupportsOtterscan = ok }},
+	{"txpool_content", func(c *Capabilities, ok bool) { c.SupportsTxpool = ok }},
+	{"eth_feeHistory", func(c *Capabilities, ok bool) { c.SupportsFeeHistory = ok }},
+}
+
+// Capabilities detects the client of the node and the optional features it supports, by
+// calling web3_clientVersion, probing each of the optional methods with Supports, and
+// checking the latest block for a base fee. The result is cached on the provider, so
+// the node is only probed once, and the probed methods are shared with Supports and the
+// method wrappers which report ErrUnsupportedMethodOnChain.
+//
+// An error is returned if the node could not be reached or responded with an error other
+// than the probed method not being found, ie. it's rate limited, in which case nothing is
+// cached.
+//
+// NOTE: nodes behind a load balancer may run different clients, in which case the
+// capabilities are those of the node which served the probes.
+func (p *Provider) Capabilities(ctx context.Context) (Capabilities, error) {
+	p.capabilitiesMu.Lock()
+	defer p.capabilitiesMu.Unlock()
+
+	if p.capabilities != nil {
+		return *p.capabilities, nil
+	}
+
+	var caps Capabilities
+
+	clientVersion, err := p.ClientVersion(ctx)
+	if err != nil && !isMethodNotFoundError(err) {
+		return Capabilities{}, err
+	}
+	if err == nil {
+		caps.ClientVersion = clientVersion
+		caps.Client = strings.ToLower(strings.TrimSpace(strings.SplitN(clientVersion, "/", 2)[0]))
+	}
+
+	for _, m := range capabilityMethods {
+		supported, err := p.Supports(ctx, m.method)
+		if err != nil {
+			return Capabilities{}, err
+		}
+		m.set(&caps, supported)
+	}
+	caps.SupportsTracing = caps.SupportsDebugTracing || caps.SupportsParityTracing
+
+	head, err := p.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	caps.Supports1559 = head.BaseFee != nil
+
+	p.capabilities = &caps
+	return caps, nil
+}
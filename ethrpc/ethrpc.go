@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
 	"github.com/0xsequence/ethkit/go-ethereum"
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
@@ -28,22 +29,38 @@ type Provider struct {
 	log                 logger.Logger
 	nodeURL             string
 	nodeWSURL           string
+	archiveURL          string // optional
 	httpClient          httpClient
 	br                  breaker.Breaker
 	jwtToken            string // optional
 	streamClosers       []StreamCloser
 	streamUnsubscribers []StreamUnsubscriber
 	strictness          StrictnessLevel
+	maxBatchSize        int
+	retry               *retryOptions // optional
+	router              *router       // optional
+	streamReconnect     *retryOptions // optional
+	streamMux           *streamMux    // optional
+	methodSupport       map[string]bool
+	readOnly            bool
+	requestLogger       *requestLogger // optional
 
 	chainID   *big.Int
 	chainIDMu sync.Mutex
 
+	capabilities   *Capabilities
+	capabilitiesMu sync.Mutex
+
 	// cache   cachestore.Store[[]byte] // NOTE: unused for now
 	lastRequestID uint64
 
 	mu sync.Mutex
 }
 
+// DefaultMaxBatchSize is the default maximum number of calls packed into a
+// single http request by BatchCall and its typed helpers.
+const DefaultMaxBatchSize = 100
+
 func NewProvider(nodeURL string, options ...Option) (*Provider, error) {
 	p := &Provider{
 		nodeURL: nodeURL,
@@ -51,6 +68,7 @@ func NewProvider(nodeURL string, options ...Option) (*Provider, error) {
 			// default timeout of 60 seconds
 			Timeout: 60 * time.Second,
 		},
+		maxBatchSize: DefaultMaxBatchSize,
 	}
 	for _, opt := range options {
 		if opt == nil {
@@ -66,12 +84,17 @@ var (
 	ErrEmptyResponse            = errors.New("ethrpc: empty response")
 	ErrUnsupportedMethodOnChain = errors.New("ethrpc: method is unsupported on this chain")
 	ErrRequestFail              = errors.New("ethrpc: request fail")
+	ErrPendingNotSupported      = errors.New("ethrpc: pending block and state are not supported by the node")
 )
 
 var _ Interface = &Provider{}
 var _ RawInterface = &Provider{}
 var _ StrictnessLevelGetter = &Provider{}
 var _ DebugInterface = &Provider{}
+var _ DebugReceiptsInterface = &Provider{}
+var _ TraceInterface = &Provider{}
+var _ DebugTracerInterface = &Provider{}
+var _ FilterLogsRangeInterface = &Provider{}
 
 // Provider adheres to the go-ethereum bind.ContractBackend interface. In case we ever
 // want to break this interface, we could also write an adapter type to keep them compat.
@@ -97,7 +120,53 @@ func (p *Provider) Do(ctx context.Context, calls ...Call) ([]byte, error) {
 	if len(calls) == 0 {
 		return nil, nil
 	}
+	if p.readOnly {
+		if err := checkReadOnly(calls); err != nil {
+			return nil, err
+		}
+	}
+	if p.retry == nil {
+		return p.do(ctx, calls...)
+	}
+	return p.doWithRetry(ctx, calls, func() ([]byte, error) {
+		return p.do(ctx, calls...)
+	})
+}
 
+func (p *Provider) do(ctx context.Context, calls ...Call) ([]byte, error) {
+	if p.router == nil || !p.IsStreamingEnabled() {
+		return p.doHTTP(ctx, calls...)
+	}
+	return p.doRouted(ctx, calls...)
+}
+
+// doHTTP sends the calls as a batch over http, see sendHTTP, and logs the request with
+// WithRequestLogger.
+func (p *Provider) doHTTP(ctx context.Context, calls ...Call) ([]byte, error) {
+	if p.requestLogger == nil {
+		return p.sendHTTP(ctx, calls...)
+	}
+
+	start := time.Now()
+	body, err := p.sendHTTP(ctx, calls...)
+	p.requestLogger.logRequest(p.batchURL(calls), calls, body, err, time.Since(start))
+	return body, err
+}
+
+// batchURL returns the url of the node which the batch of calls is sent to. The whole
+// batch is routed to the archive node if any of the calls requires it.
+func (p *Provider) batchURL(calls []Call) string {
+	if p.archiveURL != "" {
+		for _, call := range calls {
+			if call.archive {
+				return p.archiveURL
+			}
+		}
+	}
+	return p.nodeURL
+}
+
+func (p *Provider) sendHTTP(ctx context.Context, calls ...Call) ([]byte, error) {
 	batch := make(BatchCall, 0, len(calls))
 	for i, call := range calls {
 		call := call
@@ -115,7 +184,7 @@ func (p *Provider) Do(ctx context.Context, calls ...Call) ([]byte, error) {
 		return nil, superr.Wrap(ErrRequestFail, fmt.Errorf("failed to marshal JSONRPC request: %w", err))
 	}
 
-	req, err := http.NewRequest(http.MethodPost, p.nodeURL, bytes.NewBuffer(b))
+	req, err := http.NewRequest(http.MethodPost, p.batchURL(calls), bytes.NewBuffer(b))
 	if err != nil {
 		return nil, superr.Wrap(ErrRequestFail, fmt.Errorf("failed to initialize http.Request: %w", err))
 	}
@@ -140,13 +209,13 @@ func (p *Provider) Do(ctx context.Context, calls ...Call) ([]byte, error) {
 	if (res.StatusCode < 200 || res.StatusCode > 299) && res.StatusCode != 401 {
 		msg := jsonrpc.Message{}
 		if err := json.Unmarshal(body, &msg); err == nil && msg.Error != nil {
-			return body, superr.Wrap(ErrRequestFail, msg.Error)
+			return body, superr.Wrap(ErrRequestFail, &httpStatusError{statusCode: res.StatusCode, err: msg.Error})
 		}
 		details := any(body)
 		if len(body) > 100 {
 			details = fmt.Sprintf("%s … (%d bytes)", body[:100], len(body))
 		}
-		return body, superr.Wrap(ErrRequestFail, fmt.Errorf("non-200 response with status code: %d with body '%s'", res.StatusCode, details))
+		return body, superr.Wrap(ErrRequestFail, &httpStatusError{statusCode: res.StatusCode, err: fmt.Errorf("non-200 response with status code: %d with body '%s'", res.StatusCode, details)})
 	}
 
 	if err := json.Unmarshal(body, &batch); err != nil {
@@ -156,6 +225,12 @@ func (p *Provider) Do(ctx context.Context, calls ...Call) ([]byte, error) {
 		return body, superr.Wrap(ErrRequestFail, fmt.Errorf("failed to unmarshal response: '%s' due to %w", string(body), err))
 	}
 
+	return body, handleResponses(calls, batch)
+}
+
+// handleResponses checks the responses of the batch and decodes their results
+// with the resultFn of each of the calls.
+func handleResponses(calls []Call, batch BatchCall) error {
 	for i, call := range batch {
 		if call.err != nil {
 			continue
@@ -185,7 +260,7 @@ func (p *Provider) Do(ctx context.Context, calls ...Call) ([]byte, error) {
 		}
 	}
 
-	return body, batch.ErrorOrNil()
+	return batch.ErrorOrNil()
 }
 
 func (p *Provider) ChainID(ctx context.Context) (*big.Int, error) {
@@ -219,6 +294,62 @@ func (p *Provider) BalanceAt(ctx context.Context, account common.Address, blockN
 	return ret, err
 }
 
+// GetProof returns the account and storage values of the account at blockNum, along
+// with their Merkle-Patricia proofs. See VerifyProof to verify the proofs against the
+// state root of a trusted header of the block, or VerifiedBalanceAt.
+func (p *Provider) GetProof(ctx context.Context, account common.Address, storageKeys []common.Hash, blockNum *big.Int) (*AccountProof, error) {
+	var ret *AccountProof
+	_, err := p.Do(ctx, GetProof(account, storageKeys, blockNum).Strict(p.strictness).Into(&ret))
+	return ret, err
+}
+
+// VerifiedBalanceAt is like BalanceAt at the block of the trusted header, except that the
+// balance is verified against the state root of the header, so the node can't return a
+// wrong balance without it being detected. This is meant for when the node is not fully
+// trusted.
+//
+// The header must come from a trusted source, ie. a light client or a quorum of other
+// nodes, as a node able to return a wrong balance could also return a header with a state
+// root of its own making. Fetching the header from the same node doesn't verify anything.
+//
+// NOTE: this is more expensive than BalanceAt, as eth_getProof is a heavier call, and the
+// proof is verified locally.
+func (p *Provider) VerifiedBalanceAt(ctx context.Context, account common.Address, trustedHeader *types.Header) (*big.Int, error) {
+	proof, err := p.verifiedAccountAt(ctx, account, trustedHeader)
+	if err != nil {
+		return nil, err
+	}
+	return proof.Balance.ToInt(), nil
+}
+
+// VerifiedNonceAt is like NonceAt at the block of the trusted header, except that the
+// nonce is verified against the state root of the header. See VerifiedBalanceAt.
+func (p *Provider) VerifiedNonceAt(ctx context.Context, account common.Address, trustedHeader *types.Header) (uint64, error) {
+	proof, err := p.verifiedAccountAt(ctx, account, trustedHeader)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(proof.Nonce), nil
+}
+
+func (p *Provider) verifiedAccountAt(ctx context.Context, account common.Address, trustedHeader *types.Header) (*AccountProof, error) {
+	if trustedHeader == nil || trustedHeader.Number == nil {
+		return nil, fmt.Errorf("ethrpc: trusted header is required")
+	}
+
+	proof, err := p.GetProof(ctx, account, nil, trustedHeader.Number)
+	if err != nil {
+		return nil, err
+	}
+	if proof == nil || proof.Address != account {
+		return nil, fmt.Errorf("ethrpc: invalid account proof of %s", account)
+	}
+	if err := VerifyAccountProof(trustedHeader.Root, proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
 func (p *Provider) SendTransaction(ctx context.Context, tx *types.Transaction) error {
 	_, err := p.Do(ctx, SendTransaction(tx))
 	return err
@@ -251,19 +382,23 @@ func (p *Provider) BlockByHash(ctx context.Context, hash common.Hash) (*types.Bl
 func (p *Provider) RawBlockByNumber(ctx context.Context, blockNum *big.Int) (json.RawMessage, error) {
 	var result json.RawMessage
 	_, err := p.Do(ctx, RawBlockByNumber(blockNum).Strict(p.strictness).Into(&result))
-	if err != nil {
-		return nil, err
+	if err == nil && (len(result) == 0 || string(result) == "null") {
+		err = ethereum.NotFound
 	}
-	if len(result) == 0 || string(result) == "null" {
-		return nil, ethereum.NotFound
+	if err != nil {
+		return nil, pendingBlockError(blockNum, err)
 	}
 	return result, nil
 }
 
+// BlockByNumber returns the block of the number, or of the latest block if blockNum is
+// nil. The ethrpc.Pending, ethrpc.Safe and ethrpc.Finalized tags are accepted as well, ie.
+// to read the pending block with the transactions in flight. ErrPendingNotSupported is
+// returned if the node has no pending block.
 func (p *Provider) BlockByNumber(ctx context.Context, blockNum *big.Int) (*types.Block, error) {
 	var ret *types.Block
 	_, err := p.Do(ctx, BlockByNumber(blockNum).Strict(p.strictness).Into(&ret))
-	return ret, err
+	return ret, pendingBlockError(blockNum, err)
 }
 
 func (p *Provider) BlocksByNumbers(ctx context.Context, blockNumbers []*big.Int) ([]*types.Block, error) {
@@ -286,6 +421,14 @@ func (p *Provider) BlocksByNumberRange(ctx context.Context, fromBlockNumber, toB
 	return p.BlocksByNumbers(ctx, blockNumbers)
 }
 
+// ClientVersion returns the web3_clientVersion of the node, ie.
+// "Geth/v1.13.5-stable/linux-amd64/go1.21.4". See Capabilities.
+func (p *Provider) ClientVersion(ctx context.Context) (string, error) {
+	var ret string
+	_, err := p.Do(ctx, ClientVersion().Into(&ret))
+	return ret, err
+}
+
 func (p *Provider) PeerCount(ctx context.Context) (uint64, error) {
 	var ret uint64
 	_, err := p.Do(ctx, PeerCount().Strict(p.strictness).Into(&ret))
@@ -305,9 +448,12 @@ func (p *Provider) HeaderByNumber(ctx context.Context, blockNum *big.Int) (*type
 	var head *types.Header
 	_, err := p.Do(ctx, HeaderByNumber(blockNum).Strict(p.strictness).Into(&head))
 	if err == nil && head == nil {
-		return nil, ethereum.NotFound
+		err = ethereum.NotFound
 	}
-	return head, err
+	if err != nil {
+		return nil, pendingBlockError(blockNum, err)
+	}
+	return head, nil
 }
 
 func (p *Provider) HeadersByNumbers(ctx context.Context, blockNumbers []*big.Int) ([]*types.Header, error) {
@@ -419,31 +565,31 @@ func (p *Provider) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ty
 func (p *Provider) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
 	var ret *big.Int
 	_, err := p.Do(ctx, PendingBalanceAt(account).Strict(p.strictness).Into(&ret))
-	return ret, err
+	return ret, pendingError(err)
 }
 
 func (p *Provider) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
 	var result []byte
 	_, err := p.Do(ctx, PendingStorageAt(account, key).Strict(p.strictness).Into(&result))
-	return result, err
+	return result, pendingError(err)
 }
 
 func (p *Provider) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
 	var result []byte
 	_, err := p.Do(ctx, PendingCodeAt(account).Strict(p.strictness).Into(&result))
-	return result, err
+	return result, pendingError(err)
 }
 
 func (p *Provider) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
 	var result uint64
 	_, err := p.Do(ctx, PendingNonceAt(account).Strict(p.strictness).Into(&result))
-	return result, err
+	return result, pendingError(err)
 }
 
 func (p *Provider) PendingTransactionCount(ctx context.Context) (uint, error) {
 	var ret uint
 	_, err := p.Do(ctx, PendingTransactionCount().Strict(p.strictness).Into(&ret))
-	return ret, err
+	return ret, pendingError(err)
 }
 
 func (p *Provider) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
@@ -452,6 +598,14 @@ func (p *Provider) CallContract(ctx context.Context, msg ethereum.CallMsg, block
 	return result, err
 }
 
+// CallContractWithOverrides executes the call against the state of the block with the
+// accounts overridden, see the CallContractWithOverrides call builder.
+func (p *Provider) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int, overrides map[common.Address]OverrideAccount) ([]byte, error) {
+	var result []byte
+	_, err := p.Do(ctx, CallContractWithOverrides(msg, blockNum, overrides).Strict(p.strictness).Into(&result))
+	return result, err
+}
+
 func (p *Provider) CallContractAtHash(ctx context.Context, msg ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
 	var result []byte
 	_, err := p.Do(ctx, CallContractAtHash(msg, blockHash).Strict(p.strictness).Into(&result))
@@ -461,7 +615,7 @@ func (p *Provider) CallContractAtHash(ctx context.Context, msg ethereum.CallMsg,
 func (p *Provider) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
 	var result []byte
 	_, err := p.Do(ctx, PendingCallContract(msg).Strict(p.strictness).Into(&result))
-	return result, err
+	return result, pendingError(err)
 }
 
 func (p *Provider) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
@@ -476,6 +630,11 @@ func (p *Provider) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	return ret, err
 }
 
+// FeeHistory returns the fee history of up to blockCount blocks ending at lastBlock, or the
+// latest block if nil, starting from OldestBlock. GasUsedRatio has an entry per block, while
+// BaseFee has one more entry for the block after lastBlock. Reward has the priority fees at
+// the rewardPercentiles of each block, and is empty if no percentiles are passed. The
+// rewards of a block are empty if the node responded with null rewards for it.
 func (p *Provider) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
 	var fh *ethereum.FeeHistory
 	_, err := p.Do(ctx, FeeHistory(blockCount, lastBlock, rewardPercentiles).Strict(p.strictness).Into(&fh))
@@ -488,6 +647,15 @@ func (p *Provider) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint6
 	return result, err
 }
 
+// CreateAccessList returns the access list of the call with eth_createAccessList. If the
+// node does not support eth_createAccessList, ErrUnsupportedMethodOnChain is returned, and
+// the method will not be called again on this provider.
+func (p *Provider) CreateAccessList(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) (ethcoder.AccessListResult, error) {
+	var result ethcoder.AccessListResult
+	err := p.doOptionalMethod(ctx, "eth_createAccessList", CreateAccessList(msg, blockNum).Strict(p.strictness).Into(&result))
+	return result, err
+}
+
 func (p *Provider) DebugTraceBlockByNumber(ctx context.Context, blockNum *big.Int) ([]*TransactionDebugTrace, error) {
 	var result []*TransactionDebugTrace
 	_, err := p.Do(ctx, DebugTraceBlockByNumber(blockNum).Into(&result))
@@ -500,12 +668,233 @@ func (p *Provider) DebugTraceBlockByHash(ctx context.Context, blockHash common.H
 	return result, err
 }
 
+// DebugTraceTransaction returns the call trace of a transaction with the callTracer. If the
+// node does not support debug_traceTransaction, ErrUnsupportedMethodOnChain is returned,
+// and the method will not be called again on this provider.
 func (p *Provider) DebugTraceTransaction(ctx context.Context, txHash common.Hash) (*CallDebugTrace, error) {
 	var result *CallDebugTrace
-	_, err := p.Do(ctx, DebugTraceTransaction(txHash).Into(&result))
+	err := p.doOptionalMethod(ctx, "debug_traceTransaction", DebugTraceTransaction(txHash).Into(&result))
+	return result, err
+}
+
+// DebugTraceCall traces the call on top of the state of the block, or the latest block if
+// nil, with debug_traceCall and the tracer of the config, ie.
+//
+//	trace, err := provider.DebugTraceCall(ctx, msg, nil, ethrpc.DebugTracerConfig{Tracer: ethrpc.DebugTracerCallTracer})
+//	callTrace, err := trace.CallTrace()
+//
+// If the node does not support debug_traceCall, ie. the debug namespace is not enabled,
+// ErrUnsupportedMethodOnChain is returned, and the method will not be called again on
+// this provider.
+func (p *Provider) DebugTraceCall(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int, config DebugTracerConfig) (*DebugTrace, error) {
+	var result *DebugTrace
+	err := p.doOptionalMethod(ctx, "debug_traceCall", DebugTraceCall(msg, blockNum, config).Into(&result))
+	return result, err
+}
+
+// DebugTraceTransactionWithTracer traces a transaction with debug_traceTransaction and the
+// tracer of the config. If the node does not support debug_traceTransaction,
+// ErrUnsupportedMethodOnChain is returned, and the method will not be called again on
+// this provider.
+func (p *Provider) DebugTraceTransactionWithTracer(ctx context.Context, txHash common.Hash, config DebugTracerConfig) (*DebugTrace, error) {
+	var result *DebugTrace
+	err := p.doOptionalMethod(ctx, "debug_traceTransaction", DebugTraceTransactionWithTracer(txHash, config).Into(&result))
+	return result, err
+}
+
+// TraceTransaction returns the calls of a transaction with trace_transaction. If the
+// node does not support trace_transaction, ErrUnsupportedMethodOnChain is returned,
+// and the method will not be called again on this provider.
+func (p *Provider) TraceTransaction(ctx context.Context, txHash common.Hash) ([]*TransactionTrace, error) {
+	var result []*TransactionTrace
+	err := p.doOptionalMethod(ctx, "trace_transaction", TraceTransaction(txHash).Into(&result))
 	return result, err
 }
 
+// DebugGetRawReceipts returns the receipts of all of the transactions in a block, see
+// the DebugGetRawReceipts call builder for the fields which are set. If the node does not
+// support debug_getRawReceipts, ErrUnsupportedMethodOnChain is returned, and the method
+// will not be called again on this provider.
+func (p *Provider) DebugGetRawReceipts(ctx context.Context, blockNum *big.Int) ([]*types.Receipt, error) {
+	var result []*types.Receipt
+	err := p.doOptionalMethod(ctx, "debug_getRawReceipts", DebugGetRawReceipts(blockNum).Into(&result))
+	return result, err
+}
+
+// SupportsDebugGetRawReceipts reports whether the node supports debug_getRawReceipts,
+// by calling it for the latest block.
+func (p *Provider) SupportsDebugGetRawReceipts(ctx context.Context) (bool, error) {
+	_, err := p.DebugGetRawReceipts(ctx, nil)
+	if errors.Is(err, ErrUnsupportedMethodOnChain) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// BlockReceipts returns the receipts of all of the transactions in the block with a single
+// eth_getBlockReceipts call. If the node does not support the method, the receipts are
+// fetched with a batch of eth_getTransactionReceipt calls instead, and the method is not
+// tried again. See SupportsBlockReceipts for which of these is used.
+func (p *Provider) BlockReceipts(ctx context.Context, blockNumOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	var receipts []*types.Receipt
+	err := p.doOptionalMethod(ctx, "eth_getBlockReceipts", BlockReceipts(blockNumOrHash).Strict(p.strictness).Into(&receipts))
+	if !errors.Is(err, ErrUnsupportedMethodOnChain) {
+		return receipts, err
+	}
+
+	// fallback to fetching the receipt of each transaction of the block
+	var block *types.Block
+	if hash, ok := blockNumOrHash.Hash(); ok {
+		_, err := p.Do(ctx, BlockByHash(hash).Strict(p.strictness).Into(&block))
+		if err != nil {
+			return nil, err
+		}
+	} else if blockNum, ok := blockNumOrHash.Number(); ok {
+		call := CallBuilder[*types.Block]{
+			method: "eth_getBlockByNumber",
+			params: []any{blockNum, true},
+			intoFn: IntoBlock,
+		}
+		_, err := p.Do(ctx, call.Strict(p.strictness).Into(&block))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("ethrpc: BlockReceipts requires a block number or hash")
+	}
+	if block == nil {
+		return nil, ethereum.NotFound
+	}
+
+	receipts = make([]*types.Receipt, len(block.Transactions()))
+	calls := make([]Call, len(receipts))
+	for i, txn := range block.Transactions() {
+		calls[i] = TransactionReceipt(txn.Hash()).Strict(p.strictness).Into(&receipts[i])
+	}
+	if err := p.doBatch(ctx, calls); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// SupportsBlockReceipts reports whether the node supports eth_getBlockReceipts, ie.
+// whether BlockReceipts fetches the receipts of a block in a single call, by calling
+// it for the latest block. The result is cached once the method is found unsupported.
+func (p *Provider) SupportsBlockReceipts(ctx context.Context) (bool, error) {
+	var receipts []*types.Receipt
+	err := p.doOptionalMethod(ctx, "eth_getBlockReceipts", BlockReceipts(rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)).Into(&receipts))
+	if errors.Is(err, ErrUnsupportedMethodOnChain) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SupportsOtterscan reports whether the node supports the Otterscan ots_ methods, such as
+// ots_searchTransactionsBefore, by calling ots_getApiLevel. The result is cached once the
+// methods are found unsupported.
+func (p *Provider) SupportsOtterscan(ctx context.Context) (bool, error) {
+	var apiLevel uint64
+	err := p.doOptionalMethod(ctx, "ots_getApiLevel", OtsGetApiLevel().Into(&apiLevel))
+	if errors.Is(err, ErrUnsupportedMethodOnChain) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// OtsSearchTransactionsBefore returns a page of the transactions of the address from before
+// blockNum, see the OtsSearchTransactionsBefore call builder. If the node does not support the
+// Otterscan api, ErrUnsupportedMethodOnChain is returned, and the method will not be called
+// again on this provider.
+func (p *Provider) OtsSearchTransactionsBefore(ctx context.Context, address common.Address, blockNum uint64, pageSize int) (*OtsTransactionsPage, error) {
+	var page *OtsTransactionsPage
+	err := p.doOptionalMethod(ctx, "ots_searchTransactionsBefore", OtsSearchTransactionsBefore(address, blockNum, pageSize).Strict(p.strictness).Into(&page))
+	return page, err
+}
+
+// OtsSearchTransactionsAfter returns a page of the transactions of the address from after
+// blockNum, see the OtsSearchTransactionsAfter call builder. If the node does not support the
+// Otterscan api, ErrUnsupportedMethodOnChain is returned, and the method will not be called
+// again on this provider.
+func (p *Provider) OtsSearchTransactionsAfter(ctx context.Context, address common.Address, blockNum uint64, pageSize int) (*OtsTransactionsPage, error) {
+	var page *OtsTransactionsPage
+	err := p.doOptionalMethod(ctx, "ots_searchTransactionsAfter", OtsSearchTransactionsAfter(address, blockNum, pageSize).Strict(p.strictness).Into(&page))
+	return page, err
+}
+
+// Supports reports whether the node supports the JSON-RPC method, by probing it once with
+// a call without params. The method is supported if the call succeeds or the node responds
+// that the params are invalid, and unsupported if the node responds that the method isn't
+// found. The result is cached on the provider, along with the results of the methods which
+// report ErrUnsupportedMethodOnChain, so the method is only probed once. Use WithMethodSupport
+// to skip probing for known providers.
+//
+// As the method is called, only methods which read the state of the node can be probed, and
+// an error is returned for the state-changing methods blocked by WithReadOnly, ie. evm_mine
+// or anvil_setBalance, unless their support is set with WithMethodSupport.
+//
+// An error is returned if the node could not be reached or responded with any other error,
+// ie. it's rate limited, in which case nothing is cached.
+func (p *Provider) Supports(ctx context.Context, method string) (bool, error) {
+	if supported, ok := p.methodSupported(method); ok {
+		return supported, nil
+	}
+	if IsReadOnlyBlockedMethod(method) {
+		return false, fmt.Errorf("ethrpc: cannot probe the state-changing method %s, see WithMethodSupport", method)
+	}
+
+	_, err := p.Do(ctx, NewCall(method))
+	if err != nil && isMethodNotFoundError(err) {
+		p.setMethodUnsupported(method)
+		return false, nil
+	}
+	if err != nil && !isInvalidParamsError(err) {
+		return false, err
+	}
+	p.setMethodSupported(method, true)
+	return true, nil
+}
+
+// doOptionalMethod calls the method, which is one that nodes may not support, ie. of the
+// debug_, trace_ or ots_ namespaces. If the node responds that the method isn't found,
+// ErrUnsupportedMethodOnChain is returned, and the method is not called again on this
+// provider, see Supports.
+func (p *Provider) doOptionalMethod(ctx context.Context, method string, call Call) error {
+	if p.isMethodUnsupported(method) {
+		return ErrUnsupportedMethodOnChain
+	}
+	_, err := p.Do(ctx, call)
+	if err != nil && isMethodNotFoundError(err) {
+		p.setMethodUnsupported(method)
+		return superr.Wrap(ErrUnsupportedMethodOnChain, err)
+	}
+	return err
+}
+
+func (p *Provider) methodSupported(method string) (bool, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	supported, ok := p.methodSupport[method]
+	return supported, ok
+}
+
+func (p *Provider) isMethodUnsupported(method string) bool {
+	supported, ok := p.methodSupported(method)
+	return ok && !supported
+}
+
+func (p *Provider) setMethodUnsupported(method string) {
+	p.setMethodSupported(method, false)
+}
+
+func (p *Provider) setMethodSupported(method string, supported bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.methodSupport == nil {
+		p.methodSupport = map[string]bool{}
+	}
+	p.methodSupport[method] = supported
+}
+
 // ...
 func (p *Provider) IsStreamingEnabled() bool {
 	return p.nodeWSURL != ""
@@ -516,14 +905,14 @@ func (p *Provider) streamSubscribe(ctx context.Context, label string, subscribeF
 		return nil, fmt.Errorf("ethrpc: provider instance has not enabled streaming")
 	}
 
-	gethRPC, err := rpc.DialContext(ctx, p.nodeWSURL)
+	gethRPC, release, err := p.dialStream(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("ethrpc: %s failed to connect to websocket: %w", label, err)
 	}
 
 	sub, err := subscribeFn(gethRPC)
 	if err != nil {
-		gethRPC.Close()
+		release(true)
 		return nil, fmt.Errorf("ethrpc: %s failed: %w", label, err)
 	}
 
@@ -535,10 +924,11 @@ func (p *Provider) streamSubscribe(ctx context.Context, label string, subscribeF
 	go func() {
 		// close the subscription when the context is cancelled
 		// or when the subscription is explicitly closed
+		var subErr error
 		select {
 		case <-ctx.Done():
 			sub.Unsubscribe()
-		case <-sub.Err():
+		case subErr = <-sub.Err():
 		}
 
 		p.mu.Lock()
@@ -549,7 +939,7 @@ func (p *Provider) streamSubscribe(ctx context.Context, label string, subscribeF
 				break
 			}
 		}
-		gethRPC.Close()
+		release(subErr != nil)
 		for i, closer := range p.streamClosers {
 			if closer == gethRPC {
 				p.streamClosers = append(p.streamClosers[:i], p.streamClosers[i+1:]...)
@@ -568,6 +958,13 @@ func (p *Provider) streamSubscribe(ctx context.Context, label string, subscribeF
 //
 // The connection will be closed and unsubscribed when the context is cancelled.
 func (p *Provider) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return subscribeStream(p, ctx, "SubscribeFilterLogs", ch, func(conn *rpc.Client, ch chan<- types.Log) (ethereum.Subscription, error) {
+		return conn.EthSubscribe(ctx, ch, "logs", query)
+	})
+}
+
+// subscribeFilterLogs is SubscribeFilterLogs without reconnecting the stream.
+func (p *Provider) subscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
 	fn := func(conn *rpc.Client) (ethereum.Subscription, error) {
 		return conn.EthSubscribe(ctx, ch, "logs", query)
 	}
@@ -579,10 +976,19 @@ func (p *Provider) SubscribeFilterLogs(ctx context.Context, query ethereum.Filte
 //
 // The connection will be closed and unsubscribed when the context is cancelled.
 func (p *Provider) SubscribeNewHeads(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
-	fn := func(conn *rpc.Client) (ethereum.Subscription, error) {
+	return subscribeStream(p, ctx, "SubscribeNewHeads", ch, func(conn *rpc.Client, ch chan<- *types.Header) (ethereum.Subscription, error) {
 		return conn.EthSubscribe(ctx, ch, "newHeads")
-	}
-	return p.streamSubscribe(ctx, "SubscribeNewHeads", fn)
+	})
+}
+
+// SubscribeNewPendingTransactions listens for the hashes of new pending transactions
+// of the node's mempool via websocket client.
+//
+// The connection will be closed and unsubscribed when the context is cancelled.
+func (p *Provider) SubscribeNewPendingTransactions(ctx context.Context, ch chan<- common.Hash) (ethereum.Subscription, error) {
+	return subscribeStream(p, ctx, "SubscribeNewPendingTransactions", ch, func(conn *rpc.Client, ch chan<- common.Hash) (ethereum.Subscription, error) {
+		return conn.EthSubscribe(ctx, ch, "newPendingTransactions")
+	})
 }
 
 func (p *Provider) CloseStreamConns() {
@@ -596,6 +1002,13 @@ func (p *Provider) CloseStreamConns() {
 	}
 	p.streamClosers = p.streamClosers[:0]
 	p.streamUnsubscribers = p.streamUnsubscribers[:0]
+
+	if p.streamMux != nil {
+		p.streamMux.closeAll()
+	}
+	if p.router != nil {
+		p.router.closeWSConn(nil)
+	}
 }
 
 // ie, ContractQuery(context.Background(), "0xabcdef..", "balanceOf(uint256)", "uint256", []string{"1"})
@@ -2,16 +2,31 @@ package ethrpc_test
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
 	"github.com/0xsequence/ethkit/ethtest"
 	"github.com/0xsequence/ethkit/go-ethereum"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+	"github.com/0xsequence/ethkit/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
 	"github.com/goware/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -232,6 +247,1272 @@ func TestETHRPC(t *testing.T) {
 	})
 }
 
+func TestBatchCall(t *testing.T) {
+	p, err := ethrpc.NewProvider(ethtest.DefaultTestchainOptions.NodeURL, ethrpc.WithMaxBatchSize(2))
+	require.NoError(t, err)
+
+	wallets, err := testchain.DummyWallets(5, 600)
+	require.NoError(t, err)
+	addresses := ethtest.WalletAddresses(wallets)
+	require.NoError(t, testchain.FundAddresses(addresses, 1))
+
+	t.Run("BatchCall", func(t *testing.T) {
+		var (
+			chainID     hexutil.Big
+			blockNumber hexutil.Uint64
+		)
+		elems := []ethrpc.BatchElement{
+			{Method: "eth_chainId", Result: &chainID},
+			{Method: "eth_blockNumber", Result: &blockNumber},
+			{Method: "eth_unknownMethod"},
+		}
+		err := p.BatchCall(context.Background(), elems)
+		require.NoError(t, err)
+		require.NoError(t, elems[0].Error)
+		require.NoError(t, elems[1].Error)
+		require.Error(t, elems[2].Error)
+		assert.Equal(t, uint64(1337), chainID.ToInt().Uint64())
+		assert.Greater(t, uint64(blockNumber), uint64(0))
+	})
+
+	t.Run("BatchBalanceAt", func(t *testing.T) {
+		balances, err := p.BatchBalanceAt(context.Background(), addresses, nil)
+		require.NoError(t, err)
+		require.Len(t, balances, len(addresses))
+		for i, address := range addresses {
+			balance, err := p.BalanceAt(context.Background(), address, nil)
+			require.NoError(t, err)
+			assert.Equal(t, balance.String(), balances[i].String())
+		}
+	})
+}
+
+func TestArchiveURL(t *testing.T) {
+	newNode := func(hits *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*hits++
+			var req struct {
+				ID uint64 `json:"id"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+		}))
+	}
+
+	var primaryHits, archiveHits int
+	primary := newNode(&primaryHits)
+	defer primary.Close()
+	archive := newNode(&archiveHits)
+	defer archive.Close()
+
+	p, err := ethrpc.NewProvider(primary.URL, ethrpc.WithArchiveURL(archive.URL))
+	require.NoError(t, err)
+
+	var blockNumber uint64
+	_, err = p.Do(context.Background(), ethrpc.BlockNumber().Into(&blockNumber))
+	require.NoError(t, err)
+	assert.Equal(t, 1, primaryHits)
+	assert.Equal(t, 0, archiveHits)
+
+	var balance *big.Int
+	_, err = p.Do(context.Background(), ethrpc.BalanceAt(common.Address{}, big.NewInt(1)).Into(&balance).ArchiveRequired())
+	require.NoError(t, err)
+	assert.Equal(t, 1, primaryHits)
+	assert.Equal(t, 1, archiveHits)
+	assert.Equal(t, uint64(1), balance.Uint64())
+
+	// without an archive node, calls go to the primary node
+	p, err = ethrpc.NewProvider(primary.URL)
+	require.NoError(t, err)
+
+	_, err = p.Do(context.Background(), ethrpc.BalanceAt(common.Address{}, big.NewInt(1)).Into(&balance).ArchiveRequired())
+	require.NoError(t, err)
+	assert.Equal(t, 2, primaryHits)
+	assert.Equal(t, 1, archiveHits)
+}
+
+func TestWithRetry(t *testing.T) {
+	var hits int
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		switch {
+		case req.Method == "eth_call":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":3,"message":"execution reverted"}}`, req.ID)
+		case hits == 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case hits == 2:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32005,"message":"exceeded more than 100 requests per second"}}`, req.ID)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	var attempts []int
+	p, err := ethrpc.NewProvider(node.URL,
+		ethrpc.WithRetry(3, time.Millisecond, 10*time.Millisecond),
+		ethrpc.WithRetryCallback(func(attempt int, err error) {
+			attempts = append(attempts, attempt)
+		}),
+	)
+	require.NoError(t, err)
+
+	// transient errors are retried
+	blockNumber, err := p.BlockNumber(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), blockNumber)
+	assert.Equal(t, 3, hits)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+
+	// reverts are not retried
+	hits, attempts = 0, nil
+	_, err = p.CallContract(context.Background(), ethereum.CallMsg{To: &common.Address{}}, nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, []int{1}, attempts)
+}
+
+func TestWithRetrySendTransaction(t *testing.T) {
+	type request struct {
+		ID     uint64 `json:"id"`
+		Method string `json:"method"`
+	}
+	result := func(req request) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, common.Hash{0x01}.Hex())
+	}
+	dropConn := func(w http.ResponseWriter, reqs []request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}
+
+	var hits int
+	var fail func(w http.ResponseWriter, reqs []request)
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		body, _ := io.ReadAll(r.Body)
+		var reqs []request
+		if body[0] == '[' {
+			json.Unmarshal(body, &reqs)
+		} else {
+			var req request
+			json.Unmarshal(body, &req)
+			reqs = []request{req}
+		}
+		if hits == 1 {
+			fail(w, reqs)
+			return
+		}
+		if body[0] != '[' {
+			fmt.Fprint(w, result(reqs[0]))
+			return
+		}
+		results := make([]string, len(reqs))
+		for i, req := range reqs {
+			results[i] = result(req)
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(results, ","))
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL, ethrpc.WithRetry(3, time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	sendRaw := ethrpc.NewCallBuilder[common.Hash]("eth_sendRawTransaction", nil, hexutil.Bytes{0x02})
+	getHash := ethrpc.NewCallBuilder[common.Hash]("eth_getBlockHash", nil)
+
+	// the connection drops after the node got the transaction, so it's not sent again
+	hits, fail = 0, dropConn
+	_, err = p.Do(ctx, sendRaw.Into(nil))
+	require.Error(t, err)
+	assert.Equal(t, 1, hits)
+
+	// nor is a batch whose other call was rate limited, as the send succeeded
+	hits = 0
+	fail = func(w http.ResponseWriter, reqs []request) {
+		fmt.Fprintf(w, `[{"jsonrpc":"2.0","id":%d,"error":{"code":-32005,"message":"rate limited"}},%s]`, reqs[0].ID, result(reqs[1]))
+	}
+	_, err = p.Do(ctx, getHash.Into(nil), sendRaw.Into(nil))
+	require.Error(t, err)
+	assert.Equal(t, 1, hits)
+
+	// the send is retried if it was rejected before reaching the node
+	hits = 0
+	fail = func(w http.ResponseWriter, reqs []request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	var txnHash common.Hash
+	_, err = p.Do(ctx, sendRaw.Into(&txnHash))
+	require.NoError(t, err)
+	assert.Equal(t, common.Hash{0x01}, txnHash)
+	assert.Equal(t, 2, hits)
+
+	// while reads are retried on a dropped connection
+	hits, fail = 0, dropConn
+	_, err = p.Do(ctx, getHash.Into(nil))
+	require.NoError(t, err)
+	assert.Equal(t, 2, hits)
+}
+
+func TestDebugGetRawReceipts(t *testing.T) {
+	receipt := &types.Receipt{
+		Type:              types.DynamicFeeTxType,
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 21000,
+		Logs:              []*types.Log{{Address: common.HexToAddress("0x1"), Topics: []common.Hash{{0x01}}, Data: []byte{0x02}}},
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	encoded, err := receipt.MarshalBinary()
+	require.NoError(t, err)
+
+	var hits int
+	supported, available := true, true
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		var req struct {
+			ID uint64 `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !supported {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"the method debug_getRawReceipts does not exist/is not available"}}`, req.ID)
+			return
+		}
+		if !available {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"header not available"}}`, req.ID)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":["%s"]}`, req.ID, hexutil.Encode(encoded))
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	receipts, err := p.DebugGetRawReceipts(context.Background(), big.NewInt(1))
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	assert.Equal(t, receipt.CumulativeGasUsed, receipts[0].CumulativeGasUsed)
+	assert.Equal(t, receipt.Logs[0].Address, receipts[0].Logs[0].Address)
+
+	// errors which aren't about the method don't mark it as unsupported
+	available = false
+	_, err = p.DebugGetRawReceipts(context.Background(), big.NewInt(1))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+
+	available = true
+	receipts, err = p.DebugGetRawReceipts(context.Background(), big.NewInt(1))
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+
+	// unsupported method is only called once
+	supported = false
+	p, err = ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	ok, err := p.SupportsDebugGetRawReceipts(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	hits = 0
+	_, err = p.DebugGetRawReceipts(context.Background(), big.NewInt(1))
+	assert.ErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+	assert.Equal(t, 0, hits)
+}
+
+func TestTraceTransaction(t *testing.T) {
+	var hits int
+	supported := true
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		var req struct {
+			ID uint64 `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !supported {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"the method trace_transaction does not exist/is not available"}}`, req.ID)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":[`+
+			`{"type":"call","action":{"callType":"call","from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","value":"0x1","gas":"0x5208","input":"0x"},"result":{"gasUsed":"0x0","output":"0x"},"subtraces":1,"traceAddress":[]},`+
+			`{"type":"call","action":{"callType":"call","from":"0x0000000000000000000000000000000000000002","to":"0x0000000000000000000000000000000000000003","value":"0x1","gas":"0x0","input":"0x"},"result":{"gasUsed":"0x0","output":"0x"},"subtraces":0,"traceAddress":[0]}`+
+			`]}`, req.ID)
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	traces, err := p.TraceTransaction(context.Background(), common.Hash{0x01})
+	require.NoError(t, err)
+	require.Len(t, traces, 2)
+	assert.Equal(t, common.HexToAddress("0x2"), traces[0].Action.To)
+	assert.Equal(t, common.HexToAddress("0x3"), traces[1].Action.To)
+	assert.Equal(t, []int{0}, traces[1].TraceAddress)
+
+	// unsupported method is only called once
+	supported = false
+	p, err = ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	_, err = p.TraceTransaction(context.Background(), common.Hash{0x01})
+	assert.ErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+
+	hits = 0
+	_, err = p.TraceTransaction(context.Background(), common.Hash{0x01})
+	assert.ErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+	assert.Equal(t, 0, hits)
+}
+
+func TestSupports(t *testing.T) {
+	hits := map[string]int{}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		hits[req.Method]++
+
+		switch req.Method {
+		case "eth_blockNumber":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+		case "eth_getBalance":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32602,"message":"missing value for required argument 0"}}`, req.ID)
+		case "eth_getLogs":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"internal error"}}`, req.ID)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"the method %s does not exist/is not available"}}`, req.ID, req.Method)
+		}
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL, ethrpc.WithMethodSupport("debug_traceTransaction", false))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, method := range []string{"eth_blockNumber", "eth_getBalance", "trace_transaction"} {
+		expected := method != "trace_transaction"
+		for i := 0; i < 2; i++ {
+			supported, err := p.Supports(ctx, method)
+			require.NoError(t, err)
+			assert.Equal(t, expected, supported, method)
+		}
+		// probed only once
+		assert.Equal(t, 1, hits[method], method)
+	}
+
+	// the probe result is shared with the method wrappers
+	_, err = p.TraceTransaction(ctx, common.Hash{0x01})
+	assert.ErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+	assert.Equal(t, 1, hits["trace_transaction"])
+
+	// overridden methods are never probed
+	supported, err := p.Supports(ctx, "debug_traceTransaction")
+	require.NoError(t, err)
+	assert.False(t, supported)
+	_, err = p.DebugTraceTransaction(ctx, common.Hash{0x01})
+	assert.ErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+	assert.Equal(t, 0, hits["debug_traceTransaction"])
+
+	// other node errors don't tell whether the method is supported, and are not cached
+	for i := 1; i <= 2; i++ {
+		_, err = p.Supports(ctx, "eth_getLogs")
+		require.Error(t, err)
+		assert.Equal(t, i, hits["eth_getLogs"])
+	}
+
+	// state-changing methods are never probed
+	_, err = p.Supports(ctx, "evm_mine")
+	require.Error(t, err)
+	assert.Equal(t, 0, hits["evm_mine"])
+
+	// unreachable nodes are not cached
+	p, err = ethrpc.NewProvider("http://127.0.0.1:1")
+	require.NoError(t, err)
+	_, err = p.Supports(ctx, "eth_blockNumber")
+	require.Error(t, err)
+}
+
+func TestCapabilities(t *testing.T) {
+	hits := map[string]int{}
+	limited := true
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		hits[req.Method]++
+
+		if limited && req.Method == "eth_feeHistory" {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"daily request count exceeded"}}`, req.ID)
+			return
+		}
+
+		switch req.Method {
+		case "web3_clientVersion":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"Geth/v1.13.5-stable/linux-amd64/go1.21.4"}`, req.ID)
+		case "eth_getBlockReceipts", "debug_traceTransaction", "eth_feeHistory":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32602,"message":"missing value for required argument 0"}}`, req.ID)
+		case "eth_getBlockByNumber":
+			header, _ := json.Marshal(&types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(0), BaseFee: big.NewInt(1000)})
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, header)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"the method %s does not exist/is not available"}}`, req.ID, req.Method)
+		}
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// a node error while probing is not cached as the method being unsupported
+	_, err = p.Capabilities(ctx)
+	require.Error(t, err)
+	limited = false
+
+	caps, err := p.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ethrpc.Capabilities{
+		ClientVersion:         "Geth/v1.13.5-stable/linux-amd64/go1.21.4",
+		Client:                "geth",
+		SupportsBlockReceipts: true,
+		SupportsDebugTracing:  true,
+		SupportsTracing:       true,
+		SupportsFeeHistory:    true,
+		Supports1559:          true,
+	}, caps)
+
+	// cached, and shared with the method wrappers
+	caps, err = p.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.True(t, caps.SupportsTracing)
+	assert.Equal(t, 2, hits["web3_clientVersion"])
+	assert.Equal(t, 1, hits["trace_transaction"])
+
+	_, err = p.TraceTransaction(ctx, common.Hash{0x01})
+	assert.ErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+	assert.Equal(t, 1, hits["trace_transaction"])
+
+	// unreachable nodes are not cached
+	p, err = ethrpc.NewProvider("http://127.0.0.1:1")
+	require.NoError(t, err)
+	_, err = p.Capabilities(ctx)
+	require.Error(t, err)
+}
+
+func TestReadOnly(t *testing.T) {
+	hits := map[string]int{}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		hits[req.Method]++
+
+		switch req.Method {
+		case "eth_getBalance":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x64"}`, req.ID)
+		case "eth_call":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x01"}`, req.ID)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x0000000000000000000000000000000000000000000000000000000000000001"}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL, ethrpc.WithReadOnly())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// reads are allowed
+	balance, err := p.BalanceAt(ctx, common.Address{0x01}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), balance)
+
+	to := common.Address{0x02}
+	_, err = p.CallContract(ctx, ethereum.CallMsg{To: &to}, nil)
+	require.NoError(t, err)
+
+	// sends are rejected without reaching the node
+	err = p.SendTransaction(ctx, types.NewTx(&types.LegacyTx{To: &to}))
+	assert.ErrorIs(t, err, ethrpc.ErrReadOnly)
+
+	_, err = p.SendRawTransaction(ctx, "0x01")
+	assert.ErrorIs(t, err, ethrpc.ErrReadOnly)
+
+	_, err = p.Do(ctx, ethrpc.NewCall("personal_sendTransaction"), ethrpc.NewCall("evm_mine"))
+	assert.ErrorIs(t, err, ethrpc.ErrReadOnly)
+
+	// batches with any blocked call are rejected as a whole
+	var ret *big.Int
+	_, err = p.Do(ctx, ethrpc.BalanceAt(common.Address{0x01}, nil).Into(&ret), ethrpc.SendRawTransaction("0x01").Into(nil))
+	assert.ErrorIs(t, err, ethrpc.ErrReadOnly)
+	assert.Nil(t, ret)
+
+	assert.Equal(t, 1, hits["eth_getBalance"])
+	assert.Equal(t, 0, hits["eth_sendRawTransaction"])
+	assert.Equal(t, 0, hits["personal_sendTransaction"])
+	assert.Equal(t, 0, hits["evm_mine"])
+
+	assert.True(t, ethrpc.IsReadOnlyBlockedMethod("eth_signTypedData_v4"))
+	assert.True(t, ethrpc.IsReadOnlyBlockedMethod("anvil_setBalance"))
+	assert.False(t, ethrpc.IsReadOnlyBlockedMethod("eth_estimateGas"))
+	assert.False(t, ethrpc.IsReadOnlyBlockedMethod("debug_traceCall"))
+
+	// without WithReadOnly, sends reach the node
+	p, err = ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+	_, err = p.SendRawTransaction(ctx, "0x01")
+	require.NoError(t, err)
+	assert.Equal(t, 1, hits["eth_sendRawTransaction"])
+}
+
+func TestFeeHistory(t *testing.T) {
+	var response string
+	var params []json.RawMessage
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, response)
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	// the rewards of the empty second and third blocks are null, and are skipped
+	response = `{"oldestBlock":"0x10","baseFeePerGas":["0x64","0x6e","0x78","0x82"],"gasUsedRatio":[0.5,0,0,0.5],"reward":[["0x1","0x2"],[null,null],null,["0x3","0x4"]]}`
+	fh, err := p.FeeHistory(context.Background(), 2, big.NewInt(0x11), []float64{25, 75})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(0x10), fh.OldestBlock)
+	assert.Equal(t, []*big.Int{big.NewInt(100), big.NewInt(110), big.NewInt(120), big.NewInt(130)}, fh.BaseFee)
+	assert.Equal(t, []float64{0.5, 0, 0, 0.5}, fh.GasUsedRatio)
+	assert.Equal(t, [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {}, {}, {big.NewInt(3), big.NewInt(4)}}, fh.Reward)
+	assert.Equal(t, `"0x2"`, string(params[0]))
+	assert.Equal(t, `"0x11"`, string(params[1]))
+
+	// oldestBlock as a plain number, and no percentiles
+	response = `{"oldestBlock":16,"baseFeePerGas":["0x64","0x6e"],"gasUsedRatio":[0.5]}`
+	fh, err = p.FeeHistory(context.Background(), 1, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(16), fh.OldestBlock)
+	assert.Empty(t, fh.Reward)
+	assert.Equal(t, `[]`, string(params[2]))
+}
+
+func TestDebugTraceCall(t *testing.T) {
+	var params []json.RawMessage
+	hits := map[string]int{}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+		hits[req.Method]++
+
+		switch req.Method {
+		case "debug_traceCall":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"type":"CALL","from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","gas":"0x5208","gasUsed":"0x5208","input":"0x","calls":[{"type":"STATICCALL","from":"0x0000000000000000000000000000000000000002","to":"0x0000000000000000000000000000000000000003","input":"0x01"}]}}`, req.ID)
+		case "debug_traceTransaction":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"pre":{"0x0000000000000000000000000000000000000001":{"balance":"0x64","nonce":1}},"post":{"0x0000000000000000000000000000000000000001":{"balance":"0x0","nonce":2,"storage":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000002"}}}}}`, req.ID)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"the method %s does not exist/is not available"}}`, req.ID, req.Method)
+		}
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	to := common.HexToAddress("0x2")
+	trace, err := p.DebugTraceCall(ctx, ethereum.CallMsg{To: &to}, nil, ethrpc.DebugTracerConfig{
+		Tracer:       ethrpc.DebugTracerCallTracer,
+		TracerConfig: map[string]any{"onlyTopCall": false},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `"latest"`, string(params[1]))
+	assert.JSONEq(t, `{"tracer":"callTracer","tracerConfig":{"onlyTopCall":false}}`, string(params[2]))
+
+	callTrace, err := trace.CallTrace()
+	require.NoError(t, err)
+	assert.Equal(t, "CALL", callTrace.Type)
+	assert.Equal(t, to, callTrace.To)
+	assert.Equal(t, uint64(21000), callTrace.GasUsed.ToInt().Uint64())
+	require.Len(t, callTrace.Calls, 1)
+	assert.Equal(t, "STATICCALL", callTrace.Calls[0].Type)
+
+	// custom tracers are decoded by the caller
+	var custom map[string]any
+	require.NoError(t, trace.Unmarshal(&custom))
+	assert.Equal(t, "CALL", custom["type"])
+
+	trace, err = p.DebugTraceTransactionWithTracer(ctx, common.Hash{0x01}, ethrpc.DebugTracerConfig{
+		Tracer:       ethrpc.DebugTracerPreStateTracer,
+		TracerConfig: map[string]any{"diffMode": true},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tracer":"prestateTracer","tracerConfig":{"diffMode":true}}`, string(params[1]))
+
+	diff, err := trace.PrestateDiffTrace()
+	require.NoError(t, err)
+	account := common.HexToAddress("0x1")
+	assert.Equal(t, uint64(100), diff.Pre[account].Balance.ToInt().Uint64())
+	assert.Equal(t, uint64(2), diff.Post[account].Nonce)
+	assert.Equal(t, common.HexToHash("0x2"), diff.Post[account].Storage[common.HexToHash("0x1")])
+
+	// nodes without the debug namespace
+	p, err = ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+	node.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		hits[req.Method]++
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"the method %s does not exist/is not available"}}`, req.ID, req.Method)
+	})
+	hits = map[string]int{}
+	for i := 0; i < 2; i++ {
+		_, err = p.DebugTraceCall(ctx, ethereum.CallMsg{To: &to}, nil, ethrpc.DebugTracerConfig{})
+		assert.ErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+	}
+	assert.Equal(t, 1, hits["debug_traceCall"])
+}
+
+func TestOtsSearchTransactions(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	txn, err := types.SignTx(types.NewTransaction(0, common.HexToAddress("0x2"), big.NewInt(1), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	require.NoError(t, err)
+	txnJSON, err := json.Marshal(txn)
+	require.NoError(t, err)
+
+	supported := true
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !supported {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"the method %s does not exist/is not available"}}`, req.ID, req.Method)
+			return
+		}
+		switch req.Method {
+		case "ots_getApiLevel":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":8}`, req.ID)
+		case "ots_searchTransactionsBefore":
+			assert.Equal(t, `"0x0000000000000000000000000000000000000002"`, string(req.Params[0]))
+			assert.Equal(t, `100`, string(req.Params[1]))
+			assert.Equal(t, `25`, string(req.Params[2]))
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"txs":[%s],"receipts":[{"transactionHash":"%s","blockHash":"0x0000000000000000000000000000000000000000000000000000000000000001","blockNumber":"0x63","transactionIndex":"0x0","status":"0x1","cumulativeGasUsed":"0x5208","gasUsed":"0x5208","logs":[],"logsBloom":"0x%x","timestamp":1700000000}],"firstPage":true,"lastPage":false}}`, req.ID, txnJSON, txn.Hash(), make([]byte, 256))
+		case "ots_searchTransactionsAfter":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"txs":[],"receipts":[],"firstPage":false,"lastPage":true}}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	ok, err := p.SupportsOtterscan(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	page, err := p.OtsSearchTransactionsBefore(context.Background(), common.HexToAddress("0x2"), 100, 25)
+	require.NoError(t, err)
+	require.Len(t, page.Transactions, 1)
+	require.Len(t, page.Receipts, 1)
+	assert.Equal(t, txn.Hash(), page.Transactions[0].Hash())
+	assert.Equal(t, txn.Hash(), page.Receipts[0].TxHash)
+	assert.Equal(t, uint64(21000), page.Receipts[0].GasUsed)
+	assert.Equal(t, uint64(1700000000), page.Receipts[0].Timestamp)
+	assert.True(t, page.FirstPage)
+	assert.False(t, page.LastPage)
+
+	page, err = p.OtsSearchTransactionsAfter(context.Background(), common.HexToAddress("0x2"), 0, 25)
+	require.NoError(t, err)
+	assert.Empty(t, page.Transactions)
+	assert.True(t, page.LastPage)
+
+	// unsupported on nodes without the otterscan api
+	supported = false
+	p, err = ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	ok, err = p.SupportsOtterscan(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = p.OtsSearchTransactionsBefore(context.Background(), common.HexToAddress("0x2"), 0, 25)
+	assert.ErrorIs(t, err, ethrpc.ErrUnsupportedMethodOnChain)
+}
+
+func TestBlockReceipts(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	var txns []*types.Transaction
+	for i := 0; i < 2; i++ {
+		txn, err := types.SignTx(types.NewTransaction(uint64(i), common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+		require.NoError(t, err)
+		txns = append(txns, txn)
+	}
+
+	header, err := json.Marshal(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0)})
+	require.NoError(t, err)
+	block := map[string]any{}
+	require.NoError(t, json.Unmarshal(header, &block))
+	block["transactions"] = txns
+	blockJSON, err := json.Marshal(block)
+	require.NoError(t, err)
+
+	receiptJSON := func(txHash common.Hash) string {
+		data, err := json.Marshal(&types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: txHash, Logs: []*types.Log{}})
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	var methods []string
+	supported := true
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if body[0] != '[' {
+			body = append(append([]byte{'['}, body...), ']')
+		}
+		require.NoError(t, json.Unmarshal(body, &reqs))
+
+		var res []string
+		for _, req := range reqs {
+			methods = append(methods, req.Method)
+			switch {
+			case req.Method == "eth_getBlockReceipts" && supported:
+				res = append(res, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":[%s,%s]}`, req.ID, receiptJSON(txns[0].Hash()), receiptJSON(txns[1].Hash())))
+			case req.Method == "eth_getBlockReceipts":
+				res = append(res, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"the method eth_getBlockReceipts does not exist/is not available"}}`, req.ID))
+			case req.Method == "eth_getBlockByNumber":
+				res = append(res, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, blockJSON))
+			case req.Method == "eth_getTransactionReceipt":
+				res = append(res, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, receiptJSON(common.HexToHash(strings.Trim(string(req.Params[0]), `"`)))))
+			}
+		}
+		if len(res) == 1 {
+			fmt.Fprint(w, res[0])
+		} else {
+			fmt.Fprintf(w, "[%s]", strings.Join(res, ","))
+		}
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	receipts, err := p.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(1))
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+	assert.Equal(t, txns[1].Hash(), receipts[1].TxHash)
+	assert.Equal(t, []string{"eth_getBlockReceipts"}, methods)
+
+	ok, err := p.SupportsBlockReceipts(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// fallback to per-txn receipts when unsupported, which is only detected once
+	supported = false
+	p, err = ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		methods = nil
+		receipts, err = p.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(1))
+		require.NoError(t, err)
+		require.Len(t, receipts, 2)
+		assert.Equal(t, txns[0].Hash(), receipts[0].TxHash)
+		assert.Equal(t, txns[1].Hash(), receipts[1].TxHash)
+	}
+	assert.Equal(t, []string{"eth_getBlockByNumber", "eth_getTransactionReceipt", "eth_getTransactionReceipt"}, methods)
+
+	ok, err = p.SupportsBlockReceipts(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFilterLogsRange(t *testing.T) {
+	var ranges [][2]uint64
+	var tooManyResults int
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Params []struct {
+				FromBlock hexutil.Uint64 `json:"fromBlock"`
+				ToBlock   hexutil.Uint64 `json:"toBlock"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		from, to := uint64(req.Params[0].FromBlock), uint64(req.Params[0].ToBlock)
+		if to-from+1 > 10 {
+			tooManyResults++
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32005,"message":"query returned more than 10000 results"}}`, req.ID)
+			return
+		}
+		ranges = append(ranges, [2]uint64{from, to})
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":[{"address":"0x0000000000000000000000000000000000000001","topics":[],"data":"0x","blockNumber":"%s","transactionHash":"0x0000000000000000000000000000000000000000000000000000000000000001","transactionIndex":"0x0","blockHash":"0x0000000000000000000000000000000000000000000000000000000000000001","logIndex":"0x0","removed":false}]}`, req.ID, hexutil.EncodeUint64(from))
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL, ethrpc.WithRetry(3, time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+
+	var blockNums []uint64
+	err = p.FilterLogsRange(context.Background(), ethereum.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(50)}, 20, func(logs []types.Log) error {
+		for _, log := range logs {
+			blockNums = append(blockNums, log.BlockNumber)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the chunk of 20 blocks is halved to 10, without retrying the chunk as is
+	assert.Equal(t, [][2]uint64{{1, 10}, {11, 20}, {21, 30}, {31, 40}, {41, 50}}, ranges)
+	assert.Equal(t, 1, tooManyResults)
+	assert.Equal(t, []uint64{1, 11, 21, 31, 41}, blockNums)
+}
+
+func TestCallContractWithOverrides(t *testing.T) {
+	var params []json.RawMessage
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x01"}`, req.ID)
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	msg := ethereum.CallMsg{To: &account, Data: []byte{0x12}}
+
+	result, err := p.CallContractWithOverrides(context.Background(), msg, big.NewInt(10), map[common.Address]ethrpc.OverrideAccount{
+		account: {
+			Balance:   big.NewInt(1000),
+			Code:      []byte{},
+			State:     map[common.Hash]common.Hash{},
+			StateDiff: map[common.Hash]common.Hash{{0x01}: {0x02}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01}, result)
+
+	require.Len(t, params, 3)
+	assert.Equal(t, `"0xa"`, string(params[1]))
+	assert.JSONEq(t, `{"0x1111111111111111111111111111111111111111":{
+		"balance":"0x3e8",
+		"code":"0x",
+		"state":{},
+		"stateDiff":{"0x0100000000000000000000000000000000000000000000000000000000000000":"0x0200000000000000000000000000000000000000000000000000000000000000"}
+	}}`, string(params[2]))
+
+	// without overrides, the call is the same as CallContract
+	_, err = p.CallContractWithOverrides(context.Background(), msg, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, params, 2)
+}
+
+func TestStreamReconnect(t *testing.T) {
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	wsNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		n := atomic.AddInt32(&conns, 1)
+
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "eth_subscribe" {
+			return
+		}
+		conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": "0x1"})
+
+		header := &types.Header{Number: big.NewInt(int64(n)), Difficulty: big.NewInt(0)}
+		conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "method": "eth_subscription", "params": map[string]any{"subscription": "0x1", "result": header}})
+
+		// drop the first connection, and keep the next one open
+		if n == 1 {
+			return
+		}
+		for {
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": true})
+		}
+	}))
+	defer wsNode.Close()
+
+	p, err := ethrpc.NewProvider(wsNode.URL, ethrpc.WithStreaming(wsNode.URL), ethrpc.WithStreamReconnect(3, 10*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+	defer p.CloseStreamConns()
+
+	ch := make(chan *types.Header)
+	sub, err := p.SubscribeNewHeads(context.Background(), ch)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case header := <-ch:
+			assert.Equal(t, int64(i), header.Number.Int64())
+		case err := <-sub.Err():
+			t.Fatalf("unexpected subscription error: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for header")
+		}
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&conns))
+}
+
+func TestStreamMultiplexing(t *testing.T) {
+	const numSubs = 3
+
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	wsNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		n := atomic.AddInt32(&conns, 1)
+
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		for numSubscribed := 0; ; {
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method != "eth_subscribe" {
+				conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": true})
+				continue
+			}
+			numSubscribed++
+			subID := fmt.Sprintf("0x%x", numSubscribed)
+			conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": subID})
+
+			header := &types.Header{Number: big.NewInt(int64(n)), Difficulty: big.NewInt(0)}
+			conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "method": "eth_subscription", "params": map[string]any{"subscription": subID, "result": header}})
+
+			// drop the first connection once all subscriptions are made over it
+			if n == 1 && numSubscribed == numSubs {
+				return
+			}
+		}
+	}))
+	defer wsNode.Close()
+
+	p, err := ethrpc.NewProvider(wsNode.URL,
+		ethrpc.WithStreaming(wsNode.URL),
+		ethrpc.WithStreamMultiplexing(),
+		ethrpc.WithStreamReconnect(3, 10*time.Millisecond, 50*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer p.CloseStreamConns()
+
+	var chs []chan *types.Header
+	for i := 0; i < numSubs; i++ {
+		ch := make(chan *types.Header, 2)
+		sub, err := p.SubscribeNewHeads(context.Background(), ch)
+		require.NoError(t, err)
+		defer sub.Unsubscribe()
+		chs = append(chs, ch)
+	}
+	assert.Equal(t, numSubs, p.ActiveSubscriptionCount())
+
+	// every subscription receives the header of the first connection, and once resubscribed
+	// over a single new connection, the header of the second one
+	for i := 1; i <= 2; i++ {
+		for _, ch := range chs {
+			select {
+			case header := <-ch:
+				assert.Equal(t, int64(i), header.Number.Int64())
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for header")
+			}
+		}
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&conns))
+	assert.Equal(t, numSubs, p.ActiveSubscriptionCount())
+}
+
+func TestStreamMultiplexingConcurrentDial(t *testing.T) {
+	const numSubs = 3
+
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	wsNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a slow node to connect to
+		time.Sleep(500 * time.Millisecond)
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		atomic.AddInt32(&conns, 1)
+
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		for numSubscribed := 0; ; {
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			numSubscribed++
+			conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": fmt.Sprintf("0x%x", numSubscribed)})
+		}
+	}))
+	defer wsNode.Close()
+
+	p, err := ethrpc.NewProvider(wsNode.URL, ethrpc.WithStreaming(wsNode.URL), ethrpc.WithStreamMultiplexing())
+	require.NoError(t, err)
+	defer p.CloseStreamConns()
+
+	var wg sync.WaitGroup
+	for i := 0; i < numSubs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub, err := p.SubscribeNewHeads(context.Background(), make(chan *types.Header))
+			if assert.NoError(t, err) {
+				defer sub.Unsubscribe()
+			}
+		}()
+	}
+
+	// a subscription waiting for the in-flight dial gives up once its context is done
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = p.SubscribeNewHeads(ctx, make(chan *types.Header))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 250*time.Millisecond)
+
+	// the subscriptions share the single connection dialed
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+}
+
+func TestSubscribeLogsReliableReorg(t *testing.T) {
+	newLog := func(index uint, txHash string, removed bool) types.Log {
+		return types.Log{
+			Topics:      []common.Hash{},
+			BlockNumber: 5,
+			BlockHash:   common.HexToHash(txHash),
+			TxHash:      common.HexToHash(txHash),
+			Index:       index,
+			Removed:     removed,
+		}
+	}
+
+	// the block 5 logs are reorged, and replaced by logs at the same indexes
+	logs := []types.Log{
+		newLog(0, "0xa", false),
+		newLog(1, "0xa", false),
+		newLog(1, "0xa", true),
+		newLog(0, "0xa", true),
+		newLog(0, "0xb", false),
+		newLog(1, "0xb", false),
+	}
+
+	upgrader := websocket.Upgrader{}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !websocket.IsWebSocketUpgrade(r) {
+			var req struct {
+				ID json.RawMessage `json:"id"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": "0x4"})
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "eth_subscribe" {
+			return
+		}
+		conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": "0x1"})
+		for _, log := range logs {
+			conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "method": "eth_subscription", "params": map[string]any{"subscription": "0x1", "result": log}})
+		}
+		for {
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": true})
+		}
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL, ethrpc.WithStreaming(node.URL))
+	require.NoError(t, err)
+	defer p.CloseStreamConns()
+
+	ch := make(chan types.Log)
+	sub, err := p.SubscribeLogsReliable(context.Background(), ethereum.FilterQuery{}, ch)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	for i, expected := range logs {
+		select {
+		case log := <-ch:
+			assert.Equal(t, expected.TxHash, log.TxHash, "log %d", i)
+			assert.Equal(t, expected.Index, log.Index, "log %d", i)
+			assert.Equal(t, expected.Removed, log.Removed, "log %d", i)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for log %d", i)
+		}
+	}
+}
+
+func TestSmartRouting(t *testing.T) {
+	var httpHits, wsHits int
+	var httpDown bool
+	httpNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpHits++
+		var req struct {
+			ID uint64 `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if httpDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+	}))
+	defer httpNode.Close()
+
+	upgrader := websocket.Upgrader{}
+	wsNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var reqs []struct {
+				ID     json.RawMessage `json:"id"`
+				Method string          `json:"method"`
+			}
+			if err := conn.ReadJSON(&reqs); err != nil {
+				return
+			}
+			wsHits++
+			res := make([]map[string]any, len(reqs))
+			for i, req := range reqs {
+				res[i] = map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": "0x2"}
+				if req.Method == "eth_call" {
+					res[i] = map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": 3, "message": "execution reverted"}}
+				}
+			}
+			conn.WriteJSON(res)
+		}
+	}))
+	defer wsNode.Close()
+
+	p, err := ethrpc.NewProvider(httpNode.URL, ethrpc.WithStreaming(wsNode.URL), ethrpc.WithSmartRouting())
+	require.NoError(t, err)
+	defer p.CloseStreamConns()
+
+	// eth_blockNumber prefers websocket
+	blockNumber, err := p.BlockNumber(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), blockNumber)
+	assert.Equal(t, 0, httpHits)
+	assert.Equal(t, 1, wsHits)
+
+	// eth_gasPrice prefers http
+	gasPrice, err := p.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), gasPrice.Int64())
+	assert.Equal(t, 1, httpHits)
+	assert.Equal(t, 1, wsHits)
+
+	// falls back to websocket when http is unavailable
+	httpDown = true
+	gasPrice, err = p.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), gasPrice.Int64())
+	assert.Equal(t, 2, httpHits)
+	assert.Equal(t, 2, wsHits)
+
+	// and http is skipped while unhealthy
+	_, err = p.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, httpHits)
+	assert.Equal(t, 3, wsHits)
+
+	// node errors are returned as is over websocket
+	_, err = p.CallContract(context.Background(), ethereum.CallMsg{To: &common.Address{}}, nil)
+	require.ErrorContains(t, err, "execution reverted")
+	assert.Equal(t, 4, wsHits)
+
+	// without smart routing, calls are only sent over http
+	p, err = ethrpc.NewProvider(httpNode.URL, ethrpc.WithStreaming(wsNode.URL))
+	require.NoError(t, err)
+	_, err = p.SuggestGasPrice(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 3, httpHits)
+	assert.Equal(t, 4, wsHits)
+}
+
 func TestRaw(t *testing.T) {
 	p, err := ethrpc.NewProvider("https://nodes.sequence.app/polygon")
 	// p, err := ethrpc.NewProvider("http://localhost:8887/polygon")
@@ -323,3 +1604,437 @@ func TestFetchBlockWithInvalidVRS(t *testing.T) {
 		require.Equal(t, big.NewInt(0), tx.GasPrice())
 	}
 }
+
+func TestVerifyAccountProof(t *testing.T) {
+	// find two accounts whose trie paths diverge at the first nibble, so the state
+	// trie is a branch node at the root with a leaf node for each account
+	var addrs []common.Address
+	var keys [][]byte
+	for i := 1; len(addrs) < 2; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		key := crypto.Keccak256(addr.Bytes())
+		if len(keys) == 1 && keys[0][0]>>4 == key[0]>>4 {
+			continue
+		}
+		addrs = append(addrs, addr)
+		keys = append(keys, key)
+	}
+
+	type account struct {
+		Nonce       uint64
+		Balance     *big.Int
+		StorageHash common.Hash
+		CodeHash    []byte
+	}
+	emptyRoot := common.HexToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	emptyCodeHash := crypto.Keccak256Hash(nil)
+
+	branch := make([][]byte, 17)
+	leaves := make([][]byte, 2)
+	for i, key := range keys {
+		value, err := rlp.EncodeToBytes(account{Nonce: uint64(i + 1), Balance: big.NewInt(int64(1000 * (i + 1))), StorageHash: emptyRoot, CodeHash: emptyCodeHash.Bytes()})
+		require.NoError(t, err)
+
+		// hex-prefix encoding of the remaining 63 nibbles of the leaf path
+		path := []byte{0x30 | key[0]&0x0f}
+		path = append(path, key[1:]...)
+
+		leaves[i], err = rlp.EncodeToBytes([][]byte{path, value})
+		require.NoError(t, err)
+		branch[key[0]>>4] = crypto.Keccak256(leaves[i])
+	}
+	rootNode, err := rlp.EncodeToBytes(branch)
+	require.NoError(t, err)
+	stateRoot := crypto.Keccak256Hash(rootNode)
+
+	proof := &ethrpc.AccountProof{
+		Address:      addrs[1],
+		AccountProof: []hexutil.Bytes{rootNode, leaves[1]},
+		Balance:      (*hexutil.Big)(big.NewInt(2000)),
+		Nonce:        2,
+		CodeHash:     emptyCodeHash,
+		StorageHash:  emptyRoot,
+	}
+	require.NoError(t, ethrpc.VerifyAccountProof(stateRoot, proof))
+
+	// a wrong balance is detected
+	proof.Balance = (*hexutil.Big)(big.NewInt(2001))
+	require.Error(t, ethrpc.VerifyAccountProof(stateRoot, proof))
+
+	// a proof against another state root is rejected
+	proof.Balance = (*hexutil.Big)(big.NewInt(2000))
+	require.Error(t, ethrpc.VerifyAccountProof(common.Hash{0x01}, proof))
+
+	// an account which doesn't exist is proven by its absence in the branch
+	missing := ethrpc.AccountProof{AccountProof: []hexutil.Bytes{rootNode}, Balance: (*hexutil.Big)(big.NewInt(0))}
+	for i := 1; ; i++ {
+		missing.Address = common.BigToAddress(big.NewInt(int64(1000 + i)))
+		if branch[crypto.Keccak256(missing.Address.Bytes())[0]>>4] == nil {
+			break
+		}
+	}
+	require.NoError(t, ethrpc.VerifyAccountProof(stateRoot, &missing))
+
+	missing.Balance = (*hexutil.Big)(big.NewInt(1))
+	require.Error(t, ethrpc.VerifyAccountProof(stateRoot, &missing))
+}
+
+func TestVerifyStorageProof(t *testing.T) {
+	// find two slots whose trie paths diverge at the first nibble, so the storage
+	// trie is a branch node at the root with a leaf node for each slot
+	var slots []common.Hash
+	var keys [][]byte
+	for i := 0; len(slots) < 2; i++ {
+		slot := common.BigToHash(big.NewInt(int64(i)))
+		key := crypto.Keccak256(slot.Bytes())
+		if len(keys) == 1 && keys[0][0]>>4 == key[0]>>4 {
+			continue
+		}
+		slots = append(slots, slot)
+		keys = append(keys, key)
+	}
+
+	branch := make([][]byte, 17)
+	leaves := make([][]byte, 2)
+	for i, key := range keys {
+		value, err := rlp.EncodeToBytes(big.NewInt(int64(1000 * (i + 1))).Bytes())
+		require.NoError(t, err)
+
+		// hex-prefix encoding of the remaining 63 nibbles of the leaf path
+		path := []byte{0x30 | key[0]&0x0f}
+		path = append(path, key[1:]...)
+
+		leaves[i], err = rlp.EncodeToBytes([][]byte{path, value})
+		require.NoError(t, err)
+		branch[key[0]>>4] = crypto.Keccak256(leaves[i])
+	}
+	rootNode, err := rlp.EncodeToBytes(branch)
+	require.NoError(t, err)
+	storageHash := crypto.Keccak256Hash(rootNode)
+
+	storageProof := ethrpc.StorageProof{
+		Key:   slots[1],
+		Value: (*hexutil.Big)(big.NewInt(2000)),
+		Proof: []hexutil.Bytes{rootNode, leaves[1]},
+	}
+	require.NoError(t, ethrpc.VerifyStorageProof(storageHash, storageProof))
+
+	// a wrong value is detected
+	storageProof.Value = (*hexutil.Big)(big.NewInt(2001))
+	require.Error(t, ethrpc.VerifyStorageProof(storageHash, storageProof))
+
+	// a slot which isn't set is proven by its absence in the branch, with a zero value
+	missing := ethrpc.StorageProof{Value: (*hexutil.Big)(big.NewInt(0)), Proof: []hexutil.Bytes{rootNode}}
+	for i := 1; ; i++ {
+		missing.Key = common.BigToHash(big.NewInt(int64(1000 + i)))
+		if branch[crypto.Keccak256(missing.Key.Bytes())[0]>>4] == nil {
+			break
+		}
+	}
+	require.NoError(t, ethrpc.VerifyStorageProof(storageHash, missing))
+	missing.Value = (*hexutil.Big)(big.NewInt(1))
+	require.Error(t, ethrpc.VerifyStorageProof(storageHash, missing))
+
+	// accounts without storage have an empty storage trie
+	emptyRoot := common.HexToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	require.NoError(t, ethrpc.VerifyStorageProof(emptyRoot, ethrpc.StorageProof{Key: slots[0], Value: (*hexutil.Big)(big.NewInt(0))}))
+	require.Error(t, ethrpc.VerifyStorageProof(emptyRoot, ethrpc.StorageProof{Key: slots[0], Value: (*hexutil.Big)(big.NewInt(1))}))
+
+	// the account and storage proofs are verified together, where the state trie
+	// holds the single account as its root leaf
+	addr := common.HexToAddress("0x1234")
+	emptyCodeHash := crypto.Keccak256Hash(nil)
+	accountValue, err := rlp.EncodeToBytes([]any{uint64(1), big.NewInt(0), storageHash, emptyCodeHash.Bytes()})
+	require.NoError(t, err)
+	accountKey := crypto.Keccak256(addr.Bytes())
+	accountLeaf, err := rlp.EncodeToBytes([][]byte{append([]byte{0x20}, accountKey...), accountValue})
+	require.NoError(t, err)
+
+	proof := &ethrpc.AccountProof{
+		Address:      addr,
+		AccountProof: []hexutil.Bytes{accountLeaf},
+		Balance:      (*hexutil.Big)(big.NewInt(0)),
+		Nonce:        1,
+		CodeHash:     emptyCodeHash,
+		StorageHash:  storageHash,
+		StorageProof: []ethrpc.StorageProof{
+			{Key: slots[0], Value: (*hexutil.Big)(big.NewInt(1000)), Proof: []hexutil.Bytes{rootNode, leaves[0]}},
+			{Key: slots[1], Value: (*hexutil.Big)(big.NewInt(2000)), Proof: []hexutil.Bytes{rootNode, leaves[1]}},
+		},
+	}
+	stateRoot := crypto.Keccak256Hash(accountLeaf)
+	require.NoError(t, ethrpc.VerifyProof(stateRoot, proof))
+
+	proof.StorageProof[1].Value = (*hexutil.Big)(big.NewInt(1))
+	require.Error(t, ethrpc.VerifyProof(stateRoot, proof))
+}
+
+func TestCodesAt(t *testing.T) {
+	var numRequests int
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests++
+		var reqs []struct {
+			ID     uint64   `json:"id"`
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&reqs)
+
+		resps := make([]string, len(reqs))
+		for i, req := range reqs {
+			require.Equal(t, "eth_getCode", req.Method)
+			require.Equal(t, "latest", req.Params[1])
+			code := "0x"
+			if req.Params[0] == "0x2222222222222222222222222222222222222222" {
+				code = "0x6080"
+			}
+			resps[i] = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, code)
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(resps, ","))
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	accounts := []common.Address{
+		common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		common.HexToAddress("0x2222222222222222222222222222222222222222"),
+	}
+	codes, err := p.CodesAt(context.Background(), accounts, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, numRequests)
+
+	require.Len(t, codes, 2)
+	assert.Equal(t, accounts[0], codes[0].Address)
+	assert.False(t, codes[0].IsContract())
+	assert.Equal(t, accounts[1], codes[1].Address)
+	assert.Equal(t, []byte{0x60, 0x80}, codes[1].Code)
+	assert.True(t, codes[1].IsContract())
+}
+
+func TestRevertError(t *testing.T) {
+	var errData string
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID uint64 `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":3,"message":"execution reverted","data":"%s"}}`, req.ID, errData)
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	msg := ethereum.CallMsg{To: &account, Data: []byte{0x12}}
+
+	word := func(s string) string {
+		return strings.Repeat("0", 64-len(s)) + s
+	}
+
+	t.Run("Error(string)", func(t *testing.T) {
+		errData = "0x08c379a0" + word("20") + word("4") + hex.EncodeToString([]byte("nope")) + strings.Repeat("0", 56)
+		_, err := p.CallContract(context.Background(), msg, nil)
+
+		var revertErr *ethrpc.RevertError
+		require.ErrorAs(t, err, &revertErr)
+		assert.True(t, revertErr.IsErrorString())
+		assert.Equal(t, "nope", revertErr.Reason)
+		assert.Nil(t, revertErr.PanicCode)
+		assert.Equal(t, [4]byte{0x08, 0xc3, 0x79, 0xa0}, revertErr.Selector)
+		assert.Equal(t, hexutil.MustDecode(errData), revertErr.Data)
+		assert.Contains(t, err.Error(), "execution reverted: nope")
+
+		var rpcErr *jsonrpc.Error
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, 3, rpcErr.Code)
+	})
+
+	t.Run("Panic(uint256)", func(t *testing.T) {
+		errData = "0x4e487b71" + word("11")
+		_, err := p.CallContract(context.Background(), msg, nil)
+
+		var revertErr *ethrpc.RevertError
+		require.ErrorAs(t, err, &revertErr)
+		assert.False(t, revertErr.IsErrorString())
+		assert.Equal(t, big.NewInt(0x11), revertErr.PanicCode)
+		assert.Contains(t, err.Error(), "panic code 0x11")
+	})
+
+	t.Run("custom error", func(t *testing.T) {
+		errData = "0xdeadbeef" + word("1")
+		_, err := p.CallContract(context.Background(), msg, nil)
+
+		var revertErr *ethrpc.RevertError
+		require.ErrorAs(t, err, &revertErr)
+		assert.Equal(t, [4]byte{0xde, 0xad, 0xbe, 0xef}, revertErr.Selector)
+		assert.Empty(t, revertErr.Reason)
+		assert.Nil(t, revertErr.PanicCode)
+		assert.Contains(t, err.Error(), "custom error 0xdeadbeef")
+	})
+}
+
+func TestPendingReads(t *testing.T) {
+	supportsPending := true
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var blockTag string
+		json.Unmarshal(req.Params[len(req.Params)-1], &blockTag)
+		if req.Method == "eth_getBlockByNumber" {
+			json.Unmarshal(req.Params[0], &blockTag)
+		}
+		require.Equal(t, "pending", blockTag)
+
+		if !supportsPending {
+			if req.Method == "eth_getBlockByNumber" {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":null}`, req.ID)
+			} else {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"pending block is not available"}}`, req.ID)
+			}
+			return
+		}
+
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			header, _ := json.Marshal(&types.Header{Number: big.NewInt(101), Difficulty: big.NewInt(0)})
+			block := map[string]any{}
+			json.Unmarshal(header, &block)
+			block["hash"], block["nonce"], block["miner"] = nil, nil, nil
+			block["transactions"] = []any{}
+			block["uncles"] = []any{}
+			data, _ := json.Marshal(block)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, data)
+		case "eth_getBalance":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x64"}`, req.ID)
+		case "eth_getCode":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x6001"}`, req.ID)
+		case "eth_getStorageAt":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x%s"}`, req.ID, strings.Repeat("0", 63)+"2")
+		case "eth_call":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":3,"message":"execution reverted: pending","data":"0x"}}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	block, err := p.BlockByNumber(ctx, ethrpc.Pending)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(101), block.NumberU64())
+
+	balance, err := p.PendingBalanceAt(ctx, account)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), balance)
+
+	code, err := p.PendingCodeAt(ctx, account)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x60, 0x01}, code)
+
+	storage, err := p.PendingStorageAt(ctx, account, common.Hash{})
+	require.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(2)).Bytes(), storage)
+
+	// a reverted call is not mistaken for the node not supporting pending
+	_, err = p.PendingCallContract(ctx, ethereum.CallMsg{To: &account})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ethrpc.ErrPendingNotSupported)
+
+	supportsPending = false
+
+	_, err = p.BlockByNumber(ctx, ethrpc.Pending)
+	assert.ErrorIs(t, err, ethrpc.ErrPendingNotSupported)
+	assert.ErrorIs(t, err, ethereum.NotFound)
+
+	_, err = p.HeaderByNumber(ctx, ethrpc.Pending)
+	assert.ErrorIs(t, err, ethrpc.ErrPendingNotSupported)
+
+	_, err = p.PendingBalanceAt(ctx, account)
+	assert.ErrorIs(t, err, ethrpc.ErrPendingNotSupported)
+
+	_, err = p.PendingCodeAt(ctx, account)
+	assert.ErrorIs(t, err, ethrpc.ErrPendingNotSupported)
+}
+
+type captureLogger struct {
+	logger.Logger
+	lines []string
+}
+
+func (l *captureLogger) Info(v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(v...))
+}
+
+func (l *captureLogger) Warnf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestRequestLogger(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "eth_getBalance":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x%s"}`, req.ID, strings.Repeat("1", 40))
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+		}
+	}))
+	defer node.Close()
+
+	log := &captureLogger{Logger: logger.Nop()}
+	p, err := ethrpc.NewProvider(node.URL+"/v3/secret-key", ethrpc.WithRequestLogger(log, ethrpc.RequestLoggerOptions{
+		LogResponses: true,
+		MaxBytes:     64,
+	}))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	_, err = p.BalanceAt(ctx, account, nil)
+	require.NoError(t, err)
+
+	require.Len(t, log.lines, 1)
+	line := log.lines[0]
+	assert.Contains(t, line, "ethrpc: request to "+node.URL+"/***")
+	assert.NotContains(t, line, "secret-key")
+	assert.Contains(t, line, `eth_getBalance["0x1111111111111111111111111111111111111111","latest"]`)
+	assert.Contains(t, line, `response: {"jsonrpc":"2.0","id":1,"result":"0x1111`)
+	assert.Contains(t, line, "… (78 bytes)")
+
+	_, err = p.ChainID(ctx)
+	require.Error(t, err)
+	require.Len(t, log.lines, 2)
+	assert.Contains(t, log.lines[1], "eth_chainId[], failed: ")
+	assert.Contains(t, log.lines[1], "method not found")
+
+	// the url of a node which can't be dialed is redacted from the error
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	p, err = ethrpc.NewProvider(down.URL+"/v3/secret-key", ethrpc.WithRequestLogger(log))
+	require.NoError(t, err)
+	_, err = p.ChainID(ctx)
+	require.Error(t, err)
+	require.Len(t, log.lines, 3)
+	assert.Contains(t, log.lines[2], "eth_chainId[], failed: ")
+	assert.Contains(t, log.lines[2], down.URL+"/***")
+	assert.NotContains(t, log.lines[2], "secret-key")
+
+	assert.Equal(t, "https://mainnet.infura.io/***", ethrpc.RedactURL("https://mainnet.infura.io/v3/abc"))
+	assert.Equal(t, "https://node.example.com", ethrpc.RedactURL("https://node.example.com/"))
+	assert.Equal(t, "***", ethrpc.RedactURL("not a url"))
+}
@@ -1,9 +1,13 @@
 package ethrpc
 
 import (
+	"context"
 	"fmt"
+	"math/big"
+	"strings"
 
 	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 )
 
 func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
@@ -28,3 +32,92 @@ func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
 	}
 	return arg, nil
 }
+
+// FilterLogsRange fetches the logs of the query over the [FromBlock, ToBlock] range in
+// chunks of chunkSize blocks, calling fn with the logs of each chunk in block order, so
+// that large ranges can be processed without holding all of the logs in memory. A nil
+// ToBlock is the latest block at the time of calling.
+//
+// If the node responds that a chunk returned too many results or that the range is too
+// large, the chunk is halved and retried, down to a single block. Returning an error
+// from fn stops fetching, and the error is returned.
+func (p *Provider) FilterLogsRange(ctx context.Context, q ethereum.FilterQuery, chunkSize uint64, fn func(logs []types.Log) error) error {
+	if q.BlockHash != nil {
+		return fmt.Errorf("ethrpc: FilterLogsRange cannot be used with a BlockHash query")
+	}
+	if chunkSize == 0 {
+		return fmt.Errorf("ethrpc: FilterLogsRange chunkSize must be greater than 0")
+	}
+
+	var fromBlock, toBlock uint64
+	if q.FromBlock != nil {
+		fromBlock = q.FromBlock.Uint64()
+	}
+	if q.ToBlock != nil {
+		toBlock = q.ToBlock.Uint64()
+	} else {
+		head, err := p.BlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		toBlock = head
+	}
+
+	// some providers use the rate limit code for getLogs queries with too many results,
+	// which will fail again if retried as is, rather than being split into smaller chunks
+	ctx = context.WithValue(ctx, skipRetryKey{}, isTooManyResultsError)
+
+	size := chunkSize
+	for start := fromBlock; start <= toBlock; {
+		end := start + size - 1
+		if end > toBlock || end < start {
+			end = toBlock
+		}
+
+		chunk := q
+		chunk.FromBlock = new(big.Int).SetUint64(start)
+		chunk.ToBlock = new(big.Int).SetUint64(end)
+
+		logs, err := p.FilterLogs(ctx, chunk)
+		if err != nil {
+			if isTooManyResultsError(err) && size > 1 {
+				size /= 2
+				continue
+			}
+			return err
+		}
+
+		if err := fn(logs); err != nil {
+			return err
+		}
+
+		if end == toBlock {
+			break
+		}
+		start = end + 1
+	}
+
+	return nil
+}
+
+// isTooManyResultsError reports whether the error of a getLogs query is the node
+// rejecting it for returning too many results or spanning too large of a block range.
+// The messages vary across node implementations and providers. Only meaningful for
+// getLogs queries, as ie. rate limit errors read similarly.
+func isTooManyResultsError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"returned more than",
+		"too many results",
+		"range too large",
+		"range is too large",
+		"block range",
+		"exceed maximum block range",
+		"response size exceeded",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
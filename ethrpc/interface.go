@@ -204,9 +204,32 @@ type StrictnessLevelGetter interface {
 	StrictnessLevel() StrictnessLevel
 }
 
+// FilterLogsRangeInterface provides eth_getLogs queries over large block ranges, which are
+// fetched in chunks
+type FilterLogsRangeInterface interface {
+	FilterLogsRange(ctx context.Context, q ethereum.FilterQuery, chunkSize uint64, fn func(logs []types.Log) error) error
+}
+
 // DebugInterface provides additional debugging methods
 type DebugInterface interface {
 	DebugTraceBlockByNumber(ctx context.Context, blockNum *big.Int) ([]*TransactionDebugTrace, error)
 	DebugTraceBlockByHash(ctx context.Context, blockHash common.Hash) ([]*TransactionDebugTrace, error)
 	DebugTraceTransaction(ctx context.Context, txHash common.Hash) (*CallDebugTrace, error)
 }
+
+// DebugReceiptsInterface provides the receipts of a whole block with debug_getRawReceipts
+type DebugReceiptsInterface interface {
+	DebugGetRawReceipts(ctx context.Context, blockNum *big.Int) ([]*types.Receipt, error)
+}
+
+// TraceInterface provides the trace_* methods of the nodes which support them, ie. erigon
+// and nethermind
+type TraceInterface interface {
+	TraceTransaction(ctx context.Context, txHash common.Hash) ([]*TransactionTrace, error)
+}
+
+// DebugTracerInterface provides the debug tracing methods with a configurable tracer
+type DebugTracerInterface interface {
+	DebugTraceTransactionWithTracer(ctx context.Context, txHash common.Hash, config DebugTracerConfig) (*DebugTrace, error)
+	DebugTraceCall(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int, config DebugTracerConfig) (*DebugTrace, error)
+}
@@ -16,6 +16,7 @@ type Call struct {
 	resultFn   func(message json.RawMessage) error
 	err        error
 	strictness StrictnessLevel
+	archive    bool
 }
 
 func NewCall(method string, params ...any) Call {
@@ -27,6 +28,14 @@ func (c Call) Strict(strictness StrictnessLevel) Call {
 	return c
 }
 
+// ArchiveRequired marks the call as requiring archive node state, ie. an eth_call or
+// eth_getStorageAt against an old block, and the call will be routed to the archive
+// node set with WithArchiveURL. Without an archive node, the call goes to the primary node.
+func (c Call) ArchiveRequired() Call {
+	c.archive = true
+	return c
+}
+
 func (c *Call) Error() string {
 	if c == nil || c.err == nil {
 		return ""
@@ -105,6 +114,13 @@ func (b CallBuilder2[T1, T2]) Into(ret1 *T1, ret2 *T2) Call {
 
 var Pending = big.NewInt(-1)
 
+// Safe and Finalized are the block number tags of the latest safe and finalized blocks of
+// post-merge chains, ie. HeaderByNumber(ctx, ethrpc.Safe).
+var (
+	Safe      = big.NewInt(-4)
+	Finalized = big.NewInt(-3)
+)
+
 func toBlockNumArg(blockNum *big.Int) string {
 	if blockNum == nil {
 		return "latest"
@@ -112,6 +128,12 @@ func toBlockNumArg(blockNum *big.Int) string {
 	if blockNum.Cmp(Pending) == 0 {
 		return "pending"
 	}
+	if blockNum.Cmp(Safe) == 0 {
+		return "safe"
+	}
+	if blockNum.Cmp(Finalized) == 0 {
+		return "finalized"
+	}
 	return hexutil.EncodeBig(blockNum)
 }
 
@@ -132,6 +154,9 @@ func toCallArg(msg ethereum.CallMsg) any {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if len(msg.AccessList) > 0 {
+		arg["accessList"] = msg.AccessList
+	}
 	return arg
 }
 
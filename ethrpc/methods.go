@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"slices"
 
 	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
@@ -36,6 +37,41 @@ func BalanceAt(account common.Address, blockNum *big.Int) CallBuilder[*big.Int]
 	}
 }
 
+// AccountProof is the account state along with its Merkle-Patricia proof, as returned
+// by eth_getProof. See VerifyAccountProof.
+type AccountProof struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageProof  `json:"storageProof"`
+}
+
+// StorageProof is the value of a storage slot along with its proof against the
+// account's StorageHash.
+type StorageProof struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetProof = eth_getProof, which returns the account and storage values of the account
+// at blockNum, along with their Merkle-Patricia proofs.
+func GetProof(account common.Address, storageKeys []common.Hash, blockNum *big.Int) CallBuilder[*AccountProof] {
+	if storageKeys == nil {
+		storageKeys = []common.Hash{}
+	}
+	return CallBuilder[*AccountProof]{
+		method: "eth_getProof",
+		params: []any{account, storageKeys, toBlockNumArg(blockNum)},
+		intoFn: func(raw json.RawMessage, ret **AccountProof, strictness StrictnessLevel) error {
+			return json.Unmarshal(raw, ret)
+		},
+	}
+}
+
 func SendTransaction(tx *types.Transaction) Call {
 	data, err := tx.MarshalBinary()
 	if err != nil {
@@ -90,6 +126,13 @@ func RawBlockByNumber(blockNum *big.Int) CallBuilder[json.RawMessage] {
 	}
 }
 
+// ClientVersion = web3_clientVersion, ie. "Geth/v1.13.5-stable/linux-amd64/go1.21.4".
+func ClientVersion() CallBuilder[string] {
+	return CallBuilder[string]{
+		method: "web3_clientVersion",
+	}
+}
+
 func PeerCount() CallBuilder[uint64] {
 	return CallBuilder[uint64]{
 		method: "net_peerCount",
@@ -172,6 +215,21 @@ func TransactionReceipt(txHash common.Hash) CallBuilder[*types.Receipt] {
 	}
 }
 
+// BlockReceipts = eth_getBlockReceipts, which returns the receipts of all of the
+// transactions in a block.
+func BlockReceipts(blockNumOrHash rpc.BlockNumberOrHash) CallBuilder[[]*types.Receipt] {
+	return CallBuilder[[]*types.Receipt]{
+		method: "eth_getBlockReceipts",
+		params: []any{blockNumOrHash},
+		intoFn: func(raw json.RawMessage, receipts *[]*types.Receipt, strictness StrictnessLevel) error {
+			if len(raw) == 0 || string(raw) == "null" {
+				return ethereum.NotFound
+			}
+			return json.Unmarshal(raw, receipts)
+		},
+	}
+}
+
 func SyncProgress() CallBuilder[*ethereum.SyncProgress] {
 	return CallBuilder[*ethereum.SyncProgress]{
 		method: "eth_syncing",
@@ -342,6 +400,65 @@ func CallContract(msg ethereum.CallMsg, blockNum *big.Int) CallBuilder[[]byte] {
 	}
 }
 
+// OverrideAccount is the state of an account to override in a call, see CallContractWithOverrides.
+type OverrideAccount struct {
+	// Nonce sets the nonce of the account, and is only applied when non-zero.
+	Nonce uint64
+
+	// Code sets the contract code, and is applied when non-nil, so an empty
+	// slice clears the code of the account.
+	Code []byte
+
+	// Balance sets the balance of the account.
+	Balance *big.Int
+
+	// State sets the complete storage of the account, and is applied when non-nil,
+	// so an empty map wipes the storage of the account.
+	State map[common.Hash]common.Hash
+
+	// StateDiff sets individual storage slots of the account.
+	StateDiff map[common.Hash]common.Hash
+}
+
+func (a OverrideAccount) MarshalJSON() ([]byte, error) {
+	type account struct {
+		Nonce     hexutil.Uint64               `json:"nonce,omitempty"`
+		Code      *hexutil.Bytes               `json:"code,omitempty"`
+		Balance   *hexutil.Big                 `json:"balance,omitempty"`
+		State     *map[common.Hash]common.Hash `json:"state,omitempty"`
+		StateDiff map[common.Hash]common.Hash  `json:"stateDiff,omitempty"`
+	}
+
+	out := account{
+		Nonce:     hexutil.Uint64(a.Nonce),
+		Balance:   (*hexutil.Big)(a.Balance),
+		StateDiff: a.StateDiff,
+	}
+	if a.Code != nil {
+		code := hexutil.Bytes(a.Code)
+		out.Code = &code
+	}
+	if a.State != nil {
+		out.State = &a.State
+	}
+	return json.Marshal(out)
+}
+
+// CallContractWithOverrides is like CallContract, except the call is executed against the
+// state of the block with the accounts overridden, ie. to simulate a call as if an account
+// had more balance or different code, using the state override set of eth_call. Without
+// overrides, this is the same as CallContract.
+func CallContractWithOverrides(msg ethereum.CallMsg, blockNum *big.Int, overrides map[common.Address]OverrideAccount) CallBuilder[[]byte] {
+	if len(overrides) == 0 {
+		return CallContract(msg, blockNum)
+	}
+	return CallBuilder[[]byte]{
+		method: "eth_call",
+		params: []any{toCallArg(msg), toBlockNumArg(blockNum), overrides},
+		intoFn: hexIntoBytes,
+	}
+}
+
 func CallContractAtHash(msg ethereum.CallMsg, blockHash common.Hash) CallBuilder[[]byte] {
 	return CallBuilder[[]byte]{
 		method: "eth_call",
@@ -373,13 +490,20 @@ func SuggestGasTipCap() CallBuilder[*big.Int] {
 }
 
 type feeHistoryResult struct {
-	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	OldestBlock  json.RawMessage  `json:"oldestBlock"`
 	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
 	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
 	GasUsedRatio []float64        `json:"gasUsedRatio"`
 }
 
+// FeeHistory returns the base fees, gas used ratios and priority fee rewards at the
+// rewardPercentiles of up to blockCount blocks ending at lastBlock, or the latest block
+// if nil. See Provider#FeeHistory for the shape of the result.
 func FeeHistory(blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) CallBuilder[*ethereum.FeeHistory] {
+	if rewardPercentiles == nil {
+		// some nodes reject a null percentiles param
+		rewardPercentiles = []float64{}
+	}
 	return CallBuilder[*ethereum.FeeHistory]{
 		method: "eth_feeHistory",
 		params: []any{hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles},
@@ -389,8 +513,21 @@ func FeeHistory(blockCount uint64, lastBlock *big.Int, rewardPercentiles []float
 				return err
 			}
 
+			oldestBlock, err := unmarshalFeeHistoryBlockNum(res.OldestBlock)
+			if err != nil {
+				return err
+			}
+
+			// the rewards of a block may be null or have null entries, ie. for empty blocks
+			// on some nodes, in which case the block is left without rewards rather than
+			// with zero rewards, which would skew the fees estimated from them. The entries
+			// of the other blocks stay aligned to the blocks and percentiles.
 			reward := make([][]*big.Int, len(res.Reward))
 			for i, r := range res.Reward {
+				if slices.Contains(r, nil) {
+					reward[i] = []*big.Int{}
+					continue
+				}
 				reward[i] = make([]*big.Int, len(r))
 				for j, r := range r {
 					reward[i][j] = (*big.Int)(r)
@@ -401,7 +538,7 @@ func FeeHistory(blockCount uint64, lastBlock *big.Int, rewardPercentiles []float
 				baseFee[i] = (*big.Int)(b)
 			}
 			*ret = &ethereum.FeeHistory{
-				OldestBlock:  (*big.Int)(res.OldestBlock),
+				OldestBlock:  oldestBlock,
 				Reward:       reward,
 				BaseFee:      baseFee,
 				GasUsedRatio: res.GasUsedRatio,
@@ -411,6 +548,26 @@ func FeeHistory(blockCount uint64, lastBlock *big.Int, rewardPercentiles []float
 	}
 }
 
+// unmarshalFeeHistoryBlockNum unmarshals the oldestBlock of eth_feeHistory, which is a hex
+// string, or a plain number on some nodes.
+func unmarshalFeeHistoryBlockNum(raw json.RawMessage) (*big.Int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, fmt.Errorf("ethrpc: eth_feeHistory response is missing oldestBlock")
+	}
+	if raw[0] == '"' {
+		var num hexutil.Big
+		if err := json.Unmarshal(raw, &num); err != nil {
+			return nil, fmt.Errorf("ethrpc: invalid eth_feeHistory oldestBlock: %w", err)
+		}
+		return (*big.Int)(&num), nil
+	}
+	num, ok := new(big.Int).SetString(string(raw), 10)
+	if !ok {
+		return nil, fmt.Errorf("ethrpc: invalid eth_feeHistory oldestBlock %s", raw)
+	}
+	return num, nil
+}
+
 func EstimateGas(msg ethereum.CallMsg) CallBuilder[uint64] {
 	return CallBuilder[uint64]{
 		method: "eth_estimateGas",
@@ -419,6 +576,24 @@ func EstimateGas(msg ethereum.CallMsg) CallBuilder[uint64] {
 	}
 }
 
+// CreateAccessList returns the EIP-2930 access list of the accounts and storage keys
+// accessed by the call, with eth_createAccessList, along with the gas used by the call
+// with the access list.
+func CreateAccessList(msg ethereum.CallMsg, blockNum *big.Int) CallBuilder[ethcoder.AccessListResult] {
+	return CallBuilder[ethcoder.AccessListResult]{
+		method: "eth_createAccessList",
+		params: []any{toCallArg(msg), toBlockNumArg(blockNum)},
+		intoFn: func(message json.RawMessage, ret *ethcoder.AccessListResult, strictness StrictnessLevel) error {
+			result, err := ethcoder.DecodeAccessListResult(message)
+			if err != nil {
+				return err
+			}
+			*ret = result
+			return nil
+		},
+	}
+}
+
 type DebugTracer string
 
 const (
@@ -469,3 +644,307 @@ func DebugTraceTransaction(txHash common.Hash) CallBuilder[*CallDebugTrace] {
 		params: []any{txHash, debugTracerOptions{Name: string(DebugTracerCallTracer)}},
 	}
 }
+
+// DebugTracerConfig configures the tracer of debug_traceCall and debug_traceTransaction,
+// ie. DebugTracerConfig{Tracer: DebugTracerPreStateTracer, TracerConfig: map[string]any{"diffMode": true}}.
+type DebugTracerConfig struct {
+	// Tracer is the name of a built-in tracer, ie. DebugTracerCallTracer, or the code of
+	// a custom JS tracer. The node's default struct logger is used if empty.
+	Tracer DebugTracer `json:"tracer,omitempty"`
+
+	// TracerConfig is passed to the tracer, ie. {"onlyTopCall": true} for the callTracer.
+	TracerConfig any `json:"tracerConfig,omitempty"`
+
+	// Timeout overrides the node's default timeout of the trace, ie. "10s".
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// DebugTrace is the result of a trace with a DebugTracerConfig. Its Raw result is decoded
+// with the method of its tracer, ie. CallTrace for the callTracer, or with Unmarshal for
+// custom JS tracers.
+type DebugTrace struct {
+	Tracer DebugTracer
+	Raw    json.RawMessage
+}
+
+// CallTrace decodes the result of the callTracer.
+func (t *DebugTrace) CallTrace() (*CallDebugTrace, error) {
+	var trace *CallDebugTrace
+	if err := t.Unmarshal(&trace); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// PrestateTrace decodes the result of the prestateTracer, in its default mode.
+func (t *DebugTrace) PrestateTrace() (PrestateDebugTrace, error) {
+	var trace PrestateDebugTrace
+	if err := t.Unmarshal(&trace); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// PrestateDiffTrace decodes the result of the prestateTracer with {"diffMode": true}.
+func (t *DebugTrace) PrestateDiffTrace() (*PrestateDiffDebugTrace, error) {
+	var trace *PrestateDiffDebugTrace
+	if err := t.Unmarshal(&trace); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// Unmarshal decodes the result of the trace into v, ie. for custom JS tracers.
+func (t *DebugTrace) Unmarshal(v any) error {
+	if len(t.Raw) == 0 {
+		return ErrEmptyResponse
+	}
+	if err := json.Unmarshal(t.Raw, v); err != nil {
+		return fmt.Errorf("ethrpc: failed to decode %s trace: %w", t.tracerName(), err)
+	}
+	return nil
+}
+
+func (t *DebugTrace) tracerName() string {
+	switch t.Tracer {
+	case "":
+		return "default"
+	case DebugTracerCallTracer, DebugTracerPreStateTracer:
+		return string(t.Tracer)
+	default:
+		return "custom"
+	}
+}
+
+// PrestateDebugTrace is the result of the prestateTracer, the state of the accounts
+// accessed by the call before it was executed.
+type PrestateDebugTrace map[common.Address]*PrestateAccount
+
+// PrestateDiffDebugTrace is the result of the prestateTracer in diff mode, the state of
+// the accounts modified by the call, before and after it was executed.
+type PrestateDiffDebugTrace struct {
+	Pre  PrestateDebugTrace `json:"pre"`
+	Post PrestateDebugTrace `json:"post"`
+}
+
+type PrestateAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// DebugTraceCall = debug_traceCall, which traces the call executed on top of the state
+// of the block with the tracer of the config.
+func DebugTraceCall(msg ethereum.CallMsg, blockNum *big.Int, config DebugTracerConfig) CallBuilder[*DebugTrace] {
+	return CallBuilder[*DebugTrace]{
+		method: "debug_traceCall",
+		params: []any{toCallArg(msg), toBlockNumArg(blockNum), config},
+		intoFn: intoDebugTrace(config.Tracer),
+	}
+}
+
+// DebugTraceTransactionWithTracer = debug_traceTransaction with the tracer of the config,
+// while DebugTraceTransaction always uses the callTracer.
+func DebugTraceTransactionWithTracer(txHash common.Hash, config DebugTracerConfig) CallBuilder[*DebugTrace] {
+	return CallBuilder[*DebugTrace]{
+		method: "debug_traceTransaction",
+		params: []any{txHash, config},
+		intoFn: intoDebugTrace(config.Tracer),
+	}
+}
+
+func intoDebugTrace(tracer DebugTracer) IntoFn[*DebugTrace] {
+	return func(raw json.RawMessage, ret **DebugTrace, strictness StrictnessLevel) error {
+		if len(raw) == 0 || string(raw) == "null" {
+			return ErrEmptyResponse
+		}
+		*ret = &DebugTrace{Tracer: tracer, Raw: raw}
+		return nil
+	}
+}
+
+// TransactionTrace is a single call of a transaction as returned by trace_transaction,
+// where the calls of a transaction are returned as a flat list in the order they were made.
+type TransactionTrace struct {
+	Type         string                  `json:"type"`
+	Action       TransactionTraceAction  `json:"action"`
+	Result       *TransactionTraceResult `json:"result"`
+	Error        string                  `json:"error"`
+	Subtraces    int                     `json:"subtraces"`
+	TraceAddress []int                   `json:"traceAddress"`
+}
+
+type TransactionTraceAction struct {
+	CallType string         `json:"callType"`
+	From     common.Address `json:"from"`
+	To       common.Address `json:"to"`
+	Value    *hexutil.Big   `json:"value"`
+	Gas      *hexutil.Big   `json:"gas"`
+	Input    hexutil.Bytes  `json:"input"`
+}
+
+type TransactionTraceResult struct {
+	GasUsed *hexutil.Big  `json:"gasUsed"`
+	Output  hexutil.Bytes `json:"output"`
+}
+
+// TraceTransaction = trace_transaction, the parity style tracing of a transaction
+// which is supported by erigon, nethermind and reth nodes.
+func TraceTransaction(txHash common.Hash) CallBuilder[[]*TransactionTrace] {
+	return CallBuilder[[]*TransactionTrace]{
+		method: "trace_transaction",
+		params: []any{txHash},
+	}
+}
+
+// DebugGetRawReceipts = debug_getRawReceipts, which returns the consensus encoded
+// receipts of all of the transactions in a block.
+//
+// NOTE: the consensus encoding only includes the status, cumulative gas used, bloom
+// and logs of a receipt. Derived fields such as the txn hash, block hash, gas used and
+// log positions are not set.
+func DebugGetRawReceipts(blockNum *big.Int) CallBuilder[[]*types.Receipt] {
+	return CallBuilder[[]*types.Receipt]{
+		method: "debug_getRawReceipts",
+		params: []any{toBlockNumArg(blockNum)},
+		intoFn: intoRawReceipts,
+	}
+}
+
+func intoRawReceipts(raw json.RawMessage, ret *[]*types.Receipt, strictness StrictnessLevel) error {
+	var encoded []hexutil.Bytes
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return err
+	}
+
+	receipts := make([]*types.Receipt, 0, len(encoded))
+	for i, data := range encoded {
+		receipt := &types.Receipt{}
+		if err := receipt.UnmarshalBinary(data); err != nil {
+			return fmt.Errorf("failed to decode receipt %d: %w", i, err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	*ret = receipts
+	return nil
+}
+
+// OtsTransactionsPage is a page of the transactions of an address, along with their receipts,
+// as returned by the Otterscan ots_searchTransactionsBefore and ots_searchTransactionsAfter
+// methods. Transactions are ordered from newest to oldest.
+type OtsTransactionsPage struct {
+	Transactions []*types.Transaction
+	Receipts     []*OtsReceipt
+
+	// FirstPage is set when the page has the newest transactions of the address
+	FirstPage bool
+
+	// LastPage is set when the page has the oldest transactions of the address
+	LastPage bool
+}
+
+// OtsReceipt is a transaction receipt along with the timestamp of its block.
+type OtsReceipt struct {
+	*types.Receipt
+	Timestamp uint64
+}
+
+// OtsGetApiLevel = ots_getApiLevel, which returns the level of the Otterscan api
+// supported by the node.
+func OtsGetApiLevel() CallBuilder[uint64] {
+	return CallBuilder[uint64]{
+		method: "ots_getApiLevel",
+		intoFn: func(raw json.RawMessage, ret *uint64, strictness StrictnessLevel) error {
+			return json.Unmarshal(raw, ret)
+		},
+	}
+}
+
+// OtsSearchTransactionsBefore = ots_searchTransactionsBefore, which returns a page of up to
+// pageSize transactions of the address from before blockNum, where a blockNum of 0 starts
+// from the latest block. Pass the block number of the last transaction of a page to get the
+// next page of older transactions.
+//
+// NOTE: the page may hold more than pageSize transactions, as the transactions of a block
+// are never split across pages.
+func OtsSearchTransactionsBefore(address common.Address, blockNum uint64, pageSize int) CallBuilder[*OtsTransactionsPage] {
+	return CallBuilder[*OtsTransactionsPage]{
+		method: "ots_searchTransactionsBefore",
+		params: []any{address, blockNum, pageSize},
+		intoFn: intoOtsTransactionsPage,
+	}
+}
+
+// OtsSearchTransactionsAfter = ots_searchTransactionsAfter, which returns a page of up to
+// pageSize transactions of the address from after blockNum, where a blockNum of 0 starts
+// from the genesis block. Pass the block number of the first transaction of a page to get
+// the next page of newer transactions.
+//
+// NOTE: the page may hold more than pageSize transactions, as the transactions of a block
+// are never split across pages.
+func OtsSearchTransactionsAfter(address common.Address, blockNum uint64, pageSize int) CallBuilder[*OtsTransactionsPage] {
+	return CallBuilder[*OtsTransactionsPage]{
+		method: "ots_searchTransactionsAfter",
+		params: []any{address, blockNum, pageSize},
+		intoFn: intoOtsTransactionsPage,
+	}
+}
+
+func intoOtsTransactionsPage(raw json.RawMessage, ret **OtsTransactionsPage, strictness StrictnessLevel) error {
+	var body *struct {
+		Txs       []json.RawMessage `json:"txs"`
+		Receipts  []json.RawMessage `json:"receipts"`
+		FirstPage bool              `json:"firstPage"`
+		LastPage  bool              `json:"lastPage"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return err
+	}
+	if body == nil {
+		return ethereum.NotFound
+	}
+
+	page := &OtsTransactionsPage{
+		Transactions: make([]*types.Transaction, len(body.Txs)),
+		Receipts:     make([]*OtsReceipt, len(body.Receipts)),
+		FirstPage:    body.FirstPage,
+		LastPage:     body.LastPage,
+	}
+	for i, txRaw := range body.Txs {
+		if err := IntoTransaction(txRaw, &page.Transactions[i], strictness); err != nil {
+			return fmt.Errorf("failed to decode transaction %d: %w", i, err)
+		}
+	}
+	for i, receiptRaw := range body.Receipts {
+		var receipt *types.Receipt
+		if err := json.Unmarshal(receiptRaw, &receipt); err != nil {
+			return fmt.Errorf("failed to decode receipt %d: %w", i, err)
+		}
+
+		// the timestamp is a number, or a hex string on newer versions of the api
+		var extra struct {
+			Timestamp json.RawMessage `json:"timestamp"`
+		}
+		if err := json.Unmarshal(receiptRaw, &extra); err != nil {
+			return fmt.Errorf("failed to decode receipt %d: %w", i, err)
+		}
+		var timestamp uint64
+		if len(extra.Timestamp) > 0 && extra.Timestamp[0] == '"' {
+			var ts hexutil.Uint64
+			if err := json.Unmarshal(extra.Timestamp, &ts); err != nil {
+				return fmt.Errorf("failed to decode receipt %d timestamp: %w", i, err)
+			}
+			timestamp = uint64(ts)
+		} else if len(extra.Timestamp) > 0 {
+			if err := json.Unmarshal(extra.Timestamp, &timestamp); err != nil {
+				return fmt.Errorf("failed to decode receipt %d timestamp: %w", i, err)
+			}
+		}
+
+		page.Receipts[i] = &OtsReceipt{Receipt: receipt, Timestamp: timestamp}
+	}
+
+	*ret = page
+	return nil
+}
@@ -0,0 +1,147 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// ContractCall is a contract read defined with an ethcoder.ContractCallDef, so a batch of
+// reads can be expressed declaratively, ie.
+//
+//	multicall.ContractCall{
+//		To:          token,
+//		Def:         ethcoder.ContractCallDef{ABI: "balanceOf(address)", Args: []any{account.Hex()}},
+//		ReturnTypes: []string{"uint256"},
+//	}
+type ContractCall struct {
+	To  common.Address
+	Def ethcoder.ContractCallDef
+
+	// ReturnTypes are the abi types of the return values of the call, ie. []string{"uint256"}.
+	// Optional when Def.ABI is a JSON abi, in which case the outputs of its method are used.
+	ReturnTypes []string
+}
+
+// ContractCallResult is the result of a ContractCall, with its return values decoded. A
+// failed call, ie. one which reverted, has Success unset and the revert data as ReturnData.
+type ContractCallResult struct {
+	Success    bool
+	ReturnData []byte
+	Values     []interface{}
+}
+
+// AggregateContractCalls executes the contract calls at the block number, or the latest
+// block if nil, and returns their decoded results in the order of the calls, see Aggregate.
+func (c *Client) AggregateContractCalls(ctx context.Context, calls []ContractCall, blockNum *big.Int) ([]ContractCallResult, error) {
+	encoded, err := EncodeContractCalls(calls)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := c.Aggregate(ctx, encoded, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeContractCallResults(calls, results)
+}
+
+// EncodeContractCalls encodes the calldata of the contract calls, with
+// ethcoder.EncodeContractCall, as calls ready to be passed to Aggregate.
+func EncodeContractCalls(calls []ContractCall) ([]Call, error) {
+	out := make([]Call, 0, len(calls))
+	for i, call := range calls {
+		callDataHex, err := ethcoder.EncodeContractCall(call.Def)
+		if err != nil {
+			return nil, fmt.Errorf("multicall: failed to encode call %d: %w", i, err)
+		}
+		callData, err := ethcoder.HexDecode(callDataHex)
+		if err != nil {
+			return nil, fmt.Errorf("multicall: failed to encode call %d: %w", i, err)
+		}
+		out = append(out, Call{To: call.To, CallData: callData})
+	}
+	return out, nil
+}
+
+// DecodeContractCallResults decodes the return values of the results of Aggregate with
+// the return types of their contract calls, see DecodeResults. The values of failed calls
+// are left unset.
+func DecodeContractCallResults(calls []ContractCall, results []Result) ([]ContractCallResult, error) {
+	if len(calls) != len(results) {
+		return nil, fmt.Errorf("multicall: got %d results for %d calls", len(results), len(calls))
+	}
+
+	returnTypes := make([][]string, len(calls))
+	for i, call := range calls {
+		if !results[i].Success {
+			continue
+		}
+		types, err := contractCallReturnTypes(call)
+		if err != nil {
+			return nil, fmt.Errorf("multicall: failed to decode result %d: %w", i, err)
+		}
+		returnTypes[i] = types
+	}
+	return DecodeResults(results, returnTypes)
+}
+
+// DecodeResults decodes the return values of the results of Aggregate with the abi return
+// types of their calls, ie. []string{"uint256"}, where returnTypes[i] are the return types
+// of results[i]. The decoded results are in the same order as the results. Results with
+// no return types are not decoded, and the values of failed calls are left unset.
+func DecodeResults(results []Result, returnTypes [][]string) ([]ContractCallResult, error) {
+	if len(returnTypes) != len(results) {
+		return nil, fmt.Errorf("multicall: got %d return types for %d results", len(returnTypes), len(results))
+	}
+
+	out := make([]ContractCallResult, 0, len(results))
+	for i, result := range results {
+		decoded := ContractCallResult{Success: result.Success, ReturnData: result.ReturnData}
+		if result.Success && len(returnTypes[i]) > 0 {
+			values, err := ethcoder.ABIUnpackArguments(returnTypes[i], result.ReturnData)
+			if err != nil {
+				return nil, fmt.Errorf("multicall: failed to decode result %d: %w", i, err)
+			}
+			decoded.Values = values
+		}
+		out = append(out, decoded)
+	}
+	return out, nil
+}
+
+// contractCallReturnTypes returns the ReturnTypes of the call, or else the output types
+// of the method of its json abi.
+func contractCallReturnTypes(call ContractCall) ([]string, error) {
+	if len(call.ReturnTypes) > 0 {
+		return call.ReturnTypes, nil
+	}
+
+	contractABI := ethcoder.NewABI()
+	names, err := contractABI.AddABIBySigOrJSON(call.Def.ABI, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no method names added")
+	}
+	methodName := names[0]
+	if call.Def.Func != "" {
+		methodName = call.Def.Func
+	}
+
+	method, ok := contractABI.GetMethodABI(methodName)
+	if !ok {
+		return nil, fmt.Errorf("method %s not found", methodName)
+	}
+
+	returnTypes := make([]string, len(method.Outputs))
+	for i, output := range method.Outputs {
+		returnTypes[i] = output.Type.String()
+	}
+	return returnTypes, nil
+}
@@ -0,0 +1,259 @@
+// Package multicall aggregates many contract reads into a few eth_call requests through
+// the Multicall3 contract, see https://github.com/mds1/multicall.
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Multicall3Address is the canonical address of the Multicall3 contract, which is deployed
+// at the same address on most chains.
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// multicall3ABI is the abi of the Multicall3 methods used by the Client.
+const multicall3ABI = `[
+	{"type":"function","name":"aggregate3","stateMutability":"payable","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]},
+	{"type":"function","name":"getEthBalance","stateMutability":"view","inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"balance","type":"uint256"}]}
+]`
+
+var multicall3 = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		panic(fmt.Errorf("multicall: invalid multicall3 abi: %w", err))
+	}
+	return parsed
+}()
+
+// Call is a contract read to aggregate.
+type Call struct {
+	To       common.Address
+	CallData []byte
+}
+
+// Result is the result of a Call. A failed call, ie. one which reverted, has Success
+// unset and the revert data as ReturnData.
+type Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+type Options struct {
+	// Addresses overrides the address of the Multicall3 contract by chain id, for chains
+	// where it isn't deployed at the canonical Multicall3Address.
+	Addresses map[uint64]common.Address
+
+	// MaxCallDataSize is the max size in bytes of the calldata of a single multicall,
+	// above which the calls are split into multiple multicalls. Defaults to 64KB.
+	MaxCallDataSize int
+
+	// MaxCalls is the max number of calls of a single multicall, above which the calls
+	// are split into multiple multicalls, to keep each multicall within the gas cap of
+	// eth_call of the node. Defaults to 500.
+	MaxCalls int
+}
+
+var DefaultOptions = Options{
+	MaxCallDataSize: 64 * 1024,
+	MaxCalls:        500,
+}
+
+// Client aggregates contract reads with aggregate3 of the Multicall3 contract.
+type Client struct {
+	provider *ethrpc.Provider
+	options  Options
+}
+
+func NewClient(provider *ethrpc.Provider, options ...Options) (*Client, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("multicall: provider is required")
+	}
+
+	opts := DefaultOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.MaxCallDataSize <= 0 {
+		opts.MaxCallDataSize = DefaultOptions.MaxCallDataSize
+	}
+	if opts.MaxCalls <= 0 {
+		opts.MaxCalls = DefaultOptions.MaxCalls
+	}
+
+	return &Client{
+		provider: provider,
+		options:  opts,
+	}, nil
+}
+
+// Address returns the address of the Multicall3 contract on the chain of the provider.
+func (c *Client) Address(ctx context.Context) (common.Address, error) {
+	if len(c.options.Addresses) == 0 {
+		return Multicall3Address, nil
+	}
+
+	chainID, err := c.provider.ChainID(ctx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("multicall: failed to get chain id: %w", err)
+	}
+	if address, ok := c.options.Addresses[chainID.Uint64()]; ok {
+		return address, nil
+	}
+	return Multicall3Address, nil
+}
+
+// Aggregate executes the calls at the block number, or the latest block if nil, and
+// returns their results in the order of the calls. The calls are allowed to fail, in
+// which case their Result is unsuccessful.
+//
+// The calls are split into multiple multicalls according to the MaxCallDataSize and
+// MaxCalls options, which are sent in a single JSON-RPC batch.
+func (c *Client) Aggregate(ctx context.Context, calls []Call, blockNum *big.Int) ([]Result, error) {
+	if len(calls) == 0 {
+		return []Result{}, nil
+	}
+
+	address, err := c.Address(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := c.split(calls)
+
+	rpcCalls := make([]ethrpc.Call, 0, len(batches))
+	returnData := make([][]byte, len(batches))
+	for i, batch := range batches {
+		callData, err := encodeAggregate3(batch)
+		if err != nil {
+			return nil, err
+		}
+		msg := ethereum.CallMsg{To: &address, Data: callData}
+		rpcCalls = append(rpcCalls, ethrpc.CallContract(msg, blockNum).Into(&returnData[i]))
+	}
+
+	if _, err := c.provider.Do(ctx, rpcCalls...); err != nil {
+		return nil, fmt.Errorf("multicall: failed to call aggregate3: %w", err)
+	}
+
+	results := make([]Result, 0, len(calls))
+	for i, batch := range batches {
+		batchResults, err := decodeAggregate3(returnData[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(batchResults) != len(batch) {
+			return nil, fmt.Errorf("multicall: aggregate3 returned %d results for %d calls", len(batchResults), len(batch))
+		}
+		results = append(results, batchResults...)
+	}
+	return results, nil
+}
+
+// ETHBalances returns the ETH balances of the accounts at the block number, or the latest
+// block if nil, in the order of the accounts.
+func (c *Client) ETHBalances(ctx context.Context, accounts []common.Address, blockNum *big.Int) ([]*big.Int, error) {
+	address, err := c.Address(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]Call, 0, len(accounts))
+	for _, account := range accounts {
+		callData, err := multicall3.Pack("getEthBalance", account)
+		if err != nil {
+			return nil, fmt.Errorf("multicall: failed to encode getEthBalance: %w", err)
+		}
+		calls = append(calls, Call{To: address, CallData: callData})
+	}
+
+	return c.balances(ctx, calls, accounts, blockNum)
+}
+
+// ERC20Balances returns the balances of the token of the accounts at the block number, or
+// the latest block if nil, in the order of the accounts.
+func (c *Client) ERC20Balances(ctx context.Context, token common.Address, accounts []common.Address, blockNum *big.Int) ([]*big.Int, error) {
+	calls := make([]Call, 0, len(accounts))
+	for _, account := range accounts {
+		callData, err := ethcoder.ABIEncodeMethodCalldata("balanceOf(address)", []interface{}{account})
+		if err != nil {
+			return nil, fmt.Errorf("multicall: failed to encode balanceOf: %w", err)
+		}
+		calls = append(calls, Call{To: token, CallData: callData})
+	}
+
+	return c.balances(ctx, calls, accounts, blockNum)
+}
+
+func (c *Client) balances(ctx context.Context, calls []Call, accounts []common.Address, blockNum *big.Int) ([]*big.Int, error) {
+	results, err := c.Aggregate(ctx, calls, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]*big.Int, 0, len(results))
+	for i, result := range results {
+		if !result.Success || len(result.ReturnData) < 32 {
+			return nil, fmt.Errorf("multicall: failed to get balance of %s from %s", accounts[i].Hex(), calls[i].To.Hex())
+		}
+		balances = append(balances, new(big.Int).SetBytes(result.ReturnData[:32]))
+	}
+	return balances, nil
+}
+
+// split splits the calls into batches within the MaxCallDataSize and MaxCalls options,
+// where a batch always has at least one call, even if it's above MaxCallDataSize.
+func (c *Client) split(calls []Call) [][]Call {
+	var batches [][]Call
+
+	start, size := 0, 0
+	for i, call := range calls {
+		// each call is encoded as its offset, target, allowFailure, calldata offset and
+		// length, and its calldata padded to 32 bytes
+		callSize := 5*32 + (len(call.CallData)+31)/32*32
+
+		if i > start && (size+callSize > c.options.MaxCallDataSize || i-start >= c.options.MaxCalls) {
+			batches = append(batches, calls[start:i])
+			start, size = i, 0
+		}
+		size += callSize
+	}
+	return append(batches, calls[start:])
+}
+
+type aggregate3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+func encodeAggregate3(calls []Call) ([]byte, error) {
+	args := make([]aggregate3Call, 0, len(calls))
+	for _, call := range calls {
+		args = append(args, aggregate3Call{Target: call.To, AllowFailure: true, CallData: call.CallData})
+	}
+	callData, err := multicall3.Pack("aggregate3", args)
+	if err != nil {
+		return nil, fmt.Errorf("multicall: failed to encode aggregate3: %w", err)
+	}
+	return callData, nil
+}
+
+func decodeAggregate3(data []byte) ([]Result, error) {
+	values, err := multicall3.Unpack("aggregate3", data)
+	if err != nil {
+		return nil, fmt.Errorf("multicall: failed to decode aggregate3 result: %w", err)
+	}
+	var results []Result
+	if err := multicall3.Methods["aggregate3"].Outputs.Copy(&results, values); err != nil {
+		return nil, fmt.Errorf("multicall: failed to decode aggregate3 result: %w", err)
+	}
+	return results, nil
+}
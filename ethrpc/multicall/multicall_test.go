@@ -0,0 +1,282 @@
+package multicall_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethrpc/multicall"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+const aggregate3ABI = `[{"type":"function","name":"aggregate3","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`
+
+// newMockMulticall returns a provider to a mock node, which executes the aggregate3 calls
+// to the multicall address with callFn, and records the number of calls of each multicall.
+func newMockMulticall(t *testing.T, multicallAddress common.Address, callFn func(to common.Address, data []byte) (bool, []byte)) (*ethrpc.Provider, *[]int) {
+	parsed, err := abi.JSON(strings.NewReader(aggregate3ABI))
+	require.NoError(t, err)
+	method := parsed.Methods["aggregate3"]
+
+	var multicalls []int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		isBatch := body[0] == '['
+		if !isBatch {
+			body = append(append(json.RawMessage{'['}, body...), ']')
+		}
+
+		var reqs []struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.Unmarshal(body, &reqs))
+
+		responses := []string{}
+		for _, req := range reqs {
+			if req.Method == "eth_chainId" {
+				responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"0x539"}`, req.ID))
+				continue
+			}
+
+			var msg struct {
+				To    common.Address `json:"to"`
+				Input hexutil.Bytes  `json:"input"`
+				Data  hexutil.Bytes  `json:"data"`
+			}
+			require.Equal(t, "eth_call", req.Method)
+			require.NoError(t, json.Unmarshal(req.Params[0], &msg))
+			require.Equal(t, multicallAddress, msg.To)
+
+			input := msg.Input
+			if len(input) == 0 {
+				input = msg.Data
+			}
+			args, err := method.Inputs.Unpack(input[4:])
+			require.NoError(t, err)
+
+			var calls []struct {
+				Target       common.Address
+				AllowFailure bool
+				CallData     []byte
+			}
+			require.NoError(t, method.Inputs.Copy(&calls, args))
+			multicalls = append(multicalls, len(calls))
+
+			type result struct {
+				Success    bool
+				ReturnData []byte
+			}
+			results := []result{}
+			for _, call := range calls {
+				require.True(t, call.AllowFailure)
+				success, returnData := callFn(call.Target, call.CallData)
+				results = append(results, result{success, returnData})
+			}
+			output, err := method.Outputs.Pack(results)
+			require.NoError(t, err)
+
+			responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, hexutil.Encode(output)))
+		}
+		if !isBatch {
+			fmt.Fprint(w, responses[0])
+			return
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+	}))
+	t.Cleanup(srv.Close)
+
+	provider, err := ethrpc.NewProvider(srv.URL)
+	require.NoError(t, err)
+	return provider, &multicalls
+}
+
+func TestAggregate(t *testing.T) {
+	token := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	provider, multicalls := newMockMulticall(t, multicall.Multicall3Address, func(to common.Address, data []byte) (bool, []byte) {
+		if to != token {
+			return false, []byte{0xde, 0xad}
+		}
+		return true, append([]byte{}, data[4:]...)
+	})
+
+	client, err := multicall.NewClient(provider, multicall.Options{MaxCalls: 2})
+	require.NoError(t, err)
+
+	calls := []multicall.Call{
+		{To: token, CallData: []byte{1, 2, 3, 4, 5}},
+		{To: common.Address{}, CallData: []byte{1, 2, 3, 4}},
+		{To: token, CallData: []byte{1, 2, 3, 4, 6}},
+	}
+	results, err := client.Aggregate(context.Background(), calls, nil)
+	require.NoError(t, err)
+	require.Equal(t, []multicall.Result{
+		{Success: true, ReturnData: []byte{5}},
+		{Success: false, ReturnData: []byte{0xde, 0xad}},
+		{Success: true, ReturnData: []byte{6}},
+	}, results)
+
+	// calls are split into multicalls of at most 2 calls
+	require.Equal(t, []int{2, 1}, *multicalls)
+}
+
+func TestAggregateSplitByCallDataSize(t *testing.T) {
+	provider, multicalls := newMockMulticall(t, multicall.Multicall3Address, func(to common.Address, data []byte) (bool, []byte) {
+		return true, nil
+	})
+
+	client, err := multicall.NewClient(provider, multicall.Options{MaxCallDataSize: 1000})
+	require.NoError(t, err)
+
+	calls := []multicall.Call{
+		{CallData: make([]byte, 500)},
+		{CallData: make([]byte, 100)},
+		{CallData: make([]byte, 100)},
+		{CallData: make([]byte, 2000)},
+	}
+	results, err := client.Aggregate(context.Background(), calls, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	// a call above the max calldata size is sent on its own
+	require.Equal(t, []int{2, 1, 1}, *multicalls)
+}
+
+func TestERC20Balances(t *testing.T) {
+	token := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	multicallAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	accounts := []common.Address{
+		common.HexToAddress("0x3333333333333333333333333333333333333333"),
+		common.HexToAddress("0x4444444444444444444444444444444444444444"),
+	}
+
+	provider, _ := newMockMulticall(t, multicallAddress, func(to common.Address, data []byte) (bool, []byte) {
+		require.Equal(t, token, to)
+		values, err := ethcoder.ABIUnpackArguments([]string{"address"}, data[4:])
+		require.NoError(t, err)
+		balance := new(big.Int).SetBytes(values[0].(common.Address).Bytes()[:1])
+		return true, common.LeftPadBytes(balance.Bytes(), 32)
+	})
+
+	// the multicall address is overridden for the chain 1337 of the mock node
+	client, err := multicall.NewClient(provider, multicall.Options{
+		Addresses: map[uint64]common.Address{1337: multicallAddress},
+	})
+	require.NoError(t, err)
+
+	balances, err := client.ERC20Balances(context.Background(), token, accounts, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*big.Int{big.NewInt(0x33), big.NewInt(0x44)}, balances)
+}
+
+func TestAggregateContractCalls(t *testing.T) {
+	token := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	account := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	provider, _ := newMockMulticall(t, multicall.Multicall3Address, func(to common.Address, data []byte) (bool, []byte) {
+		switch {
+		case to != token:
+			return false, []byte{0xde, 0xad}
+		case ethcoder.HexEncode(data[:4]) == ethcoder.HexEncode(ethcoder.Keccak256([]byte("balanceOf(address)"))[:4]):
+			return true, common.LeftPadBytes(data[4+31:], 32)
+		default:
+			output, err := ethcoder.ABIPackArguments([]string{"string", "uint8"}, []interface{}{"TOKEN", uint8(18)})
+			require.NoError(t, err)
+			return true, output
+		}
+	})
+
+	client, err := multicall.NewClient(provider)
+	require.NoError(t, err)
+
+	calls := []multicall.ContractCall{
+		{
+			To:          token,
+			Def:         ethcoder.ContractCallDef{ABI: "balanceOf(address)", Args: []any{account.Hex()}},
+			ReturnTypes: []string{"uint256"},
+		},
+		{
+			// the return types are the outputs of the json abi method
+			To: token,
+			Def: ethcoder.ContractCallDef{
+				ABI:  `[{"type":"function","name":"info","inputs":[],"outputs":[{"name":"symbol","type":"string"},{"name":"decimals","type":"uint8"}]},{"type":"function","name":"other","inputs":[],"outputs":[]}]`,
+				Func: "info",
+			},
+		},
+		{
+			To:          common.Address{},
+			Def:         ethcoder.ContractCallDef{ABI: "balanceOf(address)", Args: []any{account.Hex()}},
+			ReturnTypes: []string{"uint256"},
+		},
+	}
+	results, err := client.AggregateContractCalls(context.Background(), calls, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	require.True(t, results[0].Success)
+	require.Equal(t, []interface{}{big.NewInt(0x33)}, results[0].Values)
+
+	require.True(t, results[1].Success)
+	require.Equal(t, []interface{}{"TOKEN", uint8(18)}, results[1].Values)
+
+	require.False(t, results[2].Success)
+	require.Nil(t, results[2].Values)
+	require.Equal(t, []byte{0xde, 0xad}, results[2].ReturnData)
+}
+
+func TestDecodeResults(t *testing.T) {
+	balance, err := ethcoder.ABIPackArguments([]string{"uint256"}, []interface{}{big.NewInt(0x33)})
+	require.NoError(t, err)
+	info, err := ethcoder.ABIPackArguments([]string{"string", "uint8"}, []interface{}{"TOKEN", uint8(18)})
+	require.NoError(t, err)
+
+	results := []multicall.Result{
+		{Success: true, ReturnData: balance},
+		{Success: false, ReturnData: []byte{0xde, 0xad}},
+		{Success: true, ReturnData: info},
+		{Success: true, ReturnData: []byte{0x01}},
+	}
+	decoded, err := multicall.DecodeResults(results, [][]string{{"uint256"}, {"uint256"}, {"string", "uint8"}, nil})
+	require.NoError(t, err)
+	require.Len(t, decoded, 4)
+
+	require.True(t, decoded[0].Success)
+	require.Equal(t, []interface{}{big.NewInt(0x33)}, decoded[0].Values)
+
+	require.False(t, decoded[1].Success)
+	require.Nil(t, decoded[1].Values)
+	require.Equal(t, []byte{0xde, 0xad}, decoded[1].ReturnData)
+
+	require.True(t, decoded[2].Success)
+	require.Equal(t, []interface{}{"TOKEN", uint8(18)}, decoded[2].Values)
+
+	// no return types, so not decoded
+	require.True(t, decoded[3].Success)
+	require.Nil(t, decoded[3].Values)
+	require.Equal(t, []byte{0x01}, decoded[3].ReturnData)
+
+	// invalid return data
+	_, err = multicall.DecodeResults(results[3:], [][]string{{"uint256"}})
+	require.Error(t, err)
+
+	// mismatched return types
+	_, err = multicall.DecodeResults(results, [][]string{{"uint256"}})
+	require.Error(t, err)
+}
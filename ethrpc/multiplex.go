@@ -0,0 +1,153 @@
+package ethrpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/rpc"
+)
+
+// streamMux shares a single websocket connection between the subscriptions of the
+// provider, see WithStreamMultiplexing. The notifications of each subscription carry its
+// subscription id, by which the connection dispatches them to the subscription's channel.
+type streamMux struct {
+	// conn is the current connection, which is dialed on first use
+	conn *rpc.Client
+
+	// refs is the number of subscriptions of each connection, including connections
+	// which have been dropped but still have subscriptions
+	refs map[*rpc.Client]int
+
+	// dialing is closed once the in-flight dial of a new connection is done, so the
+	// subscriptions acquiring the connection meanwhile wait for it instead of dialing
+	dialing chan struct{}
+
+	mu sync.Mutex
+}
+
+// acquire returns the current connection, dialing a new one if there is none, for a
+// subscription which must release it once it has ended.
+func (m *streamMux) acquire(ctx context.Context, nodeWSURL string) (*rpc.Client, error) {
+	for {
+		m.mu.Lock()
+		if m.conn != nil {
+			conn := m.ref(m.conn)
+			m.mu.Unlock()
+			return conn, nil
+		}
+
+		// wait for the in-flight dial, and check again for its connection
+		if dialing := m.dialing; dialing != nil {
+			m.mu.Unlock()
+			select {
+			case <-dialing:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		dialing := make(chan struct{})
+		m.dialing = dialing
+		m.mu.Unlock()
+
+		// dial outside of the lock, so the subscriptions releasing their connection
+		// aren't blocked by it
+		conn, err := rpc.DialContext(ctx, nodeWSURL)
+
+		m.mu.Lock()
+		m.dialing = nil
+		close(dialing)
+		if err != nil {
+			m.mu.Unlock()
+			return nil, err
+		}
+		if m.conn != nil {
+			// should not happen, as only one dial is in-flight at a time
+			conn.Close()
+		} else {
+			m.conn = conn
+		}
+		conn = m.ref(m.conn)
+		m.mu.Unlock()
+		return conn, nil
+	}
+}
+
+// ref adds a subscription to the connection, and returns it. The lock must be held.
+func (m *streamMux) ref(conn *rpc.Client) *rpc.Client {
+	if m.refs == nil {
+		m.refs = map[*rpc.Client]int{}
+	}
+	m.refs[conn]++
+	return conn
+}
+
+// release releases the connection of a subscription which has ended, and closes the
+// connection once it has no subscriptions left. If failed is set, the connection is
+// dropped first, so the subscriptions which follow, ie. when resubscribing on a
+// reconnect, dial a new connection rather than reuse a broken one.
+func (m *streamMux) release(conn *rpc.Client, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if failed && conn == m.conn {
+		m.conn = nil
+	}
+
+	refs, ok := m.refs[conn]
+	if !ok {
+		// already closed by closeAll
+		return
+	}
+	if refs > 1 {
+		m.refs[conn] = refs - 1
+		return
+	}
+
+	delete(m.refs, conn)
+	if conn == m.conn {
+		m.conn = nil
+	}
+	conn.Close()
+}
+
+// closeAll closes all of the connections, ending their subscriptions.
+func (m *streamMux) closeAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for conn := range m.refs {
+		conn.Close()
+	}
+	m.refs = nil
+	m.conn = nil
+}
+
+// dialStream returns a websocket connection for a subscription, along with a func to
+// release it once the subscription has ended, where failed is set if the subscription
+// ended with an error. Each subscription has its own connection, unless
+// WithStreamMultiplexing is set, in which case the connection is shared.
+func (p *Provider) dialStream(ctx context.Context) (*rpc.Client, func(failed bool), error) {
+	if p.streamMux != nil {
+		conn, err := p.streamMux.acquire(ctx, p.nodeWSURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn, func(failed bool) { p.streamMux.release(conn, failed) }, nil
+	}
+
+	conn, err := rpc.DialContext(ctx, p.nodeWSURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, func(bool) { conn.Close() }, nil
+}
+
+// ActiveSubscriptionCount returns the number of active websocket subscriptions of the
+// provider, including those being re-established with WithStreamReconnect.
+func (p *Provider) ActiveSubscriptionCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.streamUnsubscribers)
+}
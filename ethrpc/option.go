@@ -3,6 +3,7 @@ package ethrpc
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/goware/breaker"
 	"github.com/goware/logger"
@@ -23,6 +24,92 @@ func WithStreaming(nodeWebsocketURL string) Option {
 	}
 }
 
+// WithStreamReconnect re-establishes the websocket subscriptions of WithStreaming when the
+// connection drops, ie. SubscribeNewHeads, SubscribeFilterLogs and SubscribeNewPendingTransactions,
+// so subscribers see a continuous stream rather than a subscription error. Reconnects are
+// attempted up to maxAttempts times, where 0 is no limit, waiting between attempts with an
+// exponential backoff starting from baseDelay, up to maxDelay. Once out of attempts, the
+// subscription fails with the last error.
+//
+// NOTE: messages emitted by the node while disconnected are not delivered. See
+// SubscribeLogsReliable for a logs stream which backfills them.
+func WithStreamReconnect(maxAttempts int, baseDelay, maxDelay time.Duration) Option {
+	if baseDelay <= 0 {
+		baseDelay = reliableSubscribeMinBackoff
+	}
+	return func(p *Provider) {
+		p.streamReconnect = &retryOptions{
+			maxAttempts: maxAttempts,
+			baseDelay:   baseDelay,
+			maxDelay:    maxDelay,
+		}
+	}
+}
+
+// WithStreamMultiplexing subscribes over a single websocket connection of WithStreaming,
+// rather than a connection per subscription, which is useful with many subscriptions, ie.
+// of logs, against nodes limiting the number of concurrent websocket connections. The
+// connection is closed once all of its subscriptions have ended. Combined with
+// WithStreamReconnect, all of the subscriptions are re-established over a new connection
+// when it drops.
+func WithStreamMultiplexing() Option {
+	return func(p *Provider) {
+		p.streamMux = &streamMux{}
+	}
+}
+
+// WithSmartRouting also sends regular calls over the websocket connection of WithStreaming,
+// routing each call between http and websocket by its method and the health of each
+// transport, so calls keep working when one of them is unavailable. Without it, all calls
+// are sent over http and websocket is only used for subscriptions. See wsPreferredMethods
+// for the methods which prefer websocket.
+func WithSmartRouting() Option {
+	return func(p *Provider) {
+		p.router = &router{}
+	}
+}
+
+// WithArchiveURL sets an archive node to route calls to which are marked with
+// Call#ArchiveRequired, while all other calls continue to go to the primary node.
+func WithArchiveURL(archiveNodeURL string) Option {
+	return func(p *Provider) {
+		p.archiveURL = archiveNodeURL
+	}
+}
+
+// WithMethodSupport sets whether the node supports the JSON-RPC method, for providers which
+// are known ahead of time, so Provider#Supports doesn't need to probe it. Methods set as
+// unsupported return ErrUnsupportedMethodOnChain without being called, ie. DebugTraceTransaction.
+func WithMethodSupport(method string, supported bool) Option {
+	return func(p *Provider) {
+		p.setMethodSupported(method, supported)
+	}
+}
+
+// WithReadOnly rejects calls to state-changing methods with ErrReadOnly, without sending
+// them to the node, so the provider can safely be handed to code which must never broadcast,
+// ie. an analytics service. Blocked are the methods which broadcast transactions or sign
+// with the accounts of the node:
+//
+//   - eth_sendRawTransaction, eth_sendRawTransactionConditional, eth_sendRawTransactionSync,
+//     eth_sendTransaction, ie. SendTransaction and SendRawTransaction
+//   - eth_sendBundle, eth_sendPrivateTransaction, eth_sendPrivateRawTransaction and
+//     eth_cancelPrivateTransaction of MEV relays
+//   - eth_sign, eth_signTransaction, eth_signTypedData, eth_signTypedData_v3 and
+//     eth_signTypedData_v4
+//   - eth_submitWork, eth_submitHashrate and debug_setHead
+//   - all methods of the personal_, admin_ and miner_ namespaces, and of the evm_, anvil_
+//     and hardhat_ namespaces of test nodes
+//
+// All other methods are allowed, ie. calls, balances, logs, blocks, receipts and traces,
+// including eth_call and eth_estimateGas which simulate transactions without broadcasting
+// them. A batch with any blocked call is rejected as a whole. See IsReadOnlyBlockedMethod.
+func WithReadOnly() Option {
+	return func(p *Provider) {
+		p.readOnly = true
+	}
+}
+
 func WithHTTPClient(c httpClient) Option {
 	return func(p *Provider) {
 		p.httpClient = c
@@ -35,12 +122,68 @@ func WithLogger(log logger.Logger) Option {
 	}
 }
 
+// WithRequestLogger logs each request sent to the node, with the methods and params of its
+// calls, its latency, and its error or optionally its response, for debugging rpc issues.
+// Requests are logged to log at the info level, and failed requests at the warn level. The
+// node url is redacted with the RedactURL option, as it may hold an api key. Without this
+// option, requests carry no logging overhead. See RequestLoggerOptions.
+//
+// NOTE: retried calls are logged for every attempt, and a batch is logged as one request.
+func WithRequestLogger(log logger.Logger, options ...RequestLoggerOptions) Option {
+	return func(p *Provider) {
+		var opts RequestLoggerOptions
+		if len(options) > 0 {
+			opts = options[0]
+		}
+		p.requestLogger = &requestLogger{log: log, options: opts}
+	}
+}
+
 func WithBreaker(br breaker.Breaker) Option {
 	return func(p *Provider) {
 		p.br = br
 	}
 }
 
+// WithMaxBatchSize sets the maximum number of calls packed into a single http
+// request by BatchCall, where larger batches are split automatically. A value
+// of 0 sets no limit.
+func WithMaxBatchSize(maxBatchSize int) Option {
+	return func(p *Provider) {
+		p.maxBatchSize = maxBatchSize
+	}
+}
+
+// WithRetry retries failed calls up to maxAttempts times in total, waiting between
+// attempts with an exponential backoff starting from baseDelay, up to maxDelay.
+// Only transient errors are retried, ie. network errors, rate limits and node
+// unavailability, while deterministic errors such as reverts are returned immediately.
+//
+// NOTE: requests with state-changing calls, ie. eth_sendRawTransaction, are only retried
+// if they failed before reaching the node, see IsReadOnlyBlockedMethod.
+func WithRetry(maxAttempts int, baseDelay, maxDelay time.Duration) Option {
+	return func(p *Provider) {
+		if p.retry == nil {
+			p.retry = &retryOptions{}
+		}
+		p.retry.maxAttempts = maxAttempts
+		p.retry.baseDelay = baseDelay
+		p.retry.maxDelay = maxDelay
+	}
+}
+
+// WithRetryCallback sets a callback which is called after every attempt of a call
+// made with WithRetry, with the attempt number starting from 1, and the error of the
+// attempt if any. Useful for metrics.
+func WithRetryCallback(fn func(attempt int, err error)) Option {
+	return func(p *Provider) {
+		if p.retry == nil {
+			p.retry = &retryOptions{maxAttempts: 1}
+		}
+		p.retry.onAttempt = fn
+	}
+}
+
 // func WithCache(cache cachestore.Store[[]byte]) Option {
 // 	return func(p *Provider) {
 // 		p.cache = cache
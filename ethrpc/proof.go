@@ -0,0 +1,256 @@
+package ethrpc
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+)
+
+// VerifyAccountProof verifies the Merkle-Patricia proof of the account returned by eth_getProof
+// against the state root of a block, and that the proven account state matches the balance,
+// nonce, code hash and storage hash of the proof. An account which does not exist is proven
+// by a proof of its absence, in which case its balance and nonce must be zero.
+//
+// NOTE: the storage proofs of the account are not verified, see VerifyProof.
+func VerifyAccountProof(stateRoot common.Hash, proof *AccountProof) error {
+	if proof == nil {
+		return fmt.Errorf("ethrpc: account proof is required")
+	}
+
+	nodes := make([][]byte, len(proof.AccountProof))
+	for i, node := range proof.AccountProof {
+		nodes[i] = node
+	}
+
+	value, err := verifyMerkleProof(stateRoot, crypto.Keccak256(proof.Address.Bytes()), nodes)
+	if err != nil {
+		return fmt.Errorf("ethrpc: invalid account proof of %s: %w", proof.Address, err)
+	}
+
+	balance := proof.Balance.ToInt()
+	if balance == nil {
+		balance = new(big.Int)
+	}
+
+	if value == nil {
+		// the account does not exist
+		if balance.Sign() != 0 || proof.Nonce != 0 {
+			return fmt.Errorf("ethrpc: invalid account proof of %s: account does not exist", proof.Address)
+		}
+		return nil
+	}
+
+	var account struct {
+		Nonce       uint64
+		Balance     *big.Int
+		StorageHash common.Hash
+		CodeHash    []byte
+	}
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return fmt.Errorf("ethrpc: invalid account proof of %s: %w", proof.Address, err)
+	}
+
+	if account.Nonce != uint64(proof.Nonce) ||
+		account.Balance.Cmp(balance) != 0 ||
+		account.StorageHash != proof.StorageHash ||
+		!bytes.Equal(account.CodeHash, proof.CodeHash.Bytes()) {
+		return fmt.Errorf("ethrpc: invalid account proof of %s: account state does not match", proof.Address)
+	}
+	return nil
+}
+
+// VerifyStorageProof verifies the Merkle-Patricia proof of a storage slot returned by
+// eth_getProof against the storage root of its account, the StorageHash of the account
+// proof, and that the proven value of the slot matches the Value of the proof. A slot
+// which is not set is proven by a proof of its absence, in which case its value must be zero.
+func VerifyStorageProof(storageHash common.Hash, proof StorageProof) error {
+	expected := proof.Value.ToInt()
+	if expected == nil {
+		expected = new(big.Int)
+	}
+
+	// the storage of an account without any slots set is the empty trie, whose
+	// proof has no nodes
+	if storageHash == types.EmptyRootHash && len(proof.Proof) == 0 {
+		if expected.Sign() != 0 {
+			return fmt.Errorf("ethrpc: invalid storage proof of slot %s: slot is not set", proof.Key)
+		}
+		return nil
+	}
+
+	nodes := make([][]byte, len(proof.Proof))
+	for i, node := range proof.Proof {
+		nodes[i] = node
+	}
+
+	value, err := verifyMerkleProof(storageHash, crypto.Keccak256(proof.Key.Bytes()), nodes)
+	if err != nil {
+		return fmt.Errorf("ethrpc: invalid storage proof of slot %s: %w", proof.Key, err)
+	}
+
+	// slot values are stored as rlp encoded big-endian integers
+	actual := new(big.Int)
+	if value != nil {
+		content, _, err := rlp.SplitString(value)
+		if err != nil {
+			return fmt.Errorf("ethrpc: invalid storage proof of slot %s: %w", proof.Key, err)
+		}
+		actual.SetBytes(content)
+	}
+
+	if actual.Cmp(expected) != 0 {
+		return fmt.Errorf("ethrpc: invalid storage proof of slot %s: value does not match", proof.Key)
+	}
+	return nil
+}
+
+// VerifyProof verifies the account proof returned by eth_getProof against the state root
+// of a block, along with each of its storage proofs against the proven storage root of the
+// account, see VerifyAccountProof and VerifyStorageProof.
+func VerifyProof(stateRoot common.Hash, proof *AccountProof) error {
+	if err := VerifyAccountProof(stateRoot, proof); err != nil {
+		return err
+	}
+	for _, storageProof := range proof.StorageProof {
+		if err := VerifyStorageProof(proof.StorageHash, storageProof); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyMerkleProof walks the proof nodes from the root of the Merkle-Patricia trie along
+// the path of the key, returning the value of the key, or nil if the proof shows the key
+// is not in the trie.
+func verifyMerkleProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := make(map[common.Hash][]byte, len(proof))
+	for _, node := range proof {
+		nodes[crypto.Keccak256Hash(node)] = node
+	}
+
+	// the path of the key in nibbles
+	path := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		path = append(path, b>>4, b&0x0f)
+	}
+
+	node, ok := nodes[root]
+	if !ok {
+		return nil, fmt.Errorf("missing root node %s", root)
+	}
+
+	for {
+		elems, err := rlpListElems(node)
+		if err != nil {
+			return nil, err
+		}
+
+		var child []byte
+		switch len(elems) {
+		case 17:
+			// branch node
+			if len(path) == 0 {
+				value, _, err := rlp.SplitString(elems[16])
+				if err != nil {
+					return nil, err
+				}
+				if len(value) == 0 {
+					return nil, nil
+				}
+				return value, nil
+			}
+			child = elems[path[0]]
+			path = path[1:]
+
+		case 2:
+			// extension or leaf node, with a hex-prefix encoded path
+			encodedPath, _, err := rlp.SplitString(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			if len(encodedPath) == 0 {
+				return nil, fmt.Errorf("invalid node path")
+			}
+			nodePath, isLeaf := decodeHexPrefix(encodedPath)
+
+			if isLeaf {
+				if !bytes.Equal(nodePath, path) {
+					return nil, nil
+				}
+				value, _, err := rlp.SplitString(elems[1])
+				if err != nil {
+					return nil, err
+				}
+				return value, nil
+			}
+			if !bytes.HasPrefix(path, nodePath) {
+				return nil, nil
+			}
+			child = elems[1]
+			path = path[len(nodePath):]
+
+		default:
+			return nil, fmt.Errorf("invalid node with %d elements", len(elems))
+		}
+
+		// resolve the child node, which is either embedded when its encoding
+		// is shorter than 32 bytes, or referenced by its hash
+		kind, content, _, err := rlp.Split(child)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case kind == rlp.List:
+			node = child
+		case len(content) == 0:
+			return nil, nil
+		case len(content) == common.HashLength:
+			node, ok = nodes[common.BytesToHash(content)]
+			if !ok {
+				return nil, fmt.Errorf("missing node %s", common.BytesToHash(content))
+			}
+		default:
+			return nil, fmt.Errorf("invalid node reference")
+		}
+	}
+}
+
+// rlpListElems returns the raw encoding of each element of an rlp list.
+func rlpListElems(b []byte) ([][]byte, error) {
+	content, _, err := rlp.SplitList(b)
+	if err != nil {
+		return nil, err
+	}
+	var elems [][]byte
+	for len(content) > 0 {
+		_, _, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, content[:len(content)-len(rest)])
+		content = rest
+	}
+	return elems, nil
+}
+
+// decodeHexPrefix decodes the hex-prefix encoded path of a trie node into nibbles,
+// and reports whether the node is a leaf.
+func decodeHexPrefix(encoded []byte) ([]byte, bool) {
+	flag := encoded[0] >> 4
+	isLeaf := flag&2 != 0
+
+	var nibbles []byte
+	if flag&1 != 0 {
+		// odd length, where the first nibble is in the flag byte
+		nibbles = append(nibbles, encoded[0]&0x0f)
+	}
+	for _, b := range encoded[1:] {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	return nibbles, isLeaf
+}
@@ -0,0 +1,313 @@
+package ethrpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+// testTrie is a Merkle-Patricia trie of the keys and values, built as by the node, to
+// produce the proofs of eth_getProof.
+type testTrie map[string][]byte
+
+type testTrieEntry struct {
+	path  []byte // remaining nibbles of the key
+	value []byte
+}
+
+// root returns the root hash of the trie, and the proof of the key, which are the
+// nodes along its path from the root, where the nodes shorter than 32 bytes are
+// embedded in their parent rather than in the proof.
+func (tt testTrie) root(key []byte) (common.Hash, []hexutil.Bytes) {
+	entries := make([]testTrieEntry, 0, len(tt))
+	for k, v := range tt {
+		entries = append(entries, testTrieEntry{path: toNibbles([]byte(k)), value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return string(entries[i].path) < string(entries[j].path) })
+
+	var proof []hexutil.Bytes
+	root := buildTrieNode(entries, toNibbles(key), true, &proof)
+	proof = append(proof, root)
+
+	// the nodes are collected from the leaf up
+	for i, j := 0, len(proof)-1; i < j; i, j = i+1, j-1 {
+		proof[i], proof[j] = proof[j], proof[i]
+	}
+	return crypto.Keccak256Hash(root), proof
+}
+
+func buildTrieNode(entries []testTrieEntry, target []byte, onPath bool, proof *[]hexutil.Bytes) []byte {
+	// ref is the reference to a child node, which is embedded if shorter than 32 bytes
+	ref := func(node []byte, onPath bool) rlp.RawValue {
+		if len(node) < 32 {
+			return node
+		}
+		if onPath {
+			*proof = append(*proof, node)
+		}
+		enc, _ := rlp.EncodeToBytes(crypto.Keccak256(node))
+		return enc
+	}
+
+	if len(entries) == 1 {
+		node, _ := rlp.EncodeToBytes([][]byte{encodeHexPrefix(entries[0].path, true), entries[0].value})
+		return node
+	}
+
+	prefix := entries[0].path
+	for _, e := range entries[1:] {
+		n := 0
+		for n < len(prefix) && n < len(e.path) && prefix[n] == e.path[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+
+	if len(prefix) > 0 {
+		// extension node
+		children := make([]testTrieEntry, len(entries))
+		for i, e := range entries {
+			children[i] = testTrieEntry{path: e.path[len(prefix):], value: e.value}
+		}
+		childOnPath := onPath && len(target) >= len(prefix) && string(target[:len(prefix)]) == string(prefix)
+		var childTarget []byte
+		if childOnPath {
+			childTarget = target[len(prefix):]
+		}
+		child := ref(buildTrieNode(children, childTarget, childOnPath, proof), childOnPath)
+		node, _ := rlp.EncodeToBytes([]any{encodeHexPrefix(prefix, false), child})
+		return node
+	}
+
+	// branch node
+	branch := make([]any, 17)
+	for i := range branch {
+		branch[i] = []byte{}
+	}
+	groups := map[byte][]testTrieEntry{}
+	for _, e := range entries {
+		if len(e.path) == 0 {
+			branch[16] = e.value
+			continue
+		}
+		groups[e.path[0]] = append(groups[e.path[0]], testTrieEntry{path: e.path[1:], value: e.value})
+	}
+	for nibble, group := range groups {
+		childOnPath := onPath && len(target) > 0 && target[0] == nibble
+		var childTarget []byte
+		if childOnPath {
+			childTarget = target[1:]
+		}
+		branch[nibble] = ref(buildTrieNode(group, childTarget, childOnPath, proof), childOnPath)
+	}
+	node, _ := rlp.EncodeToBytes(branch)
+	return node
+}
+
+func toNibbles(key []byte) []byte {
+	nibbles := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	return nibbles
+}
+
+func encodeHexPrefix(nibbles []byte, isLeaf bool) []byte {
+	flag := byte(0)
+	if isLeaf {
+		flag = 2
+	}
+	if len(nibbles)%2 == 1 {
+		flag |= 1
+		nibbles = append([]byte{flag}, nibbles...)
+	} else {
+		nibbles = append([]byte{flag, 0}, nibbles...)
+	}
+	encoded := make([]byte, len(nibbles)/2)
+	for i := range encoded {
+		encoded[i] = nibbles[2*i]<<4 | nibbles[2*i+1]
+	}
+	return encoded
+}
+
+func TestTestTrie(t *testing.T) {
+	// the "puppy" trie of the ethereum/tests trie test vectors, whose root is an extension
+	// node, with branches holding values, and embedded nodes
+	trie := testTrie{
+		"do":    []byte("verb"),
+		"horse": []byte("stallion"),
+		"doge":  []byte("coin"),
+		"dog":   []byte("puppy"),
+	}
+	root, _ := trie.root(nil)
+	require.Equal(t, "0x5991bb8c6514148a29db676a14ac506cd2cd5775ace63c30a4fe457715e9ac84", root.Hex())
+}
+
+type testAccount struct {
+	Nonce       uint64
+	Balance     *big.Int
+	StorageHash common.Hash
+	CodeHash    []byte
+}
+
+// testStateTrie returns the state trie of accounts, some of which share the first two
+// nibbles of their trie path, so their proofs go through an extension node.
+func testStateTrie(t *testing.T) (testTrie, []common.Address) {
+	var addrs []common.Address
+	var keys [][]byte
+	for i := 1; len(addrs) < 3; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		key := crypto.Keccak256(addr.Bytes())
+		switch len(addrs) {
+		case 1:
+			// shares the first byte, but not the third nibble
+			if key[0] != keys[0][0] || key[1]>>4 == keys[0][1]>>4 {
+				continue
+			}
+		case 2:
+			// diverges at the first nibble
+			if key[0]>>4 == keys[0][0]>>4 {
+				continue
+			}
+		}
+		addrs = append(addrs, addr)
+		keys = append(keys, key)
+	}
+
+	trie := testTrie{}
+	for i, key := range keys {
+		value, err := rlp.EncodeToBytes(testAccount{
+			Nonce:       uint64(i + 1),
+			Balance:     big.NewInt(int64(1000 * (i + 1))),
+			StorageHash: types.EmptyRootHash,
+			CodeHash:    crypto.Keccak256(nil),
+		})
+		require.NoError(t, err)
+		trie[string(key)] = value
+	}
+	return trie, addrs
+}
+
+func testAccountProof(t *testing.T, trie testTrie, addr common.Address) (common.Hash, *ethrpc.AccountProof) {
+	stateRoot, nodes := trie.root(crypto.Keccak256(addr.Bytes()))
+	proof := &ethrpc.AccountProof{Address: addr, AccountProof: nodes, Balance: (*hexutil.Big)(big.NewInt(0)), StorageProof: []ethrpc.StorageProof{}}
+
+	if value, ok := trie[string(crypto.Keccak256(addr.Bytes()))]; ok {
+		var account testAccount
+		require.NoError(t, rlp.DecodeBytes(value, &account))
+		proof.Nonce = hexutil.Uint64(account.Nonce)
+		proof.Balance = (*hexutil.Big)(account.Balance)
+		proof.CodeHash = common.BytesToHash(account.CodeHash)
+		proof.StorageHash = account.StorageHash
+	}
+	return stateRoot, proof
+}
+
+func TestVerifyAccountProofExtension(t *testing.T) {
+	trie, addrs := testStateTrie(t)
+
+	stateRoot, proof := testAccountProof(t, trie, addrs[1])
+	require.NoError(t, ethrpc.VerifyAccountProof(stateRoot, proof))
+
+	// the proof is a branch, an extension of the shared nibble, a branch and a leaf
+	require.Len(t, proof.AccountProof, 4)
+	var extension [][]byte
+	require.NoError(t, rlp.DecodeBytes(proof.AccountProof[1], &extension))
+	require.Len(t, extension, 2)
+	require.Equal(t, byte(0x1), extension[0][0]>>4, "odd length extension path")
+
+	for _, addr := range addrs {
+		stateRoot, proof := testAccountProof(t, trie, addr)
+		require.NoError(t, ethrpc.VerifyAccountProof(stateRoot, proof))
+	}
+
+	// a tampered extension node is detected
+	tampered := *proof
+	tampered.AccountProof = append([]hexutil.Bytes{}, proof.AccountProof...)
+	tampered.AccountProof[1] = append(hexutil.Bytes{}, proof.AccountProof[1]...)
+	tampered.AccountProof[1][len(tampered.AccountProof[1])-1] ^= 0xff
+	require.Error(t, ethrpc.VerifyAccountProof(stateRoot, &tampered))
+
+	// an account which shares the path of the extension node, but diverges within it,
+	// is proven by its absence
+	for i := 1000; ; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		key := crypto.Keccak256(addr.Bytes())
+		if key[0]>>4 != crypto.Keccak256(addrs[0].Bytes())[0]>>4 || key[0] == crypto.Keccak256(addrs[0].Bytes())[0] {
+			continue
+		}
+		stateRoot, proof := testAccountProof(t, trie, addr)
+		require.NoError(t, ethrpc.VerifyAccountProof(stateRoot, proof))
+
+		proof.Balance = (*hexutil.Big)(big.NewInt(1))
+		require.Error(t, ethrpc.VerifyAccountProof(stateRoot, proof))
+		break
+	}
+}
+
+func TestVerifiedBalanceAt(t *testing.T) {
+	trie, addrs := testStateTrie(t)
+	stateRoot, proof := testAccountProof(t, trie, addrs[1])
+
+	var response *ethrpc.AccountProof
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		require.Equal(t, "eth_getProof", req.Method)
+		require.Equal(t, `"0x10"`, string(req.Params[2]))
+
+		result, _ := json.Marshal(response)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+	}))
+	defer node.Close()
+
+	p, err := ethrpc.NewProvider(node.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	trustedHeader := &types.Header{Number: big.NewInt(0x10), Root: stateRoot}
+
+	response = proof
+	balance, err := p.VerifiedBalanceAt(ctx, addrs[1], trustedHeader)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2000), balance)
+
+	nonce, err := p.VerifiedNonceAt(ctx, addrs[1], trustedHeader)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), nonce)
+
+	// the node returns a wrong balance
+	wrong := *proof
+	wrong.Balance = (*hexutil.Big)(big.NewInt(2001))
+	response = &wrong
+	_, err = p.VerifiedBalanceAt(ctx, addrs[1], trustedHeader)
+	require.Error(t, err)
+
+	// or the proof of another account
+	response = proof
+	_, err = p.VerifiedBalanceAt(ctx, addrs[0], trustedHeader)
+	require.Error(t, err)
+
+	// the proof doesn't match the state root of the trusted header
+	_, err = p.VerifiedBalanceAt(ctx, addrs[1], &types.Header{Number: big.NewInt(0x10), Root: common.Hash{0x01}})
+	require.Error(t, err)
+
+	_, err = p.VerifiedBalanceAt(ctx, addrs[1], nil)
+	require.Error(t, err)
+}
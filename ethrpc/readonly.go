@@ -0,0 +1,71 @@
+package ethrpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goware/superr"
+)
+
+// ErrReadOnly is returned for calls to state-changing methods on a provider set up with
+// WithReadOnly.
+var ErrReadOnly = errors.New("ethrpc: method is not allowed on a read-only provider")
+
+// readOnlyBlockedMethods are the JSON-RPC methods rejected by WithReadOnly, which
+// broadcast transactions or sign with the accounts of the node.
+var readOnlyBlockedMethods = map[string]bool{
+	"eth_sendRawTransaction":            true,
+	"eth_sendRawTransactionConditional": true,
+	"eth_sendRawTransactionSync":        true,
+	"eth_sendTransaction":               true,
+	"eth_sendBundle":                    true,
+	"eth_sendPrivateTransaction":        true,
+	"eth_sendPrivateRawTransaction":     true,
+	"eth_cancelPrivateTransaction":      true,
+	"eth_sign":                          true,
+	"eth_signTransaction":               true,
+	"eth_signTypedData":                 true,
+	"eth_signTypedData_v3":              true,
+	"eth_signTypedData_v4":              true,
+	"eth_submitWork":                    true,
+	"eth_submitHashrate":                true,
+	"debug_setHead":                     true,
+}
+
+// readOnlyBlockedNamespaces are the JSON-RPC namespaces rejected by WithReadOnly, which
+// manage the accounts and the state of the node, ie. personal_sendTransaction or the
+// evm_, anvil_ and hardhat_ methods of test nodes.
+var readOnlyBlockedNamespaces = []string{
+	"personal_",
+	"admin_",
+	"miner_",
+	"evm_",
+	"anvil_",
+	"hardhat_",
+}
+
+// IsReadOnlyBlockedMethod reports whether the JSON-RPC method is rejected by a provider
+// set up with WithReadOnly.
+func IsReadOnlyBlockedMethod(method string) bool {
+	if readOnlyBlockedMethods[method] {
+		return true
+	}
+	for _, namespace := range readOnlyBlockedNamespaces {
+		if strings.HasPrefix(method, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReadOnly returns ErrReadOnly if any of the calls is to a blocked method, in which
+// case none of the calls must be sent.
+func checkReadOnly(calls []Call) error {
+	for _, call := range calls {
+		if IsReadOnlyBlockedMethod(call.request.Method) {
+			return superr.Wrap(ErrReadOnly, fmt.Errorf("method %s", call.request.Method))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package ethrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goware/logger"
+)
+
+// DefaultRequestLogMaxBytes is the default length the params and responses logged by
+// WithRequestLogger are truncated to.
+const DefaultRequestLogMaxBytes = 1024
+
+// RequestLoggerOptions are the options of WithRequestLogger.
+type RequestLoggerOptions struct {
+	// LogResponses also logs the json response body of each request.
+	LogResponses bool
+
+	// MaxBytes is the length the logged params and responses are truncated to, which
+	// defaults to DefaultRequestLogMaxBytes. A value of -1 sets no limit.
+	MaxBytes int
+
+	// RedactURL rewrites the node url before it's logged, ie. to remove the api key of
+	// the endpoint. Defaults to RedactURL, which drops the path and query of the url.
+	RedactURL func(nodeURL string) string
+}
+
+// RedactURL returns the node url with its path and query replaced, as they commonly hold
+// the api key of the endpoint, ie. "https://mainnet.infura.io/v3/<key>" is returned as
+// "https://mainnet.infura.io/***". Urls which can't be parsed are redacted as a whole.
+func RedactURL(nodeURL string) string {
+	u, err := url.Parse(nodeURL)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	redacted := u.Scheme + "://" + u.Host
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		redacted += "/***"
+	}
+	return redacted
+}
+
+type requestLogger struct {
+	log     logger.Logger
+	options RequestLoggerOptions
+}
+
+// logRequest logs the calls sent to the node, with their params, latency and the
+// error or response of the request.
+func (l *requestLogger) logRequest(nodeURL string, calls []Call, body []byte, err error, latency time.Duration) {
+	redactURL := l.options.RedactURL
+	if redactURL == nil {
+		redactURL = RedactURL
+	}
+
+	var sb strings.Builder
+	for i, call := range calls {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		params := []byte("[]")
+		if call.request.Params != nil {
+			params, _ = json.Marshal(call.request.Params)
+		}
+		sb.WriteString(call.request.Method)
+		sb.WriteString(l.truncate(params))
+	}
+
+	msg := fmt.Sprintf("ethrpc: request to %s in %s: %s", redactURL(nodeURL), latency.Round(time.Microsecond), sb.String())
+	if err != nil {
+		l.log.Warnf("%s, failed: %s", msg, redactErrorURL(err, nodeURL, redactURL))
+		return
+	}
+	if l.options.LogResponses {
+		msg = fmt.Sprintf("%s, response: %s", msg, l.truncate(body))
+	}
+	l.log.Info(msg)
+}
+
+// redactErrorURL returns the text of the error with the node url redacted, as the errors
+// of the http client, ie. of a failed dial, hold the url of the request in a *url.Error.
+func redactErrorURL(err error, nodeURL string, redactURL func(string) string) string {
+	msg := err.Error()
+	if nodeURL != "" {
+		msg = strings.ReplaceAll(msg, nodeURL, redactURL(nodeURL))
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.URL != "" {
+		msg = strings.ReplaceAll(msg, urlErr.URL, redactURL(urlErr.URL))
+	}
+	return msg
+}
+
+func (l *requestLogger) truncate(data []byte) string {
+	maxBytes := l.options.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultRequestLogMaxBytes
+	}
+	if maxBytes < 0 || len(data) <= maxBytes {
+		return string(data)
+	}
+	return fmt.Sprintf("%s … (%d bytes)", data[:maxBytes], len(data))
+}
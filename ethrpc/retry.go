@@ -0,0 +1,164 @@
+package ethrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/goware/superr"
+)
+
+// retryableErrorCodes are the JSON-RPC error codes which are transient, and the
+// request may succeed if retried.
+var retryableErrorCodes = map[int]bool{
+	-32005: true, // limit exceeded / rate limited
+}
+
+// retryableStatusCodes are the http status codes of a node response which are
+// transient, and the request may succeed if retried.
+var retryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// skipRetryKey is the context key of a func reporting the errors which must not be
+// retried for the requests made with the context, see FilterLogsRange.
+type skipRetryKey struct{}
+
+type retryOptions struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	onAttempt   func(attempt int, err error)
+}
+
+// httpStatusError is a non-2xx http response from the node.
+type httpStatusError struct {
+	statusCode int
+	err        error
+}
+
+func (e *httpStatusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *httpStatusError) Unwrap() error {
+	return e.err
+}
+
+// doWithRetry calls fn until it succeeds, returns an error which isn't retryable, or
+// the max number of attempts is reached. The delay between attempts grows exponentially
+// from baseDelay up to maxDelay.
+//
+// Requests with state-changing calls, ie. eth_sendRawTransaction, are only retried if
+// they failed before reaching the node, as the node may have executed them otherwise,
+// ie. when the connection dropped before the response, and sending them again would
+// fail with "already known" or "nonce too low" even though they succeeded.
+func (p *Provider) doWithRetry(ctx context.Context, calls []Call, fn func() ([]byte, error)) ([]byte, error) {
+	r := p.retry
+	delay := r.baseDelay
+	skipRetry, _ := ctx.Value(skipRetryKey{}).(func(error) bool)
+
+	isRetryable := isRetryableError
+	for _, call := range calls {
+		if IsReadOnlyBlockedMethod(call.request.Method) {
+			isRetryable = isUnsentRequestError
+			break
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		body, err := fn()
+		if r.onAttempt != nil {
+			r.onAttempt(attempt, err)
+		}
+		if err == nil || attempt >= r.maxAttempts || !isRetryable(err) {
+			return body, err
+		}
+		if skipRetry != nil && skipRetry(err) {
+			return body, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return body, err
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if r.maxDelay > 0 && delay > r.maxDelay {
+			delay = r.maxDelay
+		}
+	}
+}
+
+// isRetryableError reports whether the error is a transient network or node error,
+// as opposed to a deterministic error such as an execution revert.
+func isRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// errors wrapped with superr are only reachable through its error stack
+	for _, e := range superr.GetErrorStack(err) {
+		if isRetryableCause(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRetryableCause(err error) bool {
+	// retry the batch if any of its calls failed with a retryable error
+	var batchErr BatchError
+	if errors.As(err, &batchErr) {
+		for _, call := range batchErr {
+			if isRetryableError(call.err) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return retryableStatusCodes[statusErr.statusCode]
+	}
+
+	var rpcErr *jsonrpc.Error
+	if errors.As(err, &rpcErr) {
+		return retryableErrorCodes[rpcErr.Code]
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isUnsentRequestError reports whether the request failed before it reached the node,
+// ie. the node couldn't be dialed, or it rejected the request without processing it.
+func isUnsentRequestError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	for _, e := range superr.GetErrorStack(err) {
+		var statusErr *httpStatusError
+		if errors.As(e, &statusErr) {
+			return statusErr.statusCode == http.StatusTooManyRequests || statusErr.statusCode == http.StatusServiceUnavailable
+		}
+		var opErr *net.OpError
+		if errors.As(e, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package ethrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+var (
+	revertErrorSelector = ethcoder.Keccak256([]byte("Error(string)"))[:4]
+	revertPanicSelector = ethcoder.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// RevertError is returned for calls which reverted, ie. eth_call and eth_estimateGas, with
+// the revert data returned by the node decoded. Use errors.As to check for it:
+//
+//	var revertErr *ethrpc.RevertError
+//	if errors.As(err, &revertErr) {
+//		fmt.Println("transfer failed:", revertErr.Reason)
+//	}
+//
+// The node's JSON-RPC error is wrapped, so errors.As with *jsonrpc.Error works as well.
+type RevertError struct {
+	// Data is the raw revert data, which is empty if the node did not return it.
+	Data []byte
+
+	// Reason is the decoded reason of an Error(string) revert, ie. require(ok, "reason").
+	Reason string
+
+	// PanicCode is the decoded code of a Panic(uint256) revert, ie. 0x11 for an
+	// arithmetic overflow, and nil for any other revert.
+	PanicCode *big.Int
+
+	// Selector is the 4-byte selector of the revert data, which identifies the custom
+	// error when the revert is neither an Error(string) nor a Panic(uint256).
+	Selector [4]byte
+
+	err *jsonrpc.Error
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.IsErrorString():
+		return fmt.Sprintf("execution reverted: %s", e.Reason)
+	case e.PanicCode != nil:
+		return fmt.Sprintf("execution reverted: panic code 0x%x", e.PanicCode)
+	case len(e.Data) >= 4:
+		return fmt.Sprintf("execution reverted: custom error %s", hexutil.Encode(e.Selector[:]))
+	default:
+		return e.err.Message
+	}
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.err
+}
+
+// IsErrorString reports whether the revert is an Error(string) revert, whose reason is
+// decoded into Reason.
+func (e *RevertError) IsErrorString() bool {
+	return len(e.Data) >= 4 && bytes.Equal(e.Data[:4], revertErrorSelector)
+}
+
+// callError returns the error of a call for the JSON-RPC error returned by the node,
+// which is a *RevertError if the call reverted.
+func callError(rpcErr *jsonrpc.Error) error {
+	if rpcErr == nil {
+		return nil
+	}
+	if revertErr, ok := newRevertError(rpcErr); ok {
+		return revertErr
+	}
+	return rpcErr
+}
+
+// newRevertError decodes the revert data of the JSON-RPC error, if it's an execution
+// reverted error. Nodes return the revert data as a hex string in the error data,
+// which some prefix with "Reverted ".
+func newRevertError(rpcErr *jsonrpc.Error) (*RevertError, bool) {
+	var data []byte
+	var dataHex string
+	if len(rpcErr.Data) > 0 && json.Unmarshal(rpcErr.Data, &dataHex) == nil {
+		dataHex = strings.TrimPrefix(dataHex, "Reverted ")
+		if b, err := hexutil.Decode(dataHex); err == nil {
+			data = b
+		}
+	}
+
+	if rpcErr.Code != 3 && !strings.Contains(strings.ToLower(rpcErr.Message), "revert") {
+		return nil, false
+	}
+
+	revertErr := &RevertError{Data: data, err: rpcErr}
+	if len(data) < 4 {
+		return revertErr, true
+	}
+	copy(revertErr.Selector[:], data[:4])
+
+	switch {
+	case bytes.Equal(data[:4], revertErrorSelector):
+		var reason string
+		if ethcoder.ABIUnpackArgumentsByRef([]string{"string"}, data[4:], []interface{}{&reason}) == nil {
+			revertErr.Reason = reason
+		}
+	case bytes.Equal(data[:4], revertPanicSelector):
+		var code *big.Int
+		if ethcoder.ABIUnpackArgumentsByRef([]string{"uint256"}, data[4:], []interface{}{&code}) == nil {
+			revertErr.PanicCode = code
+		}
+	}
+	return revertErr, true
+}
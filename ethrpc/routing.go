@@ -0,0 +1,298 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/rpc"
+	"github.com/goware/superr"
+)
+
+// Smart routing, see WithSmartRouting.
+//
+// By default the Provider sends all calls over http to the node url, and only uses the
+// websocket url of WithStreaming for subscriptions. With smart routing enabled, calls are
+// routed between both transports as follows:
+//
+//   - subscriptions, ie. eth_subscribe, are only ever sent over websocket.
+//   - wsPreferredMethods, which are small and frequently polled calls for the chain head
+//     and transactions, prefer the websocket connection to avoid a request per call.
+//   - all other methods prefer http, as large responses such as logs, traces and full
+//     blocks would otherwise hold up the shared websocket connection.
+//   - calls which require an archive node are always sent over http to the archive url.
+//
+// A batch prefers websocket only if all of its calls do. When a transport fails with a
+// connection error or an unavailable node, it's marked unhealthy for routingCooldown and
+// the call is sent over the other transport instead. Until the cooldown has elapsed,
+// calls prefer the healthy transport.
+
+// routingCooldown is how long a transport is avoided for after it has failed.
+const routingCooldown = 30 * time.Second
+
+// wsPreferredMethods are the methods which prefer the websocket transport.
+var wsPreferredMethods = map[string]bool{
+	"eth_blockNumber":           true,
+	"eth_getBlockByNumber":      true,
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionReceipt": true,
+	"eth_getTransactionCount":   true,
+	"eth_sendRawTransaction":    true,
+}
+
+// unavailableStatusCodes are the http status codes of a node response which mean
+// the node is unavailable over http.
+var unavailableStatusCodes = map[int]bool{
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+type transport int
+
+const (
+	transportHTTP transport = iota
+	transportWS
+)
+
+func (t transport) String() string {
+	switch t {
+	case transportHTTP:
+		return "http"
+	case transportWS:
+		return "ws"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+type router struct {
+	wsClient       *rpc.Client
+	unhealthyUntil [2]time.Time
+	mu             sync.Mutex
+}
+
+// route returns the transports to try the calls with, in order of preference.
+func (r *router) route(calls []Call) []transport {
+	preferWS := true
+	for _, call := range calls {
+		if !wsPreferredMethods[call.request.Method] {
+			preferWS = false
+			break
+		}
+	}
+
+	transports := []transport{transportHTTP, transportWS}
+	if preferWS {
+		transports[0], transports[1] = transportWS, transportHTTP
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// fall back to the other transport first while the preferred one is unhealthy,
+	// unless both are, in which case the preference stands
+	now := time.Now()
+	if now.Before(r.unhealthyUntil[transports[0]]) && !now.Before(r.unhealthyUntil[transports[1]]) {
+		transports[0], transports[1] = transports[1], transports[0]
+	}
+	return transports
+}
+
+func (r *router) markUnhealthy(t transport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unhealthyUntil[t] = time.Now().Add(routingCooldown)
+}
+
+func (r *router) markHealthy(t transport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unhealthyUntil[t] = time.Time{}
+}
+
+// wsConn returns the websocket connection for calls, which is dialed on first use.
+func (r *router) wsConn(ctx context.Context, nodeWSURL string) (*rpc.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.wsClient != nil {
+		return r.wsClient, nil
+	}
+	conn, err := rpc.DialContext(ctx, nodeWSURL)
+	if err != nil {
+		return nil, err
+	}
+	r.wsClient = conn
+	return conn, nil
+}
+
+// closeWSConn closes the websocket connection so it's dialed again on next use. If
+// conn is set, the connection is only closed if it's still the current one.
+func (r *router) closeWSConn(conn *rpc.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.wsClient == nil || (conn != nil && conn != r.wsClient) {
+		return
+	}
+	r.wsClient.Close()
+	r.wsClient = nil
+}
+
+// wsConnError is a failure of the websocket connection, as opposed to an error
+// returned by the node.
+type wsConnError struct {
+	err error
+}
+
+func (e *wsConnError) Error() string {
+	return e.err.Error()
+}
+
+func (e *wsConnError) Unwrap() error {
+	return e.err
+}
+
+// doRouted sends the calls over the preferred transport, and falls back to the other
+// transport if the preferred one is unavailable.
+func (p *Provider) doRouted(ctx context.Context, calls ...Call) ([]byte, error) {
+	for _, call := range calls {
+		if call.archive && p.archiveURL != "" {
+			return p.doHTTP(ctx, calls...)
+		}
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+	for _, t := range p.router.route(calls) {
+		if t == transportWS {
+			body, err = p.doWS(ctx, calls...)
+		} else {
+			body, err = p.doHTTP(ctx, calls...)
+		}
+
+		if ctx.Err() != nil {
+			return body, err
+		}
+		if err == nil || !isTransportError(err) {
+			p.router.markHealthy(t)
+			return body, err
+		}
+		p.router.markUnhealthy(t)
+	}
+	return body, err
+}
+
+// doWS sends the calls as a batch over the websocket connection, see sendWS, and logs
+// the request with WithRequestLogger.
+func (p *Provider) doWS(ctx context.Context, calls ...Call) ([]byte, error) {
+	if p.requestLogger == nil {
+		return p.sendWS(ctx, calls...)
+	}
+
+	start := time.Now()
+	body, err := p.sendWS(ctx, calls...)
+	p.requestLogger.logRequest(p.nodeWSURL, calls, body, err, time.Since(start))
+	return body, err
+}
+
+func (p *Provider) sendWS(ctx context.Context, calls ...Call) ([]byte, error) {
+	batch := make(BatchCall, 0, len(calls))
+	elems := make([]rpc.BatchElem, len(calls))
+	results := make([]json.RawMessage, len(calls))
+	for i, call := range calls {
+		call := call
+		if call.err != nil {
+			return nil, fmt.Errorf("call %d has an error: %w", i, call.err)
+		}
+
+		call.request.ID = atomic.AddUint64(&p.lastRequestID, 1)
+		batch = append(batch, &call)
+		elems[i] = rpc.BatchElem{Method: call.request.Method, Args: call.request.Params, Result: &results[i]}
+	}
+
+	conn, err := p.router.wsConn(ctx, p.nodeWSURL)
+	if err != nil {
+		return nil, superr.Wrap(ErrRequestFail, &wsConnError{fmt.Errorf("failed to connect to websocket: %w", err)})
+	}
+
+	if err := conn.BatchCallContext(ctx, elems); err != nil {
+		p.router.closeWSConn(conn)
+		return nil, superr.Wrap(ErrRequestFail, &wsConnError{fmt.Errorf("failed to send request: %w", err)})
+	}
+
+	responses := make([]jsonrpc.Message, len(batch))
+	for i, elem := range elems {
+		responses[i] = jsonrpc.Message{Version: "2.0", ID: batch[i].request.ID}
+		if elem.Error != nil {
+			responses[i].Error = toJSONRPCError(elem.Error)
+			batch[i].err = callError(responses[i].Error)
+		} else {
+			responses[i].Result = results[i]
+		}
+		batch[i].response = &responses[i]
+	}
+
+	// the body is returned as it would be over http
+	var body []byte
+	if len(responses) == 1 {
+		body, err = json.Marshal(responses[0])
+	} else {
+		body, err = json.Marshal(responses)
+	}
+	if err != nil {
+		return nil, superr.Wrap(ErrRequestFail, fmt.Errorf("failed to marshal response: %w", err))
+	}
+
+	return body, handleResponses(calls, batch)
+}
+
+// toJSONRPCError converts an error returned by the node over the websocket connection.
+func toJSONRPCError(err error) *jsonrpc.Error {
+	rpcErr := &jsonrpc.Error{Message: err.Error()}
+
+	var codeErr rpc.Error
+	if errors.As(err, &codeErr) {
+		rpcErr.Code = codeErr.ErrorCode()
+	}
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+		if data, err := json.Marshal(dataErr.ErrorData()); err == nil {
+			rpcErr.Data = data
+		}
+	}
+	return rpcErr
+}
+
+// isTransportError reports whether the error means the transport is unavailable,
+// ie. a connection failure or an unavailable node, so the call may succeed over
+// the other transport.
+func isTransportError(err error) bool {
+	for _, e := range superr.GetErrorStack(err) {
+		var statusErr *httpStatusError
+		if errors.As(e, &statusErr) {
+			if unavailableStatusCodes[statusErr.statusCode] {
+				return true
+			}
+			continue
+		}
+
+		var wsErr *wsConnError
+		var netErr net.Error
+		if errors.As(e, &wsErr) || errors.As(e, &netErr) || errors.Is(e, io.EOF) || errors.Is(e, io.ErrUnexpectedEOF) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,312 @@
+package ethrpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/event"
+	"github.com/0xsequence/ethkit/go-ethereum/rpc"
+)
+
+const (
+	// reliableSubscribeMinBackoff and reliableSubscribeMaxBackoff bound the wait between
+	// reconnect attempts of a SubscribeLogsReliable stream.
+	reliableSubscribeMinBackoff = 1 * time.Second
+	reliableSubscribeMaxBackoff = 30 * time.Second
+
+	// streamReconnectBufferSize is the number of messages of a stream which are buffered
+	// while they're being delivered to the subscriber, including across a reconnect.
+	streamReconnectBufferSize = 64
+)
+
+// SubscribeLogsReliable is like SubscribeFilterLogs, except that the websocket stream is
+// re-established whenever it drops, and any logs emitted while disconnected are
+// backfilled via eth_getLogs from the last delivered block before the live stream resumes.
+// Logs which have already been delivered are not delivered again.
+//
+// The subscription is closed when the context is cancelled or Unsubscribe is called.
+func (p *Provider) SubscribeLogsReliable(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	if !p.IsStreamingEnabled() {
+		return nil, fmt.Errorf("ethrpc: provider instance has not enabled streaming")
+	}
+
+	// the first backfill will start from the query's fromBlock, otherwise from the
+	// head at the time of subscribing
+	cursor := &logCursor{}
+	if query.FromBlock != nil {
+		cursor.blockNum = query.FromBlock.Uint64()
+	} else {
+		head, err := p.BlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ethrpc: SubscribeLogsReliable failed: %w", err)
+		}
+		cursor.blockNum = head
+	}
+
+	// the stream is reconnected here so that the logs while disconnected are backfilled,
+	// so the subscription is made without WithStreamReconnect
+	logCh := make(chan types.Log, 256)
+	sub, err := p.subscribeFilterLogs(ctx, query, logCh)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer func() {
+			if sub != nil {
+				sub.Unsubscribe()
+			}
+		}()
+
+		backoff := reliableSubscribeMinBackoff
+		backfill := query.FromBlock != nil
+
+		for {
+			if backfill {
+				logs, err := p.FilterLogs(ctx, cursor.backfillQuery(query))
+				// on failure, the backfill is retried after the backoff
+				if err == nil {
+					backfill = false
+					for _, log := range logs {
+						if !cursor.deliver(ctx, quit, ch, log) {
+							return nil
+						}
+					}
+				}
+			}
+
+			if sub != nil && !backfill {
+				backoff = reliableSubscribeMinBackoff
+			streamLoop:
+				for {
+					select {
+					case <-ctx.Done():
+						return nil
+					case <-quit:
+						return nil
+					case <-sub.Err():
+						break streamLoop
+					case log := <-logCh:
+						if !cursor.deliver(ctx, quit, ch, log) {
+							return nil
+						}
+					}
+				}
+				sub.Unsubscribe()
+				sub = nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-quit:
+				return nil
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > reliableSubscribeMaxBackoff {
+				backoff = reliableSubscribeMaxBackoff
+			}
+
+			// resubscribe before backfilling, so that logs emitted in between are
+			// picked up by the stream and deduplicated by the cursor
+			if sub == nil {
+				sub, err = p.subscribeFilterLogs(ctx, query, logCh)
+				if err != nil {
+					sub = nil
+					continue
+				}
+				backfill = true
+			}
+		}
+	}), nil
+}
+
+// logCursor tracks the position of the last log delivered to a reliable subscriber.
+type logCursor struct {
+	blockNum  uint64
+	logIndex  uint
+	delivered bool
+}
+
+// backfillQuery returns the query for logs from the cursor block to the latest block.
+func (c *logCursor) backfillQuery(query ethereum.FilterQuery) ethereum.FilterQuery {
+	q := query
+	q.BlockHash = nil
+	q.FromBlock = new(big.Int).SetUint64(c.blockNum)
+	q.ToBlock = nil
+	return q
+}
+
+// seen reports whether the log is at or behind the cursor. Removed logs are always
+// passed through so subscribers are notified of reorgs.
+func (c *logCursor) seen(log types.Log) bool {
+	if !c.delivered || log.Removed {
+		return false
+	}
+	if log.BlockNumber != c.blockNum {
+		return log.BlockNumber < c.blockNum
+	}
+	return log.Index <= c.logIndex
+}
+
+// deliver sends the log to ch unless it has already been delivered, and advances the
+// cursor. It returns false if the subscription was closed while sending.
+func (c *logCursor) deliver(ctx context.Context, quit <-chan struct{}, ch chan<- types.Log, log types.Log) bool {
+	if c.seen(log) {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-quit:
+		return false
+	case ch <- log:
+	}
+	if log.Removed {
+		c.rewind(log)
+	} else {
+		c.blockNum, c.logIndex, c.delivered = log.BlockNumber, log.Index, true
+	}
+	return true
+}
+
+// rewind moves the cursor back to before the block of a removed log, so that the logs
+// which replace it after the reorg are delivered and not dropped as already seen.
+func (c *logCursor) rewind(log types.Log) {
+	if !c.delivered || log.BlockNumber > c.blockNum {
+		return
+	}
+	if log.BlockNumber == 0 {
+		c.blockNum, c.logIndex, c.delivered = 0, 0, false
+		return
+	}
+	c.blockNum, c.logIndex = log.BlockNumber-1, math.MaxUint
+}
+
+// subscribeStream subscribes to the stream over the websocket connection, which is
+// re-established along with the subscription when it drops if WithStreamReconnect is set.
+func subscribeStream[T any](p *Provider, ctx context.Context, label string, ch chan<- T, subscribeFn func(conn *rpc.Client, ch chan<- T) (ethereum.Subscription, error)) (ethereum.Subscription, error) {
+	if p.streamReconnect == nil {
+		return p.streamSubscribe(ctx, label, func(conn *rpc.Client) (ethereum.Subscription, error) {
+			return subscribeFn(conn, ch)
+		})
+	}
+	if !p.IsStreamingEnabled() {
+		return nil, fmt.Errorf("ethrpc: provider instance has not enabled streaming")
+	}
+
+	buf := make(chan T, streamReconnectBufferSize)
+	subscribe := func() (func(failed bool), ethereum.Subscription, error) {
+		conn, release, err := p.dialStream(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to websocket: %w", err)
+		}
+		sub, err := subscribeFn(conn, buf)
+		if err != nil {
+			release(true)
+			return nil, nil, err
+		}
+		return release, sub, nil
+	}
+
+	release, sub, err := subscribe()
+	if err != nil {
+		return nil, fmt.Errorf("ethrpc: %s failed: %w", label, err)
+	}
+
+	stream := event.NewSubscription(func(quit <-chan struct{}) error {
+		defer func() {
+			if sub != nil {
+				sub.Unsubscribe()
+				release(false)
+			}
+		}()
+
+		r := p.streamReconnect
+		for {
+			// deliver messages until the connection drops
+			var subErr error
+		streamLoop:
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-quit:
+					return nil
+				case subErr = <-sub.Err():
+					break streamLoop
+				case msg := <-buf:
+					select {
+					case <-ctx.Done():
+						return nil
+					case <-quit:
+						return nil
+					case ch <- msg:
+					}
+				}
+			}
+			sub.Unsubscribe()
+			release(true)
+			sub, release = nil, nil
+
+			if p.log != nil {
+				p.log.Warnf("ethrpc: %s stream dropped, reconnecting: %v", label, subErr)
+			}
+
+			// reconnect with an exponential backoff, where the messages still buffered
+			// are delivered once resubscribed
+			delay := r.baseDelay
+			for attempt := 1; ; attempt++ {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-quit:
+					return nil
+				case <-time.After(delay):
+				}
+
+				release, sub, err = subscribe()
+				if err == nil {
+					break
+				}
+				if r.maxAttempts > 0 && attempt >= r.maxAttempts {
+					return fmt.Errorf("ethrpc: %s failed to reconnect after %d attempts: %w", label, attempt, err)
+				}
+
+				delay *= 2
+				if r.maxDelay > 0 && delay > r.maxDelay {
+					delay = r.maxDelay
+				}
+			}
+		}
+	})
+
+	p.mu.Lock()
+	p.streamUnsubscribers = append(p.streamUnsubscribers, stream)
+	p.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Unsubscribe()
+		case <-stream.Err():
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i, unsub := range p.streamUnsubscribers {
+			if unsub == stream {
+				p.streamUnsubscribers = append(p.streamUnsubscribers[:i], p.streamUnsubscribers[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	return stream, nil
+}
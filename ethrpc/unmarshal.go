@@ -13,7 +13,7 @@ import (
 )
 
 type rpcBlock struct {
-	Hash         common.Hash       `json:"hash"`
+	Hash         *common.Hash      `json:"hash"` // nil for the pending block
 	Transactions []rpcTransaction  `json:"transactions"`
 	UncleHashes  []common.Hash     `json:"uncles"`
 	Withdrawals  types.Withdrawals `json:"withdrawals"`
@@ -107,11 +107,16 @@ func IntoBlock(raw json.RawMessage, ret **types.Block, strictness StrictnessLeve
 		return err
 	}
 
+	var blockHash common.Hash
+	if body.Hash != nil {
+		blockHash = *body.Hash
+	}
+
 	// Fill the sender cache of transactions in the block.
 	txs := make([]*types.Transaction, 0, len(body.Transactions))
 	for _, tx := range body.Transactions {
 		if tx.From != nil {
-			setSenderFromServer(tx.tx, *tx.From, body.Hash)
+			setSenderFromServer(tx.tx, *tx.From, blockHash)
 		}
 
 		if strictness >= StrictnessLevel_Semi && tx.txVRSInvalid {
@@ -148,8 +153,8 @@ func IntoBlock(raw json.RawMessage, ret **types.Block, strictness StrictnessLeve
 	// ...
 	if strictness == StrictnessLevel_Strict {
 		block.SetHash(block.ComputedBlockHash())
-	} else {
-		block.SetHash(body.Hash)
+	} else if body.Hash != nil {
+		block.SetHash(*body.Hash)
 	}
 
 	*ret = block
@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
 	"github.com/0xsequence/ethkit/go-ethereum"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/goware/superr"
 )
 
 func WaitForTxnReceipt(ctx context.Context, provider *Provider, txHash common.Hash) (*types.Receipt, error) {
@@ -39,3 +44,76 @@ func WaitForTxnReceipt(ctx context.Context, provider *Provider, txHash common.Ha
 		time.Sleep(1 * time.Second)
 	}
 }
+
+// methodDoesNotExistRegexp matches the method not found messages of nodes which don't
+// respond with the -32601 code, ie. "the method debug_traceCall does not exist/is not available".
+var methodDoesNotExistRegexp = regexp.MustCompile(`method [\w.]+ does not exist`)
+
+// isMethodNotFoundError reports whether the error is the node responding that the
+// JSON-RPC method does not exist. Other errors which merely read as something being
+// unavailable, ie. "header not available", are not matched.
+func isMethodNotFoundError(err error) bool {
+	for _, e := range superr.GetErrorStack(err) {
+		var rpcErr *jsonrpc.Error
+		if !errors.As(e, &rpcErr) {
+			continue
+		}
+		if rpcErr.Code == -32601 {
+			return true
+		}
+		msg := strings.ToLower(rpcErr.Message)
+		if strings.Contains(msg, "method not found") || methodDoesNotExistRegexp.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInvalidParamsError reports whether the error is the node responding that the params
+// of the JSON-RPC call are invalid, which means the method exists.
+func isInvalidParamsError(err error) bool {
+	for _, e := range superr.GetErrorStack(err) {
+		var rpcErr *jsonrpc.Error
+		if errors.As(e, &rpcErr) && rpcErr.Code == -32602 {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingError returns the error of a read of the pending block or state, wrapped with
+// ErrPendingNotSupported if the node responded that it doesn't support the "pending" tag.
+func pendingError(err error) error {
+	if err == nil || !isPendingNotSupportedError(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrPendingNotSupported, err)
+}
+
+// pendingBlockError is pendingError of a read of the block by number, where the node
+// having no pending block is ErrPendingNotSupported as well.
+func pendingBlockError(blockNum *big.Int, err error) error {
+	if err == nil || blockNum == nil || blockNum.Cmp(Pending) != 0 {
+		return err
+	}
+	if errors.Is(err, ethereum.NotFound) {
+		return fmt.Errorf("%w: %w", ErrPendingNotSupported, err)
+	}
+	return pendingError(err)
+}
+
+// isPendingNotSupportedError reports whether the error is the node responding that the
+// "pending" block tag is not supported, as opposed to ie. a reverted call.
+func isPendingNotSupportedError(err error) bool {
+	for _, e := range superr.GetErrorStack(err) {
+		var rpcErr *jsonrpc.Error
+		if !errors.As(e, &rpcErr) || rpcErr.Code == 3 {
+			continue
+		}
+		msg := strings.ToLower(rpcErr.Message)
+		if strings.Contains(msg, "pending") || strings.Contains(msg, "block tag") || strings.Contains(msg, "unsupported block") {
+			return true
+		}
+	}
+	return false
+}
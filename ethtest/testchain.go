@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,6 +20,7 @@ import (
 	"github.com/0xsequence/ethkit/ethwallet"
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 )
 
@@ -27,6 +30,9 @@ type Testchain struct {
 	chainID        *big.Int         // chainID determined by the test chain
 	walletMnemonic string           // test wallet mnemonic parsed from package.json
 	Provider       *ethrpc.Provider // provider rpc to the test chain
+
+	flavor string // node implementation, detected from the client version
+	mu     sync.Mutex
 }
 
 type TestchainOptions struct {
@@ -317,6 +323,143 @@ func (c *Testchain) WaitMined(txn common.Hash) error {
 	return err
 }
 
+// Mine will mine n blocks on demand, so tests can advance the chain precisely
+// instead of waiting for blocks to be mined.
+func (c *Testchain) Mine(n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	flavor, err := c.nodeFlavor()
+	if err != nil {
+		return err
+	}
+
+	var method string
+	switch flavor {
+	case nodeFlavorAnvil:
+		method = "anvil_mine"
+	case nodeFlavorHardhat:
+		method = "hardhat_mine"
+	default:
+		// evm_mine mines a single block on any other node
+		for i := 0; i < n; i++ {
+			_, err := c.Provider.Do(context.Background(), ethrpc.NewCall("evm_mine"))
+			if err != nil {
+				return fmt.Errorf("ethtest: evm_mine failed: %w", err)
+			}
+		}
+		return nil
+	}
+
+	_, err = c.Provider.Do(context.Background(), ethrpc.NewCall(method, hexutil.EncodeUint64(uint64(n))))
+	if err != nil {
+		return fmt.Errorf("ethtest: %s failed: %w", method, err)
+	}
+	return nil
+}
+
+// SetAutomine toggles whether the node mines a new block for each transaction sent.
+//
+// NOTE: the testchain is also configured with interval mining, which is not affected.
+func (c *Testchain) SetAutomine(enabled bool) error {
+	flavor, err := c.nodeFlavor()
+	if err != nil {
+		return err
+	}
+
+	method := "evm_setAutomine"
+	if flavor == nodeFlavorAnvil {
+		method = "anvil_setAutomine"
+	}
+
+	_, err = c.Provider.Do(context.Background(), ethrpc.NewCall(method, enabled))
+	if err != nil {
+		return fmt.Errorf("ethtest: %s failed: %w", method, err)
+	}
+	return nil
+}
+
+// Snapshot takes a snapshot of the state of the chain with evm_snapshot, and returns its
+// id, which can be passed to Revert to restore the chain to it.
+func (c *Testchain) Snapshot() (string, error) {
+	var id string
+	call := ethrpc.NewCallBuilder[string]("evm_snapshot", nil)
+	_, err := c.Provider.Do(context.Background(), call.Into(&id))
+	if err != nil {
+		return "", fmt.Errorf("ethtest: evm_snapshot failed: %w", err)
+	}
+	return id, nil
+}
+
+// Revert restores the state of the chain to the snapshot id with evm_revert.
+//
+// NOTE: a snapshot can only be reverted to once, as reverting deletes it along with
+// the snapshots taken after it, so take a new snapshot to revert to the same state again.
+func (c *Testchain) Revert(id string) error {
+	var reverted bool
+	call := ethrpc.NewCallBuilder[bool]("evm_revert", nil, id)
+	_, err := c.Provider.Do(context.Background(), call.Into(&reverted))
+	if err != nil {
+		return fmt.Errorf("ethtest: evm_revert failed: %w", err)
+	}
+	if !reverted {
+		return fmt.Errorf("ethtest: evm_revert failed: snapshot %s not found", id)
+	}
+	return nil
+}
+
+// MustSnapshot takes a snapshot of the chain, and returns a func which reverts the
+// chain to it, so a test which mutates the chain state can run against a clean chain:
+//
+//	defer testchain.MustSnapshot(t)()
+func (c *Testchain) MustSnapshot(t *testing.T) func() {
+	id, err := c.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if err := c.Revert(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const (
+	nodeFlavorHardhat = "hardhat"
+	nodeFlavorAnvil   = "anvil"
+	nodeFlavorUnknown = "unknown"
+)
+
+// nodeFlavor detects the test node implementation from its client version, ie.
+// "HardhatNetwork/2.22.2/@ethereumjs/vm/7.0.2" or "anvil/v0.2.0".
+func (c *Testchain) nodeFlavor() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.flavor != "" {
+		return c.flavor, nil
+	}
+
+	var clientVersion string
+	call := ethrpc.NewCallBuilder[string]("web3_clientVersion", nil)
+	_, err := c.Provider.Do(context.Background(), call.Into(&clientVersion))
+	if err != nil {
+		return "", fmt.Errorf("ethtest: failed to get client version: %w", err)
+	}
+
+	clientVersion = strings.ToLower(clientVersion)
+	switch {
+	case strings.HasPrefix(clientVersion, "hardhat"):
+		c.flavor = nodeFlavorHardhat
+	case strings.HasPrefix(clientVersion, "anvil"):
+		c.flavor = nodeFlavorAnvil
+	default:
+		c.flavor = nodeFlavorUnknown
+	}
+	return c.flavor, nil
+}
+
 func (c *Testchain) RandomNonce() *big.Int {
 	space := big.NewInt(int64(time.Now().Nanosecond()))
 	return space
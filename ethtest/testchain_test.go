@@ -1,8 +1,10 @@
 package ethtest_test
 
 import (
+	"context"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/ethtest"
@@ -54,3 +56,48 @@ func TestContractHelpers(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(143), result.Uint64())
 }
+
+func TestMine(t *testing.T) {
+	head, err := testchain.Provider.BlockNumber(context.Background())
+	assert.NoError(t, err)
+
+	assert.NoError(t, testchain.Mine(5))
+
+	latest, err := testchain.Provider.BlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, latest, head+5)
+}
+
+func TestSnapshot(t *testing.T) {
+	// fresh wallet, which is unfunded
+	wallet, err := testchain.DummyWallet(uint64(time.Now().UnixNano()))
+	assert.NoError(t, err)
+
+	before, err := testchain.Provider.BalanceAt(context.Background(), wallet.Address(), nil)
+	assert.NoError(t, err)
+
+	id, err := testchain.Snapshot()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	assert.NoError(t, testchain.FundAddress(wallet.Address(), 1))
+
+	funded, err := testchain.Provider.BalanceAt(context.Background(), wallet.Address(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, funded.Cmp(before))
+
+	assert.NoError(t, testchain.Revert(id))
+
+	reverted, err := testchain.Provider.BalanceAt(context.Background(), wallet.Address(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reverted.Cmp(before))
+
+	// the snapshot is deleted once reverted to
+	assert.Error(t, testchain.Revert(id))
+
+	t.Run("MustSnapshot", func(t *testing.T) {
+		revert := testchain.MustSnapshot(t)
+		assert.NoError(t, testchain.Mine(1))
+		revert()
+	})
+}
@@ -0,0 +1,80 @@
+package ethtxn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var ErrAccessListUnsupported = errors.New("ethtxn: access lists are unsupported on this chain")
+
+// CreateAccessList returns a copy of the transaction request with its AccessList populated
+// from eth_createAccessList, of the accounts and storage keys accessed by the transaction,
+// which reduces the gas of transactions accessing many storage keys of other contracts.
+// The entries which cost more gas than they save are pruned, see ethcoder.PruneAccessList,
+// and the AccessList is left nil if none are worth including.
+//
+// ErrAccessListUnsupported is returned on legacy chains, which don't support access lists.
+func CreateAccessList(ctx context.Context, provider *ethrpc.Provider, txnRequest *TransactionRequest) (*TransactionRequest, error) {
+	if txnRequest == nil {
+		return nil, fmt.Errorf("ethtxn: txnRequest is required")
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("ethtxn: provider is not set")
+	}
+
+	if err := checkAccessListSupport(ctx, provider); err != nil {
+		return nil, err
+	}
+
+	callMsg := ethereum.CallMsg{
+		From:  txnRequest.From,
+		To:    txnRequest.To,
+		Gas:   txnRequest.GasLimit,
+		Value: txnRequest.ETHValue,
+		Data:  txnRequest.Data,
+	}
+
+	result, err := provider.CreateAccessList(ctx, callMsg, nil)
+	if errors.Is(err, ethrpc.ErrUnsupportedMethodOnChain) {
+		return nil, fmt.Errorf("%w: %w", ErrAccessListUnsupported, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to create access list: %w", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("ethtxn: failed to create access list: %s", result.Error)
+	}
+
+	// the sender and the recipient are always warm
+	warmAddresses := []common.Address{txnRequest.From}
+	if txnRequest.To != nil {
+		warmAddresses = append(warmAddresses, *txnRequest.To)
+	}
+
+	txr := *txnRequest
+	txr.AccessList = ethcoder.PruneAccessList(result.AccessList, warmAddresses...)
+	if len(txr.AccessList) == 0 {
+		txr.AccessList = nil
+	}
+	return &txr, nil
+}
+
+// checkAccessListSupport returns ErrAccessListUnsupported if the chain is a legacy chain.
+// Chains without a base fee in their blocks, ie. before the London fork, are considered
+// legacy chains, as the Berlin fork introducing access lists shortly preceded it.
+func checkAccessListSupport(ctx context.Context, provider *ethrpc.Provider) error {
+	head, err := provider.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ethtxn: failed to get latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return ErrAccessListUnsupported
+	}
+	return nil
+}
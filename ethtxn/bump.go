@@ -0,0 +1,330 @@
+package ethtxn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// minReplacementBumpPercent is the minimum fee increase nodes require to replace a
+// pending transaction with the same nonce, ie. geth's default txpool.pricebump.
+const minReplacementBumpPercent = 10
+
+// Signer is the account of the pending transactions to bump, ie. an *ethwallet.Wallet.
+type Signer interface {
+	Address() common.Address
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// txpoolContent is the response of txpool_contentFrom, of the transactions by nonce.
+type txpoolContent struct {
+	Pending map[string]*types.Transaction `json:"pending"`
+}
+
+// BumpAllPending speeds up all of the pending transactions of an account, by re-signing
+// and re-sending each of them with the same nonce and their fees increased by bumpPercent.
+// The fees are always increased by at least the minimum required by nodes to replace a
+// pending transaction. The pending transactions are read from the node's txpool, so the
+// node must support txpool_contentFrom.
+//
+// Transactions which have been mined in the meantime are skipped. The replacement
+// transactions sent are returned in nonce order, along with the error of the first
+// transaction which failed to be replaced.
+func BumpAllPending(ctx context.Context, provider *ethrpc.Provider, wallet Signer, bumpPercent int) ([]*types.Transaction, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ethtxn: provider is not set")
+	}
+	if wallet == nil {
+		return nil, fmt.Errorf("ethtxn: wallet is required")
+	}
+	if bumpPercent <= 0 {
+		return nil, fmt.Errorf("ethtxn: bumpPercent must be positive")
+	}
+
+	content, err := fetchTxpoolContent(ctx, provider, wallet.Address())
+	if err != nil {
+		return nil, err
+	}
+	if len(content.Pending) == 0 {
+		return nil, nil
+	}
+
+	pending := make([]*types.Transaction, 0, len(content.Pending))
+	for _, txn := range content.Pending {
+		if txn != nil {
+			pending = append(pending, txn)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Nonce() < pending[j].Nonce()
+	})
+
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: %w", err)
+	}
+
+	// the txpool can still hold transactions which have just been mined
+	minedNonce, err := provider.NonceAt(ctx, wallet.Address(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to get nonce: %w", err)
+	}
+
+	var bumped []*types.Transaction
+	for _, txn := range pending {
+		if txn.Nonce() < minedNonce {
+			continue
+		}
+
+		replacement, err := bumpTransaction(txn, chainID, bumpPercent)
+		if err != nil {
+			return bumped, err
+		}
+		signedTx, err := wallet.SignTx(replacement, chainID)
+		if err != nil {
+			return bumped, fmt.Errorf("ethtxn: failed to sign replacement of nonce %d: %w", txn.Nonce(), err)
+		}
+
+		_, _, err = SendTransaction(ctx, provider, signedTx)
+		if errors.Is(err, ErrNonceTooLow) {
+			// mined since we checked
+			continue
+		}
+		if err != nil {
+			return bumped, fmt.Errorf("ethtxn: failed to send replacement of nonce %d: %w", txn.Nonce(), err)
+		}
+		bumped = append(bumped, signedTx)
+	}
+
+	return bumped, nil
+}
+
+// SpeedUp returns a replacement of the pending transaction of the wallet, with the same
+// nonce, recipient, value and data, and its fees increased by bumpPercent, signed by the
+// wallet and ready to be sent with SendTransaction. The replacement is only accepted by
+// nodes if its fees are increased by at least their replacement minimum, typically 10%, so
+// a lower bumpPercent returns ErrReplacementUnderpriced. ErrNonceTooLow is returned if the
+// transaction has already been mined.
+func SpeedUp(ctx context.Context, provider *ethrpc.Provider, wallet Signer, txn *types.Transaction, bumpPercent int) (*types.Transaction, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ethtxn: provider is not set")
+	}
+	if wallet == nil {
+		return nil, fmt.Errorf("ethtxn: wallet is required")
+	}
+	if txn == nil {
+		return nil, fmt.Errorf("ethtxn: txn is required")
+	}
+	if err := checkReplacementBump(bumpPercent); err != nil {
+		return nil, err
+	}
+
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: %w", err)
+	}
+	if v, r, s := txn.RawSignatureValues(); v.Sign() != 0 || r.Sign() != 0 || s.Sign() != 0 {
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), txn)
+		if err != nil {
+			return nil, fmt.Errorf("ethtxn: invalid transaction signature: %w", err)
+		}
+		if sender != wallet.Address() {
+			return nil, fmt.Errorf("ethtxn: transaction %s is from %s, not the wallet %s", txn.Hash(), sender, wallet.Address())
+		}
+	}
+	if err := checkNonceNotMined(ctx, provider, wallet.Address(), txn.Nonce()); err != nil {
+		return nil, err
+	}
+
+	replacement, err := bumpTransaction(txn, chainID, bumpPercent)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := wallet.SignTx(replacement, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to sign replacement of nonce %d: %w", txn.Nonce(), err)
+	}
+	return signedTx, nil
+}
+
+// Cancel returns a transaction which cancels the pending transaction of the wallet with
+// the nonce, by replacing it with a zero value transfer to the wallet itself, signed by
+// the wallet and ready to be sent with SendTransaction.
+//
+// The fees of the pending transaction are read from the node's txpool with
+// txpool_contentFrom, and increased by bumpPercent, where a bumpPercent below the nodes
+// replacement minimum, typically 10%, returns ErrReplacementUnderpriced. If the node
+// doesn't support txpool_contentFrom, or the transaction isn't in its txpool, the current
+// suggested fees of the network are increased by bumpPercent instead. ErrNonceTooLow is
+// returned if the nonce has already been mined.
+func Cancel(ctx context.Context, provider *ethrpc.Provider, wallet Signer, nonce uint64, bumpPercent int) (*types.Transaction, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ethtxn: provider is not set")
+	}
+	if wallet == nil {
+		return nil, fmt.Errorf("ethtxn: wallet is required")
+	}
+	if err := checkReplacementBump(bumpPercent); err != nil {
+		return nil, err
+	}
+
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: %w", err)
+	}
+	if err := checkNonceNotMined(ctx, provider, wallet.Address(), nonce); err != nil {
+		return nil, err
+	}
+
+	self := wallet.Address()
+	var cancelTx *types.Transaction
+
+	content, err := fetchTxpoolContent(ctx, provider, self)
+	if pending := content.Pending[strconv.FormatUint(nonce, 10)]; err == nil && pending != nil {
+		// a self-transfer with the fees of the pending transaction
+		switch pending.Type() {
+		case types.DynamicFeeTxType:
+			cancelTx = types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: nonce, GasTipCap: pending.GasTipCap(), GasFeeCap: pending.GasFeeCap(), Gas: 21000, To: &self})
+		default:
+			cancelTx = types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: pending.GasPrice(), Gas: 21000, To: &self})
+		}
+	} else {
+		cancelTx, err = cancelTxWithNetworkFees(ctx, provider, chainID, self, nonce)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	replacement, err := bumpTransaction(cancelTx, chainID, bumpPercent)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := wallet.SignTx(replacement, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to sign cancellation of nonce %d: %w", nonce, err)
+	}
+	return signedTx, nil
+}
+
+// cancelTxWithNetworkFees returns a self-transfer with the current suggested fees of the
+// network, for when the fees of the transaction to cancel are unknown.
+func cancelTxWithNetworkFees(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, self common.Address, nonce uint64) (*types.Transaction, error) {
+	head, err := provider.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to get latest block: %w", err)
+	}
+
+	if head.BaseFee == nil {
+		gasPrice, err := provider.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ethtxn: %w", err)
+		}
+		return types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: gasPrice, Gas: 21000, To: &self}), nil
+	}
+
+	gasTip, err := provider.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: %w", err)
+	}
+	// allow the base fee to double before the transaction is no longer includable
+	gasFeeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), gasTip)
+	return types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: nonce, GasTipCap: gasTip, GasFeeCap: gasFeeCap, Gas: 21000, To: &self}), nil
+}
+
+// checkReplacementBump returns ErrReplacementUnderpriced if bumpPercent is below the
+// minimum fee increase nodes require to replace a pending transaction.
+func checkReplacementBump(bumpPercent int) error {
+	if bumpPercent < minReplacementBumpPercent {
+		return fmt.Errorf("%w: bumpPercent %d is below the minimum of %d%%", ErrReplacementUnderpriced, bumpPercent, minReplacementBumpPercent)
+	}
+	return nil
+}
+
+// checkNonceNotMined returns ErrNonceTooLow if a transaction of the account with the
+// nonce has already been mined.
+func checkNonceNotMined(ctx context.Context, provider *ethrpc.Provider, account common.Address, nonce uint64) error {
+	minedNonce, err := provider.NonceAt(ctx, account, nil)
+	if err != nil {
+		return fmt.Errorf("ethtxn: failed to get nonce: %w", err)
+	}
+	if nonce < minedNonce {
+		return fmt.Errorf("%w: nonce %d has already been mined", ErrNonceTooLow, nonce)
+	}
+	return nil
+}
+
+// fetchTxpoolContent returns the transactions of the account in the node's txpool.
+func fetchTxpoolContent(ctx context.Context, provider *ethrpc.Provider, account common.Address) (txpoolContent, error) {
+	var content txpoolContent
+	call := ethrpc.NewCallBuilder[txpoolContent]("txpool_contentFrom", nil, account)
+	_, err := provider.Do(ctx, call.Into(&content))
+	if err != nil {
+		return txpoolContent{}, fmt.Errorf("ethtxn: failed to get pending transactions: %w", err)
+	}
+	return content, nil
+}
+
+// bumpTransaction returns an unsigned copy of the transaction with its fees bumped.
+func bumpTransaction(txn *types.Transaction, chainID *big.Int, bumpPercent int) (*types.Transaction, error) {
+	switch txn.Type() {
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    txn.Nonce(),
+			GasPrice: bumpFee(txn.GasPrice(), bumpPercent),
+			Gas:      txn.Gas(),
+			To:       txn.To(),
+			Value:    txn.Value(),
+			Data:     txn.Data(),
+		}), nil
+
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      txn.Nonce(),
+			GasPrice:   bumpFee(txn.GasPrice(), bumpPercent),
+			Gas:        txn.Gas(),
+			To:         txn.To(),
+			Value:      txn.Value(),
+			Data:       txn.Data(),
+			AccessList: txn.AccessList(),
+		}), nil
+
+	case types.DynamicFeeTxType:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      txn.Nonce(),
+			GasTipCap:  bumpFee(txn.GasTipCap(), bumpPercent),
+			GasFeeCap:  bumpFee(txn.GasFeeCap(), bumpPercent),
+			Gas:        txn.Gas(),
+			To:         txn.To(),
+			Value:      txn.Value(),
+			Data:       txn.Data(),
+			AccessList: txn.AccessList(),
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("ethtxn: unable to bump transaction %s of type %d", txn.Hash(), txn.Type())
+	}
+}
+
+// bumpFee increases the fee by bumpPercent, and by at least the minimum nodes require
+// to replace a transaction, rounding up.
+func bumpFee(fee *big.Int, bumpPercent int) *big.Int {
+	if bumpPercent < minReplacementBumpPercent {
+		bumpPercent = minReplacementBumpPercent
+	}
+	bumped := new(big.Int).Mul(fee, big.NewInt(int64(100+bumpPercent)))
+	bumped.Add(bumped, big.NewInt(99))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(fee) <= 0 {
+		bumped.Add(fee, big.NewInt(1))
+	}
+	return bumped
+}
@@ -0,0 +1,211 @@
+package ethtxn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// Bundle is a set of signed transactions which are included in order within the same
+// block, or not at all. Bundles are submitted to MEV relays with eth_sendBundle, see
+// BundleRelay, and are never seen by the public mempool.
+type Bundle struct {
+	// Txns are the signed transactions of the bundle, in order of execution.
+	Txns []*types.Transaction
+
+	// BlockNumber is the only block the bundle is valid for. Bundles which are not
+	// included in it must be re-submitted for a later block.
+	BlockNumber uint64
+
+	// MinTimestamp and MaxTimestamp optionally bound the timestamp of the block the
+	// bundle is valid for, in seconds. A value of 0 sets no bound.
+	MinTimestamp uint64
+	MaxTimestamp uint64
+
+	// RevertingTxHashes are the hashes of the transactions of the bundle which are
+	// allowed to revert, while the bundle is dropped if any other transaction reverts.
+	RevertingTxHashes []common.Hash
+}
+
+// NewBundle prepares and signs the transaction requests as a bundle for blockNumber.
+// Requests without a nonce are assigned consecutive nonces from the wallet's pending
+// nonce, and the From of all requests is set to the wallet.
+//
+// NOTE: gas is estimated against the current state, rather than after the previous
+// transactions of the bundle, so set the GasLimit of requests which depend on them.
+func NewBundle(ctx context.Context, provider *ethrpc.Provider, wallet Signer, blockNumber uint64, txnRequests ...*TransactionRequest) (*Bundle, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ethtxn: provider is not set")
+	}
+	if wallet == nil {
+		return nil, fmt.Errorf("ethtxn: wallet is required")
+	}
+	if len(txnRequests) == 0 {
+		return nil, fmt.Errorf("ethtxn: bundle requires at least one txnRequest")
+	}
+
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to get chain id: %w", err)
+	}
+
+	nonce, err := provider.PendingNonceAt(ctx, wallet.Address())
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to get pending nonce: %w", err)
+	}
+
+	bundle := &Bundle{
+		Txns:        make([]*types.Transaction, 0, len(txnRequests)),
+		BlockNumber: blockNumber,
+	}
+	for _, txnRequest := range txnRequests {
+		if txnRequest == nil {
+			return nil, fmt.Errorf("ethtxn: txnRequest is required")
+		}
+
+		txr := *txnRequest
+		txr.From = wallet.Address()
+		if txr.Nonce == nil {
+			txr.Nonce = new(big.Int).SetUint64(nonce)
+			nonce++
+		}
+
+		rawTx, err := NewTransaction(ctx, provider, &txr)
+		if err != nil {
+			return nil, err
+		}
+		signedTx, err := wallet.SignTx(rawTx, chainID)
+		if err != nil {
+			return nil, fmt.Errorf("ethtxn: failed to sign transaction: %w", err)
+		}
+		bundle.Txns = append(bundle.Txns, signedTx)
+	}
+
+	return bundle, nil
+}
+
+// BundleAuthSigner signs the payloads sent to a BundleRelay, ie. an *ethwallet.Wallet.
+// Relays use its address to identify the searcher and build its reputation, so it
+// doesn't need to hold any funds, and is best kept separate from the wallets signing
+// the transactions.
+type BundleAuthSigner interface {
+	Address() common.Address
+	SignMessage(message []byte) ([]byte, error)
+}
+
+// BundleRelay submits bundles to a Flashbots-compatible MEV relay, ie.
+// https://relay.flashbots.net.
+//
+// NOTE: eth_sendBundle is not part of the standard Ethereum JSON-RPC API, and is only
+// served by relays and builders, not regular nodes, so the relay is configured separately
+// from the provider of the chain. Relays are only available on some chains, and a bundle
+// accepted by the relay is not guaranteed to be included.
+type BundleRelay struct {
+	provider *ethrpc.Provider
+}
+
+// NewBundleRelay returns a relay client for relayURL, which signs every request payload
+// with authSigner in the X-Flashbots-Signature header, as required by relays.
+func NewBundleRelay(relayURL string, authSigner BundleAuthSigner) (*BundleRelay, error) {
+	if relayURL == "" {
+		return nil, fmt.Errorf("ethtxn: relayURL is required")
+	}
+	if authSigner == nil {
+		return nil, fmt.Errorf("ethtxn: authSigner is required")
+	}
+
+	httpClient := &bundleAuthClient{
+		client:     &http.Client{Timeout: 60 * time.Second},
+		authSigner: authSigner,
+	}
+	provider, err := ethrpc.NewProvider(relayURL, ethrpc.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: %w", err)
+	}
+	return &BundleRelay{provider: provider}, nil
+}
+
+// sendBundleParams is the eth_sendBundle request payload.
+type sendBundleParams struct {
+	Txs               []hexutil.Bytes `json:"txs"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	MinTimestamp      uint64          `json:"minTimestamp,omitempty"`
+	MaxTimestamp      uint64          `json:"maxTimestamp,omitempty"`
+	RevertingTxHashes []common.Hash   `json:"revertingTxHashes,omitempty"`
+}
+
+type sendBundleResult struct {
+	BundleHash common.Hash `json:"bundleHash"`
+}
+
+// SendBundle submits the bundle to the relay, and returns the bundle hash assigned to it
+// by the relay. All of the transactions of the bundle must be signed.
+func (r *BundleRelay) SendBundle(ctx context.Context, bundle *Bundle) (common.Hash, error) {
+	if bundle == nil || len(bundle.Txns) == 0 {
+		return common.Hash{}, fmt.Errorf("ethtxn: bundle has no transactions")
+	}
+	if bundle.BlockNumber == 0 {
+		return common.Hash{}, fmt.Errorf("ethtxn: bundle block number is required")
+	}
+
+	params := sendBundleParams{
+		Txs:               make([]hexutil.Bytes, 0, len(bundle.Txns)),
+		BlockNumber:       hexutil.Uint64(bundle.BlockNumber),
+		MinTimestamp:      bundle.MinTimestamp,
+		MaxTimestamp:      bundle.MaxTimestamp,
+		RevertingTxHashes: bundle.RevertingTxHashes,
+	}
+	for i, txn := range bundle.Txns {
+		if v, r, s := txn.RawSignatureValues(); v.Sign() == 0 && r.Sign() == 0 && s.Sign() == 0 {
+			return common.Hash{}, fmt.Errorf("ethtxn: bundle transaction %d is not signed", i)
+		}
+		rawTx, err := txn.MarshalBinary()
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("ethtxn: failed to encode bundle transaction %d: %w", i, err)
+		}
+		params.Txs = append(params.Txs, rawTx)
+	}
+
+	var result sendBundleResult
+	call := ethrpc.NewCallBuilder[sendBundleResult]("eth_sendBundle", nil, params)
+	_, err := r.provider.Do(ctx, call.Into(&result))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("ethtxn: failed to send bundle: %w", err)
+	}
+	return result.BundleHash, nil
+}
+
+// bundleAuthClient is the http client of a BundleRelay, which signs the request body
+// in the X-Flashbots-Signature header, as "<address>:<signature>" of the EIP-191
+// signature of the hex encoded keccak256 hash of the body.
+type bundleAuthClient struct {
+	client     *http.Client
+	authSigner BundleAuthSigner
+}
+
+func (c *bundleAuthClient) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	sig, err := c.authSigner.SignMessage([]byte(hexutil.Encode(ethcoder.Keccak256(body))))
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to sign relay request: %w", err)
+	}
+	req.Header.Set("X-Flashbots-Signature", fmt.Sprintf("%s:%s", c.authSigner.Address().Hex(), hexutil.Encode(sig)))
+
+	return c.client.Do(req)
+}
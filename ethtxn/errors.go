@@ -0,0 +1,68 @@
+package ethtxn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNonceTooLow            = errors.New("ethtxn: nonce too low")
+	ErrAlreadyKnown           = errors.New("ethtxn: transaction already known")
+	ErrReplacementUnderpriced = errors.New("ethtxn: replacement transaction underpriced")
+	ErrInsufficientFunds      = errors.New("ethtxn: insufficient funds")
+)
+
+// sendErrorMessages maps the error messages returned by the different node
+// implementations (geth, erigon, nethermind, parity/openethereum, besu) when
+// sending a transaction to a typed error. Messages are matched lowercased.
+var sendErrorMessages = []struct {
+	err      error
+	messages []string
+}{
+	{ErrNonceTooLow, []string{
+		"nonce too low",
+		"nonce is too low",
+		"oldnonce",
+	}},
+	{ErrAlreadyKnown, []string{
+		"already known",
+		"known transaction",
+		"alreadyknown",
+		"already imported",
+		"already exists",
+	}},
+	{ErrReplacementUnderpriced, []string{
+		"replacement transaction underpriced",
+		"replacement underpriced",
+		"replacementunderpriced",
+		"another transaction with same nonce",
+		"gas price too low to replace",
+	}},
+	{ErrInsufficientFunds, []string{
+		"insufficient funds",
+		"insufficientfunds",
+		"insufficient balance",
+	}},
+}
+
+// ClassifySendError returns the error from sending a transaction wrapped with one of
+// ErrNonceTooLow, ErrAlreadyKnown, ErrReplacementUnderpriced or ErrInsufficientFunds,
+// so callers can check for it with errors.Is. Unrecognized errors are returned as is.
+func ClassifySendError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	for _, c := range sendErrorMessages {
+		if errors.Is(err, c.err) {
+			return err
+		}
+		for _, m := range c.messages {
+			if strings.Contains(msg, m) {
+				return fmt.Errorf("%w: %w", c.err, err)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package ethtxn
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/0xsequence/ethkit/ethgas"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+)
+
+var DefaultEstimateGasOptions = EstimateGasOptions{
+	GasLimitMultiplier: 1.2,
+	MaxGasLimit:        0,
+}
+
+type EstimateGasOptions struct {
+	// GasLimitMultiplier is the buffer factor applied to the estimated gas, to avoid
+	// running out of gas on state-dependent calls. ie. 1.2 will add 20% to the estimate.
+	// Values below 1 are treated as 1.
+	GasLimitMultiplier float64
+
+	// MaxGasLimit is a hard cap on the gas limit after the multiplier has been applied.
+	// If the estimate itself is above the cap, an error is returned. Value of 0 sets no cap.
+	MaxGasLimit uint64
+
+	// GasGauge (optional) is used to populate the fees of the transaction request from
+	// the gauge's suggested "fast" gas fees, when the request has no GasPrice set.
+	GasGauge *ethgas.GasGauge
+}
+
+// EstimateGas returns a copy of the transaction request where an empty GasLimit is populated
+// from eth_estimateGas, with the buffer and cap from the options applied. If a GasGauge is
+// passed and the request has no GasPrice, the fees are populated from the gauge as well.
+// The returned request is ready to be passed to NewTransaction and signed.
+func EstimateGas(ctx context.Context, provider *ethrpc.Provider, txnRequest *TransactionRequest, options ...EstimateGasOptions) (*TransactionRequest, error) {
+	if txnRequest == nil {
+		return nil, fmt.Errorf("ethtxn: txnRequest is required")
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("ethtxn: provider is not set")
+	}
+
+	opts := DefaultEstimateGasOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	txr := *txnRequest
+
+	if txr.GasPrice == nil && opts.GasGauge != nil {
+		fees := opts.GasGauge.SuggestedGasFees()
+		if fees.Fast.MaxFeePerGas != nil {
+			txr.GasPrice = fees.Fast.MaxFeePerGas
+			if fees.EIP1559 {
+				txr.GasTip = fees.Fast.MaxPriorityFeePerGas
+			}
+		}
+	}
+
+	if txr.GasLimit == 0 {
+		callMsg := ethereum.CallMsg{
+			From:       txr.From,
+			To:         txr.To,
+			Gas:        0, // estimating this value
+			Value:      txr.ETHValue,
+			Data:       txr.Data,
+			AccessList: txr.AccessList,
+		}
+
+		gasLimit, err := provider.EstimateGas(ctx, callMsg)
+		if err != nil {
+			return nil, fmt.Errorf("ethtxn: failed to estimate gas: %w", err)
+		}
+
+		if opts.MaxGasLimit > 0 && gasLimit > opts.MaxGasLimit {
+			return nil, fmt.Errorf("ethtxn: estimated gas %d is above the max gas limit %d", gasLimit, opts.MaxGasLimit)
+		}
+
+		if opts.GasLimitMultiplier > 1 {
+			gasLimit = uint64(math.Ceil(float64(gasLimit) * opts.GasLimitMultiplier))
+		}
+		if opts.MaxGasLimit > 0 && gasLimit > opts.MaxGasLimit {
+			gasLimit = opts.MaxGasLimit
+		}
+
+		txr.GasLimit = gasLimit
+	}
+
+	return &txr, nil
+}
@@ -37,7 +37,8 @@ type TransactionRequest struct {
 	GasTip *big.Int
 
 	// AccessList optional key-values to pre-import
-	// saves cost by pre-importing storage related values before executing the tx
+	// saves cost by pre-importing storage related values before executing the tx,
+	// see CreateAccessList. Not supported on legacy chains.
 	AccessList types.AccessList
 
 	// ETHValue (in WEI) amount of ETH currency to send with this transaction. Optional.
@@ -59,6 +60,12 @@ func NewTransaction(ctx context.Context, provider *ethrpc.Provider, txnRequest *
 		return nil, fmt.Errorf("ethtxn: provider is not set")
 	}
 
+	if len(txnRequest.AccessList) > 0 {
+		if err := checkAccessListSupport(ctx, provider); err != nil {
+			return nil, err
+		}
+	}
+
 	if txnRequest.Nonce == nil {
 		nonce, err := provider.PendingNonceAt(ctx, txnRequest.From)
 		if err != nil {
@@ -78,12 +85,13 @@ func NewTransaction(ctx context.Context, provider *ethrpc.Provider, txnRequest *
 
 	if txnRequest.GasLimit == 0 {
 		callMsg := ethereum.CallMsg{
-			From:     txnRequest.From,
-			To:       txnRequest.To,
-			Gas:      0, // estimating this value
-			GasPrice: txnRequest.GasPrice,
-			Value:    txnRequest.ETHValue,
-			Data:     txnRequest.Data,
+			From:       txnRequest.From,
+			To:         txnRequest.To,
+			Gas:        0, // estimating this value
+			GasPrice:   txnRequest.GasPrice,
+			Value:      txnRequest.ETHValue,
+			Data:       txnRequest.Data,
+			AccessList: txnRequest.AccessList,
 		}
 
 		gasLimit, err := provider.EstimateGas(ctx, callMsg)
@@ -154,7 +162,9 @@ func SendTransaction(ctx context.Context, provider *ethrpc.Provider, signedTx *t
 		return ethrpc.WaitForTxnReceipt(ctx, provider, signedTx.Hash())
 	}
 
-	return signedTx, waitFn, provider.SendTransaction(ctx, signedTx)
+	// node errors are classified into typed errors, see ClassifySendError
+	err := provider.SendTransaction(ctx, signedTx)
+	return signedTx, waitFn, ClassifySendError(err)
 }
 
 var zeroBigInt = big.NewInt(0)
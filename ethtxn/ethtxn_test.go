@@ -1,7 +1,461 @@
 package ethtxn_test
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
 
 func TestTxnSend(t *testing.T) {
 
 }
+
+func TestEstimateGas(t *testing.T) {
+	provider := newMockProvider(t, map[string]string{
+		"eth_estimateGas": `"result":"0x5208"`, // 21000
+	})
+
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	txr := &ethtxn.TransactionRequest{To: &to}
+
+	estimated, err := ethtxn.EstimateGas(context.Background(), provider, txr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(25200), estimated.GasLimit)
+	require.Zero(t, txr.GasLimit)
+
+	estimated, err = ethtxn.EstimateGas(context.Background(), provider, txr, ethtxn.EstimateGasOptions{
+		GasLimitMultiplier: 2,
+		MaxGasLimit:        30000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(30000), estimated.GasLimit)
+
+	_, err = ethtxn.EstimateGas(context.Background(), provider, txr, ethtxn.EstimateGasOptions{
+		MaxGasLimit: 20000,
+	})
+	require.Error(t, err)
+
+	// gas limit which is already set is kept
+	txr.GasLimit = 50000
+	estimated, err = ethtxn.EstimateGas(context.Background(), provider, txr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(50000), estimated.GasLimit)
+}
+
+func TestPlan(t *testing.T) {
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	account := ethtxn.Account(mockAccount(common.HexToAddress("0x2222222222222222222222222222222222222222")))
+
+	t.Run("Success", func(t *testing.T) {
+		provider := newMockProvider(t, map[string]string{
+			"eth_getTransactionCount": `"result":"0x7"`,
+			"eth_call":                `"result":"0x01"`,
+			"eth_estimateGas":         `"result":"0x5208"`,
+			"eth_gasPrice":            `"result":"0x3b9aca00"`, // 1 gwei
+		})
+
+		plan, err := ethtxn.Plan(context.Background(), provider, account, ethtxn.TransactionRequest{
+			To:       &to,
+			ETHValue: big.NewInt(1000),
+		})
+		require.NoError(t, err)
+		require.False(t, plan.Reverted)
+		require.Equal(t, uint64(7), plan.Nonce)
+		require.Equal(t, uint64(25200), plan.GasLimit)
+		require.Equal(t, []byte{1}, plan.ReturnData)
+		require.Equal(t, account.Address(), plan.Request.From)
+		require.Equal(t, "25200000001000", plan.TotalCost.String())
+	})
+
+	t.Run("Revert", func(t *testing.T) {
+		// revert with Error("nope")
+		provider := newMockProvider(t, map[string]string{
+			"eth_getTransactionCount": `"result":"0x7"`,
+			"eth_call":                `"error":{"code":3,"message":"execution reverted: nope","data":"0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000046e6f706500000000000000000000000000000000000000000000000000000000"}`,
+			"eth_gasPrice":            `"result":"0x3b9aca00"`,
+		})
+
+		plan, err := ethtxn.Plan(context.Background(), provider, account, ethtxn.TransactionRequest{To: &to})
+		require.NoError(t, err)
+		require.True(t, plan.Reverted)
+		require.Equal(t, "nope", plan.RevertReason)
+		require.Zero(t, plan.GasLimit)
+	})
+}
+
+func TestClassifySendError(t *testing.T) {
+	tests := []struct {
+		message string
+		err     error
+	}{
+		{"nonce too low", ethtxn.ErrNonceTooLow},                                                                                           // geth, erigon
+		{"Transaction nonce is too low. Try incrementing the nonce.", ethtxn.ErrNonceTooLow},                                               // parity
+		{"OldNonce, Current nonce: 5, nonce of rejected tx: 4", ethtxn.ErrNonceTooLow},                                                     // nethermind
+		{"already known", ethtxn.ErrAlreadyKnown},                                                                                          // geth
+		{"Transaction with the same hash was already imported.", ethtxn.ErrAlreadyKnown},                                                   // parity
+		{"AlreadyKnown", ethtxn.ErrAlreadyKnown},                                                                                           // nethermind
+		{"replacement transaction underpriced", ethtxn.ErrReplacementUnderpriced},                                                          // geth
+		{"Transaction gas price is too low. There is another transaction with same nonce in the queue.", ethtxn.ErrReplacementUnderpriced}, // parity
+		{"insufficient funds for gas * price + value", ethtxn.ErrInsufficientFunds},                                                        // geth
+		{"InsufficientFunds, Account balance: 0", ethtxn.ErrInsufficientFunds},                                                             // nethermind
+	}
+	for _, tt := range tests {
+		err := ethtxn.ClassifySendError(fmt.Errorf("jsonrpc error -32000: %s", tt.message))
+		require.ErrorIs(t, err, tt.err, tt.message)
+	}
+
+	err := fmt.Errorf("execution reverted")
+	require.Equal(t, err, ethtxn.ClassifySendError(err))
+	require.NoError(t, ethtxn.ClassifySendError(nil))
+
+	// errors from SendTransaction are classified
+	provider := newMockProvider(t, map[string]string{
+		"eth_sendRawTransaction": `"error":{"code":-32000,"message":"nonce too low"}`,
+	})
+	signedTx := types.NewTx(&types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(1), Gas: 21000})
+	_, _, err = ethtxn.SendTransaction(context.Background(), provider, signedTx)
+	require.ErrorIs(t, err, ethtxn.ErrNonceTooLow)
+}
+
+func TestBumpAllPending(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	// nonce 4 has already been mined, while nonces 5 and 6 are pending
+	pending := map[string]*types.Transaction{}
+	for _, txn := range []*types.Transaction{
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 4, GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(2000), Gas: 21000, To: &to}),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 5, GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(2000), Gas: 21000, To: &to}),
+		types.NewTx(&types.LegacyTx{Nonce: 6, GasPrice: big.NewInt(1000), Gas: 21000, To: &to}),
+	} {
+		signedTx, err := wallet.SignTx(txn, chainID)
+		require.NoError(t, err)
+		pending[fmt.Sprint(txn.Nonce())] = signedTx
+	}
+	content, err := json.Marshal(map[string]any{"pending": pending, "queued": map[string]any{}})
+	require.NoError(t, err)
+
+	provider := newMockProvider(t, map[string]string{
+		"txpool_contentFrom":      fmt.Sprintf(`"result":%s`, content),
+		"eth_chainId":             `"result":"0x539"`,
+		"eth_getTransactionCount": `"result":"0x5"`,
+		"eth_sendRawTransaction":  `"result":"0x0000000000000000000000000000000000000000000000000000000000000000"`,
+	})
+
+	bumped, err := ethtxn.BumpAllPending(context.Background(), provider, wallet, 5)
+	require.NoError(t, err)
+	require.Len(t, bumped, 2)
+
+	// fees are bumped by at least the 10% replacement minimum
+	require.Equal(t, uint64(5), bumped[0].Nonce())
+	require.Equal(t, int64(110), bumped[0].GasTipCap().Int64())
+	require.Equal(t, int64(2200), bumped[0].GasFeeCap().Int64())
+
+	require.Equal(t, uint64(6), bumped[1].Nonce())
+	require.Equal(t, int64(1100), bumped[1].GasPrice().Int64())
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), bumped[1])
+	require.NoError(t, err)
+	require.Equal(t, wallet.Address(), sender)
+}
+
+func TestSpeedUpAndCancel(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	pendingTx, err := wallet.SignTx(types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 5, GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(2000), Gas: 50000, To: &to, Value: big.NewInt(1), Data: []byte{0x01}}), chainID)
+	require.NoError(t, err)
+	content, err := json.Marshal(map[string]any{"pending": map[string]any{"5": pendingTx}, "queued": map[string]any{}})
+	require.NoError(t, err)
+
+	provider := newMockProvider(t, map[string]string{
+		"txpool_contentFrom":       fmt.Sprintf(`"result":%s`, content),
+		"eth_chainId":              `"result":"0x539"`,
+		"eth_getTransactionCount":  `"result":"0x5"`,
+		"eth_getBlockByNumber":     mockHeaderResponse("0x3e8"),
+		"eth_maxPriorityFeePerGas": `"result":"0x64"`,
+	})
+	ctx := context.Background()
+
+	// speed up keeps the call, with fees bumped by bumpPercent
+	spedUp, err := ethtxn.SpeedUp(ctx, provider, wallet, pendingTx, 20)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), spedUp.Nonce())
+	require.Equal(t, int64(120), spedUp.GasTipCap().Int64())
+	require.Equal(t, int64(2400), spedUp.GasFeeCap().Int64())
+	require.Equal(t, uint64(50000), spedUp.Gas())
+	require.Equal(t, &to, spedUp.To())
+	require.Equal(t, int64(1), spedUp.Value().Int64())
+	require.Equal(t, []byte{0x01}, spedUp.Data())
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), spedUp)
+	require.NoError(t, err)
+	require.Equal(t, wallet.Address(), sender)
+
+	// bumps below the replacement minimum are rejected
+	_, err = ethtxn.SpeedUp(ctx, provider, wallet, pendingTx, 5)
+	require.ErrorIs(t, err, ethtxn.ErrReplacementUnderpriced)
+	_, err = ethtxn.Cancel(ctx, provider, wallet, 5, 5)
+	require.ErrorIs(t, err, ethtxn.ErrReplacementUnderpriced)
+
+	// transactions of another wallet are rejected
+	otherWallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	_, err = ethtxn.SpeedUp(ctx, provider, otherWallet, pendingTx, 10)
+	require.Error(t, err)
+
+	// cancel replaces the pending transaction with a self-transfer, with its fees bumped
+	cancelTx, err := ethtxn.Cancel(ctx, provider, wallet, 5, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), cancelTx.Nonce())
+	require.Equal(t, wallet.Address(), *cancelTx.To())
+	require.Zero(t, cancelTx.Value().Sign())
+	require.Empty(t, cancelTx.Data())
+	require.Equal(t, uint64(21000), cancelTx.Gas())
+	require.Equal(t, int64(110), cancelTx.GasTipCap().Int64())
+	require.Equal(t, int64(2200), cancelTx.GasFeeCap().Int64())
+
+	// nonces not in the txpool are cancelled with the bumped network fees
+	cancelTx, err = ethtxn.Cancel(ctx, provider, wallet, 6, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), cancelTx.Nonce())
+	require.Equal(t, int64(110), cancelTx.GasTipCap().Int64())
+	require.Equal(t, int64(2310), cancelTx.GasFeeCap().Int64())
+
+	// mined nonces can't be replaced
+	_, err = ethtxn.Cancel(ctx, provider, wallet, 4, 10)
+	require.ErrorIs(t, err, ethtxn.ErrNonceTooLow)
+}
+
+type mockAccount common.Address
+
+func (a mockAccount) Address() common.Address {
+	return common.Address(a)
+}
+
+// newMockProvider returns a provider to a mock node, which responds to each
+// method with the json "result" or "error" field from responses.
+func newMockProvider(t *testing.T, responses map[string]string) *ethrpc.Provider {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response, ok := responses[req.Method]
+		if !ok {
+			response = `"error":{"code":-32601,"message":"method not found"}`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,%s}`, req.ID, response)
+	}))
+	t.Cleanup(srv.Close)
+
+	provider, err := ethrpc.NewProvider(srv.URL)
+	require.NoError(t, err)
+	return provider
+}
+
+func TestPriceForInclusionBy(t *testing.T) {
+	provider := newMockProvider(t, map[string]string{
+		"eth_getBlockByNumber": mockHeaderResponse("0x3b9aca00"),
+		"eth_feeHistory": `"result":{
+			"oldestBlock":"0x63",
+			"reward":[
+				["0x64","0xc8","0x12c","0x190","0x1f4","0x258","0x2bc"],
+				["0x12c","0x190","0x1f4","0x258","0x2bc","0x320","0x384"]
+			],
+			"baseFeePerGas":["0x3b9aca00","0x3b9aca00","0x77359400"],
+			"gasUsedRatio":[0.5,0.5]
+		}`,
+	})
+
+	// many blocks away, the lowest percentile is enough and the base fee can double
+	fee, err := ethtxn.PriceForInclusionBy(context.Background(), provider, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(200), fee.MaxPriorityFeePerGas)
+	require.Equal(t, big.NewInt(4_000_000_200), fee.MaxFeePerGas)
+
+	// within the next block, the 95th percentile is paid
+	fee, err = ethtxn.PriceForInclusionBy(context.Background(), provider, time.Now().Add(1500*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(700), fee.MaxPriorityFeePerGas)
+	require.Equal(t, big.NewInt(2_250_000_700), fee.MaxFeePerGas)
+
+	_, err = ethtxn.PriceForInclusionBy(context.Background(), provider, time.Now().Add(-time.Second))
+	require.Error(t, err)
+}
+
+func TestCreateAccessList(t *testing.T) {
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	provider := newMockProvider(t, map[string]string{
+		"eth_chainId":          `"result":"0x1"`,
+		"eth_getBlockByNumber": mockHeaderResponse("0x3b9aca00"),
+		"eth_createAccessList": `"result":{
+			"accessList":[
+				{"address":"0x2222222222222222222222222222222222222222","storageKeys":[]},
+				{"address":"0x3333333333333333333333333333333333333333","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000001"]}
+			],
+			"gasUsed":"0x7a12"
+		}`,
+	})
+
+	txr, err := ethtxn.CreateAccessList(context.Background(), provider, &ethtxn.TransactionRequest{From: from, To: &to, Data: []byte{0x01}})
+	require.NoError(t, err)
+
+	// the recipient is warm, and pruned from the access list
+	require.Equal(t, types.AccessList{{
+		Address:     common.HexToAddress("0x3333333333333333333333333333333333333333"),
+		StorageKeys: []common.Hash{common.HexToHash("0x01")},
+	}}, txr.AccessList)
+
+	txr.Nonce = big.NewInt(0)
+	txr.GasLimit = 50000
+	txr.GasPrice = big.NewInt(1)
+
+	txn, err := ethtxn.NewTransaction(context.Background(), provider, txr)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.AccessListTxType), txn.Type())
+	require.Equal(t, txr.AccessList, txn.AccessList())
+
+	txr.GasTip = big.NewInt(1)
+	txn, err = ethtxn.NewTransaction(context.Background(), provider, txr)
+	require.NoError(t, err)
+	require.Equal(t, uint8(types.DynamicFeeTxType), txn.Type())
+	require.Equal(t, txr.AccessList, txn.AccessList())
+
+	// legacy chain
+	legacyProvider := newMockProvider(t, map[string]string{
+		"eth_getBlockByNumber": mockHeaderResponse(""),
+	})
+
+	_, err = ethtxn.CreateAccessList(context.Background(), legacyProvider, &ethtxn.TransactionRequest{From: from, To: &to})
+	require.ErrorIs(t, err, ethtxn.ErrAccessListUnsupported)
+
+	_, err = ethtxn.NewTransaction(context.Background(), legacyProvider, txr)
+	require.ErrorIs(t, err, ethtxn.ErrAccessListUnsupported)
+}
+
+// mockHeaderResponse is the response of eth_getBlockByNumber of block 100, with the base
+// fee if set.
+func mockHeaderResponse(baseFee string) string {
+	header := map[string]any{
+		"number":           "0x64",
+		"hash":             "0x0100000000000000000000000000000000000000000000000000000000000000",
+		"parentHash":       "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"sha3Uncles":       "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		"miner":            "0x0000000000000000000000000000000000000000",
+		"stateRoot":        "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"transactionsRoot": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		"receiptsRoot":     "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		"logsBloom":        "0x" + strings.Repeat("00", 256),
+		"difficulty":       "0x0",
+		"gasLimit":         "0x1c9c380",
+		"gasUsed":          "0x0",
+		"timestamp":        "0x6553f100",
+		"extraData":        "0x",
+	}
+	if baseFee != "" {
+		header["baseFeePerGas"] = baseFee
+	}
+	result, _ := json.Marshal(header)
+	return fmt.Sprintf(`"result":%s`, result)
+}
+
+func TestBundle(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	authSigner, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+
+	provider := newMockProvider(t, map[string]string{
+		"eth_chainId":             `"result":"0x539"`,
+		"eth_getTransactionCount": `"result":"0x7"`,
+	})
+
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bundle, err := ethtxn.NewBundle(context.Background(), provider, wallet, 100,
+		&ethtxn.TransactionRequest{To: &to, GasLimit: 21000, GasPrice: big.NewInt(2000), GasTip: big.NewInt(100)},
+		&ethtxn.TransactionRequest{To: &to, GasLimit: 50000, GasPrice: big.NewInt(2000), GasTip: big.NewInt(100), Data: []byte{0x01}},
+	)
+	require.NoError(t, err)
+	require.Len(t, bundle.Txns, 2)
+	require.Equal(t, uint64(7), bundle.Txns[0].Nonce())
+	require.Equal(t, uint64(8), bundle.Txns[1].Nonce())
+
+	var params []struct {
+		Txs         []hexutil.Bytes `json:"txs"`
+		BlockNumber string          `json:"blockNumber"`
+	}
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		// the payload is signed by the auth signer
+		header := strings.SplitN(r.Header.Get("X-Flashbots-Signature"), ":", 2)
+		require.Len(t, header, 2)
+		sig, err := hexutil.Decode(header[1])
+		require.NoError(t, err)
+		signer, err := ethwallet.RecoverMessageSigner([]byte(hexutil.Encode(ethcoder.Keccak256(body))), sig)
+		require.NoError(t, err)
+		require.Equal(t, authSigner.Address().Hex(), header[0])
+		require.Equal(t, authSigner.Address(), signer)
+
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Equal(t, "eth_sendBundle", req.Method)
+		require.NoError(t, json.Unmarshal(req.Params, &params))
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"bundleHash":"0x2228f5d8954ce31dc1601a8ba264dbd401bf1428388ce88238932815c5d6f23f"}}`, req.ID)
+	}))
+	t.Cleanup(relay.Close)
+
+	bundleRelay, err := ethtxn.NewBundleRelay(relay.URL, authSigner)
+	require.NoError(t, err)
+
+	bundleHash, err := bundleRelay.SendBundle(context.Background(), bundle)
+	require.NoError(t, err)
+	require.Equal(t, common.HexToHash("0x2228f5d8954ce31dc1601a8ba264dbd401bf1428388ce88238932815c5d6f23f"), bundleHash)
+
+	require.Len(t, params, 1)
+	require.Equal(t, "0x64", params[0].BlockNumber)
+	require.Len(t, params[0].Txs, 2)
+	var txn types.Transaction
+	require.NoError(t, txn.UnmarshalBinary(params[0].Txs[1]))
+	require.Equal(t, bundle.Txns[1].Hash(), txn.Hash())
+
+	// unsigned transactions are rejected
+	_, err = bundleRelay.SendBundle(context.Background(), &ethtxn.Bundle{
+		Txns:        []*types.Transaction{types.NewTx(&types.LegacyTx{To: &to, Gas: 21000, GasPrice: big.NewInt(1)})},
+		BlockNumber: 100,
+	})
+	require.Error(t, err)
+}
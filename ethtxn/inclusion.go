@@ -0,0 +1,120 @@
+package ethtxn
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethgas"
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+const (
+	// inclusionFeeHistoryNumBlocks is the number of recent blocks sampled by PriceForInclusionBy.
+	inclusionFeeHistoryNumBlocks = 20
+
+	// inclusionConfidence is the targeted probability of inclusion before the deadline.
+	inclusionConfidence = 0.95
+)
+
+// inclusionPercentiles are the reward percentiles sampled by PriceForInclusionBy, from
+// which the priority fee is picked.
+var inclusionPercentiles = []float64{10, 25, 50, 75, 90, 95, 99}
+
+// PriceForInclusionBy estimates the fees for a transaction to likely be included in a block
+// before the deadline, from the eth_feeHistory of recent blocks and the average block time.
+// The closer the deadline, the higher the priority fee, ie. a deadline of a single block
+// away pays the 95th percentile of recent priority fees, while a deadline many blocks away
+// pays close to the lowest. The max fee per gas allows the base fee to rise at the max rate
+// of 12.5% per block until the deadline, up to doubling.
+//
+// The estimate is probabilistic, as fees of future blocks can't be known in advance. It
+// aims for a 95% chance of inclusion, taking the chance of inclusion in each block as the
+// reward percentile paid, and rounds up to the next sampled percentile. Inclusion is not
+// guaranteed on a sudden spike of demand, in which case the transaction can be bumped,
+// see BumpAllPending.
+//
+// On legacy chains, both values are set to the node's suggested gas price.
+func PriceForInclusionBy(ctx context.Context, provider *ethrpc.Provider, deadline time.Time) (ethgas.GasFee, error) {
+	if provider == nil {
+		return ethgas.GasFee{}, fmt.Errorf("ethtxn: provider is not set")
+	}
+	timeLeft := time.Until(deadline)
+	if timeLeft <= 0 {
+		return ethgas.GasFee{}, fmt.Errorf("ethtxn: deadline %s has passed", deadline.Format(time.RFC3339))
+	}
+
+	head, err := provider.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return ethgas.GasFee{}, fmt.Errorf("ethtxn: failed to get latest header: %w", err)
+	}
+
+	if head.BaseFee == nil {
+		gasPrice, err := provider.SuggestGasPrice(ctx)
+		if err != nil {
+			return ethgas.GasFee{}, fmt.Errorf("ethtxn: failed to get gas price: %w", err)
+		}
+		return ethgas.GasFee{MaxFeePerGas: gasPrice, MaxPriorityFeePerGas: new(big.Int).Set(gasPrice)}, nil
+	}
+
+	feeHistory, err := provider.FeeHistory(ctx, inclusionFeeHistoryNumBlocks, head.Number, inclusionPercentiles)
+	if err != nil {
+		return ethgas.GasFee{}, fmt.Errorf("ethtxn: failed to get fee history: %w", err)
+	}
+	if len(feeHistory.Reward) == 0 {
+		return ethgas.GasFee{}, fmt.Errorf("ethtxn: fee history returned no rewards")
+	}
+
+	// average block time of the sampled blocks, of at least a second to stay on the safe
+	// side on chains with sub-second blocks
+	blockTime := time.Second
+	if feeHistory.OldestBlock != nil && feeHistory.OldestBlock.Cmp(head.Number) < 0 {
+		oldest, err := provider.HeaderByNumber(ctx, feeHistory.OldestBlock)
+		if err != nil {
+			return ethgas.GasFee{}, fmt.Errorf("ethtxn: failed to get header %d: %w", feeHistory.OldestBlock, err)
+		}
+		if oldest.Number.Cmp(head.Number) < 0 && head.Time > oldest.Time {
+			numBlocks := head.Number.Uint64() - oldest.Number.Uint64()
+			blockTime = max(blockTime, time.Duration(head.Time-oldest.Time)*time.Second/time.Duration(numBlocks))
+		}
+	}
+
+	numBlocks := max(int(timeLeft/blockTime), 1)
+
+	// a priority fee at the qth reward percentile is included in a block with a probability
+	// of about q, and so within n blocks with a probability of 1-(1-q)^n
+	q := 100 * (1 - math.Pow(1-inclusionConfidence, 1/float64(numBlocks)))
+	percentile := len(inclusionPercentiles) - 1
+	for i, p := range inclusionPercentiles {
+		if p >= q {
+			percentile = i
+			break
+		}
+	}
+
+	// average the rewards of the percentile across the sampled blocks
+	tip, n := new(big.Int), int64(0)
+	for _, rewards := range feeHistory.Reward {
+		if percentile < len(rewards) && rewards[percentile] != nil {
+			tip.Add(tip, rewards[percentile])
+			n++
+		}
+	}
+	if n > 0 {
+		tip.Div(tip, big.NewInt(n))
+	}
+
+	// the fee history includes the base fee of the next block
+	baseFee := head.BaseFee
+	if len(feeHistory.BaseFee) > 0 && feeHistory.BaseFee[len(feeHistory.BaseFee)-1] != nil {
+		baseFee = feeHistory.BaseFee[len(feeHistory.BaseFee)-1]
+	}
+
+	growth := math.Min(math.Pow(1.125, float64(numBlocks)), 2)
+	maxFee, _ := new(big.Float).Mul(new(big.Float).SetInt(baseFee), big.NewFloat(growth)).Int(nil)
+	maxFee.Add(maxFee, tip)
+
+	return ethgas.GasFee{MaxFeePerGas: maxFee, MaxPriorityFeePerGas: tip}, nil
+}
@@ -0,0 +1,148 @@
+package ethtxn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// Account is the sender of a planned transaction, ie. an *ethwallet.Wallet.
+type Account interface {
+	Address() common.Address
+}
+
+// TxnPlan is a preview of a transaction with all of its details resolved, as it
+// would be sent by SendTransaction.
+type TxnPlan struct {
+	// Request is the fully-resolved transaction request, which can be passed
+	// to NewTransaction to prepare the transaction for signing.
+	Request *TransactionRequest
+
+	Nonce    uint64
+	GasLimit uint64
+
+	// GasPrice is the max fee per gas, and GasTip the max priority fee per gas
+	// which is only set for EIP-1559 transactions.
+	GasPrice *big.Int
+	GasTip   *big.Int
+
+	// TotalCost is the maximum cost of the transaction in wei, as in
+	// GasLimit * GasPrice + ETHValue.
+	TotalCost *big.Int
+
+	// ReturnData is the data returned from simulating the transaction.
+	ReturnData []byte
+
+	// Reverted is set if the simulation of the transaction reverted, with
+	// RevertReason holding the decoded reason if one was returned.
+	Reverted     bool
+	RevertReason string
+}
+
+// Plan resolves the nonce, gas limit and fees of a transaction request, and simulates
+// the transaction, without sending it. This is the "prepare" step to show a summary of
+// a transaction before it's signed and sent. A simulation which reverts does not return
+// an error, but is reported in the plan, in which case the gas limit is not estimated.
+func Plan(ctx context.Context, provider *ethrpc.Provider, account Account, txnRequest TransactionRequest, options ...EstimateGasOptions) (*TxnPlan, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ethtxn: provider is not set")
+	}
+	if account == nil {
+		return nil, fmt.Errorf("ethtxn: account is required")
+	}
+
+	txr := txnRequest
+	txr.From = account.Address()
+
+	if txr.Nonce == nil {
+		nonce, err := provider.PendingNonceAt(ctx, txr.From)
+		if err != nil {
+			return nil, fmt.Errorf("ethtxn: failed to get pending nonce: %w", err)
+		}
+		txr.Nonce = big.NewInt(0).SetUint64(nonce)
+	}
+
+	plan := &TxnPlan{}
+
+	// Simulate the transaction
+	returnData, err := provider.CallContract(ctx, ethereum.CallMsg{
+		From:       txr.From,
+		To:         txr.To,
+		Gas:        txr.GasLimit,
+		Value:      txr.ETHValue,
+		Data:       txr.Data,
+		AccessList: txr.AccessList,
+	}, nil)
+	if err != nil {
+		reason, ok := revertReason(err)
+		if !ok {
+			return nil, fmt.Errorf("ethtxn: failed to simulate transaction: %w", err)
+		}
+		plan.Reverted = true
+		plan.RevertReason = reason
+	}
+	plan.ReturnData = returnData
+
+	// Estimate gas and fill in the fees, unless the simulation reverted,
+	// in which case estimating gas would fail as well.
+	if !plan.Reverted {
+		estimated, err := EstimateGas(ctx, provider, &txr, options...)
+		if err != nil {
+			return nil, err
+		}
+		txr = *estimated
+	}
+
+	if txr.GasPrice == nil {
+		gasPrice, err := provider.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ethtxn: %w", err)
+		}
+		txr.GasPrice = gasPrice
+	}
+
+	plan.Request = &txr
+	plan.Nonce = txr.Nonce.Uint64()
+	plan.GasLimit = txr.GasLimit
+	plan.GasPrice = txr.GasPrice
+	plan.GasTip = txr.GasTip
+
+	plan.TotalCost = new(big.Int).Mul(new(big.Int).SetUint64(txr.GasLimit), txr.GasPrice)
+	if txr.ETHValue != nil {
+		plan.TotalCost.Add(plan.TotalCost, txr.ETHValue)
+	}
+
+	return plan, nil
+}
+
+// revertReason returns the decoded revert reason if err is an execution reverted
+// error from the node.
+func revertReason(err error) (string, bool) {
+	var revertErr *ethrpc.RevertError
+	if !errors.As(err, &revertErr) {
+		return "", false
+	}
+
+	switch {
+	case revertErr.IsErrorString():
+		return revertErr.Reason, true
+	case revertErr.PanicCode != nil:
+		return fmt.Sprintf("panic code 0x%x", revertErr.PanicCode), true
+	case len(revertErr.Data) >= 4:
+		return hexutil.Encode(revertErr.Data), true
+	}
+
+	// node returned an execution reverted error, but without revert data
+	var rpcErr *jsonrpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Message, true
+	}
+	return revertErr.Error(), true
+}
@@ -27,6 +27,7 @@ type Wallet struct {
 	hdnode         *HDNode
 	provider       *ethrpc.Provider
 	walletProvider *WalletProvider
+	nonces         nonceManager
 }
 
 type WalletOptions struct {
@@ -201,6 +202,20 @@ func (w *Wallet) DeriveAccountIndex(accountIndex uint32) (*Wallet, common.Addres
 	return wallet, address, err
 }
 
+// DeriveAccountIndexes returns numWallets new wallets derived from the wallet's derivation
+// path, with the account index set to startIndex, startIndex+1, etc.
+func (w *Wallet) DeriveAccountIndexes(startIndex uint32, numWallets int) ([]*Wallet, error) {
+	wallets := make([]*Wallet, 0, numWallets)
+	for i := 0; i < numWallets; i++ {
+		wallet, _, err := w.DeriveAccountIndex(startIndex + uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+	return wallets, nil
+}
+
 func (w *Wallet) Address() common.Address {
 	return w.hdnode.Address()
 }
@@ -259,7 +274,9 @@ func (w *Wallet) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transac
 //
 // This is the same as SignData, but it adds the prefix "Ethereum Signed Message:\n" to
 // the message and encodes the length of the message in the prefix. In case the message
-// already has the prefix, it will not be added again.
+// already has the prefix, it will not be added again. The signature has a recovery id
+// of 27/28, the same as a personal_sign signature from MetaMask. The signer can be
+// recovered with RecoverMessageSigner.
 func (w *Wallet) SignMessage(message []byte) ([]byte, error) {
 	message191 := []byte("\x19Ethereum Signed Message:\n")
 	if !bytes.HasPrefix(message, message191) {
@@ -273,7 +290,10 @@ func (w *Wallet) SignMessage(message []byte) ([]byte, error) {
 }
 
 // SignTypedData signs a typed data with EIP-712 prefix with the wallet's private key.
-// It returns the signature and the digest of the typed data.
+// It returns the signature and the encoded typed data, whose keccak256 hash is the
+// EIP-712 digest. The signature has a recovery id of 27/28, the same as an
+// eth_signTypedData_v4 signature from MetaMask. The signer can be recovered with
+// RecoverTypedDataSigner.
 func (w *Wallet) SignTypedData(typedData *ethcoder.TypedData) ([]byte, []byte, error) {
 	_, encodedData, err := typedData.Encode()
 	if err != nil {
@@ -290,6 +310,10 @@ func (w *Wallet) SignTypedData(typedData *ethcoder.TypedData) ([]byte, []byte, e
 // SignData signs a message with the wallet's private key.
 //
 // This is the same as SignMessage, but it does not add the EIP-191 prefix.
+//
+// Signatures are deterministic, with the nonce derived from the key and the data as per
+// RFC 6979, and canonical, with a low-s value, see IsCanonicalSignature. This applies to
+// all signatures of the Wallet, including SignMessage, SignTypedData and SignTx.
 // Please be careful with this method as it can be used to sign arbitrary data, but
 // its helpful for signing typed data as defined by EIP-712.
 func (w *Wallet) SignData(data []byte) ([]byte, error) {
@@ -1,13 +1,26 @@
 package ethwallet_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
 	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/keystore"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/0xsequence/ethkit/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWalletRandom(t *testing.T) {
@@ -111,6 +124,196 @@ func TestWalletSignAndRecover(t *testing.T) {
 	assert.True(t, valid)
 }
 
+func TestRecoverMessageSigner(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromPrivateKey("3c121e5b2c2b2426f386bfc0257820846d77610c20e0fd4144417fb8fd79bfb8")
+	assert.NoError(t, err)
+
+	message := []byte("Sign in to example.com\nNonce: 1234")
+	sig, err := wallet.SignMessage(message)
+	assert.NoError(t, err)
+	assert.Contains(t, []byte{27, 28}, sig[64])
+
+	signer, err := ethwallet.RecoverMessageSigner(message, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), signer)
+
+	// recovery id of 0/1
+	sig01 := append([]byte{}, sig...)
+	sig01[64] -= 27
+	signer, err = ethwallet.RecoverMessageSigner(message, sig01)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), signer)
+
+	// already prefixed message
+	prefixed := []byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message))
+	signer, err = ethwallet.RecoverMessageSigner(prefixed, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), signer)
+
+	// different message
+	signer, err = ethwallet.RecoverMessageSigner([]byte("hi"), sig)
+	assert.NoError(t, err)
+	assert.NotEqual(t, wallet.Address(), signer)
+
+	// invalid recovery id and length
+	sigInvalid := append([]byte{}, sig...)
+	sigInvalid[64] = 29
+	_, err = ethwallet.RecoverMessageSigner(message, sigInvalid)
+	assert.Error(t, err)
+	_, err = ethwallet.RecoverMessageSigner(message, sig[:64])
+	assert.Error(t, err)
+}
+
+func TestWalletSignTypedData(t *testing.T) {
+	// EIP-712 spec example, https://eips.ethereum.org/EIPS/eip-712
+	typedData, err := ethcoder.TypedDataFromJSON(`{
+		"types": {
+			"EIP712Domain": [
+				{ "name": "name", "type": "string" },
+				{ "name": "version", "type": "string" },
+				{ "name": "chainId", "type": "uint256" },
+				{ "name": "verifyingContract", "type": "address" }
+			],
+			"Person": [
+				{ "name": "name", "type": "string" },
+				{ "name": "wallet", "type": "address" }
+			],
+			"Mail": [
+				{ "name": "from", "type": "Person" },
+				{ "name": "to", "type": "Person" },
+				{ "name": "contents", "type": "string" }
+			]
+		},
+		"primaryType": "Mail",
+		"domain": {
+			"name": "Ether Mail",
+			"version": "1",
+			"chainId": 1,
+			"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+		},
+		"message": {
+			"from": { "name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826" },
+			"to": { "name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB" },
+			"contents": "Hello, Bob!"
+		}
+	}`)
+	require.NoError(t, err)
+
+	digest, err := typedData.EncodeDigest()
+	require.NoError(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hexutil.Encode(digest))
+
+	// private key of the spec example is keccak256("cow")
+	wallet, err := ethwallet.NewWalletFromPrivateKey(hexutil.Encode(crypto.Keccak256([]byte("cow")))[2:])
+	require.NoError(t, err)
+	assert.Equal(t, "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826", wallet.Address().Hex())
+
+	sig, _, err := wallet.SignTypedData(typedData)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c",
+		hexutil.Encode(sig),
+	)
+
+	signer, err := ethwallet.RecoverTypedDataSigner(typedData, sig)
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Address(), signer)
+
+	// recovery id of 0/1
+	sig01 := append([]byte{}, sig...)
+	sig01[64] -= 27
+	signer, err = ethwallet.RecoverTypedDataSigner(typedData, sig01)
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Address(), signer)
+
+	// invalid recovery id and length
+	sigInvalid := append([]byte{}, sig...)
+	sigInvalid[64] = 29
+	_, err = ethwallet.RecoverTypedDataSigner(typedData, sigInvalid)
+	assert.Error(t, err)
+	_, err = ethwallet.RecoverTypedDataSigner(typedData, sig[:64])
+	assert.Error(t, err)
+
+	// different message
+	typedData.Message["contents"] = "Hello, Alice!"
+	signer, err = ethwallet.RecoverTypedDataSigner(typedData, sig)
+	require.NoError(t, err)
+	assert.NotEqual(t, wallet.Address(), signer)
+}
+
+func TestWalletSignatureCanonical(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromPrivateKey(hexutil.Encode(crypto.Keccak256([]byte("cow")))[2:])
+	require.NoError(t, err)
+
+	halfN := new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+	for i := 0; i < 50; i++ {
+		message := []byte(fmt.Sprintf("message %d", i))
+		sig, err := wallet.SignMessage(message)
+		require.NoError(t, err)
+		require.True(t, ethwallet.IsCanonicalSignature(sig), "message %d", i)
+		require.LessOrEqual(t, new(big.Int).SetBytes(sig[32:64]).Cmp(halfN), 0)
+
+		// deterministic, as per RFC 6979
+		again, err := wallet.SignMessage(message)
+		require.NoError(t, err)
+		require.Equal(t, sig, again)
+
+		to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+		txn := types.NewTransaction(uint64(i), to, big.NewInt(1), 21000, big.NewInt(1), nil)
+		signedTxn, err := wallet.SignTx(txn, big.NewInt(1))
+		require.NoError(t, err)
+		_, _, s := signedTxn.RawSignatureValues()
+		require.LessOrEqual(t, s.Cmp(halfN), 0, "txn %d", i)
+	}
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromPrivateKey(hexutil.Encode(crypto.Keccak256([]byte("cow")))[2:])
+	require.NoError(t, err)
+
+	message := []byte("hello")
+	sig, err := wallet.SignMessage(message)
+	require.NoError(t, err)
+
+	// the malleable high-s form of the signature, which recovers the same signer
+	highS := make([]byte, 65)
+	copy(highS, sig)
+	s := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(sig[32:64]))
+	s.FillBytes(highS[32:64])
+	highS[64] = 55 - sig[64] // 27 <-> 28
+	require.False(t, ethwallet.IsCanonicalSignature(highS))
+
+	signer, err := ethwallet.RecoverMessageSigner(message, highS)
+	require.NoError(t, err)
+	require.Equal(t, wallet.Address(), signer)
+
+	normalized, err := ethwallet.NormalizeSignature(highS)
+	require.NoError(t, err)
+	require.Equal(t, sig, normalized)
+	require.Equal(t, byte(55)-sig[64], highS[64], "input is not modified")
+
+	// recovery ids of 0/1 are kept in that form
+	highS[64] -= 27
+	normalized, err = ethwallet.NormalizeSignature(highS)
+	require.NoError(t, err)
+	require.Equal(t, sig[64]-27, normalized[64])
+	require.Equal(t, sig[:64], normalized[:64])
+
+	// canonical signatures are returned as is
+	normalized, err = ethwallet.NormalizeSignature(sig)
+	require.NoError(t, err)
+	require.Equal(t, sig, normalized)
+
+	_, err = ethwallet.NormalizeSignature(sig[:64])
+	require.Error(t, err)
+
+	invalid := append([]byte{}, sig...)
+	invalid[64] = 2
+	_, err = ethwallet.NormalizeSignature(invalid)
+	require.Error(t, err)
+}
+
 func TestWalletSignDataAndRecover(t *testing.T) {
 	wallet, err := ethwallet.NewWalletFromPrivateKey("3c121e5b2c2b2426f386bfc0257820846d77610c20e0fd4144417fb8fd79bfb8")
 	assert.NoError(t, err)
@@ -128,3 +331,198 @@ func TestWalletSignDataAndRecover(t *testing.T) {
 
 	assert.Equal(t, address, recoveredAddress)
 }
+
+func TestWalletDeriveAccountIndexes(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	assert.NoError(t, err)
+
+	wallets, err := wallet.DeriveAccountIndexes(0, 3)
+	assert.NoError(t, err)
+	assert.Len(t, wallets, 3)
+	assert.Equal(t, "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", wallets[0].Address().Hex())
+	assert.Equal(t, "0x9e02d584c27Ec74f832154985046C0f3c5E0f724", wallets[1].Address().Hex())
+	assert.Equal(t, "m/44'/60'/0'/0/2", wallets[2].HDNode().DerivationPath().String())
+
+	// the source wallet is unchanged
+	assert.Equal(t, "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", wallet.Address().Hex())
+}
+
+func TestWalletKeystore(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+
+	opts := ethwallet.KeystoreOptions{
+		ScryptN:             keystore.LightScryptN,
+		ScryptP:             keystore.LightScryptP,
+		MinPassphraseLength: 8,
+	}
+	keyJSON, err := wallet.ExportKeystore("correct horse", opts)
+	require.NoError(t, err)
+
+	var keyFile struct {
+		Address string `json:"address"`
+		Version int    `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(keyJSON, &keyFile))
+	require.Equal(t, 3, keyFile.Version)
+
+	imported, err := ethwallet.NewWalletFromKeystore(keyJSON, "correct horse")
+	require.NoError(t, err)
+	require.Equal(t, wallet.Address(), imported.Address())
+	require.Equal(t, wallet.PrivateKeyHex(), imported.PrivateKeyHex())
+
+	_, err = ethwallet.NewWalletFromKeystore(keyJSON, "wrong horse")
+	require.ErrorIs(t, err, keystore.ErrDecrypt)
+
+	_, err = wallet.ExportKeystore("horse", opts)
+	require.ErrorIs(t, err, ethwallet.ErrWeakPassphrase)
+}
+
+func TestWalletSendTransactions(t *testing.T) {
+	var nonceCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64   `json:"id"`
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var response string
+		switch req.Method {
+		case "eth_chainId":
+			response = `"result":"0x1"`
+		case "eth_getTransactionCount":
+			nonceCalls.Add(1)
+			response = `"result":"0x5"`
+		case "eth_sendRawTransaction":
+			var txn types.Transaction
+			require.NoError(t, txn.UnmarshalBinary(hexutil.MustDecode(req.Params[0])))
+			if txn.Nonce() == 6 && txn.Value().Uint64() == 1 {
+				response = `"error":{"code":-32000,"message":"insufficient funds for gas * price + value"}`
+			} else {
+				response = fmt.Sprintf(`"result":"%s"`, txn.Hash())
+			}
+		default:
+			response = `"error":{"code":-32601,"message":"method not found"}`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,%s}`, req.ID, response)
+	}))
+	defer srv.Close()
+
+	provider, err := ethrpc.NewProvider(srv.URL)
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	wallet.SetProvider(provider)
+
+	txnRequests := func(n int) []*ethtxn.TransactionRequest {
+		var reqs []*ethtxn.TransactionRequest
+		for i := 0; i < n; i++ {
+			reqs = append(reqs, &ethtxn.TransactionRequest{
+				To:       &common.Address{},
+				ETHValue: big.NewInt(1),
+				GasLimit: 21000,
+				GasPrice: big.NewInt(1_000_000_000),
+			})
+		}
+		return reqs
+	}
+	nonces := func(sent []ethwallet.SentTransaction) []uint64 {
+		var nonces []uint64
+		for _, x := range sent {
+			nonces = append(nonces, x.Nonce)
+		}
+		return nonces
+	}
+
+	sent, err := wallet.SendTransactions(context.Background(), txnRequests(3))
+	require.ErrorIs(t, err, ethtxn.ErrInsufficientFunds)
+	require.Equal(t, []uint64{5, 6, 7}, nonces(sent))
+	require.NoError(t, sent[0].Err)
+	require.ErrorIs(t, sent[1].Err, ethtxn.ErrInsufficientFunds)
+	require.NoError(t, sent[2].Err)
+	require.Equal(t, uint64(7), sent[2].Transaction.Nonce())
+
+	// the gap left by the failed nonce is filled first
+	reqs := txnRequests(2)
+	reqs[0].ETHValue = big.NewInt(2)
+	sent, err = wallet.SendTransactions(context.Background(), reqs)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{6, 8}, nonces(sent))
+	require.Equal(t, int32(1), nonceCalls.Load())
+
+	// the nonce is fetched again after a reset
+	wallet.ResetNonce()
+	sent, err = wallet.SendTransactions(context.Background(), txnRequests(1))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5}, nonces(sent))
+	require.Equal(t, int32(2), nonceCalls.Load())
+}
+
+func TestWalletSendTransactionsRetry(t *testing.T) {
+	var failSend atomic.Bool
+	failSend.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64   `json:"id"`
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var response string
+		switch req.Method {
+		case "eth_chainId":
+			response = `"result":"0x1"`
+		case "eth_getTransactionCount":
+			response = `"result":"0x5"`
+		case "eth_gasPrice":
+			response = `"result":"0x3b9aca00"`
+		case "eth_sendRawTransaction":
+			var txn types.Transaction
+			require.NoError(t, txn.UnmarshalBinary(hexutil.MustDecode(req.Params[0])))
+			if txn.Nonce() == 5 && failSend.Load() {
+				response = `"error":{"code":-32000,"message":"insufficient funds for gas * price + value"}`
+			} else {
+				response = fmt.Sprintf(`"result":"%s"`, txn.Hash())
+			}
+		default:
+			response = `"error":{"code":-32601,"message":"method not found"}`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,%s}`, req.ID, response)
+	}))
+	defer srv.Close()
+
+	provider, err := ethrpc.NewProvider(srv.URL)
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	wallet.SetProvider(provider)
+
+	txnRequest := &ethtxn.TransactionRequest{
+		To:       &common.Address{},
+		ETHValue: big.NewInt(1),
+		GasLimit: 21000,
+	}
+
+	sent, err := wallet.SendTransactions(context.Background(), []*ethtxn.TransactionRequest{txnRequest})
+	require.ErrorIs(t, err, ethtxn.ErrInsufficientFunds)
+	require.Equal(t, uint64(5), sent[0].Nonce)
+
+	// the request isn't modified by the failed send
+	require.Nil(t, txnRequest.Nonce)
+	require.Nil(t, txnRequest.GasPrice)
+
+	// the retry is assigned a managed nonce, filling the gap it left
+	failSend.Store(false)
+	sent, err = wallet.SendTransactions(context.Background(), []*ethtxn.TransactionRequest{txnRequest})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), sent[0].Nonce)
+
+	sent, err = wallet.SendTransactions(context.Background(), []*ethtxn.TransactionRequest{txnRequest})
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), sent[0].Nonce)
+}
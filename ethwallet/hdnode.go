@@ -159,6 +159,31 @@ func ParseDerivationPath(path string) (accounts.DerivationPath, error) {
 	return accounts.ParseDerivationPath(path)
 }
 
+// ParseBIP44DerivationPath parses the derivation path in string format, and validates it
+// follows the standard BIP-44 Ethereum format of m/44'/60'/account'/change/index.
+func ParseBIP44DerivationPath(path string) (accounts.DerivationPath, error) {
+	derivationPath, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(derivationPath) != 5 {
+		return nil, fmt.Errorf("invalid BIP-44 derivation path %q, expecting m/44'/60'/account'/change/index", path)
+	}
+	if derivationPath[0] != 0x80000000+44 || derivationPath[1] != 0x80000000+60 {
+		return nil, fmt.Errorf("invalid BIP-44 derivation path %q, expecting m/44'/60' prefix", path)
+	}
+	if derivationPath[2] < 0x80000000 {
+		return nil, fmt.Errorf("invalid BIP-44 derivation path %q, account must be hardened", path)
+	}
+	if derivationPath[3] > 1 {
+		return nil, fmt.Errorf("invalid BIP-44 derivation path %q, change must be 0 or 1", path)
+	}
+	if derivationPath[4] >= 0x80000000 {
+		return nil, fmt.Errorf("invalid BIP-44 derivation path %q, address index must not be hardened", path)
+	}
+	return derivationPath, nil
+}
+
 func (h *HDNode) Mnemonic() string {
 	return h.mnemonic
 }
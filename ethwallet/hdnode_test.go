@@ -71,3 +71,22 @@ func TestHDNodeDerivationFailsWithInvalidMnemonic(t *testing.T) {
 		assert.Nilf(t, hdnode, "Expected nil hdnode for invalid mnemonic '%v'", mnemonic)
 	}
 }
+
+func TestParseBIP44DerivationPath(t *testing.T) {
+	path, err := ethwallet.ParseBIP44DerivationPath("m/44'/60'/0'/0/5")
+	assert.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0/5", path.String())
+
+	invalidPaths := []string{
+		"m/44'/60'/0'/0",
+		"m/44'/0'/0'/0/0",
+		"m/44'/60'/0/0/0",
+		"m/44'/60'/0'/2/0",
+		"m/44'/60'/0'/0/0'",
+		"m/44'/60'/0'/0/x",
+	}
+	for _, p := range invalidPaths {
+		_, err := ethwallet.ParseBIP44DerivationPath(p)
+		assert.Error(t, err, p)
+	}
+}
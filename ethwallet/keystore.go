@@ -0,0 +1,76 @@
+package ethwallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/keystore"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+var ErrWeakPassphrase = errors.New("ethwallet: keystore passphrase is too weak")
+
+var DefaultKeystoreOptions = KeystoreOptions{
+	ScryptN: keystore.StandardScryptN,
+	ScryptP: keystore.StandardScryptP,
+}
+
+type KeystoreOptions struct {
+	// ScryptN and ScryptP are the scrypt parameters the key is encrypted with, see
+	// keystore.StandardScryptN and keystore.LightScryptN.
+	ScryptN int
+	ScryptP int
+
+	// MinPassphraseLength rejects passphrases shorter than it with ErrWeakPassphrase.
+	// A value of 0 accepts any passphrase.
+	MinPassphraseLength int
+}
+
+// ExportKeystore returns the private key of the wallet encrypted with passphrase, as
+// the JSON of a V3 keystore file (Web3 Secret Storage), which is compatible with geth
+// and other wallets, and can be loaded back with NewWalletFromKeystore.
+//
+// NOTE: only the private key of the current account is stored, not the mnemonic of
+// the wallet, so other accounts can't be derived from the imported wallet.
+func (w *Wallet) ExportKeystore(passphrase string, options ...KeystoreOptions) ([]byte, error) {
+	opts := DefaultKeystoreOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if len(passphrase) < opts.MinPassphraseLength {
+		return nil, fmt.Errorf("%w: must be at least %d characters", ErrWeakPassphrase, opts.MinPassphraseLength)
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to generate keystore id: %w", err)
+	}
+	key := &keystore.Key{
+		Id:         id,
+		Address:    w.Address(),
+		PrivateKey: w.hdnode.PrivateKey(),
+	}
+
+	keyJSON, err := keystore.EncryptKey(key, passphrase, opts.ScryptN, opts.ScryptP)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to encrypt keystore: %w", err)
+	}
+	return keyJSON, nil
+}
+
+// NewWalletFromKeystore decrypts the JSON of a V3 keystore file (Web3 Secret Storage)
+// with passphrase, ie. as returned by Wallet#ExportKeystore or stored by geth.
+func NewWalletFromKeystore(keyJSON []byte, passphrase string) (*Wallet, error) {
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to decrypt keystore: %w", err)
+	}
+
+	hdnode := &HDNode{
+		privateKey: key.PrivateKey,
+		publicKey:  &key.PrivateKey.PublicKey,
+		address:    crypto.PubkeyToAddress(key.PrivateKey.PublicKey),
+	}
+	return &Wallet{hdnode: hdnode}, nil
+}
@@ -0,0 +1,191 @@
+package ethwallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// nonceManager hands out the nonces of the wallet's transactions, so many transactions
+// can be sent in parallel without querying the node for each nonce. The next nonce is
+// fetched once from PendingNonceAt, and incremented locally from then on.
+type nonceManager struct {
+	synced    bool
+	nextNonce uint64
+
+	// released are the nonces of transactions which failed to send, which are handed
+	// out again before nextNonce to fill the gaps they left in the nonce sequence
+	released []uint64
+
+	mu sync.Mutex
+}
+
+func (n *nonceManager) acquire(ctx context.Context, w *Wallet, count int) ([]uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.synced {
+		nonce, err := w.GetProvider().PendingNonceAt(ctx, w.Address())
+		if err != nil {
+			return nil, fmt.Errorf("ethwallet: failed to get pending nonce: %w", err)
+		}
+		n.nextNonce = nonce
+		n.released = nil
+		n.synced = true
+	}
+
+	nonces := make([]uint64, 0, count)
+	for len(nonces) < count && len(n.released) > 0 {
+		nonces = append(nonces, n.released[0])
+		n.released = n.released[1:]
+	}
+	for len(nonces) < count {
+		nonces = append(nonces, n.nextNonce)
+		n.nextNonce++
+	}
+	return nonces, nil
+}
+
+func (n *nonceManager) release(nonce uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.synced || nonce >= n.nextNonce {
+		// the nonces were reset since it was acquired
+		return
+	}
+	n.released = append(n.released, nonce)
+	sort.Slice(n.released, func(i, j int) bool { return n.released[i] < n.released[j] })
+}
+
+func (n *nonceManager) reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.synced = false
+	n.nextNonce = 0
+	n.released = nil
+}
+
+// SentTransaction is the outcome of each transaction request passed to SendTransactions.
+type SentTransaction struct {
+	// Nonce assigned to the transaction.
+	Nonce uint64
+
+	// Transaction is the signed transaction, which is nil if it failed to be prepared.
+	Transaction *types.Transaction
+
+	// WaitReceipt waits for the receipt of the transaction, if it was sent.
+	WaitReceipt ethtxn.WaitReceipt
+
+	// Err is the error of preparing or sending the transaction.
+	Err error
+}
+
+// SendTransactions prepares, signs and sends the transaction requests concurrently, with
+// sequential nonces assigned in the order of the requests. Nonces are managed by the wallet,
+// they're fetched once from the node's pending nonce and incremented locally from then on,
+// so consecutive calls don't wait for the previous transactions to be mined. Requests with
+// a Nonce already set are sent as is.
+//
+// The outcome of each request is returned in the order of the requests, along with the
+// errors of the requests which failed. The nonce of a transaction which failed to be sent
+// is assigned to the next transaction sent by the wallet, to fill the gap left in the nonce
+// sequence. The txnRequests aren't modified, so the requests which failed can be sent
+// again. Call ResetNonce after sending transactions of the wallet from elsewhere.
+func (w *Wallet) SendTransactions(ctx context.Context, txnRequests []*ethtxn.TransactionRequest) ([]SentTransaction, error) {
+	if w.GetProvider() == nil {
+		return nil, fmt.Errorf("ethwallet: provider is not set")
+	}
+	for _, txnRequest := range txnRequests {
+		if txnRequest == nil {
+			return nil, fmt.Errorf("ethwallet: txnRequest is required")
+		}
+	}
+
+	// work on copies of the requests, as the nonce and the other unset fields of the
+	// requests are filled in when the transactions are prepared
+	requests := make([]*ethtxn.TransactionRequest, len(txnRequests))
+	var managed []int
+	for i, txnRequest := range txnRequests {
+		request := *txnRequest
+		requests[i] = &request
+		if txnRequest.Nonce == nil {
+			managed = append(managed, i)
+		}
+	}
+
+	nonces, err := w.nonces.acquire(ctx, w, len(managed))
+	if err != nil {
+		return nil, err
+	}
+
+	sent := make([]SentTransaction, len(txnRequests))
+	for i, idx := range managed {
+		requests[idx].Nonce = new(big.Int).SetUint64(nonces[i])
+	}
+	for i, txnRequest := range requests {
+		sent[i].Nonce = txnRequest.Nonce.Uint64()
+	}
+
+	var wg sync.WaitGroup
+	for i, txnRequest := range requests {
+		wg.Add(1)
+		go func(i int, txnRequest *ethtxn.TransactionRequest) {
+			defer wg.Done()
+
+			signedTx, err := w.NewTransaction(ctx, txnRequest)
+			if err != nil {
+				sent[i].Err = err
+				return
+			}
+			sent[i].Transaction = signedTx
+
+			_, waitReceipt, err := w.SendTransaction(ctx, signedTx)
+			if err != nil {
+				sent[i].Err = err
+				return
+			}
+			sent[i].WaitReceipt = waitReceipt
+		}(i, txnRequest)
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, x := range sent {
+		if x.Err != nil {
+			errs = append(errs, fmt.Errorf("ethwallet: transaction %d with nonce %d: %w", i, x.Nonce, x.Err))
+		}
+	}
+
+	for _, idx := range managed {
+		err := sent[idx].Err
+		if err == nil {
+			continue
+		}
+		switch {
+		case errors.Is(err, ethtxn.ErrNonceTooLow), errors.Is(err, ethtxn.ErrReplacementUnderpriced):
+			// the nonce has been used by a transaction sent from elsewhere
+			w.nonces.reset()
+		case errors.Is(err, ethtxn.ErrAlreadyKnown):
+			// the transaction is already pending with the nonce
+		default:
+			// the nonce is free to be used again
+			w.nonces.release(sent[idx].Nonce)
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// ResetNonce discards the nonce managed by the wallet for SendTransactions, so the next
+// nonce is fetched from the node again. Call it after sending transactions of the wallet
+// by other means, ie. from another process or with an explicit nonce.
+func (w *Wallet) ResetNonce() {
+	w.nonces.reset()
+}
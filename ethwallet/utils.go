@@ -3,6 +3,7 @@ package ethwallet
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 
 	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
@@ -17,6 +18,105 @@ func RecoverAddress(message, signature []byte) (common.Address, error) {
 	return RecoverAddressFromDigest(crypto.Keccak256([]byte(msg)), signature)
 }
 
+// RecoverMessageSigner recovers the address which signed the message with the EIP-191
+// personal message prefix, ie. a personal_sign signature as produced by MetaMask and by
+// Wallet#SignMessage. As with SignMessage, the prefix is only added if the message
+// doesn't already have it.
+//
+// The recovery id of the signature may be either 27/28 as produced by MetaMask, or 0/1
+// as produced by some hardware wallets, any other value is rejected.
+func RecoverMessageSigner(message, signature []byte) (common.Address, error) {
+	if len(signature) != 65 {
+		return common.Address{}, fmt.Errorf("ethwallet: signature is not of proper length (=65)")
+	}
+	if v := signature[64]; v != 0 && v != 1 && v != 27 && v != 28 {
+		return common.Address{}, fmt.Errorf("ethwallet: signature has invalid recovery id %d", v)
+	}
+
+	message191 := []byte("\x19Ethereum Signed Message:\n")
+	if !bytes.HasPrefix(message, message191) {
+		message191 = append(message191, []byte(fmt.Sprintf("%d", len(message)))...)
+		message191 = append(message191, message...)
+	} else {
+		message191 = message
+	}
+	return RecoverAddressFromDigest(crypto.Keccak256(message191), signature)
+}
+
+// RecoverTypedDataSigner recovers the address which signed the EIP-712 typed data, ie. an
+// eth_signTypedData_v4 signature as produced by MetaMask and by Wallet#SignTypedData.
+//
+// The recovery id of the signature may be either 27/28 as produced by MetaMask, or 0/1
+// as produced by some hardware wallets, any other value is rejected.
+func RecoverTypedDataSigner(typedData *ethcoder.TypedData, signature []byte) (common.Address, error) {
+	if typedData == nil {
+		return common.Address{}, fmt.Errorf("ethwallet: typed data is required")
+	}
+	if len(signature) != 65 {
+		return common.Address{}, fmt.Errorf("ethwallet: signature is not of proper length (=65)")
+	}
+	if v := signature[64]; v != 0 && v != 1 && v != 27 && v != 28 {
+		return common.Address{}, fmt.Errorf("ethwallet: signature has invalid recovery id %d", v)
+	}
+
+	digest, err := typedData.EncodeDigest()
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ethwallet: failed to encode typed data: %w", err)
+	}
+	return RecoverAddressFromDigest(digest, signature)
+}
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// IsCanonicalSignature reports whether the 65 byte [r || s || v] signature is canonical,
+// ie. its s value is in the lower half of the curve order, s <= N/2, as required by
+// EIP-2 for transactions, and by contracts which reject malleable signatures, ie.
+// OpenZeppelin's ECDSA library. Signatures of the Wallet are always canonical.
+func IsCanonicalSignature(signature []byte) bool {
+	if len(signature) != 65 {
+		return false
+	}
+	s := new(big.Int).SetBytes(signature[32:64])
+	return s.Sign() > 0 && s.Cmp(secp256k1HalfN) <= 0
+}
+
+// NormalizeSignature returns the canonical low-s form of the 65 byte [r || s || v]
+// signature, see IsCanonicalSignature. A high-s signature has its s value flipped to
+// N - s and its recovery id flipped to the other parity, which recovers the same signer,
+// while a canonical signature is returned as is. The recovery id may be either 27/28 or
+// 0/1, and is kept in the same form. The signature passed in is not modified.
+func NormalizeSignature(signature []byte) ([]byte, error) {
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("ethwallet: signature is not of proper length (=65)")
+	}
+	v := signature[64]
+	if v != 0 && v != 1 && v != 27 && v != 28 {
+		return nil, fmt.Errorf("ethwallet: signature has invalid recovery id %d", v)
+	}
+	s := new(big.Int).SetBytes(signature[32:64])
+	if s.Sign() == 0 || s.Cmp(secp256k1N) >= 0 {
+		return nil, fmt.Errorf("ethwallet: signature has invalid s value")
+	}
+
+	sig := make([]byte, 65)
+	copy(sig, signature)
+	if s.Cmp(secp256k1HalfN) <= 0 {
+		return sig, nil
+	}
+
+	s.Sub(secp256k1N, s)
+	s.FillBytes(sig[32:64])
+	if v == 0 || v == 27 {
+		sig[64] = v + 1
+	} else {
+		sig[64] = v - 1
+	}
+	return sig, nil
+}
+
 func RecoverAddressFromDigest(digest, signature []byte) (common.Address, error) {
 	if len(digest) != 32 {
 		return common.Address{}, fmt.Errorf("digest is not of proper length (=32)")